@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+)
+
+// Operations guarded by permissions besides actions and slash commands.
+const (
+	opMetrics = "metrics"
+	opHealth  = "health"
+	opApprove = "approve"
+)
+
+// permissions maps what a credential can invoke (webhook actions, slash
+// commands and endpoints) to the least privileged role allowed to.
+// Operations missing here need admin.
+var permissions = map[string]string{
+	opMetrics:                 config.RoleViewer,
+	opHealth:                  config.RoleViewer,
+	config.BotStatus:          config.RoleViewer,
+	config.BotBackup:          config.RoleOperator,
+	config.ActionBackupCreate: config.RoleOperator,
+	config.ActionRestart:      config.RoleOperator, // also the /restart slash command
+	config.ActionUpdateMods:   config.RoleAdmin,    // also the /update-mods slash command
+	opApprove:                 config.RoleAdmin,
+}
+
+// allows reports whether role may invoke op. With api.read_only every role
+// counts as viewer.
+func (s *Server) allows(role, op string) bool {
+	if role == "" {
+		return false
+	}
+	if s.cfg.API.ReadOnly {
+		role = config.RoleViewer
+	}
+	need, ok := permissions[op]
+	if !ok {
+		need = config.RoleAdmin
+	}
+	return slices.Index(config.Roles, role) >= slices.Index(config.Roles, need)
+}
+
+// bearer returns the api.tokens entry sent as "Authorization: Bearer".
+func (s *Server) bearer(r *http.Request) (config.APITokenConfig, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return config.APITokenConfig{}, false
+	}
+	for _, t := range s.cfg.API.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return t, true
+		}
+	}
+	return config.APITokenConfig{}, false
+}
+
+// authorize checks the request's bearer token against op, answering 401
+// for a missing or unknown token and 403 when its role does not allow op.
+// It reports whether the request may go on.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, op string) bool {
+	token, ok := s.bearer(r)
+	if !ok {
+		s.logger.Warn("Rejected API request without a valid token", zap.String("path", r.URL.Path), zap.String("remote", r.RemoteAddr))
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+		return false
+	}
+	return s.permit(w, token.Name, token.Role, op)
+}
+
+// permit answers 403 unless role, held by the credential named who, allows
+// op, and reports whether it does.
+func (s *Server) permit(w http.ResponseWriter, who, role, op string) bool {
+	if s.allows(role, op) {
+		return true
+	}
+	s.logger.Warn("Refused operation not allowed for role", zap.String("credential", who),
+		zap.String("role", role), zap.String("operation", op), zap.Bool("read_only", s.cfg.API.ReadOnly))
+	writeJSON(w, http.StatusForbidden, map[string]string{"error": op + " is not allowed for " + who})
+	return false
+}
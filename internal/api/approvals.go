@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// Approver decides pending approval requests.
+type Approver interface {
+	Decide(id string, approve bool) error
+}
+
+// WithApprovals lets POST /approvals/{id}/approve and /reject, signed with
+// approvals.secret or sent with an admin token, decide requests held by
+// approver. The signature covers the request line as well as the body (see
+// approvalPayload), so one signed decision cannot be replayed on another
+// request or turned into the opposite decision.
+func (s *Server) WithApprovals(approver Approver) *Server {
+	s.approver = approver
+	return s
+}
+
+func (s *Server) handleApproval(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unreadable body"})
+		return
+	}
+	if r.Header.Get("Authorization") != "" {
+		if !s.authorize(w, r, opApprove) {
+			return
+		}
+	} else if s.cfg.Approvals.Secret == "" || !validSignature(s.cfg.Approvals.Secret, approvalPayload(r, body), r.Header) {
+		s.logger.Warn("Rejected approval with bad signature", zap.String("remote", r.RemoteAddr))
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+		return
+	} else if !s.permit(w, "approvals secret", config.RoleAdmin, opApprove) {
+		return
+	}
+	var approve bool
+	status := "rejected"
+	switch r.PathValue("decision") {
+	case "approve":
+		approve, status = true, "approved"
+	case "reject":
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "decision must be approve or reject"})
+		return
+	}
+	id := r.PathValue("id")
+	if err := s.approver.Decide(id, approve); err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	s.logger.Info("Approval decided through the API", zap.String("id", id), zap.Bool("approved", approve))
+	writeJSON(w, http.StatusOK, map[string]string{"status": status})
+}
+
+// approvalPayload is what an approval signature is computed over:
+// "POST /approvals/<id>/<decision>\n" followed by the body.
+func approvalPayload(r *http.Request, body []byte) []byte {
+	return append([]byte(r.Method+" "+r.URL.Path+"\n"), body...)
+}
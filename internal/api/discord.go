@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// Discord interaction and response types.
+const (
+	interactionPing    = 1
+	interactionCommand = 2
+
+	responsePong     = 1
+	responseMessage  = 4
+	responseDeferred = 5
+
+	flagEphemeral = 1 << 6
+)
+
+// maxInteractionAge is how far X-Signature-Timestamp may be from now, so a
+// captured interaction cannot be replayed later.
+const maxInteractionAge = 5 * time.Minute
+
+// BotCommand runs a Discord slash command and returns the reply text.
+type BotCommand func(ctx context.Context) (string, error)
+
+// Replier delivers the result of a deferred slash command to Discord.
+type Replier interface {
+	Reply(ctx context.Context, reply domain.BotReply) error
+}
+
+// WithDiscordBot answers the discord_bot slash commands on
+// POST /discord/interactions, sending results through replier.
+func (s *Server) WithDiscordBot(commands map[string]BotCommand, replier Replier) *Server {
+	s.botCommands, s.replier = commands, replier
+	return s
+}
+
+type interaction struct {
+	Type      int    `json:"type"`
+	Token     string `json:"token"`
+	ChannelID string `json:"channel_id"`
+	Data      struct {
+		Name string `json:"name"`
+	} `json:"data"`
+	Member *struct {
+		Roles []string `json:"roles"`
+		User  struct {
+			Username string `json:"username"`
+		} `json:"user"`
+	} `json:"member"`
+}
+
+func (s *Server) handleInteraction(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unreadable body"})
+		return
+	}
+	received := time.Now()
+	if !validInteraction(s.cfg.DiscordBot.PublicKey, body, r.Header, received) {
+		s.logger.Warn("Rejected Discord interaction with bad signature", zap.String("remote", r.RemoteAddr))
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid request signature"})
+		return
+	}
+	var in interaction
+	if err := json.Unmarshal(body, &in); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid interaction"})
+		return
+	}
+	switch in.Type {
+	case interactionPing:
+		writeJSON(w, http.StatusOK, map[string]int{"type": responsePong})
+		return
+	case interactionCommand:
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported interaction"})
+		return
+	}
+
+	name := in.Data.Name
+	role := ""
+	if in.Member != nil {
+		role = s.cfg.DiscordBot.RoleOf(in.Member.Roles)
+	}
+	if !s.allows(role, name) {
+		s.logger.Warn("Unauthorized slash command", zap.String("command", name), zap.String("role", role))
+		writeJSON(w, http.StatusOK, ephemeral("You are not allowed to run /"+name+"."))
+		return
+	}
+	command, ok := s.botCommands[name]
+	if !ok {
+		writeJSON(w, http.StatusOK, ephemeral("/"+name+" is not available."))
+		return
+	}
+	s.logger.Info("Slash command received", zap.String("command", name), zap.String("user", in.Member.User.Username))
+
+	// Discord wants an answer within 3 seconds; the result follows as an
+	// edit of the deferred response.
+	reply := func(ctx context.Context) error {
+		text, err := command(ctx)
+		out := domain.BotReply{Token: in.Token, ChannelID: in.ChannelID, Received: received,
+			Title: "/" + name, Message: text, OK: err == nil}
+		if err != nil {
+			out.Message = err.Error()
+		}
+		if rerr := s.replier.Reply(ctx, out); rerr != nil {
+			s.logger.Warn("Slash command reply failed", zap.String("command", name), zap.Error(rerr))
+		}
+		return err
+	}
+	if name == config.BotStatus {
+		s.wg.Go(func() { _ = reply(s.baseCtx) })
+	} else if !s.spawn(name, "Slash command", reply) {
+		writeJSON(w, http.StatusOK, ephemeral("Busy: "+s.current()+" is running."))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"type": responseDeferred})
+}
+
+// ephemeral is an immediate reply only the invoking user sees.
+func ephemeral(text string) map[string]any {
+	return map[string]any{
+		"type": responseMessage,
+		"data": map[string]any{"content": text, "flags": flagEphemeral},
+	}
+}
+
+// validInteraction checks Discord's Ed25519 signature over the timestamp
+// and body, sent in X-Signature-Ed25519 and X-Signature-Timestamp, and that
+// the timestamp is within maxInteractionAge of now.
+func validInteraction(publicKey string, body []byte, h http.Header, now time.Time) bool {
+	ts, err := strconv.ParseInt(h.Get("X-Signature-Timestamp"), 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := now.Sub(time.Unix(ts, 0)); age > maxInteractionAge || age < -maxInteractionAge {
+		return false
+	}
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return false
+	}
+	sig, err := hex.DecodeString(h.Get("X-Signature-Ed25519"))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return false
+	}
+	msg := append([]byte(h.Get("X-Signature-Timestamp")), body...)
+	return ed25519.Verify(key, msg, sig)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"craftops/internal/domain"
+)
+
+// healthTTL is how long /readyz answers from the last run of the checks,
+// so frequent probes do not hit Modrinth and the server on every request.
+var healthTTL = 15 * time.Second
+
+// HealthCheck runs the health checks /readyz aggregates.
+type HealthCheck func(ctx context.Context) []domain.HealthCheck
+
+// healthCache holds the last result of the checks.
+type healthCache struct {
+	mu     sync.Mutex
+	at     time.Time
+	checks []domain.HealthCheck
+}
+
+// WithHealth answers GET /readyz with the checks run by check: 200 unless
+// one fails, 503 otherwise. GET /healthz, answered regardless, only says
+// the daemon is alive.
+func (s *Server) WithHealth(check HealthCheck) *Server {
+	s.health = check
+	return s
+}
+
+func (s *Server) handleLiveness(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReadiness reports the aggregated checks. The individual results,
+// which name paths and hosts, are left out for requests without a viewer
+// token once api.tokens are set.
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	checks := s.healthChecks()
+	status, code := "ok", http.StatusOK
+	if slices.ContainsFunc(checks, func(c domain.HealthCheck) bool { return c.Status == domain.StatusError }) {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	body := map[string]any{"status": status}
+	if token, ok := s.bearer(r); len(s.cfg.API.Tokens) == 0 || ok && s.allows(token.Role, opHealth) {
+		body["checks"] = checks
+	}
+	writeJSON(w, code, body)
+}
+
+// healthChecks returns the cached checks, running them again once they are
+// older than healthTTL. Concurrent probes wait for the same run, which is
+// not cut short by a probe giving up.
+func (s *Server) healthChecks() []domain.HealthCheck {
+	s.healthCache.mu.Lock()
+	defer s.healthCache.mu.Unlock()
+	if s.healthCache.checks == nil || time.Since(s.healthCache.at) > healthTTL {
+		s.healthCache.checks, s.healthCache.at = s.health(s.baseCtx), time.Now()
+	}
+	return s.healthCache.checks
+}
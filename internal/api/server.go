@@ -0,0 +1,271 @@
+// Package api serves craftops over HTTP for daemon mode: inbound webhooks
+// that trigger predefined operations, Discord slash commands, approval
+// decisions, Prometheus metrics and liveness and readiness probes. What each credential may invoke is
+// decided in one place by its role (see permissions).
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+const maxWebhookBody = 1 << 20
+
+// Action is an operation a webhook can trigger.
+type Action func(ctx context.Context) error
+
+// Server is the craftops HTTP API.
+type Server struct {
+	cfg     *config.Config
+	logger  *zap.Logger
+	actions map[string]Action
+	metrics func() domain.RequestStats
+
+	botCommands map[string]BotCommand
+	replier     Replier
+	approver    Approver
+	health      HealthCheck
+	healthCache healthCache
+
+	mu      sync.Mutex
+	running string // action in progress, "" when idle
+	wg      sync.WaitGroup
+	baseCtx context.Context
+}
+
+// New creates an API server dispatching webhooks to actions by name.
+func New(cfg *config.Config, logger *zap.Logger, actions map[string]Action) *Server {
+	return &Server{cfg: cfg, logger: logger, actions: actions, baseCtx: context.Background()}
+}
+
+// WithMetrics serves the counters returned by stats on GET /metrics.
+func (s *Server) WithMetrics(stats func() domain.RequestStats) *Server {
+	s.metrics = stats
+	return s
+}
+
+// Handler returns the HTTP routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hooks/{name}", s.handleWebhook)
+	mux.HandleFunc("GET /healthz", s.handleLiveness)
+	if s.health != nil {
+		mux.HandleFunc("GET /readyz", s.handleReadiness)
+	}
+	if len(s.cfg.API.Tokens) > 0 {
+		mux.HandleFunc("POST /actions/{name}", s.handleAction)
+	}
+	if s.metrics != nil {
+		mux.HandleFunc("GET /metrics", s.handleMetrics)
+	}
+	if s.botCommands != nil {
+		mux.HandleFunc("POST /discord/interactions", s.handleInteraction)
+	}
+	if s.approver != nil && (s.cfg.Approvals.Secret != "" || len(s.cfg.API.Tokens) > 0) {
+		mux.HandleFunc("POST /approvals/{id}/{decision}", s.handleApproval)
+	}
+	return mux
+}
+
+// handleMetrics writes the outbound request counters in the Prometheus text
+// exposition format. Once api.tokens are set it needs a viewer token.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if len(s.cfg.API.Tokens) > 0 && !s.authorize(w, r, opMetrics) {
+		return
+	}
+	st := s.metrics()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, m := range []struct {
+		name, help string
+		value      int64
+	}{
+		{"craftops_http_requests_total", "Outbound Modrinth API and download requests.", st.Requests},
+		{"craftops_http_request_failures_total", "Outbound requests that failed or returned 4xx/5xx.", st.Failures},
+		{"craftops_http_retries_total", "Retried outbound requests.", st.Retries},
+		{"craftops_http_downloaded_bytes_total", "Response bytes received.", st.BytesDownloaded},
+	} {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n", m.name, m.help, m.name, m.name, m.value)
+	}
+}
+
+// Run serves on api.listen, over TLS when api.tls is set, until ctx is
+// cancelled, then waits for a running action to finish.
+func (s *Server) Run(ctx context.Context) error {
+	s.baseCtx = ctx
+	tlsConf, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Addr:              s.cfg.API.Listen,
+		Handler:           s.requireClientCert(s.Handler()),
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         tlsConf,
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		if tlsConf != nil {
+			errCh <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		errCh <- srv.ListenAndServe()
+	}()
+	s.logger.Info("API listening", zap.String("addr", s.cfg.API.Listen), zap.Bool("tls", tlsConf != nil),
+		zap.Bool("client_certs", s.cfg.API.TLS.ClientCA != ""))
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err = srv.Shutdown(shutdownCtx)
+	s.wg.Wait()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+func (s *Server) handleWebhook(w http.ResponseWriter, r *http.Request) {
+	hook, ok := s.findHook(r.PathValue("name"))
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown webhook"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unreadable body"})
+		return
+	}
+	if !validSignature(hook.Secret, body, r.Header) {
+		s.logger.Warn("Rejected webhook with bad signature", zap.String("hook", hook.Name), zap.String("remote", r.RemoteAddr))
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid signature"})
+		return
+	}
+	// A webhook secret grants its one action, subject to api.read_only.
+	if !s.permit(w, "webhook "+hook.Name, config.RoleAdmin, hook.Action) {
+		return
+	}
+	action, ok := s.actions[hook.Action]
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, map[string]string{"error": "action not available: " + hook.Action})
+		return
+	}
+	if !s.start(hook, action) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "busy", "running": s.current()})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "accepted", "action": hook.Action})
+}
+
+// handleAction runs a webhook action for a bearer token whose role allows
+// it, like a webhook but without a signed body.
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	action, ok := s.actions[name]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown action"})
+		return
+	}
+	if !s.authorize(w, r, name) {
+		return
+	}
+	token, _ := s.bearer(r)
+	started := s.spawn(name, "API action", func(ctx context.Context) error {
+		s.logger.Info("API action started", zap.String("token", token.Name), zap.String("action", name))
+		return action(ctx)
+	})
+	if !started {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "busy", "running": s.current()})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "accepted", "action": name})
+}
+
+// start runs action in the background unless another action is running.
+func (s *Server) start(hook config.WebhookConfig, action Action) bool {
+	return s.spawn(hook.Action, "Webhook action", func(ctx context.Context) error {
+		s.logger.Info("Webhook action started", zap.String("hook", hook.Name), zap.String("action", hook.Action))
+		return action(ctx)
+	})
+}
+
+// spawn runs fn in the background as the named action unless another
+// action is running; source labels its log lines.
+func (s *Server) spawn(name, source string, fn func(ctx context.Context) error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running != "" {
+		return false
+	}
+	s.running = name
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			s.running = ""
+			s.mu.Unlock()
+		}()
+		if err := fn(s.baseCtx); err != nil {
+			s.logger.Error(source+" failed", zap.String("action", name), zap.Error(err))
+			return
+		}
+		s.logger.Info(source+" finished", zap.String("action", name))
+	}()
+	return true
+}
+
+func (s *Server) current() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+func (s *Server) findHook(name string) (config.WebhookConfig, bool) {
+	for _, h := range s.cfg.API.Webhooks {
+		if h.Name == name {
+			return h, true
+		}
+	}
+	return config.WebhookConfig{}, false
+}
+
+// validSignature checks an HMAC-SHA256 of body sent as "sha256=<hex>" in
+// X-Hub-Signature-256 (GitHub, Gitea) or X-Signature-256.
+func validSignature(secret string, body []byte, h http.Header) bool {
+	sig := h.Get("X-Hub-Signature-256")
+	if sig == "" {
+		sig = h.Get("X-Signature-256")
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(sig, "sha256="))
+	if err != nil || len(got) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
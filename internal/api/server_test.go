@@ -0,0 +1,398 @@
+package api_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/api"
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhook(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.API.Webhooks = []config.WebhookConfig{{Name: "github", Action: config.ActionUpdateMods, Secret: "s3cret"}}
+	ran := make(chan struct{}, 1)
+	srv := httptest.NewServer(api.New(cfg, zap.NewNop(), map[string]api.Action{
+		config.ActionUpdateMods: func(context.Context) error { ran <- struct{}{}; return nil },
+	}).Handler())
+	defer srv.Close()
+
+	post := func(path, body, sig string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(body))
+		if sig != "" {
+			req.Header.Set("X-Hub-Signature-256", sig)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	body := `{"ref":"refs/heads/main"}`
+	if code := post("/hooks/github", body, sign("wrong", body)); code != http.StatusUnauthorized {
+		t.Errorf("bad signature: status %d, want 401", code)
+	}
+	if code := post("/hooks/github", body, ""); code != http.StatusUnauthorized {
+		t.Errorf("missing signature: status %d, want 401", code)
+	}
+	if code := post("/hooks/other", body, sign("s3cret", body)); code != http.StatusNotFound {
+		t.Errorf("unknown hook: status %d, want 404", code)
+	}
+	if code := post("/hooks/github", body, sign("s3cret", body)); code != http.StatusAccepted {
+		t.Fatalf("valid hook: status %d, want 202", code)
+	}
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("action did not run")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	stats := func() domain.RequestStats {
+		return domain.RequestStats{Requests: 7, Failures: 1, Retries: 2, BytesDownloaded: 4096}
+	}
+	srv := httptest.NewServer(api.New(config.DefaultConfig(), zap.NewNop(), nil).WithMetrics(stats).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	for _, want := range []string{
+		"# TYPE craftops_http_requests_total counter",
+		"craftops_http_requests_total 7\n",
+		"craftops_http_retries_total 2\n",
+		"craftops_http_downloaded_bytes_total 4096\n",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+type replyRecorder chan string
+
+func (r replyRecorder) Reply(_ context.Context, reply domain.BotReply) error {
+	r <- fmt.Sprintf("%s %t %s", reply.Title, reply.OK, reply.Message)
+	return nil
+}
+
+func TestDiscordInteraction(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.DiscordBot = config.DiscordBotConfig{Enabled: true, PublicKey: hex.EncodeToString(pub), AllowedRoles: []string{"42"},
+		Roles: map[string]string{"9": config.RoleViewer}}
+	replies := make(replyRecorder, 1)
+	srv := httptest.NewServer(api.New(cfg, zap.NewNop(), nil).WithDiscordBot(map[string]api.BotCommand{
+		config.BotStatus: func(context.Context) (string, error) { return "Server is running", nil },
+	}, replies).Handler())
+	defer srv.Close()
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	postAt := func(ts, body string, key ed25519.PrivateKey) (int, map[string]any) {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/discord/interactions", strings.NewReader(body))
+		req.Header.Set("X-Signature-Timestamp", ts)
+		req.Header.Set("X-Signature-Ed25519", hex.EncodeToString(ed25519.Sign(key, []byte(ts+body))))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var out map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+	post := func(body string, key ed25519.PrivateKey) (int, map[string]any) { return postAt(now, body, key) }
+
+	if code, _ := postAt("1700000000", `{"type":1}`, priv); code != http.StatusUnauthorized {
+		t.Errorf("replayed interaction: status %d, want 401", code)
+	}
+	_, other, _ := ed25519.GenerateKey(nil)
+	if code, _ := post(`{"type":1}`, other); code != http.StatusUnauthorized {
+		t.Errorf("bad signature: status %d, want 401", code)
+	}
+	if _, out := post(`{"type":1}`, priv); out["type"] != 1.0 {
+		t.Errorf("ping answered with %v, want a pong", out)
+	}
+	command := `{"type":2,"token":"tok","data":{"name":"status"},"member":{"roles":[%q]}}`
+	if _, out := post(fmt.Sprintf(command, "7"), priv); out["type"] != 4.0 {
+		t.Errorf("member without an allowed role got %v, want an immediate refusal", out)
+	}
+	restart := `{"type":2,"token":"tok","data":{"name":"restart"},"member":{"roles":["9"]}}`
+	if _, out := post(restart, priv); out["type"] != 4.0 {
+		t.Errorf("viewer running /restart got %v, want an immediate refusal", out)
+	}
+	if _, out := post(fmt.Sprintf(command, "42"), priv); out["type"] != 5.0 {
+		t.Fatalf("allowed member got %v, want a deferred response", out)
+	}
+	select {
+	case got := <-replies:
+		if got != "/status true Server is running" {
+			t.Errorf("reply = %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("command result was not delivered")
+	}
+}
+
+type approver map[string]bool
+
+func (a approver) Decide(id string, approve bool) error {
+	if _, ok := a[id]; !ok {
+		return domain.ErrApprovalNotFound
+	}
+	a[id] = approve
+	return nil
+}
+
+func TestApprovalEndpoint(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Approvals.Secret = "s3cret"
+	pending := approver{"ab12": false}
+	srv := httptest.NewServer(api.New(cfg, zap.NewNop(), nil).WithApprovals(pending).Handler())
+	defer srv.Close()
+
+	post := func(path, sig string) int {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+path, strings.NewReader(""))
+		req.Header.Set("X-Signature-256", sig)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	signed := func(secret, path string) string { return sign(secret, "POST "+path+"\n") }
+	if code := post("/approvals/ab12/approve", signed("wrong", "/approvals/ab12/approve")); code != http.StatusUnauthorized {
+		t.Errorf("bad signature: status %d, want 401", code)
+	}
+	if code := post("/approvals/ab12/approve", sign("s3cret", "")); code != http.StatusUnauthorized {
+		t.Errorf("body-only signature: status %d, want 401", code)
+	}
+	if code := post("/approvals/ff00/approve", signed("s3cret", "/approvals/ff00/approve")); code != http.StatusNotFound {
+		t.Errorf("unknown request: status %d, want 404", code)
+	}
+	// A signature for one id or decision must not work for another.
+	if code := post("/approvals/ab12/approve", signed("s3cret", "/approvals/ff00/approve")); code != http.StatusUnauthorized {
+		t.Errorf("signature replayed on another id: status %d, want 401", code)
+	}
+	if code := post("/approvals/ab12/approve", signed("s3cret", "/approvals/ab12/reject")); code != http.StatusUnauthorized {
+		t.Errorf("signature replayed on another decision: status %d, want 401", code)
+	}
+	if code := post("/approvals/ab12/approve", signed("s3cret", "/approvals/ab12/approve")); code != http.StatusOK || !pending["ab12"] {
+		t.Errorf("approve: status %d, decided %v", code, pending["ab12"])
+	}
+}
+
+func TestAPITokenRoles(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.API.Tokens = []config.APITokenConfig{
+		{Name: "grafana", Token: "view", Role: config.RoleViewer},
+		{Name: "ops", Token: "oper", Role: config.RoleOperator},
+	}
+	ran := make(chan string, 1)
+	serve := func(cfg *config.Config) *httptest.Server {
+		action := func(name string) api.Action {
+			return func(context.Context) error { ran <- name; return nil }
+		}
+		return httptest.NewServer(api.New(cfg, zap.NewNop(), map[string]api.Action{
+			config.ActionRestart:    action(config.ActionRestart),
+			config.ActionUpdateMods: action(config.ActionUpdateMods),
+		}).WithMetrics(func() domain.RequestStats { return domain.RequestStats{} }).Handler())
+	}
+	srv := serve(cfg)
+	defer srv.Close()
+	readOnly := *cfg
+	readOnly.API.ReadOnly = true
+	roSrv := serve(&readOnly)
+	defer roSrv.Close()
+
+	call := func(srv *httptest.Server, method, path, token string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	for _, tc := range []struct {
+		srv                 *httptest.Server
+		method, path, token string
+		want                int
+	}{
+		{srv, http.MethodGet, "/metrics", "", http.StatusUnauthorized},
+		{srv, http.MethodGet, "/metrics", "wrong", http.StatusUnauthorized},
+		{srv, http.MethodGet, "/metrics", "view", http.StatusOK},
+		{srv, http.MethodPost, "/actions/restart", "view", http.StatusForbidden},
+		{srv, http.MethodPost, "/actions/update-mods", "oper", http.StatusForbidden},
+		{srv, http.MethodPost, "/actions/nope", "oper", http.StatusNotFound},
+		{roSrv, http.MethodPost, "/actions/restart", "oper", http.StatusForbidden},
+		{roSrv, http.MethodGet, "/metrics", "oper", http.StatusOK},
+	} {
+		if code := call(tc.srv, tc.method, tc.path, tc.token); code != tc.want {
+			t.Errorf("%s %s with %q (read only %t): status %d, want %d",
+				tc.method, tc.path, tc.token, tc.srv == roSrv, code, tc.want)
+		}
+	}
+	if code := call(srv, http.MethodPost, "/actions/restart", "oper"); code != http.StatusAccepted {
+		t.Fatalf("operator restart: status %d, want 202", code)
+	}
+	select {
+	case got := <-ran:
+		if got != config.ActionRestart {
+			t.Errorf("ran %s, want restart", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("action did not run")
+	}
+}
+
+func TestRunTLS(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Paths.State = t.TempDir()
+	cfg.API.Listen = addr
+	cfg.API.TLS.SelfSigned = true
+	cfg.API.TLS.ClientCA = filepath.Join(cfg.Paths.State, "api-tls.crt") // any CA; no client presents one
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- api.New(cfg, zap.NewNop(), nil).WithMetrics(func() domain.RequestStats { return domain.RequestStats{} }).Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}()
+
+	// The certificate is written before the server listens.
+	url := "https://localhost:" + strings.Split(addr, ":")[1]
+	var client *http.Client
+	for range 100 {
+		if pem, err := os.ReadFile(filepath.Join(cfg.Paths.State, "api-tls.crt")); err == nil {
+			pool := x509.NewCertPool()
+			pool.AppendCertsFromPEM(pem)
+			client = &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}}}
+			if resp, err := client.Get(url + "/"); err == nil {
+				_ = resp.Body.Close()
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if client == nil {
+		t.Fatal("no self-signed certificate written")
+	}
+	get := func(path string) int {
+		resp, err := client.Get(url + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := get("/metrics"); code != http.StatusUnauthorized {
+		t.Errorf("metrics without a client certificate: status %d, want 401", code)
+	}
+	if code := get("/hooks/none"); code != http.StatusMethodNotAllowed {
+		t.Errorf("hooks without a client certificate: status %d, want 405 from the route itself", code)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	cfg := config.DefaultConfig()
+	status := domain.StatusOK
+	check := func(context.Context) []domain.HealthCheck {
+		return []domain.HealthCheck{{Name: "Server directory", Status: status, Message: "OK"}}
+	}
+	get := func(cfg *config.Config, path, token string) (int, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		api.New(cfg, zap.NewNop(), nil).WithHealth(check).Handler().ServeHTTP(rec, req)
+		var out map[string]any
+		_ = json.NewDecoder(rec.Body).Decode(&out)
+		return rec.Code, out
+	}
+
+	if code, out := get(cfg, "/healthz", ""); code != http.StatusOK || out["status"] != "ok" {
+		t.Errorf("healthz: %d %v", code, out)
+	}
+	if code, out := get(cfg, "/readyz", ""); code != http.StatusOK || out["checks"] == nil {
+		t.Errorf("readyz: %d %v, want 200 with the checks", code, out)
+	}
+	status = domain.StatusError
+	if code, out := get(cfg, "/readyz", ""); code != http.StatusServiceUnavailable || out["status"] != "unavailable" {
+		t.Errorf("readyz with a failed check: %d %v, want 503", code, out)
+	}
+
+	cfg.API.Tokens = []config.APITokenConfig{{Name: "grafana", Token: "view", Role: config.RoleViewer}}
+	if _, out := get(cfg, "/readyz", ""); out["checks"] != nil {
+		t.Errorf("readyz without a token showed the checks: %v", out)
+	}
+	if _, out := get(cfg, "/readyz", "view"); out["checks"] == nil {
+		t.Errorf("readyz with a viewer token hid the checks: %v", out)
+	}
+}
+
+func TestReadinessCached(t *testing.T) {
+	runs := 0
+	handler := api.New(config.DefaultConfig(), zap.NewNop(), nil).WithHealth(func(context.Context) []domain.HealthCheck {
+		runs++
+		return []domain.HealthCheck{}
+	}).Handler()
+	for range 3 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	}
+	if runs != 1 {
+		t.Errorf("checks ran %d times for 3 probes, want 1", runs)
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Files the self-signed certificate is kept in under paths.state, so
+// clients can pin it across restarts.
+const (
+	selfSignedCert = "api-tls.crt"
+	selfSignedKey  = "api-tls.key"
+)
+
+// selfSignedValidity is how long a generated certificate is valid; an
+// expired one is replaced at the next start.
+const selfSignedValidity = 365 * 24 * time.Hour
+
+// tlsConfig returns the TLS settings of api.tls, or nil when it is off.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	c := s.cfg.API.TLS
+	if !c.Enabled() {
+		return nil, nil
+	}
+	var cert tls.Certificate
+	var err error
+	if c.SelfSigned {
+		cert, err = s.selfSigned()
+	} else {
+		cert, err = tls.LoadX509KeyPair(c.Cert, c.Key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading API certificate: %w", err)
+	}
+	conf := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+	if c.ClientCA != "" {
+		pem, err := os.ReadFile(c.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("reading API client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in API client CA %s", c.ClientCA)
+		}
+		// Webhooks, Discord and probes cannot present a certificate;
+		// requireClientCert insists on one everywhere else.
+		conf.ClientCAs, conf.ClientAuth = pool, tls.VerifyClientCertIfGiven
+	}
+	return conf, nil
+}
+
+// requireClientCert refuses requests without a verified client certificate
+// when api.tls.client_ca is set, except on the routes authenticated by a
+// signature from a third party (/hooks and /discord/interactions) and the
+// probes (/healthz and /readyz).
+func (s *Server) requireClientCert(next http.Handler) http.Handler {
+	if s.cfg.API.TLS.ClientCA == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exempt := strings.HasPrefix(r.URL.Path, "/hooks/") || r.URL.Path == "/discord/interactions" ||
+			r.URL.Path == "/healthz" || r.URL.Path == "/readyz"
+		if !exempt && (r.TLS == nil || len(r.TLS.VerifiedChains) == 0) {
+			s.logger.Warn("Rejected API request without a client certificate", zap.String("path", r.URL.Path), zap.String("remote", r.RemoteAddr))
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "client certificate required"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// selfSigned loads the certificate kept under paths.state, generating and
+// saving a new one when there is none or it has expired. Without
+// paths.state the certificate lives only as long as the process.
+func (s *Server) selfSigned() (tls.Certificate, error) {
+	dir := s.cfg.Paths.State
+	if dir != "" {
+		certPath, keyPath := filepath.Join(dir, selfSignedCert), filepath.Join(dir, selfSignedKey)
+		if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil && time.Now().Before(cert.Leaf.NotAfter) {
+			return cert, nil
+		}
+	}
+	certPEM, keyPEM, err := generateCert(s.cfg.API.Listen, time.Now())
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return tls.Certificate{}, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, selfSignedKey), keyPEM, 0o600); err != nil {
+			return tls.Certificate{}, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, selfSignedCert), certPEM, 0o644); err != nil { //nolint:gosec // public certificate
+			return tls.Certificate{}, err
+		}
+		s.logger.Info("Generated self-signed API certificate", zap.String("path", filepath.Join(dir, selfSignedCert)))
+	}
+	return tls.X509KeyPair(certPEM, keyPEM)
+}
+
+// generateCert creates a self-signed ECDSA certificate for localhost, the
+// host name and the host of listen, returning it and its key as PEM.
+func generateCert(listen string, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "craftops"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if name, err := os.Hostname(); err == nil && name != "localhost" {
+		tmpl.DNSNames = append(tmpl.DNSNames, name)
+	}
+	if host, _, err := net.SplitHostPort(listen); err == nil && host != "" {
+		if ip := net.ParseIP(host); ip == nil {
+			tmpl.DNSNames = append(tmpl.DNSNames, host)
+		} else if !ip.IsUnspecified() && !ip.IsLoopback() {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
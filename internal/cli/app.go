@@ -2,13 +2,15 @@
 package cli
 
 import (
+	"context"
 	"os"
-	"path/filepath"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
 	"craftops/internal/config"
+	"craftops/internal/i18n"
 	"craftops/internal/service"
 	"craftops/internal/ui"
 )
@@ -21,6 +23,10 @@ type app struct {
 	Mods         *service.Mods
 	Backup       *service.Backup
 	Notification *service.Notification
+	Proxy        *service.Proxy
+	State        *service.StateStore
+	Tracer       *service.Tracer
+	Reports      *service.ReportStore
 }
 
 func newLogger(cfg *config.Config) *zap.Logger {
@@ -35,20 +41,28 @@ func newLogger(cfg *config.Config) *zap.Logger {
 		encoderCfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
 	}
 
+	// --quiet and --verbose only move the console; the file keeps Logging.Level.
+	consoleLevel := level
+	switch {
+	case cfg.Quiet:
+		consoleLevel = zap.NewAtomicLevelAt(zap.ErrorLevel)
+	case cfg.Verbose:
+		consoleLevel = zap.NewAtomicLevelAt(zap.DebugLevel)
+	}
+
 	var cores []zapcore.Core
 
 	if cfg.Logging.ConsoleEnabled {
 		cores = append(cores, zapcore.NewCore(
 			zapcore.NewConsoleEncoder(encoderCfg),
-			zapcore.AddSync(os.Stderr),
-			level,
+			zapcore.AddSync(ui.Stderr),
+			consoleLevel,
 		))
 	}
 
 	if cfg.Logging.FileEnabled && cfg.Paths.Logs != "" {
 		if err := os.MkdirAll(cfg.Paths.Logs, 0o750); err == nil {
-			logPath := filepath.Join(cfg.Paths.Logs, "craftops.log")
-			if f, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600); err == nil { //nolint:gosec
+			if f, err := openRotatingFile(cfg.Paths.Logs, cfg.Logging); err == nil {
 				var enc zapcore.Encoder
 				if cfg.Logging.Format == "text" {
 					enc = zapcore.NewConsoleEncoder(encoderCfg)
@@ -68,18 +82,57 @@ func newLogger(cfg *config.Config) *zap.Logger {
 
 func newApp(cfg *config.Config) *app {
 	logger := newLogger(cfg)
+	messages, err := i18n.Load(cfg.UI.Language, cfg.UI.MessagesDir)
+	if err != nil {
+		logger.Warn("Messages not loaded, using English", zap.Error(err))
+		messages = i18n.English()
+	}
+	terminal := newTerminal()
+	terminal.UseMessages(messages)
+	terminal.UseDesktopNotifications(cfg.UI.DesktopNotifications)
+	server := service.NewServer(cfg, logger)
+	notification := service.NewNotification(cfg, logger)
+	notification.UseConsole(server)
+	notification.UseMessages(messages)
+	proxy := service.NewProxy(cfg, logger)
+	server.UseProxy(proxy)
+	notification.UseProxy(proxy)
+	backup := service.NewBackup(cfg, logger)
+	backup.UseNotification(notification)
+	tracer := service.NewTracer(cfg, logger)
+	service.UseTracer(tracer)
 	return &app{
 		Config:       cfg,
 		Logger:       logger,
-		Terminal:     ui.NewTerminal(),
-		Server:       service.NewServer(cfg, logger),
+		Terminal:     terminal,
+		Server:       server,
 		Mods:         service.NewMods(cfg, logger),
-		Backup:       service.NewBackup(cfg, logger),
-		Notification: service.NewNotification(cfg, logger),
+		Backup:       backup,
+		Notification: notification,
+		Proxy:        proxy,
+		State:        service.NewStateStore(cfg),
+		Tracer:       tracer,
+		Reports:      service.NewReportStore(cfg),
 	}
 }
 
+// newTerminal returns a terminal configured from the global output flags.
+func newTerminal() *ui.Terminal {
+	t := ui.NewTerminal()
+	if noColor {
+		t.SetNoColor()
+	}
+	t.SetQuiet(quiet)
+	t.SetAssumeYes(assumeYes)
+	return t
+}
+
 func (a *app) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.Tracer.Flush(ctx); err != nil {
+		a.Logger.Warn("Failed to export traces", zap.Error(err))
+	}
 	if a.Logger != nil {
 		_ = a.Logger.Sync()
 	}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var (
+	bansJSON  bool
+	banReason string
+	bansLocal bool
+)
+
+func init() {
+	rootCmd.AddCommand(bansCmd)
+	bansCmd.AddCommand(bansListCmd, bansAddCmd, bansRemoveCmd, bansSyncCmd)
+	bansListCmd.Flags().BoolVar(&bansJSON, "json", false, "print the bans as JSON")
+	bansAddCmd.Flags().StringVar(&banReason, "reason", "", "reason shown to the banned player")
+	for _, c := range []*cobra.Command{bansAddCmd, bansRemoveCmd} {
+		c.Flags().BoolVar(&bansLocal, "local", false, "only change this server, even with bans.shared")
+	}
+}
+
+var bansCmd = &cobra.Command{
+	Use:   "bans",
+	Short: "Ban list management (banned-players.json, banned-ips.json)",
+	Long: `Bans are sent through the server console while it runs and written to
+banned-players.json or banned-ips.json otherwise. With bans.shared, add and
+remove also apply to every local server in [fleet], and sync merges their
+ban lists.`,
+}
+
+var bansListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List banned players and IP addresses",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		list, err := service.NewBans(a.Config, a.Logger, a.Server).List()
+		if err != nil {
+			return err
+		}
+		if bansJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(list)
+		}
+		if len(list.Players)+len(list.IPs) == 0 {
+			a.Terminal.Info("Nobody is banned")
+			return nil
+		}
+		rows := make([][]string, 0, len(list.Players)+len(list.IPs))
+		for _, e := range list.Players {
+			rows = append(rows, []string{"player", e.Name, e.Reason, e.Created, e.Source})
+		}
+		for _, e := range list.IPs {
+			rows = append(rows, []string{"ip", e.IP, e.Reason, e.Created, e.Source})
+		}
+		a.Terminal.Table([]string{"Type", "Target", "Reason", "Since", "By"}, rows)
+		return nil
+	},
+}
+
+var bansAddCmd = &cobra.Command{
+	Use:   "add <player|ip>",
+	Short: "Ban a player or an IP address",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		entry, err := service.NewBans(a.Config, a.Logger, a.Server).Add(ctx, args[0], banReason)
+		if err != nil {
+			return err
+		}
+		a.Terminal.Successf("Banned %s", entry.Target())
+		return forEachBanMember(a, "ban", func(b *service.Bans) error {
+			_, err := b.Apply(ctx, domain.BanListOf(*entry))
+			return err
+		})
+	},
+}
+
+var bansRemoveCmd = &cobra.Command{
+	Use:   "remove <player|uuid|ip>",
+	Short: "Pardon a player or an IP address",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		if err := service.NewBans(a.Config, a.Logger, a.Server).Remove(ctx, args[0]); err != nil {
+			return err
+		}
+		a.Terminal.Successf("Pardoned %s", args[0])
+		return forEachBanMember(a, "pardon", func(b *service.Bans) error {
+			if err := b.Remove(ctx, args[0]); err != nil && !errors.Is(err, domain.ErrNotBanned) {
+				return err
+			}
+			return nil
+		})
+	},
+}
+
+var bansSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Give every server in [fleet] the bans of all the others",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		if !a.Config.Bans.Shared {
+			return withExitCode(ExitConfig, errors.New("bans.shared is not enabled"))
+		}
+		members, err := banMembers(a)
+		if err != nil {
+			return err
+		}
+		members = append([]banMember{{"this server", service.NewBans(a.Config, a.Logger, a.Server)}}, members...)
+		lists := make([]*domain.BanList, 0, len(members))
+		for _, m := range members {
+			list, err := m.bans.List()
+			if err != nil {
+				return fmt.Errorf("%s: %w", m.name, err)
+			}
+			lists = append(lists, list)
+		}
+		merged := service.MergeBans(lists...)
+
+		rows := make([][]string, 0, len(members))
+		failed := 0
+		for _, m := range members {
+			added, err := m.bans.Apply(ctx, merged)
+			result := fmt.Sprintf("%d added", len(added))
+			if len(added) > 0 {
+				result += ": " + strings.Join(added, ", ")
+			}
+			if err != nil {
+				failed++
+				result = a.Terminal.ErrorSprint("FAILED") + " " + err.Error()
+			}
+			rows = append(rows, []string{m.name, result})
+		}
+		a.Terminal.Table([]string{"Server", "Bans"}, rows)
+		if failed > 0 {
+			return fmt.Errorf("bans sync: %d of %d server(s) failed", failed, len(members))
+		}
+		a.Terminal.Successf("%d player and %d IP ban(s) on every server", len(merged.Players), len(merged.IPs))
+		return nil
+	},
+}
+
+// banMember is another server sharing this one's ban list.
+type banMember struct {
+	name string
+	bans *service.Bans
+}
+
+// banMembers loads the local [fleet] servers other than this one. Servers
+// on other hosts are skipped with a warning.
+func banMembers(a *app) ([]banMember, error) {
+	paths, err := fleetConfigs(a.Config)
+	if err != nil {
+		return nil, withExitCode(ExitConfig, err)
+	}
+	self, _ := filepath.Abs(a.Config.Paths.Server)
+	var members []banMember
+	for _, path := range paths {
+		cfg, err := config.LoadConfig(path)
+		if err != nil {
+			return nil, withExitCode(ExitConfig, err)
+		}
+		applyGlobalFlags(cfg)
+		if dir, _ := filepath.Abs(cfg.Paths.Server); dir == self {
+			continue
+		}
+		if cfg.Host != "" {
+			a.Terminal.Warningf("Skipping %s: bans are only shared between servers on this host", profileName(path))
+			continue
+		}
+		members = append(members, banMember{profileName(path), service.NewBans(cfg, a.Logger, service.NewServer(cfg, a.Logger))})
+	}
+	return members, nil
+}
+
+// forEachBanMember runs fn on the servers sharing the ban list, unless
+// bans.shared is off or --local is given, and reports each outcome.
+func forEachBanMember(a *app, verb string, fn func(*service.Bans) error) error {
+	if !a.Config.Bans.Shared || bansLocal {
+		return nil
+	}
+	members, err := banMembers(a)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, m := range members {
+		if err := fn(m.bans); err != nil {
+			failed++
+			a.Terminal.Warningf("%s: %v", m.name, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s not applied on %d of %d shared server(s)", verb, failed, len(members))
+	}
+	if len(members) > 0 {
+		a.Terminal.Infof("Applied the %s on %d shared server(s)", verb, len(members))
+	}
+	return nil
+}
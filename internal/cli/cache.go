@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheGCCmd)
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the shared download cache",
+}
+
+var cacheGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Remove cached jars no registered mods directory uses",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		res, err := a.Mods.GCArtifacts()
+		if err != nil {
+			return err
+		}
+		for _, p := range res.Profiles {
+			a.Terminal.Printf("   %s\n", a.Terminal.DimSprint(p))
+		}
+		verb := "Removed"
+		if a.Config.DryRun {
+			verb = "Dry run: would remove"
+		}
+		a.Terminal.Successf("%s %d artifact(s), freeing %s; %d still in use by %d mods director(ies)",
+			verb, res.Removed, domain.FormatSize(res.Freed), res.Kept, len(res.Profiles))
+		return nil
+	},
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var (
+	cloneFrom, cloneTo string
+	cloneOptions       domain.CloneOptions
+)
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+	cloneCmd.Flags().StringVar(&cloneFrom, "from", "", "profile to copy (a [fleet] config name, e.g. survival)")
+	cloneCmd.Flags().StringVar(&cloneTo, "to", "", "profile to overwrite with the copy (e.g. staging)")
+	cloneCmd.Flags().IntVar(&cloneOptions.Port, "port", 0, "server-port for the copy (default: the target's, else the source's + 1)")
+	cloneCmd.Flags().StringVar(&cloneOptions.Name, "name", "", "motd for the copy (default: the target profile name)")
+	_ = cloneCmd.MarkFlagRequired("from")
+	_ = cloneCmd.MarkFlagRequired("to")
+}
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone --from <profile> --to <profile>",
+	Short: "Copy one profile's server into another's paths (e.g. a staging copy for testing mod updates)",
+	Long: `Clone copies the --from server directory, minus backup.exclude_patterns,
+into the --to profile's server directory and gives the copy its own ports
+and motd in server.properties. The target's mods and lockfile are replaced
+with the source's. Profiles are the config files listed in fleet.configs,
+named after the file (servers/staging.toml is "staging"). The target's old
+server directory is kept as <dir>.pre-clone.`,
+	Annotations: local,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		from, err := loadProfile(a.Config, cloneFrom)
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		to, err := loadProfile(a.Config, cloneTo)
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if from.Host != "" || to.Host != "" {
+			return withExitCode(ExitConfig, fmt.Errorf("clone works on local profiles only; %s or %s sets host", cloneFrom, cloneTo))
+		}
+
+		status, err := service.NewServer(to, a.Logger).Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.IsRunning {
+			return fmt.Errorf("%s is running; stop it before cloning over it", cloneTo)
+		}
+		if status, err := service.NewServer(from, a.Logger).Status(ctx); err == nil && status.IsRunning {
+			a.Terminal.Warningf("%s is running; the copy may catch the world mid-save", cloneFrom)
+		}
+		if !a.Config.DryRun {
+			if err := confirm(a, fmt.Sprintf("Replace %s with a copy of %s?", to.Paths.Server, cloneFrom)); err != nil {
+				return err
+			}
+		}
+
+		opts := cloneOptions
+		if opts.Name == "" {
+			opts.Name = cloneTo
+		}
+		a.Terminal.Infof("Cloning %s into %s...", cloneFrom, cloneTo)
+		res, err := service.NewBackup(from, a.Logger).CloneInto(ctx, to, opts)
+		if err != nil {
+			return err
+		}
+		if a.Config.DryRun {
+			a.Terminal.Infof("Dry run: would copy %s to %s on port %d", from.Paths.Server, res.ServerDir, res.Port)
+			return nil
+		}
+		if res.PreviousDir != "" {
+			a.Terminal.Printf("   Previous server directory kept at %s\n", a.Terminal.DimSprint(res.PreviousDir))
+		}
+		a.Terminal.Successf("Cloned %s into %s: %d file(s), %d mod(s), port %d", cloneFrom, cloneTo, res.Files, res.Mods, res.Port)
+		return nil
+	},
+}
+
+// loadProfile loads the fleet config called name.
+func loadProfile(cfg *config.Config, name string) (*config.Config, error) {
+	paths, err := fleetConfigs(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, path := range paths {
+		if profileName(path) == name {
+			profile, err := config.LoadConfig(path)
+			if err != nil {
+				return nil, err
+			}
+			applyGlobalFlags(profile)
+			return profile, nil
+		}
+		names = append(names, profileName(path))
+	}
+	return nil, fmt.Errorf("no profile %q in fleet.configs (have %s)", name, strings.Join(names, ", "))
+}
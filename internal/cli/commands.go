@@ -1,35 +1,80 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"maps"
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"craftops/internal/config"
 	"craftops/internal/domain"
-	"craftops/internal/ui"
+	"craftops/internal/service"
 )
 
 var (
-	forceUpdate bool
-	noBackup    bool
-	outputPath  string
-	force       bool
+	forceUpdate   bool
+	noBackup      bool
+	checkOnly     bool
+	failOnError   bool
+	outputPath    string
+	force         bool
+	forceStop     bool
+	cancelRestart bool
+	onlyMods      []string
+	excludeMods   []string
+	backupTag     string
+	backupProtect bool
+	backupInclude []string
+	backupLevel   int
+	estimateOnly  bool
+	inspectPath   string
+	extractDest   string
+	statusJSON    bool
+	dumpThreads   bool
+	dumpHeap      bool
+	repairMods    bool
+	healthTimeout time.Duration
+	healthBudget  time.Duration
 )
 
 func init() {
 	rootCmd.AddCommand(serverCmd, modsCmd, backupCmd, healthCmd, initCmd)
-	serverCmd.AddCommand(serverStartCmd, serverStopCmd, serverRestartCmd, serverStatusCmd)
-	modsCmd.AddCommand(modsUpdateCmd, modsListCmd)
-	backupCmd.AddCommand(backupCreateCmd, backupListCmd, backupDeleteCmd)
+	serverCmd.AddCommand(serverStartCmd, serverStopCmd, serverRestartCmd, serverStatusCmd, serverPerfCmd, serverAdoptCmd,
+		serverGCReportCmd, serverDumpCmd, serverInitCmd)
+	modsCmd.AddCommand(modsUpdateCmd, modsListCmd, modsVerifyCmd)
+	backupCmd.AddCommand(backupCreateCmd, backupListCmd, backupDeleteCmd, backupInspectCmd, backupExtractCmd)
 
 	modsUpdateCmd.Flags().BoolVar(&forceUpdate, "force", false, "force update even if mod is current")
 	modsUpdateCmd.Flags().BoolVar(&noBackup, "no-backup", false, "skip pre-update backup")
+	modsUpdateCmd.Flags().BoolVar(&checkOnly, "check", false, "only report available updates, download nothing")
+	modsUpdateCmd.Flags().StringSliceVar(&onlyMods, "only", nil, "update only these mods (slug or filename, comma-separated)")
+	modsUpdateCmd.Flags().StringSliceVar(&excludeMods, "exclude", nil, "skip these mods (slug or filename, comma-separated)")
+	modsUpdateCmd.Flags().BoolVar(&failOnError, "fail-on-error", true, "exit non-zero and notify when any mod fails")
+	modsVerifyCmd.Flags().BoolVar(&repairMods, "repair", false, "re-download missing, modified or corrupt mods")
+	backupCreateCmd.Flags().StringVar(&backupTag, "tag", "", "tag substituted for {tag} in backup.name_template; max_backups applies per tag")
+	backupCreateCmd.Flags().BoolVar(&backupProtect, "protect", false, "never prune this backup for max_backups or disk space")
+	backupCreateCmd.Flags().StringSliceVar(&backupInclude, "include", nil, "only archive paths matching these patterns (overrides backup.include_patterns)")
+	backupCreateCmd.Flags().IntVar(&backupLevel, "compression", 0, "gzip level 1-9 for this backup (default backup.compression_level)")
+	backupCreateCmd.Flags().BoolVar(&estimateOnly, "estimate", false, "report the file count and projected size without writing a backup")
+	serverRestartCmd.Flags().BoolVar(&cancelRestart, "cancel", false, "abort a pending warned restart")
+	serverStopCmd.Flags().BoolVar(&forceStop, "force", false, "escalate to SIGTERM/SIGKILL if the server ignores stop")
+	serverStatusCmd.Flags().BoolVar(&statusJSON, "json", false, "print status as JSON")
+	serverPerfCmd.Flags().BoolVar(&statusJSON, "json", false, "print the sample as JSON")
+	serverGCReportCmd.Flags().BoolVar(&statusJSON, "json", false, "print the report as JSON")
+	serverDumpCmd.Flags().BoolVar(&dumpThreads, "threads", false, "capture a thread dump (default)")
+	serverDumpCmd.Flags().BoolVar(&dumpHeap, "heap", false, "capture a heap dump (.hprof, as large as the used heap)")
+	serverDumpCmd.MarkFlagsMutuallyExclusive("threads", "heap")
+	backupInspectCmd.Flags().StringVar(&inspectPath, "path", "", "only list entries under this path (e.g. world/)")
+	backupExtractCmd.Flags().StringVarP(&extractDest, "output", "o", ".", "directory to extract into")
+	healthCmd.Flags().DurationVar(&healthTimeout, "timeout", 10*time.Second, "time each component's checks may take before reporting WARN")
+	healthCmd.Flags().DurationVar(&healthBudget, "budget", 30*time.Second, "time limit for the whole health run")
 	initCmd.Flags().StringVarP(&outputPath, "output", "o", "", "config file output path")
 	initCmd.Flags().BoolVar(&force, "force", false, "overwrite existing config file")
 }
@@ -42,79 +87,351 @@ var serverCmd = &cobra.Command{
 }
 
 var serverStartCmd = &cobra.Command{
-	Use:   "start",
-	Short: "Start the Minecraft server",
+	Use:         "start",
+	Short:       "Start the Minecraft server",
+	Annotations: disruptive,
 	RunE: func(cmd *cobra.Command, _ []string) error {
-		a := appFrom(cmd)
-		a.Terminal.Info("Starting server...")
-		if err := a.Server.Start(cmd.Context()); err != nil {
-			a.Terminal.Errorf("Failed to start server: %v", err)
+		ctx, a := cmd.Context(), appFrom(cmd)
+		a.Terminal.Info(a.Terminal.T("server.starting"))
+		if err := a.Server.Start(ctx); err != nil {
+			a.Terminal.Error(a.Terminal.T("server.start_failed", "error", err))
+			displayStartupError(a, err)
+			_ = a.Notification.SendError(ctx, a.Notification.T("notify.start_failed", "error", err))
 			return err
 		}
-		a.Terminal.Success("Server is now running")
+		a.Terminal.Success(a.Terminal.T("server.running"))
 		return nil
 	},
 }
 
+// displayStartupError prints the log tail captured when a start timed out.
+func displayStartupError(a *app, err error) {
+	var se *domain.StartupError
+	if !errors.As(err, &se) || len(se.LogTail) == 0 {
+		return
+	}
+	a.Terminal.Section(a.Terminal.T("server.log_tail"))
+	for _, line := range se.LogTail {
+		a.Terminal.Println("  " + a.Terminal.DimSprint(line))
+	}
+}
+
 var serverStopCmd = &cobra.Command{
-	Use:   "stop",
-	Short: "Stop the Minecraft server",
+	Use:         "stop",
+	Short:       "Stop the Minecraft server",
+	Annotations: disruptive,
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		a := appFrom(cmd)
-		a.Terminal.Info("Stopping server...")
+		if forceStop {
+			if err := confirm(a, a.Terminal.T("server.force_stop_confirm")); err != nil {
+				return err
+			}
+			a.Config.Server.ForceStop = true
+		}
+		a.Terminal.Info(a.Terminal.T("server.stopping"))
 		if err := a.Server.Stop(cmd.Context()); err != nil {
-			a.Terminal.Errorf("Failed to stop server: %v", err)
+			a.Terminal.Error(a.Terminal.T("server.stop_failed", "error", err))
 			return err
 		}
-		a.Terminal.Success("Server stopped")
+		a.Terminal.Success(a.Terminal.T("server.stopped"))
 		return nil
 	},
 }
 
 var serverRestartCmd = &cobra.Command{
-	Use:   "restart",
-	Short: "Restart the Minecraft server",
-	RunE: func(cmd *cobra.Command, _ []string) error {
+	Use:         "restart",
+	Short:       "Restart the Minecraft server",
+	Annotations: disruptive,
+	RunE: func(cmd *cobra.Command, _ []string) (err error) {
 		ctx, a := cmd.Context(), appFrom(cmd)
-		if len(a.Config.Notifications.WarningIntervals) > 0 {
-			a.Terminal.Info("Sending restart warnings...")
-			if err := a.Notification.SendRestartWarnings(ctx); err != nil {
-				a.Terminal.Warningf("Warning notifications failed: %v", err)
+		if cancelRestart {
+			pending, err := a.Server.CancelPendingRestart(ctx)
+			if err != nil {
+				return err
 			}
+			a.Terminal.Success(a.Terminal.T("restart.cancelled", "at", pending.At.Format(timeFormat)))
+			_ = a.Notification.SendInfo(ctx, a.Notification.T("notify.restart_cancelled_title"), a.Notification.T("notify.restart_cancelled"))
+			return nil
 		}
-		a.Terminal.Info("Restarting server...")
-		if err := a.Server.Restart(ctx); err != nil {
-			a.Terminal.Errorf("Failed to restart: %v", err)
-			_ = a.Notification.SendError(ctx, fmt.Sprintf("Server restart failed: %v", err))
+		report := startReport(domain.OpRestart)
+		defer func() { finishReport(a, report, err) }()
+		err = warnedRestart(ctx, a)
+		switch {
+		case errors.Is(err, domain.ErrRestartCancelled):
+			a.Terminal.Warning(a.Terminal.T("restart.aborted"))
+			return nil
+		case err != nil:
 			return err
 		}
-		a.Terminal.Success("Server restarted")
-		_ = a.Notification.SendSuccess(ctx, "Server restarted successfully")
+		a.Terminal.Success(a.Terminal.T("restart.done"))
+		_ = a.Notification.SendSuccess(ctx, a.Notification.T("notify.restarted"))
 		return nil
 	},
 }
 
+// warnedRestart restarts the server the way `server restart` does: it
+// defers while players are online, counts down with the restart warnings
+// (which `server restart --cancel` aborts with domain.ErrRestartCancelled)
+// and then restarts. A failed restart is reported before it is returned.
+func warnedRestart(ctx context.Context, a *app) error {
+	err := a.Server.AwaitQuiet(ctx, func(online int, remaining time.Duration) {
+		msg := a.Terminal.T("restart.deferred", "players", online, "remaining", remaining.Round(time.Minute))
+		if remaining <= 0 {
+			msg = a.Terminal.T("restart.deferral_limit", "players", online)
+		}
+		a.Terminal.Info(msg)
+		_ = a.Notification.SendInfo(ctx, a.Notification.T("notify.restart_deferred_title"), msg)
+	})
+	if err != nil {
+		return err
+	}
+	if intervals := a.Config.Notifications.WarningIntervals; len(intervals) > 0 {
+		a.Terminal.Info(a.Terminal.T("restart.sending_warnings"))
+		at := time.Now().Add(time.Duration(slices.Max(intervals)) * time.Minute)
+		warnCtx, done := a.Server.BeginPendingRestart(ctx, at)
+		err := a.Notification.SendRestartWarnings(warnCtx)
+		cancelled := errors.Is(context.Cause(warnCtx), domain.ErrRestartCancelled)
+		done()
+		if cancelled {
+			return domain.ErrRestartCancelled
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			a.Terminal.Warning(a.Terminal.T("restart.warnings_failed", "error", err))
+		}
+	}
+	a.Terminal.Info(a.Terminal.T("restart.restarting"))
+	if err := restartWithSpinner(ctx, a); err != nil {
+		a.Terminal.Error(a.Terminal.T("restart.failed", "error", err))
+		displayStartupError(a, err)
+		_ = a.Notification.SendError(ctx, a.Notification.T("notify.restart_failed", "error", err))
+		return err
+	}
+	return nil
+}
+
+// restartWithSpinner restarts the server with a spinner running until it
+// is back up.
+func restartWithSpinner(ctx context.Context, a *app) error {
+	spin := a.Terminal.Spinner(a.Terminal.T("spinner.restarting"))
+	defer spin.Stop()
+	return a.Server.Restart(ctx)
+}
+
 var serverStatusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show server status",
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		a := appFrom(cmd)
-		status, err := a.Server.Status(cmd.Context())
+		status, err := a.Server.Usage(cmd.Context())
+		if err != nil {
+			a.Terminal.Error(a.Terminal.T("status.failed", "error", err))
+			return err
+		}
+		if statusJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(status)
+		}
+		switch {
+		case status.Unmanaged && status.Adopted:
+			a.Terminal.Warning(a.Terminal.T("status.adopted"))
+		case status.Unmanaged:
+			a.Terminal.Warning(a.Terminal.T("status.unmanaged"))
+		case status.IsRunning:
+			a.Terminal.Success(a.Terminal.T("status.running"))
+		default:
+			a.Terminal.Warning(a.Terminal.T("status.stopped"))
+		}
+		field := func(key string, value any) { a.Terminal.Printf("  %-12s: %v\n", a.Terminal.T(key), value) }
+		field("status.session", status.SessionName)
+		if status.PID > 0 {
+			field("status.pid", status.PID)
+			field("status.cpu", fmt.Sprintf("%.1f%%", status.CPUPercent))
+			field("status.memory", domain.FormatSize(status.MemoryRSS))
+			field("status.uptime", status.Uptime.Round(time.Second))
+		}
+		if status.Port > 0 {
+			state := "status.port_closed"
+			if status.PortOpen {
+				state = "status.port_listening"
+			}
+			field("status.port", a.Terminal.T(state, "port", status.Port))
+		}
+		for _, last := range []struct{ key, op string }{
+			{"status.last_backup", domain.OpBackup}, {"status.last_update", domain.OpModUpdate}, {"status.last_restart", domain.OpRestart},
+		} {
+			at, ok := status.LastSuccess[last.op]
+			if !ok {
+				field(last.key, a.Terminal.DimSprint(a.Terminal.T("status.never")))
+				continue
+			}
+			field(last.key, fmt.Sprintf("%s (%s)", at.Format(timeFormat), domain.FormatAge(time.Since(at))))
+		}
+		field("status.checked", status.CheckedAt.Format("2006-01-02 15:04:05"))
+		if warn := time.Duration(a.Config.Backup.MaxAgeHours) * time.Hour; a.Config.Backup.Enabled && warn > 0 &&
+			time.Since(status.LastSuccess[domain.OpBackup]) > warn {
+			a.Terminal.Warning(a.Terminal.T("status.backup_stale", "age", warn))
+		}
+		return nil
+	},
+}
+
+var serverAdoptCmd = &cobra.Command{
+	Use:   "adopt",
+	Short: "Take over a server process that outlived its screen session",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		pid, err := a.Server.Adopt(cmd.Context())
+		if err != nil {
+			return err
+		}
+		a.Terminal.Successf("Adopted server process %d; `craftops server stop` will send it SIGTERM", pid)
+		return nil
+	},
+}
+
+var serverPerfCmd = &cobra.Command{
+	Use:   "perf",
+	Short: "Show TPS and MSPT via RCON",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		sample, err := a.Server.Perf(cmd.Context())
+		if err != nil {
+			a.Terminal.Errorf("Failed to sample performance: %v", err)
+			return err
+		}
+		if statusJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(sample)
+		}
+		switch {
+		case sample.TPS1m >= 19.5:
+			a.Terminal.Successf("TPS %.1f", sample.TPS1m)
+		case sample.TPS1m >= 15:
+			a.Terminal.Warningf("TPS %.1f", sample.TPS1m)
+		default:
+			a.Terminal.Errorf("TPS %.1f", sample.TPS1m)
+		}
+		if sample.TPS5m > 0 || sample.TPS15m > 0 {
+			a.Terminal.Printf("  TPS 5m/15m : %.1f / %.1f\n", sample.TPS5m, sample.TPS15m)
+		}
+		if sample.MSPT > 0 {
+			a.Terminal.Printf("  MSPT       : %.2f ms\n", sample.MSPT)
+		}
+		a.Terminal.Printf("  Source     : %s\n", sample.Source)
+		return nil
+	},
+}
+
+var serverGCReportCmd = &cobra.Command{
+	Use:   "gc-report",
+	Short: "Summarize GC pause times from the server's GC log",
+	Long: "Reads the GC log written with server.diagnostics.gc_log enabled and reports pause\n" +
+		"counts, totals, percentiles and the longest pauses since the server started.",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		report, err := a.Server.GCReport()
+		if err != nil {
+			return err
+		}
+		if statusJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+		if report.Pauses == 0 {
+			a.Terminal.Infof("No GC pauses logged yet in %s", report.File)
+			return nil
+		}
+		pause := func(d time.Duration) string { return d.Round(100 * time.Microsecond).String() }
+		a.Terminal.Section("GC pauses")
+		a.Terminal.Printf("  %-10s: %s\n", "Uptime", report.Uptime.Round(time.Second))
+		a.Terminal.Printf("  %-10s: %d, %s total (%.2f%% of uptime)\n", "Pauses", report.Pauses,
+			report.Total.Round(time.Millisecond), pausePercent(report.Total, report.Uptime))
+		a.Terminal.Printf("  %-10s: mean %s, p95 %s, max %s\n", "Duration",
+			pause(report.Mean), pause(report.P95), pause(report.Max))
+		rows := make([][]string, 0, len(report.Kinds))
+		for _, k := range report.Kinds {
+			rows = append(rows, []string{k.Kind, strconv.Itoa(k.Count), pause(k.Total), pause(k.Max)})
+		}
+		a.Terminal.Table([]string{"Kind", "Count", "Total", "Max"}, rows)
+		a.Terminal.Section("Longest pauses")
+		rows = rows[:0]
+		for _, p := range report.Longest {
+			rows = append(rows, []string{p.At, p.Kind, p.Heap, pause(p.Duration)})
+		}
+		a.Terminal.Table([]string{"At", "Kind", "Heap", "Duration"}, rows)
+		if report.Max >= 50*time.Millisecond {
+			a.Terminal.Warning("Pauses over 50ms cost a full tick; consider more heap, flags_preset = \"aikar\" or fewer memory-hungry mods")
+		}
+		return nil
+	},
+}
+
+var serverDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Save a thread or heap dump of the running server",
+	Long: "Runs jcmd (or jstack/jmap) against the server's java process and stores the\n" +
+		"output under dumps/ in paths.logs. Needs the JDK tools next to java or in PATH.",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		kind := service.DumpThreads
+		if dumpHeap {
+			kind = service.DumpHeap
+			a.Terminal.Info("Writing heap dump; the server pauses while it is taken...")
+		}
+		file, err := a.Server.Dump(ctx, kind)
+		if err != nil {
+			return err
+		}
+		a.Terminal.Successf("Saved %s dump to %s", kind, file)
+		return nil
+	},
+}
+
+var serverInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Provision the server directory from [templates]",
+	Long: "Renders every *.tmpl file under templates.dir into paths.server, e.g.\n" +
+		"server.properties.tmpl to server.properties, using templates.values.\n" +
+		"Only files whose content changes are written; --dry-run lists them.",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		if a.Config.Templates.Dir == "" {
+			return withExitCode(ExitConfig, errors.New("templates.dir is not set"))
+		}
+		changed, err := a.Server.RenderTemplates()
 		if err != nil {
-			a.Terminal.Errorf("Failed to get status: %v", err)
 			return err
 		}
-		if status.IsRunning {
-			a.Terminal.Success("Server is running")
-		} else {
-			a.Terminal.Warning("Server is not running")
+		if len(changed) == 0 {
+			a.Terminal.Success("Server files already match the templates")
+			return nil
+		}
+		verb := "Rendered"
+		if a.Config.DryRun {
+			verb = "Would render"
+		}
+		for _, f := range changed {
+			a.Terminal.Printf("  %s\n", f)
 		}
-		a.Terminal.Printf("  Session : %s\n", status.SessionName)
-		a.Terminal.Printf("  Checked : %s\n", status.CheckedAt.Format("2006-01-02 15:04:05"))
+		a.Terminal.Successf("%s %d file(s) into %s", verb, len(changed), a.Config.Paths.Server)
 		return nil
 	},
 }
 
+// pausePercent is the share of uptime spent paused.
+func pausePercent(paused, uptime time.Duration) float64 {
+	if uptime <= 0 {
+		return 0
+	}
+	return float64(paused) / float64(uptime) * 100
+}
+
 // ── Mods ─────────────────────────────────────────────────────────────────────
 
 var modsCmd = &cobra.Command{
@@ -123,25 +440,59 @@ var modsCmd = &cobra.Command{
 }
 
 var modsUpdateCmd = &cobra.Command{
-	Use:   "update",
-	Short: "Update all configured mods",
-	RunE: func(cmd *cobra.Command, _ []string) error {
+	Use:         "update",
+	Short:       "Update all configured mods",
+	Annotations: disruptive,
+	RunE: func(cmd *cobra.Command, _ []string) (err error) {
 		ctx, a := cmd.Context(), appFrom(cmd)
-		a.Terminal.Banner("Mod Update Manager")
-		if !noBackup && a.Config.Backup.Enabled {
-			a.Terminal.Info("Creating pre-update backup...")
-			if path, err := a.Backup.Create(ctx); err != nil && !errors.Is(err, domain.ErrBackupsDisabled) {
+		a.Terminal.Banner(a.Terminal.T("mods.banner"))
+		report := startReport(domain.OpModUpdate)
+		defer func() {
+			finishReport(a, report, err)
+			notifyDone(a, report)
+		}()
+		if checkOnly {
+			a.Config.DryRun = true
+		}
+		if stagingUpdate || stagingProfile != "" {
+			return stagedUpdate(ctx, a, report)
+		}
+		var backup string
+		if !noBackup && !checkOnly && a.Config.Backup.Enabled {
+			a.Terminal.Info(a.Terminal.T("mods.pre_update_backup"))
+			spin := a.Terminal.Spinner(a.Terminal.T("spinner.backing_up"))
+			path, err := a.Backup.CreatePreUpdate(ctx)
+			spin.Stop()
+			if err != nil && !errors.Is(err, domain.ErrBackupsDisabled) {
 				return err
-			} else if path != "" {
-				a.Terminal.Successf("Backup created: %s", path)
 			}
+			if path != "" {
+				backup = path
+				a.Terminal.Success(a.Terminal.T("backup.created", "path", path))
+			}
+		}
+		if err := a.Mods.SaveRollback(ctx, backup); err != nil {
+			return fmt.Errorf("saving mods for rollback: %w", err)
 		}
-		a.Terminal.Info("Updating mods...")
-		result, err := a.Mods.UpdateAll(ctx, forceUpdate)
+		a.Terminal.Info(a.Terminal.T("mods.updating"))
+		spin := a.Terminal.Spinner(a.Terminal.T("spinner.updating"))
+		result, err := a.Mods.UpdateSelected(ctx, forceUpdate, domain.ModFilter{Only: onlyMods, Exclude: excludeMods})
+		spin.Stop()
 		if err != nil {
 			return err
 		}
+		report.Mods = result
 		displayModResults(a, result)
+		if err := a.Notification.SendModDigest(ctx, result); err != nil {
+			a.Terminal.Warning(a.Terminal.T("mods.notify_failed", "error", err))
+		}
+		if len(result.FailedMods) > 0 && failOnError {
+			total := len(result.UpdatedMods) + len(result.FailedMods) + len(result.SkippedMods)
+			return fmt.Errorf("%w: %d of %d", domain.ErrModUpdatesFailed, len(result.FailedMods), total)
+		}
+		if restartAfter {
+			return restartAfterUpdate(ctx, a, result, backup)
+		}
 		return nil
 	},
 }
@@ -157,7 +508,7 @@ var modsListCmd = &cobra.Command{
 			return err
 		}
 		if len(mods) == 0 {
-			a.Terminal.Warning("No mods installed in " + a.Config.Paths.Mods)
+			a.Terminal.Warning("No mods installed in " + a.Config.ModsDir())
 			return nil
 		}
 		a.Terminal.Section(fmt.Sprintf("Installed Mods (%d)", len(mods)))
@@ -171,10 +522,62 @@ var modsListCmd = &cobra.Command{
 	},
 }
 
+var modsVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check installed mods against the lockfile hashes",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		results, err := a.Mods.Verify(ctx)
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			a.Terminal.Warning("Lockfile is empty and no jars found; run `craftops mods update` first")
+			return nil
+		}
+
+		var issues []domain.ModIntegrity
+		rows := make([][]string, len(results))
+		for i, r := range results {
+			status := string(r.Status)
+			switch r.Status {
+			case domain.IntegrityOK:
+				status = a.Terminal.SuccessSprint(status)
+			case domain.IntegrityUntracked:
+				status = a.Terminal.WarningSprint(status)
+			default:
+				status = a.Terminal.ErrorSprint(status)
+				issues = append(issues, r)
+			}
+			rows[i] = []string{r.Filename, status, r.Detail}
+		}
+		a.Terminal.Section(fmt.Sprintf("Mod Integrity (%d)", len(results)))
+		a.Terminal.Table([]string{"File", "Status", "Details"}, rows)
+
+		if len(issues) == 0 {
+			a.Terminal.Success("All locked mods match the lockfile")
+			return nil
+		}
+		if !repairMods {
+			return fmt.Errorf("%d mod file(s) failed verification (run with --repair to re-download)", len(issues))
+		}
+		a.Terminal.Infof("Repairing %d file(s)...", len(issues))
+		fixed, err := a.Mods.Repair(ctx, issues)
+		for _, f := range fixed {
+			a.Terminal.Printf("   %s\n", a.Terminal.SuccessSprint(f))
+		}
+		if err != nil {
+			return err
+		}
+		a.Terminal.Successf("Repaired %d file(s)", len(fixed))
+		return nil
+	},
+}
+
 func displayModResults(a *app, result *domain.ModUpdateResult) {
-	a.Terminal.Section("Update Results")
+	a.Terminal.Section(a.Terminal.T("mods.results"))
 	if len(result.UpdatedMods) == 0 && len(result.FailedMods) == 0 && len(result.SkippedMods) == 0 {
-		a.Terminal.Info("No mods configured for updates")
+		a.Terminal.Info(a.Terminal.T("mods.none"))
 		return
 	}
 
@@ -189,15 +592,81 @@ func displayModResults(a *app, result *domain.ModUpdateResult) {
 		a.Terminal.Println()
 	}
 
-	printList(fmt.Sprintf("Updated (%d):", len(result.UpdatedMods)), result.UpdatedMods, a.Terminal.SuccessSprint)
+	details := result.Details()
+	if len(result.UpdatedMods) > 0 {
+		a.Terminal.Println(a.Terminal.T("mods.updated", "count", len(result.UpdatedMods)))
+		for _, d := range details {
+			if d.Outcome != domain.ModUpdated {
+				continue
+			}
+			a.Terminal.Printf("   %s %s %s\n", a.Terminal.SuccessSprint(d.Name), d.Versions(),
+				a.Terminal.DimSprint(fmt.Sprintf("(%s, %s)", domain.FormatSize(d.Bytes), d.Duration.Round(10*time.Millisecond))))
+		}
+		a.Terminal.Println()
+	}
 	if len(result.FailedMods) > 0 {
-		a.Terminal.Errorf("Failed (%d):", len(result.FailedMods))
-		for _, m := range slices.Sorted(maps.Keys(result.FailedMods)) {
-			a.Terminal.Printf("   %s: %s\n", a.Terminal.ErrorSprint(m), a.Terminal.DimSprint(result.FailedMods[m]))
+		a.Terminal.Error(a.Terminal.T("mods.failed", "count", len(result.FailedMods)))
+		for _, d := range details {
+			if d.Outcome == domain.ModFailed {
+				a.Terminal.Printf("   %s [%s]: %s\n", a.Terminal.ErrorSprint(d.Name), d.ErrorKind, a.Terminal.DimSprint(d.Error))
+			}
+		}
+		a.Terminal.Println()
+	}
+	printList(a.Terminal.T("mods.skipped", "count", len(result.SkippedMods)), result.SkippedMods, a.Terminal.WarningSprint)
+	if len(result.Incompatible) > 0 {
+		a.Terminal.Warning(a.Terminal.T("mods.incompatible", "loader", a.Config.Minecraft.Modloader,
+			"version", a.Config.Minecraft.Version, "count", len(result.Incompatible)))
+		for _, m := range result.Incompatible {
+			a.Terminal.Printf("   %s\n", a.Terminal.WarningSprint(m))
 		}
 		a.Terminal.Println()
 	}
-	printList(fmt.Sprintf("Skipped (%d):", len(result.SkippedMods)), result.SkippedMods, a.Terminal.WarningSprint)
+	if len(result.Advisories) > 0 {
+		a.Terminal.Warning(a.Terminal.T("mods.advisories", "count", len(result.Advisories)))
+		for _, adv := range result.Advisories {
+			a.Terminal.Printf("   %s [%s]: %s\n", a.Terminal.WarningSprint(adv.Mod), adv.Kind, a.Terminal.DimSprint(adv.Reason))
+		}
+		a.Terminal.Println()
+	}
+	if len(result.Quarantined) > 0 {
+		a.Terminal.Warning(a.Terminal.T("mods.quarantined", "count", len(result.Quarantined)))
+		for _, f := range result.Quarantined {
+			a.Terminal.Printf("   %s\n", a.Terminal.WarningSprint(f))
+		}
+		a.Terminal.Println()
+	}
+	if len(result.ForeignJars) > 0 {
+		a.Terminal.Warning(a.Terminal.T("mods.foreign", "dir", a.Config.ModsDir(), "count", len(result.ForeignJars)))
+		for _, f := range result.ForeignJars {
+			a.Terminal.Printf("   %s\n", a.Terminal.WarningSprint(f))
+		}
+		a.Terminal.Println()
+	}
+	if !result.Diff.Empty() {
+		displayModsDiff(a, result.Diff)
+	}
+}
+
+// displayModsDiff prints the jars an update added, removed and replaced.
+func displayModsDiff(a *app, diff *domain.ModsDiff) {
+	a.Terminal.Section(a.Terminal.T("mods.changes"))
+	var rows [][]string
+	for _, u := range diff.Updated {
+		jar := u.From.Name
+		if u.To.Name != u.From.Name {
+			jar += " → " + u.To.Name
+		}
+		rows = append(rows, []string{a.Terminal.WarningSprint(a.Terminal.T("mods.change_updated")), jar,
+			domain.FormatSize(u.From.Size) + " → " + domain.FormatSize(u.To.Size)})
+	}
+	for _, j := range diff.Added {
+		rows = append(rows, []string{a.Terminal.SuccessSprint(a.Terminal.T("mods.change_added")), j.Name, domain.FormatSize(j.Size)})
+	}
+	for _, j := range diff.Removed {
+		rows = append(rows, []string{a.Terminal.ErrorSprint(a.Terminal.T("mods.change_removed")), j.Name, domain.FormatSize(j.Size)})
+	}
+	a.Terminal.Table([]string{a.Terminal.T("mods.change"), a.Terminal.T("mods.jar"), a.Terminal.T("mods.size")}, rows)
 }
 
 // ── Backup ────────────────────────────────────────────────────────────────────
@@ -210,24 +679,57 @@ var backupCmd = &cobra.Command{
 var backupCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create a backup",
-	RunE: func(cmd *cobra.Command, _ []string) error {
+	RunE: func(cmd *cobra.Command, _ []string) (err error) {
 		a := appFrom(cmd)
-		a.Terminal.Info("Creating backup...")
-		path, err := a.Backup.Create(cmd.Context())
+		opts := domain.BackupOptions{Tag: backupTag, Protect: backupProtect, Include: backupInclude, CompressionLevel: backupLevel}
+		if estimateOnly {
+			est, err := a.Backup.Estimate(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			displayEstimate(a, est)
+			return nil
+		}
+		report := startReport(domain.OpBackup)
+		defer func() {
+			finishReport(a, report, err)
+			notifyDone(a, report)
+		}()
+		a.Terminal.Info(a.Terminal.T("backup.creating"))
+		status := a.Terminal.StatusLine()
+		opts.Progress = func(p domain.BackupProgress) {
+			status.Set("%s", a.Terminal.T("backup.progress", "files", p.Files, "size", domain.FormatSize(p.Bytes)))
+		}
+		path, err := a.Backup.CreateWith(cmd.Context(), opts)
+		status.Clear()
+		report.Backup = backupInfo(path)
 		if err != nil {
 			if errors.Is(err, domain.ErrBackupsDisabled) {
-				a.Terminal.Warning("Backups are disabled in config")
+				a.Terminal.Warning(a.Terminal.T("backup.disabled"))
 				return nil
 			}
 			return err
 		}
 		if path != "" {
-			a.Terminal.Success("Backup created: " + path)
+			a.Terminal.Success(a.Terminal.T("backup.created", "path", path))
 		}
 		return nil
 	},
 }
 
+func displayEstimate(a *app, est *domain.BackupEstimate) {
+	a.Terminal.Section(a.Terminal.T("backup.estimate"))
+	field := func(key string, value any) { a.Terminal.Printf("  %-10s: %v\n", a.Terminal.T(key), value) }
+	field("backup.estimate_files", est.Files)
+	field("backup.estimate_content", domain.FormatSize(est.Bytes))
+	if est.Ratio > 0 {
+		field("backup.estimate_archive", a.Terminal.T("backup.estimate_ratio", "size", domain.FormatSize(est.Projected),
+			"percent", fmt.Sprintf("%.0f", est.Ratio*100), "from", est.RatioFrom))
+	} else {
+		field("backup.estimate_archive", a.Terminal.T("backup.estimate_no_ratio", "size", domain.FormatSize(est.Projected)))
+	}
+}
+
 var backupListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available backups",
@@ -259,21 +761,59 @@ var backupDeleteCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		a := appFrom(cmd)
-		name := args[0]
-		backups, err := a.Backup.List()
+		if err := confirm(a, fmt.Sprintf("Delete backup %s?", args[0])); err != nil {
+			return err
+		}
+		if err := a.Backup.Delete(args[0]); err != nil {
+			return err
+		}
+		a.Terminal.Successf("Deleted backup: %s", args[0])
+		return nil
+	},
+}
+
+var backupInspectCmd = &cobra.Command{
+	Use:   "inspect <name>",
+	Short: "List the contents of a backup",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a := appFrom(cmd)
+		entries, err := a.Backup.Contents(cmd.Context(), args[0], inspectPath)
 		if err != nil {
 			return err
 		}
-		for _, b := range backups {
-			if b.Name == name {
-				if err := os.Remove(b.Path); err != nil {
-					return fmt.Errorf("failed to delete backup: %w", err)
-				}
-				a.Terminal.Successf("Deleted backup: %s", name)
-				return nil
+		if len(entries) == 0 {
+			a.Terminal.Warning("No entries found")
+			return nil
+		}
+		var total int64
+		rows := make([][]string, len(entries))
+		for i, e := range entries {
+			size := domain.FormatSize(e.Size)
+			if e.IsDir {
+				size = "-"
 			}
+			total += e.Size
+			rows[i] = []string{e.Name, size, e.Modified.Format("2006-01-02 15:04:05")}
 		}
-		return fmt.Errorf("backup not found: %s", name)
+		a.Terminal.Section(fmt.Sprintf("%s (%d entries, %s)", args[0], len(entries), domain.FormatSize(total)))
+		a.Terminal.Table([]string{"Path", "Size", "Modified"}, rows)
+		return nil
+	},
+}
+
+var backupExtractCmd = &cobra.Command{
+	Use:   "extract <name> <path>",
+	Short: "Extract a file or directory from a backup without a full restore",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a := appFrom(cmd)
+		n, err := a.Backup.Extract(cmd.Context(), args[0], args[1], extractDest)
+		if err != nil {
+			return err
+		}
+		a.Terminal.Successf("Extracted %d file(s) from %s into %s", n, args[0], extractDest)
+		return nil
 	},
 }
 
@@ -284,30 +824,45 @@ var healthCmd = &cobra.Command{
 	Short: "Run system health checks",
 	RunE: func(cmd *cobra.Command, _ []string) error {
 		ctx, a := cmd.Context(), appFrom(cmd)
-		a.Terminal.Banner("System Health Check")
-
-		var checks []domain.HealthCheck
-		a.Terminal.Step(1, 4, "Checking paths...")
-		checks = append(checks, domain.CheckPath("Server directory", a.Config.Paths.Server))
-		checks = append(checks, domain.CheckPath("Mods directory", a.Config.Paths.Mods))
-		checks = append(checks, domain.CheckPath("Backups directory", a.Config.Paths.Backups))
-		checks = append(checks, domain.CheckPath("Logs directory", a.Config.Paths.Logs))
-		a.Terminal.Step(2, 4, "Checking server...")
-		checks = append(checks, a.Server.HealthCheck(ctx)...)
-		checks = append(checks, a.Mods.HealthCheck(ctx)...)
-		a.Terminal.Step(3, 4, "Checking backup & notifications...")
-		checks = append(checks, a.Backup.HealthCheck(ctx)...)
-		checks = append(checks, a.Notification.HealthCheck(ctx)...)
-		a.Terminal.Step(4, 4, "Done")
-
-		a.Terminal.Section("Results")
+		a.Terminal.Banner(a.Terminal.T("health.banner"))
+
+		a.Terminal.Info(a.Terminal.T("health.running", "timeout", healthTimeout, "budget", healthBudget))
+		checks := runHealthChecks(ctx, a, healthTimeout, healthBudget)
+
+		a.Terminal.Section(a.Terminal.T("health.results"))
 		a.Terminal.HealthCheckTable(checks)
 		return healthSummary(a, checks)
 	},
 }
 
+// runHealthChecks runs every component's checks, perCheck each and budget
+// in all, and applies the [health] policy.
+func runHealthChecks(ctx context.Context, a *app, perCheck, budget time.Duration) []domain.HealthCheck {
+	paths := func(context.Context) []domain.HealthCheck {
+		return []domain.HealthCheck{
+			domain.CheckPath("Server directory", a.Config.Paths.Server),
+			domain.CheckPath("Mods directory", a.Config.Paths.Mods),
+			domain.CheckPath("Backups directory", a.Config.Paths.Backups),
+			domain.CheckPath("Logs directory", a.Config.Paths.Logs),
+		}
+	}
+	proxy := func(ctx context.Context) []domain.HealthCheck {
+		return []domain.HealthCheck{service.CheckProxy(ctx, a.Config)}
+	}
+	checks := service.RunHealthChecks(ctx, perCheck, budget, []service.HealthProbe{
+		{Name: "Paths", Run: paths},
+		{Name: "Server", Run: a.Server.HealthCheck},
+		{Name: "Mods", Run: a.Mods.HealthCheck},
+		{Name: "Network proxy", Run: proxy},
+		{Name: "Backup", Run: a.Backup.HealthCheck},
+		{Name: "Notifications", Run: a.Notification.HealthCheck},
+		{Name: "Proxy", Run: a.Proxy.HealthCheck},
+	})
+	return service.ApplyHealthPolicy(a.Config.Health, checks)
+}
+
 func healthSummary(a *app, checks []domain.HealthCheck) error {
-	var passed, warned, failed int
+	var passed, warned, failed, skipped int
 	for _, c := range checks {
 		switch c.Status {
 		case domain.StatusOK:
@@ -316,17 +871,22 @@ func healthSummary(a *app, checks []domain.HealthCheck) error {
 			warned++
 		case domain.StatusError:
 			failed++
+		case domain.StatusSkipped:
+			skipped++
 		}
 	}
-	a.Terminal.Section("Summary")
+	if skipped > 0 {
+		defer a.Terminal.Info(a.Terminal.T("health.skipped", "skipped", skipped))
+	}
+	a.Terminal.Section(a.Terminal.T("health.summary"))
 	if failed > 0 {
-		a.Terminal.Errorf("%d failed, %d warnings, %d passed", failed, warned, passed)
-		return fmt.Errorf("%d health checks failed", failed)
+		a.Terminal.Error(a.Terminal.T("health.failed", "failed", failed, "warned", warned, "passed", passed))
+		return withExitCode(ExitHealth, fmt.Errorf("%d health checks failed", failed))
 	}
 	if warned > 0 {
-		a.Terminal.Warningf("%d warnings, %d passed", warned, passed)
+		a.Terminal.Warning(a.Terminal.T("health.warned", "warned", warned, "passed", passed))
 	} else {
-		a.Terminal.Successf("All %d checks passed", passed)
+		a.Terminal.Success(a.Terminal.T("health.passed", "passed", passed))
 	}
 	return nil
 }
@@ -339,7 +899,7 @@ var initCmd = &cobra.Command{
 	// Skip normal app initialization — config may not exist yet.
 	PersistentPreRunE: func(_ *cobra.Command, _ []string) error { return nil },
 	RunE: func(_ *cobra.Command, _ []string) error {
-		t := ui.NewTerminal()
+		t := newTerminal()
 
 		if outputPath == "" {
 			outputPath = "config.toml"
@@ -350,9 +910,12 @@ var initCmd = &cobra.Command{
 			if info.IsDir() {
 				return errors.New("output path is a directory")
 			}
-			t.Warning("Config already exists: " + outputPath)
-			t.Info("Use --force to overwrite")
-			return nil
+			if ok, err := t.Confirm("Config already exists: "+outputPath+". Overwrite?", false); err != nil {
+				return err
+			} else if !ok {
+				t.Info("Use --force to overwrite")
+				return nil
+			}
 		}
 
 		if err := os.MkdirAll(filepath.Dir(outputPath), 0o750); err != nil {
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/config"
+)
+
+func init() {
+	configCmd.AddCommand(configValidateCmd, configMigrateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Maintain the config file",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file, listing keys no setting reads",
+	Long: `Validate loads the config file like every command does and lists the keys
+no setting reads, which are usually typos, with the key probably meant.
+They are ignored with a warning otherwise; --strict makes them an error
+here and for every other command.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		if a.Config.Path == "" {
+			a.Terminal.Success("No config file found; the defaults are valid")
+			return nil
+		}
+		if len(a.Config.Unknown) == 0 {
+			a.Terminal.Successf("%s is valid", a.Config.Path)
+			return nil
+		}
+		rows := make([][]string, 0, len(a.Config.Unknown))
+		for _, key := range a.Config.Unknown {
+			rows = append(rows, []string{key, config.Suggest(key)})
+		}
+		a.Terminal.Table([]string{"Unknown key", "Did you mean"}, rows)
+		if strict {
+			return withExitCode(ExitConfig, fmt.Errorf("%d unknown key(s) in %s", len(rows), a.Config.Path))
+		}
+		a.Terminal.Warningf("%d unknown key(s) in %s are ignored", len(rows), a.Config.Path)
+		return nil
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rewrite the config file in the current schema version",
+	Long: `Migrate rewrites the config file with renamed and retired keys updated and
+schema_version set, as loading already does in memory with a warning. The
+original is kept next to it as <file>.bak; comments are not carried over.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		path := a.Config.Path
+		if path == "" {
+			return withExitCode(ExitConfig, errors.New("no config file to migrate (pass --config)"))
+		}
+		data, err := os.ReadFile(path) //nolint:gosec // the loaded config file
+		if err != nil {
+			return err
+		}
+		out, notes, err := config.MigrateFile(data)
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if bytes.Equal(out, data) {
+			a.Terminal.Successf("%s is already at schema version %d", path, config.SchemaVersion)
+			return nil
+		}
+		for _, note := range notes {
+			a.Terminal.Info(note)
+		}
+		if a.Config.DryRun {
+			a.Terminal.Infof("Dry run: would rewrite %s at schema version %d", path, config.SchemaVersion)
+			return nil
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path+".bak", data, info.Mode().Perm()); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+			return err
+		}
+		a.Terminal.Successf("Migrated %s to schema version %d (previous file: %s.bak)", path, config.SchemaVersion, path)
+		return nil
+	},
+}
+
+// unknownKeyNotes lists cfg's unknown keys with the key probably meant.
+func unknownKeyNotes(cfg *config.Config) []string {
+	notes := make([]string, 0, len(cfg.Unknown))
+	for _, key := range cfg.Unknown {
+		if s := config.Suggest(key); s != "" {
+			key += " (did you mean " + s + "?)"
+		}
+		notes = append(notes, key)
+	}
+	return notes
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigMigrate_RewritesFile(t *testing.T) {
+	resetGlobals(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	legacy := "[notifications]\nwarning_message = \"Restarting in {minutes}m\"\n"
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile = path
+	os.Args = []string{"craftops", "config", "migrate", "-c", path}
+
+	if err := Execute(context.Background()); err != nil {
+		t.Fatalf("Execute(config migrate) error: %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if !strings.Contains(string(got), `restart_warning = "Restarting in {minutes}m"`) || !strings.Contains(string(got), "schema_version = 1") {
+		t.Errorf("migrated config:\n%s", got)
+	}
+	if bak, _ := os.ReadFile(path + ".bak"); string(bak) != legacy {
+		t.Errorf("backup = %q, want the original file", bak)
+	}
+}
+
+func TestStrictRejectsUnknownKeys(t *testing.T) {
+	resetGlobals(t)
+	origStrict := strict
+	t.Cleanup(func() { strict = origStrict })
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte("schema_version = 1\n[mods]\nmodrinth_source = []\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfgFile = path
+	os.Args = []string{"craftops", "config", "validate", "-c", path}
+	if err := Execute(context.Background()); err != nil {
+		t.Fatalf("unknown keys failed without --strict: %v", err)
+	}
+	os.Args = []string{"craftops", "config", "validate", "--strict", "-c", path}
+	if err := Execute(context.Background()); ExitCode(err) != ExitConfig {
+		t.Errorf("--strict: err %v (exit %d), want a config error", err, ExitCode(err))
+	}
+}
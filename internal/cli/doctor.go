@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var doctorFix bool
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "apply the fixes (preview them with --dry-run)")
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Find and repair setup problems (missing directories, permissions, eula.txt, session name)",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		fixes := service.NewDoctor(a.Config, a.Logger).Diagnose()
+		if len(fixes) == 0 {
+			a.Terminal.Success("No fixable problems found; run `craftops health-check` for a full diagnosis")
+			return nil
+		}
+
+		apply := doctorFix && !a.Config.DryRun
+		a.Terminal.Section(fmt.Sprintf("Problems (%d)", len(fixes)))
+		var failed, skipped int
+		for _, f := range fixes {
+			a.Terminal.Printf("  %s %s\n", a.Terminal.WarningSprint("•"), f.Problem)
+			if !apply {
+				a.Terminal.Printf("    %s %s\n", a.Terminal.DimSprint("would:"), f.Action)
+				continue
+			}
+			if f.Consent {
+				if err := confirm(a, f.Action+"?"); errors.Is(err, domain.ErrAborted) {
+					a.Terminal.Printf("    %s %s\n", a.Terminal.DimSprint("skipped:"), f.Action)
+					skipped++
+					continue
+				} else if err != nil {
+					return err
+				}
+			}
+			if err := f.Apply(); err != nil {
+				a.Terminal.Printf("    %s %s: %v\n", a.Terminal.ErrorSprint("failed:"), f.Action, err)
+				failed++
+				continue
+			}
+			a.Terminal.Printf("    %s %s\n", a.Terminal.SuccessSprint("fixed:"), f.Action)
+		}
+
+		switch {
+		case !apply:
+			if doctorFix {
+				a.Terminal.Info("Dry run: nothing was changed")
+			} else {
+				a.Terminal.Info("Run `craftops doctor --fix` to apply these changes")
+			}
+			return withExitCode(ExitHealth, fmt.Errorf("%d fixable problem(s) found", len(fixes)))
+		case failed+skipped > 0:
+			return withExitCode(ExitHealth, fmt.Errorf("%d problem(s) left unfixed", failed+skipped))
+		}
+		a.Terminal.Successf("Fixed %d problem(s)", len(fixes))
+		return nil
+	},
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var (
+	eventsSince  string
+	eventsTypes  []string
+	eventsOutput string
+	eventsLimit  int
+	eventsPage   int
+)
+
+// eventTypes lists what the event log records.
+var eventTypes = []string{
+	domain.OpStart, domain.OpStop, domain.OpRestart, domain.OpBackup, domain.OpModUpdate,
+	domain.EventCrash, domain.EventError,
+}
+
+func init() {
+	rootCmd.AddCommand(eventsCmd)
+	eventsCmd.Flags().StringVar(&eventsSince, "since", "", "only events newer than a duration (24h, 7d) or a time (2006-01-02, RFC 3339)")
+	eventsCmd.Flags().StringSliceVar(&eventsTypes, "type", nil, "only these types ("+strings.Join(eventTypes, ", ")+")")
+	eventsCmd.Flags().StringVarP(&eventsOutput, "output", "o", "table", "output format: table or json")
+	eventsCmd.Flags().IntVar(&eventsLimit, "limit", 50, "events per page")
+	eventsCmd.Flags().IntVar(&eventsPage, "page", 1, "page to show, newest first")
+}
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Query the history of operations, crashes and alerted errors",
+	Long: `Events lists the event log kept in paths.state (events.jsonl): successful
+starts, stops, restarts, backups and mod updates, crashes and hangs, and every
+error that was alerted, newest first.`,
+	Example: `  craftops events --since 24h --type backup,restart
+  craftops events --since 2024-06-01 --output json --limit 100 --page 2`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		since, err := parseSince(eventsSince, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, t := range eventsTypes {
+			if !slices.Contains(eventTypes, t) {
+				return fmt.Errorf("invalid event type: %s. Must be one of %v", t, eventTypes)
+			}
+		}
+		if eventsOutput != "table" && eventsOutput != "json" {
+			return fmt.Errorf("invalid output: %s. Must be one of [table json]", eventsOutput)
+		}
+		if eventsLimit < 1 || eventsPage < 1 {
+			return errors.New("--limit and --page must be at least 1")
+		}
+
+		events, err := service.NewEventLog(a.Config).Query(since, eventsTypes)
+		if err != nil {
+			return err
+		}
+		total := len(events)
+		start := min((eventsPage-1)*eventsLimit, total)
+		page := events[start:min(start+eventsLimit, total)]
+
+		if eventsOutput == "json" {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Total  int            `json:"total"`
+				Page   int            `json:"page"`
+				Limit  int            `json:"limit"`
+				Events []domain.Event `json:"events"`
+			}{total, eventsPage, eventsLimit, page})
+		}
+		if len(page) == 0 {
+			a.Terminal.Info("No matching events")
+			return nil
+		}
+		rows := make([][]string, 0, len(page))
+		for _, e := range page {
+			rows = append(rows, []string{e.At.Local().Format(timeFormat), e.Type, e.Message})
+		}
+		a.Terminal.Table([]string{"Time", "Type", "Message"}, rows)
+		if pages := (total + eventsLimit - 1) / eventsLimit; pages > 1 {
+			more := ""
+			if eventsPage < pages {
+				more = fmt.Sprintf("; --page %d for older events", eventsPage+1)
+			}
+			a.Terminal.Printf("Page %d of %d (%d events)%s\n", eventsPage, pages, total, more)
+		}
+		return nil
+	},
+}
+
+// parseSince turns --since into a cutoff: a duration before now, which may
+// be given in days (7d), or a date or RFC 3339 time. Empty means no cutoff.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since: %s. Use a duration such as 24h or 7d, or a time such as 2006-01-02", s)
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+// Process exit codes. Scripts and systemd units may rely on these values.
+const (
+	ExitOK            = 0
+	ExitFailure       = 1
+	ExitConfig        = 2
+	ExitHealth        = 3
+	ExitPartialUpdate = 4
+	ExitServerTimeout = 5
+	ExitInterrupted   = 130
+)
+
+// exitError attaches an explicit exit code to an error.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode maps an error returned by Execute to a process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	switch {
+	case errors.Is(err, domain.ErrModUpdatesFailed):
+		return ExitPartialUpdate
+	case errors.Is(err, domain.ErrServerTimeout):
+		return ExitServerTimeout
+	case errors.Is(err, context.Canceled):
+		return ExitInterrupted
+	}
+	return ExitFailure
+}
+
+// exitCodesCmd is a help topic: `craftops help exit-codes`.
+var exitCodesCmd = &cobra.Command{
+	Use:   "exit-codes",
+	Short: "Process exit codes and their meaning",
+	Long: `craftops exits with a status that identifies the class of failure:
+
+  0    Success
+  1    General failure
+  2    Configuration could not be loaded or is invalid
+  3    One or more health checks failed
+  4    Mod update finished but some mods failed
+  5    Server did not start or stop within the configured timeout
+  130  Interrupted (SIGINT/SIGTERM)`,
+}
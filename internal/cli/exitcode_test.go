@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"craftops/internal/domain"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"generic", errors.New("boom"), ExitFailure},
+		{"explicit config", withExitCode(ExitConfig, errors.New("bad toml")), ExitConfig},
+		{"wrapped explicit", fmt.Errorf("outer: %w", withExitCode(ExitHealth, errors.New("x"))), ExitHealth},
+		{"server timeout", fmt.Errorf("start: %w", domain.ErrServerTimeout), ExitServerTimeout},
+		{"partial update", domain.ErrModUpdatesFailed, ExitPartialUpdate},
+		{"interrupted", context.Canceled, ExitInterrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithExitCode_Nil(t *testing.T) {
+	if err := withExitCode(ExitConfig, nil); err != nil {
+		t.Errorf("withExitCode(nil) = %v, want nil", err)
+	}
+}
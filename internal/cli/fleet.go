@@ -0,0 +1,318 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var (
+	fleetJSON     bool
+	fleetParallel int
+)
+
+func init() {
+	rootCmd.AddCommand(fleetCmd)
+	fleetCmd.AddCommand(fleetStatusCmd, fleetRestartCmd, fleetUpdateModsCmd)
+	fleetCmd.PersistentFlags().BoolVar(&fleetJSON, "json", false, "print the combined result as JSON")
+	fleetCmd.PersistentFlags().IntVar(&fleetParallel, "parallel", 0, "servers worked on at once (default: fleet.parallel)")
+}
+
+// fleetResult is one server's outcome in a fleet run.
+type fleetResult struct {
+	Name     string               `json:"name"`
+	Config   string               `json:"config"`
+	OK       bool                 `json:"ok"`
+	Detail   string               `json:"detail,omitempty"`
+	Error    string               `json:"error,omitempty"`
+	ExitCode int                  `json:"exit_code"`
+	Status   *domain.ServerStatus `json:"status,omitempty"`
+}
+
+// fleetReport is the document `fleet --json` prints.
+type fleetReport struct {
+	Command  string        `json:"command"`
+	ExitCode int           `json:"exit_code"`
+	Servers  []fleetResult `json:"servers"`
+}
+
+var fleetCmd = &cobra.Command{
+	Use:   "fleet",
+	Short: "Run status, restart or update-mods across every server in [fleet]",
+	Long: `Fleet commands load each config file listed in fleet.configs and run the
+operation on every server, fleet.parallel (or --parallel) at a time; servers
+whose config sets host are reached over SSH. The exit code is 0 when every
+server succeeded, the failed servers' exit code when they agree (e.g. 4 when
+only mod updates failed), and 1 otherwise.`,
+	Annotations: local,
+}
+
+var fleetStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether each server is running",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runFleet(cmd, fleetOp{name: "status", remote: []string{"server", "status", "--json"},
+			run: func(ctx context.Context, m *app, r *fleetResult) error {
+				status, err := m.Server.Usage(ctx)
+				if err != nil {
+					return err
+				}
+				r.Status = status
+				return nil
+			},
+			remoteOutput: func(out []byte, r *fleetResult) error {
+				return json.Unmarshal(out, &r.Status)
+			},
+		})
+	},
+}
+
+var fleetRestartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Restart each server, with its restart warnings",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runFleet(cmd, fleetOp{name: "restart", disruptive: true, remote: []string{"server", "restart"},
+			// The same warned path as `server restart`, which a remote
+			// member runs: deferred while players are online and
+			// cancellable with `server restart --cancel`.
+			run: func(ctx context.Context, m *app, r *fleetResult) error {
+				err := warnedRestart(ctx, m)
+				if errors.Is(err, domain.ErrRestartCancelled) {
+					r.Detail = "restart cancelled"
+					return nil
+				}
+				if err != nil {
+					return err
+				}
+				_ = m.Notification.SendSuccess(ctx, m.Notification.T("notify.restarted"))
+				return nil
+			},
+		})
+	},
+}
+
+var fleetUpdateModsCmd = &cobra.Command{
+	Use:   "update-mods",
+	Short: "Back up and update the mods of each server",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		return runFleet(cmd, fleetOp{name: "update-mods", disruptive: true, remote: []string{"mods", "update"},
+			run: func(ctx context.Context, m *app, _ *fleetResult) error {
+				return autoUpdateMods(ctx, m, false)
+			},
+		})
+	},
+}
+
+// fleetOp is a fleet command: run works on a local server, remote is the
+// craftops command line for a server whose config sets host, and
+// remoteOutput, if set, reads that command's stdout into the result.
+type fleetOp struct {
+	name         string
+	disruptive   bool
+	run          func(context.Context, *app, *fleetResult) error
+	remote       []string
+	remoteOutput func([]byte, *fleetResult) error
+}
+
+// runFleet runs op on every fleet server with bounded parallelism and
+// prints the combined result. Disruptive ops honor --respect-window per
+// server, against that server's own [maintenance] window.
+func runFleet(cmd *cobra.Command, op fleetOp) error {
+	ctx, a := cmd.Context(), appFrom(cmd)
+	paths, err := fleetConfigs(a.Config)
+	if err != nil {
+		return withExitCode(ExitConfig, err)
+	}
+
+	results := make([]fleetResult, len(paths))
+	members := make([]*app, len(paths))
+	stateOwners := map[string]string{}
+	for i, path := range paths {
+		results[i] = fleetResult{Name: profileName(path), Config: path}
+		cfg, err := config.LoadConfig(path)
+		if err == nil {
+			err = claimStateDir(stateOwners, cfg, results[i].Name)
+		}
+		if err != nil {
+			results[i].Error, results[i].ExitCode = err.Error(), ExitConfig
+			continue
+		}
+		applyGlobalFlags(cfg)
+		members[i] = newApp(cfg)
+		defer members[i].Close()
+	}
+	// newApp installs each member's tracer; spans belong to this command.
+	service.UseTracer(a.Tracer)
+
+	parallel := fleetParallel
+	if parallel < 1 {
+		parallel = a.Config.Fleet.Parallel
+	}
+	var g errgroup.Group
+	g.SetLimit(parallel)
+	for i, m := range members {
+		if m == nil {
+			continue
+		}
+		g.Go(func() error {
+			r := &results[i]
+			var err error
+			if m.Config.Host != "" {
+				err = runFleetRemote(ctx, m, op, r)
+			} else {
+				err = runFleetLocal(ctx, m, op, r)
+			}
+			r.OK, r.ExitCode = err == nil, ExitCode(err)
+			switch {
+			case err != nil:
+				r.Error = err.Error()
+			case r.Status != nil:
+				r.Detail = statusDetail(r.Status)
+			case r.Detail == "":
+				r.Detail = "done"
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	code, failed := fleetExitCode(results)
+	if fleetJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(fleetReport{Command: op.name, ExitCode: code, Servers: results}); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, 0, len(results))
+		for _, r := range results {
+			result, detail := a.Terminal.SuccessSprint("OK"), r.Detail
+			if !r.OK {
+				result, detail = a.Terminal.ErrorSprint("FAILED"), r.Error
+			}
+			rows = append(rows, []string{r.Name, result, detail})
+		}
+		a.Terminal.Table([]string{"Server", "Result", "Detail"}, rows)
+	}
+	if failed > 0 {
+		return withExitCode(code, fmt.Errorf("fleet %s: %d of %d server(s) failed", op.name, failed, len(results)))
+	}
+	return nil
+}
+
+func runFleetLocal(ctx context.Context, m *app, op fleetOp, r *fleetResult) error {
+	if op.disruptive && respectWindow {
+		if err := waitForWindow(ctx, m); err != nil {
+			return err
+		}
+	}
+	return op.run(ctx, m, r)
+}
+
+// runFleetRemote runs op's command line on the member's host. The last
+// line the remote command printed to stderr becomes the error.
+func runFleetRemote(ctx context.Context, m *app, op fleetOp, r *fleetResult) error {
+	var stdout, stderr bytes.Buffer
+	code, err := service.NewRemote(m.Config, m.Logger).Run(ctx, append(globalArgs(), op.remote...),
+		service.RemoteIO{Stdout: &stdout, Stderr: &stderr})
+	if err != nil {
+		return err
+	}
+	if code != ExitOK {
+		msg := fmt.Sprintf("exited with status %d", code)
+		if lines := strings.Split(strings.TrimSpace(stderr.String()), "\n"); lines[len(lines)-1] != "" {
+			msg = strings.TrimPrefix(lines[len(lines)-1], "Error: ")
+		}
+		return withExitCode(code, errors.New(msg))
+	}
+	if op.remoteOutput != nil {
+		return op.remoteOutput(stdout.Bytes(), r)
+	}
+	return nil
+}
+
+func statusDetail(status *domain.ServerStatus) string {
+	switch {
+	case status.Unmanaged:
+		return fmt.Sprintf("running outside screen (PID %d)", status.PID)
+	case status.IsRunning:
+		return fmt.Sprintf("running, up %s", status.Uptime.Round(time.Second))
+	default:
+		return "stopped"
+	}
+}
+
+// fleetConfigs expands fleet.configs, resolving relative entries against
+// the directory of the config file that lists them.
+func fleetConfigs(cfg *config.Config) ([]string, error) {
+	var paths []string
+	for _, pattern := range cfg.Fleet.Configs {
+		if !filepath.IsAbs(pattern) && cfg.Path != "" {
+			pattern = filepath.Join(filepath.Dir(cfg.Path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fleet config pattern %q: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("fleet config %s matches no files", pattern)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("fleet.configs lists no server config files")
+	}
+	return paths, nil
+}
+
+// claimStateDir records cfg's state directory as name's, failing when
+// another fleet member already uses it: servers sharing one would mix their
+// mod lockfiles, backup indexes and last-success times.
+func claimStateDir(owners map[string]string, cfg *config.Config, name string) error {
+	if cfg.Paths.State == "" {
+		return nil
+	}
+	key := cfg.Host + ":" + filepath.Clean(cfg.Paths.State)
+	if other, ok := owners[key]; ok {
+		return fmt.Errorf("paths.state %s is also used by %s; give each server its own state directory", cfg.Paths.State, other)
+	}
+	owners[key] = name
+	return nil
+}
+
+// profileName names a fleet member after its config file:
+// servers/staging.toml is "staging".
+func profileName(path string) string {
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
+// fleetExitCode combines the servers' exit codes: their shared code when
+// every failure agrees, ExitFailure when they differ.
+func fleetExitCode(results []fleetResult) (code, failed int) {
+	for _, r := range results {
+		if r.ExitCode == ExitOK {
+			continue
+		}
+		failed++
+		switch code {
+		case ExitOK:
+			code = r.ExitCode
+		case r.ExitCode:
+		default:
+			code = ExitFailure
+		}
+	}
+	return code, failed
+}
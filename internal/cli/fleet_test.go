@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"craftops/internal/config"
+)
+
+func TestFleetExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		codes      []int
+		wantCode   int
+		wantFailed int
+	}{
+		{"all ok", []int{ExitOK, ExitOK}, ExitOK, 0},
+		{"shared failure", []int{ExitOK, ExitPartialUpdate, ExitPartialUpdate}, ExitPartialUpdate, 2},
+		{"mixed failures", []int{ExitPartialUpdate, ExitServerTimeout}, ExitFailure, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]fleetResult, len(tt.codes))
+			for i, c := range tt.codes {
+				results[i].ExitCode = c
+			}
+			code, failed := fleetExitCode(results)
+			if code != tt.wantCode || failed != tt.wantFailed {
+				t.Errorf("fleetExitCode = %d, %d; want %d, %d", code, failed, tt.wantCode, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestFleetConfigs(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "servers"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"survival.toml", "creative.toml"} {
+		if err := os.WriteFile(filepath.Join(dir, "servers", name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := config.DefaultConfig()
+	cfg.Path = filepath.Join(dir, "fleet.toml")
+	cfg.Fleet.Configs = []string{"servers/*.toml"}
+	paths, err := fleetConfigs(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2 || filepath.Base(paths[0]) != "creative.toml" {
+		t.Errorf("fleetConfigs = %v, want creative.toml and survival.toml", paths)
+	}
+
+	cfg.Fleet.Configs = []string{"missing.toml"}
+	if _, err := fleetConfigs(cfg); err == nil {
+		t.Error("expected error for a config that matches no files")
+	}
+	cfg.Fleet.Configs = nil
+	if _, err := fleetConfigs(cfg); err == nil {
+		t.Error("expected error for an empty fleet")
+	}
+}
+
+func TestClaimStateDir(t *testing.T) {
+	owners := map[string]string{}
+	survival, creative := config.DefaultConfig(), config.DefaultConfig()
+	if err := claimStateDir(owners, survival, "survival"); err != nil {
+		t.Fatal(err)
+	}
+	if err := claimStateDir(owners, creative, "creative"); err == nil {
+		t.Error("two servers on the default state directory were accepted")
+	}
+	creative.Paths.State = filepath.Join(t.TempDir(), "creative")
+	if err := claimStateDir(owners, creative, "creative"); err != nil {
+		t.Errorf("own state directory: %v", err)
+	}
+	remote := config.DefaultConfig()
+	remote.Host = "mc2"
+	if err := claimStateDir(owners, remote, "remote"); err != nil {
+		t.Errorf("same path on another host: %v", err)
+	}
+}
@@ -17,6 +17,7 @@ func resetGlobals(t *testing.T) {
 	origForce := force
 	origDebug := debug
 	origDryRun := dryRun
+	origOffline := offline
 	t.Cleanup(func() {
 		os.Args = origArgs
 		cfgFile = origCfgFile
@@ -24,6 +25,7 @@ func resetGlobals(t *testing.T) {
 		force = origForce
 		debug = origDebug
 		dryRun = origDryRun
+		offline = origOffline
 	})
 }
 
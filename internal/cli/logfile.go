@@ -0,0 +1,163 @@
+package cli
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"craftops/internal/config"
+)
+
+const (
+	logFileName      = "craftops.log"
+	logBackupTimeFmt = "2006-01-02T15-04-05.000000000"
+)
+
+// rotatingFile is the file logger's writer. Once the log exceeds MaxSizeMB
+// it is renamed to craftops-<time>.log (gzipped with Compress), and backups
+// beyond MaxBackups or older than MaxAgeDays are removed.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+func openRotatingFile(dir string, cfg config.LoggingConfig) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:       filepath.Join(dir, logFileName),
+		maxSize:    int64(cfg.MaxSizeMB) << 20,
+		maxBackups: cfg.MaxBackups,
+		maxAge:     time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+		compress:   cfg.Compress,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	r.f, r.size = f, info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if it would push the file past maxSize.
+// A failed rotation is reported once p is written to the reopened file.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var rotateErr error
+	if r.f != nil && r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		rotateErr = r.rotate()
+	}
+	if r.f == nil {
+		if err := r.open(); err != nil {
+			return 0, errors.Join(rotateErr, err)
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Sync flushes the current file.
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	return r.f.Sync()
+}
+
+// rotate moves the log aside and reopens path. It reopens whatever fails
+// after the close, so a failed rename keeps logging to the old file
+// instead of a closed one.
+func (r *rotatingFile) rotate() error {
+	err := r.f.Close()
+	r.f = nil
+	backup := filepath.Join(filepath.Dir(r.path), "craftops-"+time.Now().Format(logBackupTimeFmt)+".log")
+	if renameErr := os.Rename(r.path, backup); renameErr != nil {
+		return errors.Join(err, renameErr, r.open())
+	}
+	if r.compress {
+		if gzErr := gzipFile(backup); gzErr != nil {
+			err = errors.Join(err, fmt.Errorf("compress %s: %w", backup, gzErr))
+		}
+	}
+	r.prune()
+	return errors.Join(err, r.open())
+}
+
+// prune removes backups past maxBackups (newest kept) or older than maxAge.
+func (r *rotatingFile) prune() {
+	backups := logBackups(filepath.Dir(r.path))
+	for i, b := range backups {
+		info, err := os.Stat(b)
+		if err != nil {
+			continue
+		}
+		tooMany := r.maxBackups > 0 && i >= r.maxBackups
+		tooOld := r.maxAge > 0 && time.Since(info.ModTime()) > r.maxAge
+		if tooMany || tooOld {
+			_ = os.Remove(b)
+		}
+	}
+}
+
+// logBackups lists rotated logs in dir, newest first.
+func logBackups(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "craftops-*.log*"))
+	slices.SortFunc(matches, func(a, b string) int { return strings.Compare(b, a) })
+	return matches
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"craftops/internal/config"
+)
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	r, err := openRotatingFile(dir, config.LoggingConfig{MaxBackups: 2, Compress: true})
+	if err != nil {
+		t.Fatalf("openRotatingFile: %v", err)
+	}
+	r.maxSize = 100 // bytes, to rotate quickly
+
+	line := strings.Repeat("x", 59) + "\n"
+	for range 8 {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+	_ = r.f.Close()
+
+	backups := logBackups(dir)
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want 2 kept", backups)
+	}
+	for _, b := range backups {
+		if !strings.HasSuffix(b, ".log.gz") {
+			t.Errorf("backup %s not compressed", b)
+		}
+	}
+	info, err := os.Stat(filepath.Join(dir, logFileName))
+	if err != nil || info.Size() > 100 {
+		t.Errorf("current log not rotated: %v, %v", info, err)
+	}
+}
+
+func TestRotatingFile_ReopensAfterFailedRotation(t *testing.T) {
+	dir := t.TempDir()
+	r, err := openRotatingFile(dir, config.LoggingConfig{})
+	if err != nil {
+		t.Fatalf("openRotatingFile: %v", err)
+	}
+	r.maxSize = 10
+	if _, err := r.Write([]byte("first line\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	// The rename and the reopen fail while path is in a missing directory.
+	r.path = filepath.Join(dir, "missing", logFileName)
+	if _, err := r.Write([]byte("second\n")); err == nil {
+		t.Error("Write: expected the failed rotation to be reported")
+	}
+	r.path = filepath.Join(dir, logFileName)
+	if _, err := r.Write([]byte("third\n")); err != nil {
+		t.Errorf("Write after failed rotation: %v", err)
+	}
+	_ = r.f.Close()
+}
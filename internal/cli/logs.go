@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var logLines int
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+	logsCmd.AddCommand(logsShowCmd)
+	logsShowCmd.Flags().IntVarP(&logLines, "lines", "n", 50, "number of lines to show (0 for all)")
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "CraftOps' own log files",
+}
+
+var logsShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the end of craftops.log and list rotated logs",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		dir := a.Config.Paths.Logs
+		if dir == "" || !a.Config.Logging.FileEnabled {
+			return errors.New("file logging is disabled (logging.file_enabled, paths.logs)")
+		}
+		path := filepath.Join(dir, logFileName)
+		f, err := os.Open(path) //nolint:gosec
+		if err != nil {
+			return fmt.Errorf("failed to open log: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+
+		var lines []string
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+			if logLines > 0 && len(lines) > logLines {
+				lines = lines[1:]
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
+		for _, l := range lines {
+			a.Terminal.Println(l)
+		}
+
+		if backups := logBackups(dir); len(backups) > 0 {
+			a.Terminal.Section(fmt.Sprintf("Rotated Logs (%d)", len(backups)))
+			for _, b := range backups {
+				a.Terminal.Printf("  %s\n", b)
+			}
+		}
+		return nil
+	},
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// annotationDisruptive marks commands gated by --respect-window.
+const annotationDisruptive = "craftops/disruptive"
+
+var disruptive = map[string]string{annotationDisruptive: "true"}
+
+// waitForWindow refuses to proceed outside the maintenance window, or with
+// maintenance.action = "defer" sleeps until the window opens.
+func waitForWindow(ctx context.Context, a *app) error {
+	m := a.Config.Maintenance
+	now := time.Now()
+	if m.Allows(now) {
+		return nil
+	}
+	next := m.NextOpen(now)
+	if next.IsZero() {
+		return fmt.Errorf("%w: the window never opens", domain.ErrOutsideWindow)
+	}
+	if m.Action != config.MaintenanceDefer {
+		return fmt.Errorf("%w: next opens %s", domain.ErrOutsideWindow, next.Format(timeFormat))
+	}
+	a.Terminal.Infof("Outside maintenance window, deferring until %s", next.Format(timeFormat))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(next)):
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+var modInfoJSON bool
+
+func init() {
+	modsCmd.AddCommand(modsInfoCmd, modsReportCmd)
+	modsInfoCmd.Flags().BoolVar(&modInfoJSON, "json", false, "print the project metadata as JSON")
+	modsReportCmd.Flags().BoolVar(&modInfoJSON, "json", false, "print the project metadata as JSON")
+}
+
+var modsInfoCmd = &cobra.Command{
+	Use:   "info <slug|id|url>",
+	Short: "Show a mod's license, client/server side support and links",
+	Long: `Info looks up a Modrinth project and shows what matters when deciding to
+ship it: its license, whether it runs on clients and servers, its status
+and where it is developed, with the version installed here if any. Lookups
+use the Modrinth response cache, and only it with --offline.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		info, err := a.Mods.Info(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		if modInfoJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+		displayModProject(a, info)
+		return nil
+	},
+}
+
+var modsReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Audit the licenses and side support of every configured mod",
+	Long: `Report looks up every mod source in bulk and lists its license and client
+and server side support, noting client-only mods, restrictive or custom
+licenses and archived projects. Lookups use the Modrinth response cache,
+and only it with --offline.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		report, err := a.Mods.Report(ctx)
+		if err != nil {
+			return err
+		}
+		if modInfoJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}
+		if len(report) == 0 {
+			a.Terminal.Warning("No mod sources configured")
+			return nil
+		}
+		a.Terminal.Section("Mods Report")
+		rows := make([][]string, 0, len(report))
+		flagged := 0
+		for _, p := range report {
+			notes := p.Notes()
+			if len(notes) > 0 {
+				flagged++
+			}
+			rows = append(rows, []string{p.Slug, orDash(p.Version), orDash(p.License),
+				orDash(p.ClientSide), orDash(p.ServerSide), a.Terminal.WarningSprint(strings.Join(notes, "; "))})
+		}
+		a.Terminal.Table([]string{"Mod", "Installed", "License", "Client", "Server", "Notes"}, rows)
+		if flagged > 0 {
+			a.Terminal.Warningf("%d of %d mod(s) have notes", flagged, len(report))
+		}
+		return nil
+	},
+}
+
+func displayModProject(a *app, p *domain.ModProject) {
+	title := p.Title
+	if title == "" {
+		title = p.Slug
+	}
+	a.Terminal.Section(title)
+	if p.Description != "" {
+		a.Terminal.Printf("  %s\n\n", p.Description)
+	}
+	license := orDash(p.License)
+	if p.LicenseURL != "" {
+		license += " (" + p.LicenseURL + ")"
+	}
+	for _, kv := range [][2]string{
+		{"Slug", p.Slug},
+		{"Project ID", orDash(p.ID)},
+		{"Installed", orDash(strings.TrimSpace(p.Version + " " + p.Filename))},
+		{"License", license},
+		{"Client side", orDash(p.ClientSide)},
+		{"Server side", orDash(p.ServerSide)},
+		{"Status", orDash(p.Status)},
+		{"Downloads", strconv.FormatInt(p.Downloads, 10)},
+	} {
+		a.Terminal.Printf("  %-12s: %s\n", kv[0], kv[1])
+	}
+	for _, name := range slices.Sorted(maps.Keys(p.Links)) {
+		a.Terminal.Printf("  %-12s: %s\n", name, p.Links[name])
+	}
+	for _, note := range p.Notes() {
+		a.Terminal.Warning(note)
+	}
+}
+
+// orDash stands in "-" for an empty table cell.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+var (
+	importForce bool
+	packOptions domain.PackOptions
+	packOutput  string
+)
+
+func init() {
+	modsCmd.AddCommand(modsExportCmd, modsImportCmd, modsPackCmd)
+	modsImportCmd.Flags().BoolVar(&noBackup, "no-backup", false, "skip pre-import backup")
+	modsImportCmd.Flags().BoolVar(&importForce, "force", false, "import even if the manifest targets another Minecraft version or loader")
+	modsPackCmd.Flags().StringVarP(&packOutput, "output", "o", "", "pack file to write (default: <name>-<version>.mrpack)")
+	modsPackCmd.Flags().StringVar(&packOptions.Name, "name", "", "pack name shown in launchers (default: server directory name)")
+	modsPackCmd.Flags().StringVar(&packOptions.Version, "version", "", "pack version (default: today's date)")
+	modsPackCmd.Flags().StringVar(&packOptions.LoaderVersion, "loader-version", "", "mod loader version players should run (required)")
+	_ = modsPackCmd.MarkFlagRequired("loader-version")
+}
+
+var modsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print the installed mod set as a JSON manifest (slugs, versions, hashes)",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		manifest, err := a.Mods.Export()
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(manifest)
+	},
+}
+
+var modsImportCmd = &cobra.Command{
+	Use:         "import <modlist.json>",
+	Short:       "Install the exact mod set from a manifest written by `mods export`",
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationDisruptive: "true", annotationUploadArg: "true"},
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		var manifest domain.ModManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("invalid manifest %s: %w", args[0], err)
+		}
+		mc := a.Config.Minecraft
+		if !importForce && (manifest.MinecraftVersion != mc.Version || manifest.Modloader != mc.Modloader) {
+			return fmt.Errorf("manifest targets %s %s but this server runs %s %s (use --force to import anyway)",
+				manifest.Modloader, manifest.MinecraftVersion, mc.Modloader, mc.Version)
+		}
+
+		report := startReport(domain.OpModUpdate)
+		defer func() {
+			finishReport(a, report, err)
+			notifyDone(a, report)
+		}()
+		var backup string
+		if !noBackup && a.Config.Backup.Enabled {
+			a.Terminal.Info("Creating pre-import backup...")
+			if path, err := a.Backup.CreatePreUpdate(ctx); err != nil && !errors.Is(err, domain.ErrBackupsDisabled) {
+				return err
+			} else if path != "" {
+				backup = path
+				a.Terminal.Success(a.Terminal.T("backup.created", "path", path))
+			}
+		}
+		if err := a.Mods.SaveRollback(ctx, backup); err != nil {
+			return fmt.Errorf("saving mods for rollback: %w", err)
+		}
+
+		a.Terminal.Infof("Importing %d mod(s) from %s...", len(manifest.Mods), args[0])
+		result, added, err := a.Mods.Import(ctx, &manifest)
+		if err != nil {
+			return err
+		}
+		report.Mods = result
+		displayModResults(a, result)
+
+		if err := saveModSources(a, len(added)); err != nil {
+			return err
+		}
+		if len(result.FailedMods) > 0 {
+			return fmt.Errorf("%w: %d of %d", domain.ErrModUpdatesFailed, len(result.FailedMods), len(manifest.Mods))
+		}
+		return nil
+	},
+}
+
+// saveModSources records mods.modrinth_sources, after added sources were
+// declared in memory, in the config file, unless nothing was added or this
+// is a dry run. Only that key is rewritten, in place, so the file keeps its
+// comments: the loaded config also holds defaults and command-line
+// overrides that do not belong in the file.
+func saveModSources(a *app, added int) error {
+	switch {
+	case added == 0:
+	case a.Config.DryRun:
+		a.Terminal.Infof("Dry run: would add %d mod source(s) to the config", added)
+	case a.Config.Path == "":
+		a.Terminal.Warningf("No config file to record %d new mod source(s) in (pass --config)", added)
+	default:
+		err := config.SetFileKey(a.Config.Path, "mods", "modrinth_sources", a.Config.Mods.ModrinthSources)
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		a.Terminal.Successf("Added %d mod source(s) to %s", added, a.Config.Path)
+	}
+	return nil
+}
+
+var modsPackCmd = &cobra.Command{
+	Use:   "pack",
+	Short: "Build a Modrinth .mrpack of the client-side mods for players",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		opts := packOptions
+		if opts.Name == "" {
+			opts.Name = filepath.Base(a.Config.Paths.Server)
+		}
+		if opts.Version == "" {
+			opts.Version = time.Now().Format("2006.01.02")
+		}
+		dest := packOutput
+		if dest == "" {
+			dest = fmt.Sprintf("%s-%s.mrpack", opts.Name, opts.Version)
+		}
+
+		a.Terminal.Info("Reading client/server support from Modrinth...")
+		pack, err := a.Mods.WriteClientPack(ctx, dest, opts)
+		if err != nil {
+			return err
+		}
+		if len(pack.Excluded) > 0 {
+			a.Terminal.Section(fmt.Sprintf("Left Out (%d)", len(pack.Excluded)))
+			for _, name := range slices.Sorted(maps.Keys(pack.Excluded)) {
+				a.Terminal.Printf("   %s: %s\n", a.Terminal.WarningSprint(name), a.Terminal.DimSprint(pack.Excluded[name]))
+			}
+		}
+		if a.Config.DryRun {
+			a.Terminal.Infof("Dry run: would write %s with %d mod(s)", pack.Path, len(pack.Included))
+			return nil
+		}
+		a.Terminal.Successf("Wrote %s with %d mod(s); import it in the Modrinth App or Prism Launcher", pack.Path, len(pack.Included))
+		return nil
+	},
+}
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+var (
+	searchLimit int
+	searchJSON  bool
+)
+
+func init() {
+	modsCmd.AddCommand(modsSearchCmd)
+	modsSearchCmd.Flags().IntVar(&searchLimit, "limit", 10, "number of results to show (1-100)")
+	modsSearchCmd.Flags().BoolVar(&searchJSON, "json", false, "print the results as JSON without offering to add one")
+}
+
+var modsSearchCmd = &cobra.Command{
+	Use:   "search <query>",
+	Short: "Search Modrinth for mods and add one to the mod sources",
+	Long: `Search finds Modrinth mods with a build for the configured loader and
+Minecraft version, then offers to add one of them to mods.modrinth_sources
+and save the config. Pick nothing (the default, and what --yes or closed
+input picks) to only search. Run mods update afterwards to install it.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		if searchLimit < 1 || searchLimit > 100 {
+			return fmt.Errorf("invalid --limit: %d. Must be between 1 and 100", searchLimit)
+		}
+		hits, err := a.Mods.Search(ctx, strings.Join(args, " "), searchLimit)
+		if err != nil {
+			return err
+		}
+		if searchJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(hits)
+		}
+		if len(hits) == 0 {
+			a.Terminal.Warningf("No %s mods for Minecraft %s match %q", a.Config.Minecraft.Modloader,
+				a.Config.Minecraft.Version, strings.Join(args, " "))
+			return nil
+		}
+		displaySearchHits(a, hits)
+
+		options := make([]string, 0, len(hits)+1)
+		for _, h := range hits {
+			options = append(options, fmt.Sprintf("%s (%s)", h.Title, h.Slug))
+		}
+		options = append(options, "Nothing")
+		i, err := a.Terminal.Select("Add a mod to the mod sources?", options, len(hits))
+		if err != nil || i == len(hits) {
+			return err
+		}
+		if !a.Mods.AddSource(hits[i].Slug) {
+			a.Terminal.Infof("%s already is a mod source", hits[i].Slug)
+			return nil
+		}
+		if err := saveModSources(a, 1); err != nil {
+			return err
+		}
+		if !a.Config.DryRun {
+			a.Terminal.Info("Run `craftops mods update` to install it")
+		}
+		return nil
+	},
+}
+
+func displaySearchHits(a *app, hits []domain.ModSearchHit) {
+	rows := make([][]string, 0, len(hits))
+	for i, h := range hits {
+		slug := h.Slug
+		if h.Declared {
+			slug += a.Terminal.DimSprint(" (added)")
+		}
+		rows = append(rows, []string{strconv.Itoa(i + 1), slug, h.Title, h.Author,
+			strconv.FormatInt(h.Downloads, 10), orDash(h.License), orDash(h.ServerSide)})
+	}
+	a.Terminal.Table([]string{"#", "Slug", "Title", "Author", "Downloads", "License", "Server"}, rows)
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var restoreFrom string
+
+func init() {
+	rootCmd.AddCommand(playersCmd)
+	playersCmd.AddCommand(playersRestoreCmd)
+	playersRestoreCmd.Flags().StringVar(&restoreFrom, "from", "", "backup to restore from (required)")
+	_ = playersRestoreCmd.MarkFlagRequired("from")
+}
+
+var playersCmd = &cobra.Command{
+	Use:   "players",
+	Short: "Player data management",
+}
+
+var playersRestoreCmd = &cobra.Command{
+	Use:   "restore <uuid|name>",
+	Short: "Restore one player's inventory, advancements and stats from a backup",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		status, err := a.Server.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.IsRunning {
+			return errors.New("server is running: stop it before restoring player data")
+		}
+
+		if err := confirm(a, fmt.Sprintf("Overwrite %s's player data with the copy from %s?", args[0], restoreFrom)); err != nil {
+			return err
+		}
+		files, err := a.Backup.RestorePlayer(ctx, restoreFrom, args[0])
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			a.Terminal.Printf("   %s\n", f)
+		}
+		a.Terminal.Successf("Restored %d file(s) for %s from %s", len(files), args[0], restoreFrom)
+		return nil
+	},
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.AddCommand(proxyBroadcastCmd, proxyEvacuateCmd)
+}
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Message and move players through the Velocity/BungeeCord proxy",
+}
+
+// requireProxy fails commands that only make sense with [proxy] enabled.
+func requireProxy(a *app) error {
+	if !a.Config.Proxy.Enabled {
+		return withExitCode(ExitConfig, domain.ErrProxyDisabled)
+	}
+	return nil
+}
+
+var proxyBroadcastCmd = &cobra.Command{
+	Use:   "broadcast <message>",
+	Short: "Show a message (e.g. a maintenance notice) to every player on the network",
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a := appFrom(cmd)
+		if err := requireProxy(a); err != nil {
+			return err
+		}
+		if err := a.Proxy.Broadcast(cmd.Context(), strings.Join(args, " ")); err != nil {
+			return err
+		}
+		a.Terminal.Success("Message sent through the proxy")
+		return nil
+	},
+}
+
+var proxyEvacuateCmd = &cobra.Command{
+	Use:         "evacuate",
+	Short:       "Send every player on this server to the fallback server",
+	Annotations: disruptive,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		if err := requireProxy(a); err != nil {
+			return err
+		}
+		p := a.Config.Proxy
+		if p.Fallback == "" {
+			return withExitCode(ExitConfig, errors.New("proxy.fallback is not set"))
+		}
+		if err := a.Proxy.Evacuate(cmd.Context()); err != nil {
+			return err
+		}
+		a.Terminal.Successf("Players on %s sent to %s", p.ServerName, p.Fallback)
+		return nil
+	},
+}
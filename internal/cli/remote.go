@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"craftops/internal/service"
+)
+
+// annotationLocal marks commands that always run on this machine, even
+// when the config names a host (fleet drives its members itself).
+const annotationLocal = "craftops/local"
+
+var local = map[string]string{annotationLocal: "true"}
+
+// annotationUploadArg marks commands whose first argument names a file on
+// this machine, uploaded to the host when the command runs there.
+const annotationUploadArg = "craftops/upload-arg"
+
+// forwardsToHost reports whether cmd should run on the config's host
+// rather than here.
+func forwardsToHost(cmd *cobra.Command, a *app) bool {
+	if a.Config.Host == "" || os.Getenv(service.RemoteEnv) != "" {
+		return false
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[annotationLocal] != "" || c.Name() == "help" || c.Name() == "completion" {
+			return false
+		}
+	}
+	return true
+}
+
+// runOnHost repeats this invocation of cmd on the config's host with the
+// terminal attached, exiting with the remote command's status.
+func runOnHost(cmd *cobra.Command, args []string, a *app) error {
+	ctx, remote := cmd.Context(), service.NewRemote(a.Config, a.Logger)
+	forwarded := remoteArgs(os.Args[1:])
+	if cmd.Annotations[annotationUploadArg] != "" && len(args) > 0 {
+		uploaded, err := remote.Upload(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		forwarded = replaceArg(forwarded, args[0], uploaded)
+	}
+	tty := term.IsTerminal(int(os.Stdin.Fd())) //nolint:gosec // fd fits in int
+	code, err := remote.Run(ctx, forwarded,
+		service.RemoteIO{Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr, TTY: tty})
+	if err != nil || code == ExitOK {
+		return err
+	}
+	return withExitCode(code, fmt.Errorf("craftops on %s exited with status %d", a.Config.Host, code))
+}
+
+// remoteArgs drops --config from args; the remote side gets the uploaded
+// copy instead.
+func remoteArgs(args []string) []string {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch a := args[i]; {
+		case a == "--":
+			return append(out, args[i:]...)
+		case a == "-c" || a == "--config":
+			i++
+		case strings.HasPrefix(a, "--config=") || (strings.HasPrefix(a, "-c") && !strings.HasPrefix(a, "--")):
+		default:
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// replaceArg replaces the last occurrence of arg in args, the positional
+// argument rather than a flag value that happens to match it.
+func replaceArg(args []string, arg, with string) []string {
+	out := slices.Clone(args)
+	for i := len(out) - 1; i >= 0; i-- {
+		if out[i] == arg {
+			out[i] = with
+			break
+		}
+	}
+	return out
+}
+
+// globalArgs renders the global flags for a command run on another host.
+func globalArgs() []string {
+	var args []string
+	for _, f := range []struct {
+		set  bool
+		name string
+	}{
+		{debug, "--debug"}, {dryRun, "--dry-run"}, {offline, "--offline"},
+		{noColor, "--no-color"}, {quiet, "--quiet"}, {verbose, "--verbose"},
+		{respectWindow, "--respect-window"}, {assumeYes, "--yes"}, {strict, "--strict"},
+	} {
+		if f.set {
+			args = append(args, f.name)
+		}
+	}
+	return args
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRemoteArgs(t *testing.T) {
+	tests := []struct {
+		args, want []string
+	}{
+		{[]string{"-c", "p.toml", "server", "status"}, []string{"server", "status"}},
+		{[]string{"--config=p.toml", "backup", "list", "-y"}, []string{"backup", "list", "-y"}},
+		{[]string{"server", "restart", "--config", "p.toml", "--dry-run"}, []string{"server", "restart", "--dry-run"}},
+		{[]string{"-cp.toml", "mods", "import", "--", "-c"}, []string{"mods", "import", "--", "-c"}},
+	}
+	for _, tt := range tests {
+		if got := remoteArgs(tt.args); !slices.Equal(got, tt.want) {
+			t.Errorf("remoteArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestReplaceArg(t *testing.T) {
+	args := []string{"--dry-run", "mods", "import", "list.json", "--force"}
+	got := replaceArg(args, "list.json", ".cache/craftops/upload-1-list.json")
+	want := []string{"--dry-run", "mods", "import", ".cache/craftops/upload-1-list.json", "--force"}
+	if !slices.Equal(got, want) {
+		t.Errorf("replaceArg = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var (
+	reportOp   string
+	reportJSON bool
+)
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportLastCmd)
+	reportLastCmd.Flags().StringVar(&reportOp, "op", "", "only consider this operation (mod_update, backup, restart)")
+	reportLastCmd.Flags().BoolVar(&reportJSON, "json", false, "print the raw JSON report")
+}
+
+// startReport begins timing a run of op. Requests holds the traffic counters
+// at the start until finishReport replaces them with the run's share.
+func startReport(op string) *domain.Report {
+	stats := service.RequestStats()
+	return &domain.Report{Operation: op, StartedAt: time.Now(), Requests: &stats}
+}
+
+// finishReport stamps r with its outcome and saves it. Failing to save a
+// report never fails the operation itself.
+func finishReport(a *app, r *domain.Report, err error) {
+	r.FinishedAt = time.Now()
+	r.DurationMS = r.FinishedAt.Sub(r.StartedAt).Milliseconds()
+	if err != nil {
+		r.Error = err.Error()
+	}
+	if r.Requests != nil {
+		delta := service.RequestStats().Sub(*r.Requests)
+		r.Requests = &delta
+	}
+	if _, werr := a.Reports.Write(r); werr != nil {
+		a.Logger.Warn("Failed to write operation report", zap.Error(werr))
+	}
+}
+
+// notifyDone fires a desktop notification for a finished backup or mod
+// update (see ui.Terminal.NotifyDone). Call it after finishReport.
+func notifyDone(a *app, r *domain.Report) {
+	var msg string
+	switch {
+	case r.Operation == domain.OpBackup && r.Error != "":
+		msg = a.Terminal.T("desktop.backup_failed", "error", r.Error)
+	case r.Operation == domain.OpBackup && r.Backup != nil:
+		msg = a.Terminal.T("desktop.backup_done", "name", r.Backup.Name)
+	case r.Operation == domain.OpModUpdate && r.Error != "":
+		msg = a.Terminal.T("desktop.update_failed", "error", r.Error)
+	case r.Operation == domain.OpModUpdate && r.Mods != nil:
+		msg = a.Terminal.T("desktop.update_done", "updated", len(r.Mods.UpdatedMods), "failed", len(r.Mods.FailedMods))
+	default:
+		return
+	}
+	a.Terminal.NotifyDone(r.StartedAt, a.Terminal.T("desktop.title"), msg)
+}
+
+// backupInfo describes a freshly created backup for a report.
+func backupInfo(path string) *domain.BackupInfo {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	return &domain.BackupInfo{
+		Name:      filepath.Base(path),
+		Path:      path,
+		CreatedAt: info.ModTime(),
+		Size:      info.Size(),
+		Snapshot:  info.IsDir(),
+	}
+}
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Operation run reports",
+}
+
+var reportLastCmd = &cobra.Command{
+	Use:   "last",
+	Short: "Show the most recent operation report",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		r, path, err := a.Reports.Last(reportOp)
+		if err != nil {
+			return err
+		}
+		if reportJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(r)
+		}
+		displayReport(a, r, path)
+		return nil
+	},
+}
+
+func displayReport(a *app, r *domain.Report, path string) {
+	a.Terminal.Section(fmt.Sprintf("%s at %s", r.Operation, r.StartedAt.Format(timeFormat)))
+	a.Terminal.Printf("  Report:   %s\n", path)
+	a.Terminal.Printf("  Duration: %s\n", (time.Duration(r.DurationMS) * time.Millisecond).String())
+	if r.Error != "" {
+		a.Terminal.Printf("  Result:   %s\n", a.Terminal.ErrorSprint("failed: "+r.Error))
+	} else {
+		a.Terminal.Printf("  Result:   %s\n", a.Terminal.SuccessSprint("ok"))
+	}
+
+	if b := r.Backup; b != nil {
+		a.Terminal.Printf("  Backup:   %s (%s)\n", b.Path, domain.FormatSize(b.Size))
+	}
+	if q := r.Requests; q != nil && q.Requests > 0 {
+		a.Terminal.Printf("  Requests: %d (%d failed, %d retries), %s downloaded\n",
+			q.Requests, q.Failures, q.Retries, domain.FormatSize(q.BytesDownloaded))
+	}
+	if m := r.Mods; m != nil {
+		a.Terminal.Printf("  Mods:     %d updated, %d failed, %d unchanged\n",
+			len(m.UpdatedMods), len(m.FailedMods), len(m.SkippedMods))
+		var rows [][]string
+		details := m.Details()
+		for _, d := range details {
+			if d.Outcome != domain.ModUpdated {
+				continue
+			}
+			from := d.From
+			if from == "" {
+				from = "-"
+			}
+			rows = append(rows, []string{d.Name, from, d.To, domain.FormatSize(d.Bytes), d.Duration.Round(10 * time.Millisecond).String()})
+		}
+		if len(rows) > 0 {
+			a.Terminal.Table([]string{"Mod", "From", "To", "Size", "Time"}, rows)
+		}
+		for _, d := range details {
+			if d.Outcome == domain.ModFailed {
+				a.Terminal.Printf("  %s %s [%s]: %s\n", a.Terminal.ErrorSprint("✗"), d.Name, d.ErrorKind, d.Error)
+			}
+		}
+	}
+}
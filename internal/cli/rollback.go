@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var restartAfter bool
+
+func init() {
+	modsUpdateCmd.Flags().BoolVar(&restartAfter, "restart", false, "restart onto the updated mods, rolling back automatically if the server fails to boot")
+	modsUpdateCmd.MarkFlagsMutuallyExclusive("restart", "check")
+	modsCmd.AddCommand(modsRollbackCmd)
+}
+
+var modsRollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "Put back the mods installed before the last update (server must be stopped)",
+	Long: "Restores the mod jars and lockfile that the last mods update saved before\n" +
+		"changing anything, removing jars installed since. The backup taken before that\n" +
+		"update is shown and, if confirmed, restored too, putting back worlds and configs.",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		saved, err := a.Mods.SavedRollback()
+		if err != nil {
+			return err
+		}
+		status, err := a.Server.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.IsRunning {
+			return errors.New("server is running: stop it before rolling back mods")
+		}
+		if saved.Backup != "" {
+			a.Terminal.Infof("Backup taken before that update: %s", filepath.Base(saved.Backup))
+		}
+		if err := confirm(a, fmt.Sprintf("Replace the installed mods with those saved before the update of %s?",
+			saved.SavedAt.Format("2006-01-02 15:04"))); err != nil {
+			return err
+		}
+		jars, err := a.Mods.Rollback(ctx)
+		if err != nil {
+			return err
+		}
+		for _, j := range jars {
+			a.Terminal.Printf("   %s\n", j)
+		}
+		a.Terminal.Successf("Restored %d mod jar(s)", len(jars))
+		if saved.Backup == "" {
+			return nil
+		}
+		ok, err := a.Terminal.Confirm(fmt.Sprintf("Also restore worlds and configs from %s?", filepath.Base(saved.Backup)), false)
+		if err != nil || !ok {
+			return err
+		}
+		return restorePreUpdate(ctx, a, saved.Backup)
+	},
+}
+
+// restartAfterUpdate restarts the server onto the updated mods through the
+// same warned, cancellable path as `server restart`. If the server crashes
+// or exits while booting, the previous mod set and the pre-update backup
+// are put back, the server is started on them and the failure is reported
+// with the versions that broke it. A server still running when the restart
+// gives up (a slow boot past its timeout) is left alone.
+func restartAfterUpdate(ctx context.Context, a *app, result *domain.ModUpdateResult, backup string) error {
+	if len(result.UpdatedMods) == 0 || a.Config.DryRun {
+		return nil
+	}
+	a.Terminal.Info(a.Terminal.T("mods.restarting"))
+	bootErr := warnedRestart(ctx, a)
+	switch {
+	case bootErr == nil:
+		a.Terminal.Success(a.Terminal.T("mods.restarted"))
+		return nil
+	case errors.Is(bootErr, domain.ErrRestartCancelled):
+		a.Terminal.Warning(a.Terminal.T("mods.restart_cancelled"))
+		return nil
+	case ctx.Err() != nil:
+		return bootErr
+	case !serverDown(ctx, a):
+		a.Terminal.Warning(a.Terminal.T("mods.still_running"))
+		return bootErr
+	}
+
+	msg := a.Notification.T("notify.update_boot_failed", "changes", describeChanges(result), "error", bootErr)
+	a.Terminal.Warning(a.Terminal.T("mods.rolling_back"))
+	if err := rollBack(ctx, a, backup); err != nil {
+		_ = a.Notification.SendError(ctx, a.Notification.T("notify.rollback_failed", "message", msg, "error", err))
+		return fmt.Errorf("%w; rollback failed: %w", bootErr, err)
+	}
+	a.Terminal.Success(a.Terminal.T("mods.rolled_back"))
+	_ = a.Notification.SendError(ctx, a.Notification.T("notify.rolled_back", "message", msg))
+	return fmt.Errorf("mod update rolled back: %w", bootErr)
+}
+
+// serverDown reports whether the server is known to have stopped, e.g.
+// crashed during boot. An unreadable status counts as running.
+func serverDown(ctx context.Context, a *app) bool {
+	status, err := a.Server.Status(ctx)
+	return err == nil && !status.IsRunning
+}
+
+// rollBack force-stops the failed server, restores the mods saved before the
+// update and the pre-update backup, and starts the server again. A backup
+// that went only to backup.remote_command cannot be restored here; the
+// server then starts on the previous mods with its current world.
+func rollBack(ctx context.Context, a *app, backup string) error {
+	a.Config.Server.ForceStop = true
+	if err := a.Server.Stop(ctx); err != nil {
+		return err
+	}
+	jars, err := a.Mods.Rollback(ctx)
+	if err != nil {
+		return err
+	}
+	a.Terminal.Success(a.Terminal.T("mods.restored_jars", "count", len(jars)))
+	if err := restorePreUpdate(ctx, a, backup); err != nil {
+		return err
+	}
+	return a.Server.Start(ctx)
+}
+
+// restorePreUpdate restores the server files from backup, the path
+// CreatePreUpdate returned, unless it is empty or went only to
+// backup.remote_command.
+func restorePreUpdate(ctx context.Context, a *app, backup string) error {
+	switch {
+	case service.RemoteOnly(backup):
+		a.Terminal.Warning(a.Terminal.T("mods.backup_remote", "name", backup))
+	case backup != "":
+		n, err := a.Backup.Restore(ctx, filepath.Base(backup))
+		if err != nil {
+			return err
+		}
+		a.Terminal.Success(a.Terminal.T("mods.restored_files", "count", n, "name", filepath.Base(backup)))
+	}
+	return nil
+}
+
+// describeChanges lists the updated versions as "name from → to".
+func describeChanges(result *domain.ModUpdateResult) string {
+	var parts []string
+	for _, d := range result.Details() {
+		if d.Outcome == domain.ModUpdated {
+			parts = append(parts, d.Name+" "+d.Versions())
+		}
+	}
+	return strings.Join(parts, ", ")
+}
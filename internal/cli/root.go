@@ -3,16 +3,29 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"go.uber.org/zap"
 
 	"craftops/internal/config"
+	"craftops/internal/domain"
 )
 
 var (
 	cfgFile string
 	debug   bool
 	dryRun  bool
+	offline bool
+
+	respectWindow bool
+	strict        bool
+	assumeYes     bool
+	noColor       bool
+	quiet         bool
+	verbose       bool
+	quietSet      bool
+	verboseSet    bool
 
 	// Version is set by ldflags during build.
 	Version = "dev"
@@ -26,33 +39,76 @@ var rootCmd = &cobra.Command{
 	SilenceErrors: true,
 	SilenceUsage:  true,
 	PersistentPreRunE: initApp,
-	PersistentPostRun: func(cmd *cobra.Command, _ []string) {
-		if a, ok := cmd.Context().Value(appKey{}).(*app); ok {
-			a.Close()
-		}
-	},
 }
 
-// Execute runs the root command.
+// Execute runs the root command. The app is closed even when the command
+// fails, so logs are synced and traces of failed operations exported.
 func Execute(ctx context.Context) error {
-	return rootCmd.ExecuteContext(ctx)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if cmd != nil && cmd.Context() != nil {
+		if a, ok := cmd.Context().Value(appKey{}).(*app); ok {
+			a.Close()
+		}
+	}
+	return err
 }
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file path")
 	rootCmd.PersistentFlags().BoolVar(&debug, "debug", false, "enable debug mode")
 	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show what would be done")
+	rootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "use cached data only, make no network calls")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to confirmation prompts")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also honors NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only print errors")
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose", false, "log debug output to the console")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	rootCmd.PersistentFlags().BoolVar(&respectWindow, "respect-window", false, "refuse or defer disruptive work outside the [maintenance] window")
+	rootCmd.PersistentFlags().BoolVar(&strict, "strict", false, "fail on unknown config keys instead of warning")
 	rootCmd.Version = Version
 	rootCmd.SetVersionTemplate("CraftOps v{{.Version}}\n")
 	rootCmd.Run = func(cmd *cobra.Command, _ []string) { _ = cmd.Help() }
+	rootCmd.AddCommand(exitCodesCmd)
 }
 
 func initApp(cmd *cobra.Command, _ []string) error {
 	cfg, err := config.LoadConfig(cfgFile)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return withExitCode(ExitConfig, fmt.Errorf("failed to load config: %w", err))
+	}
+
+	quietSet, verboseSet = cmd.Flags().Changed("quiet"), cmd.Flags().Changed("verbose")
+	applyGlobalFlags(cfg)
+
+	// config validate reports unknown keys itself.
+	if strict && len(cfg.Unknown) > 0 && cmd != configValidateCmd {
+		return withExitCode(ExitConfig, fmt.Errorf("unknown config keys in %s: %s", cfg.Path, strings.Join(unknownKeyNotes(cfg), ", ")))
 	}
 
+	application := newApp(cfg)
+	if len(cfg.Migrated) > 0 && cmd != configMigrateCmd {
+		application.Logger.Warn("Config file uses an older layout; run `craftops config migrate` to update it",
+			zap.String("path", cfg.Path), zap.Strings("changes", cfg.Migrated))
+	}
+	if len(cfg.Unknown) > 0 && cmd != configValidateCmd {
+		application.Logger.Warn("Config file has unknown keys, which are ignored", zap.String("path", cfg.Path),
+			zap.Strings("keys", unknownKeyNotes(cfg)))
+	}
+	ctx := context.WithValue(cmd.Context(), appKey{}, application)
+	cmd.SetContext(ctx)
+	if forwardsToHost(cmd, application) {
+		cmd.Run = nil
+		cmd.RunE = func(cmd *cobra.Command, args []string) error { return runOnHost(cmd, args, application) }
+		return nil
+	}
+	if respectWindow && cmd.Annotations[annotationDisruptive] != "" {
+		return waitForWindow(ctx, application)
+	}
+	return nil
+}
+
+// applyGlobalFlags overrides cfg with the global command-line flags.
+func applyGlobalFlags(cfg *config.Config) {
 	if debug {
 		cfg.Debug = true
 		cfg.Logging.Level = "DEBUG"
@@ -60,11 +116,18 @@ func initApp(cmd *cobra.Command, _ []string) error {
 	if dryRun {
 		cfg.DryRun = true
 	}
-
-	application := newApp(cfg)
-	ctx := context.WithValue(cmd.Context(), appKey{}, application)
-	cmd.SetContext(ctx)
-	return nil
+	if offline {
+		cfg.Offline = true
+	}
+	// Unset, they leave the quiet and verbose config keys alone.
+	if quietSet {
+		cfg.Quiet = quiet
+		cfg.Verbose = cfg.Verbose && !quiet
+	}
+	if verboseSet {
+		cfg.Verbose = verbose
+		cfg.Quiet = cfg.Quiet && !verbose
+	}
 }
 
 // Panics if called before initApp — programming error, not user error.
@@ -75,3 +138,15 @@ func appFrom(cmd *cobra.Command) *app {
 	}
 	return a
 }
+
+// confirm asks before a destructive action, returning ErrAborted if declined.
+func confirm(a *app, question string) error {
+	ok, err := a.Terminal.Confirm(question, false)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w (pass --yes to skip this prompt)", domain.ErrAborted)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/config"
+)
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	// Set here: runAlias checks steps against runCmd itself.
+	runCmd.RunE = runAlias
+}
+
+var runCmd = &cobra.Command{
+	Use:   "run [alias]",
+	Short: "Run a command alias from [aliases], or list them",
+	Long: `Run executes the craftops commands of an alias in [aliases] one after the
+other, each as its own craftops process with this config and the global
+flags given here (--dry-run, --yes, ...). It stops at the first command that
+fails and exits with that command's exit code. Without an alias it lists
+them.`,
+	Args: cobra.MaximumNArgs(1),
+}
+
+// runAlias runs the commands of the alias in args, or lists the aliases.
+func runAlias(cmd *cobra.Command, args []string) error {
+	ctx, a := cmd.Context(), appFrom(cmd)
+	if len(args) == 0 {
+		listAliases(a)
+		return nil
+	}
+	name := args[0]
+	steps, err := aliasSteps(a.Config.Aliases, name)
+	if err != nil {
+		return withExitCode(ExitConfig, err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	base := globalArgs()
+	if a.Config.Path != "" {
+		base = append(base, "--config", a.Config.Path)
+	}
+	lines := a.Config.Aliases[name]
+	for i, step := range steps {
+		a.Terminal.Step(i+1, len(steps), lines[i])
+		c := exec.CommandContext(ctx, exe, append(slices.Clone(base), step...)...) //nolint:gosec // commands from user config
+		c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+		if err := c.Run(); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			var ee *exec.ExitError
+			if errors.As(err, &ee) {
+				return withExitCode(ee.ExitCode(), fmt.Errorf("alias %s stopped at %q (exit status %d)", name, lines[i], ee.ExitCode()))
+			}
+			return err
+		}
+	}
+	a.Terminal.Successf("Alias %s finished: %d command(s)", name, len(steps))
+	return nil
+}
+
+// aliasSteps returns the arguments of each command of alias name, checking
+// all of them name craftops commands other than run before any runs, so an
+// alias can never start itself again.
+func aliasSteps(aliases config.Aliases, name string) ([][]string, error) {
+	lines, ok := aliases[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(aliases))
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unknown alias %q: no [aliases] configured", name)
+		}
+		return nil, fmt.Errorf("unknown alias %q (aliases: %s)", name, strings.Join(names, ", "))
+	}
+	steps := make([][]string, 0, len(lines))
+	for _, line := range lines {
+		args, err := aliases.Command(name, line)
+		if err != nil {
+			return nil, err
+		}
+		found, _, err := rootCmd.Find(args)
+		if err != nil || found == rootCmd || !found.Runnable() {
+			return nil, fmt.Errorf("alias %s: %q is not a craftops command", name, line)
+		}
+		if found == runCmd {
+			return nil, fmt.Errorf("alias %s: %q runs an alias, which aliases may not do", name, line)
+		}
+		steps = append(steps, args)
+	}
+	return steps, nil
+}
+
+func listAliases(a *app) {
+	aliases := a.Config.Aliases
+	if len(aliases) == 0 {
+		a.Terminal.Info("No aliases configured; add them under [aliases] in the config")
+		return
+	}
+	rows := make([][]string, 0, len(aliases))
+	for name, lines := range aliases {
+		rows = append(rows, []string{name, strings.Join(lines, "; ")})
+	}
+	slices.SortFunc(rows, func(x, y []string) int { return strings.Compare(x[0], y[0]) })
+	a.Terminal.Table([]string{"Alias", "Commands"}, rows)
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"craftops/internal/config"
+)
+
+func TestAliasSteps(t *testing.T) {
+	aliases := config.Aliases{
+		"nightly": {"backup create --tag nightly", "craftops mods update --no-backup"},
+		"broken":  {"backup create", "mods frobnicate"},
+		"loop":    {"backup create", "--yes run loop"},
+		"loop2":   {"-y --dry-run run nightly"},
+	}
+	steps, err := aliasSteps(aliases, "nightly")
+	if err != nil {
+		t.Fatalf("aliasSteps: %v", err)
+	}
+	if len(steps) != 2 || !slices.Equal(steps[0], []string{"backup", "create", "--tag", "nightly"}) ||
+		!slices.Equal(steps[1], []string{"mods", "update", "--no-backup"}) {
+		t.Errorf("steps = %q", steps)
+	}
+
+	if _, err := aliasSteps(aliases, "broken"); err == nil || !strings.Contains(err.Error(), "mods frobnicate") {
+		t.Errorf("unknown command in alias: err = %v", err)
+	}
+	for _, name := range []string{"loop", "loop2"} {
+		if _, err := aliasSteps(aliases, name); err == nil || !strings.Contains(err.Error(), "runs an alias") {
+			t.Errorf("alias %s running an alias behind flags: err = %v", name, err)
+		}
+	}
+	if _, err := aliasSteps(aliases, "weekly"); err == nil || !strings.Contains(err.Error(), "broken, loop, loop2, nightly") {
+		t.Errorf("unknown alias: err = %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"craftops/internal/api"
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+// Limits for the checks behind /readyz, tighter than `health` so probes
+// get an answer within their own timeouts.
+const (
+	serveHealthTimeout = 5 * time.Second
+	serveHealthBudget  = 8 * time.Second
+)
+
+// traceExportInterval is how often serve sends its spans to the collector.
+const traceExportInterval = 30 * time.Second
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run the HTTP API (inbound webhooks) until interrupted",
+	Long: `Serve runs the HTTP API until interrupted. While it runs, it also sends an
+error notification when no backup has succeeded within backup.max_age_hours,
+posts the [announcements] messages in game on their cron schedules and, with
+watchdog.enabled, probes the server for hangs and force-restarts it. It runs
+the batches of a ` + "`world pregen`" + ` job in the pregen window. With
+discord_bot.enabled it registers and answers the Discord slash commands.
+Bearer tokens in [[api.tokens]] may run the webhook actions on
+POST /actions/<action> as their role (viewer, operator, admin) allows.
+Every action waits for the [maintenance] window, or is refused outside it.
+GET /healthz answers while serve runs and GET /readyz with the ` + "`health`" + `
+checks (503 when one fails), for Kubernetes probes and uptime monitors.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		scheme := "http"
+		if a.Config.API.TLS.Enabled() {
+			scheme = "https"
+		}
+		a.Terminal.Infof("Serving API on %s://%s (%d webhook(s))", scheme, a.Config.API.Listen, len(a.Config.API.Webhooks))
+		go a.Tracer.Export(cmd.Context(), traceExportInterval)
+		go a.Backup.WatchFreshness(cmd.Context(), a.Notification)
+		go a.Server.WatchHangs(cmd.Context(), a.Notification)
+		go a.Server.RunPregen(cmd.Context(), a.Notification)
+		announcer := service.NewAnnouncer(a.Config, a.Logger)
+		announcer.UseConsole(a.Server)
+		go announcer.Run(cmd.Context())
+		approvals := service.NewApprovals(a.Config, a.Logger, a.Notification)
+		approvals.ServeAPI()
+		actions := webhookActions(a)
+		for op, action := range actions {
+			actions[op] = gated(a, approvals, op, "the API", action)
+		}
+		srv := api.New(a.Config, a.Logger, actions).WithMetrics(service.RequestStats).WithApprovals(approvals).
+			WithHealth(func(ctx context.Context) []domain.HealthCheck {
+				return runHealthChecks(ctx, a, serveHealthTimeout, serveHealthBudget)
+			})
+		if a.Config.DiscordBot.Enabled {
+			bot := service.NewDiscordBot(a.Config, a.Logger)
+			if err := bot.RegisterCommands(cmd.Context()); err != nil {
+				a.Terminal.Warningf("Registering Discord slash commands failed: %v", err)
+			}
+			srv.WithDiscordBot(botCommands(a, approvals), bot)
+		}
+		return srv.Run(cmd.Context())
+	},
+}
+
+// gated holds action until the [maintenance] window allows it (refusing or
+// deferring per maintenance.action) and approvals lets op, triggered by
+// source, run.
+func gated(a *app, approvals *service.Approvals, op, source string, action api.Action) api.Action {
+	return func(ctx context.Context) error {
+		if err := waitForWindow(ctx, a); err != nil {
+			return err
+		}
+		if err := approvals.Request(ctx, op, source); err != nil {
+			return err
+		}
+		return action(ctx)
+	}
+}
+
+// botCommands wires the Discord slash commands to the services; the actions
+// notify like their webhook counterparts and wait for the same maintenance
+// window and approvals.
+func botCommands(a *app, approvals *service.Approvals) map[string]api.BotCommand {
+	actions := webhookActions(a)
+	restart := gated(a, approvals, config.ActionRestart, "Discord /"+config.BotRestart, actions[config.ActionRestart])
+	updateMods := gated(a, approvals, config.ActionUpdateMods, "Discord /"+config.BotUpdateMods, actions[config.ActionUpdateMods])
+	return map[string]api.BotCommand{
+		config.BotStatus: func(ctx context.Context) (string, error) {
+			status, err := a.Server.Usage(ctx)
+			if err != nil {
+				return "", err
+			}
+			if !status.IsRunning {
+				return a.Notification.T("bot.stopped"), nil
+			}
+			msg := a.Notification.T("bot.running")
+			if status.PID > 0 {
+				msg += "\n" + a.Notification.T("bot.usage", "uptime", status.Uptime.Round(time.Second),
+					"cpu", fmt.Sprintf("%.1f", status.CPUPercent), "memory", domain.FormatSize(status.MemoryRSS))
+			}
+			if online, err := a.Server.PlayerCount(ctx); err == nil {
+				msg += "\n" + a.Notification.T("bot.players", "count", online)
+			}
+			return msg, nil
+		},
+		config.BotRestart: func(ctx context.Context) (string, error) {
+			return a.Notification.T("bot.restarted"), restart(ctx)
+		},
+		config.BotBackup: func(ctx context.Context) (string, error) {
+			var path string
+			backup := gated(a, approvals, config.ActionBackupCreate, "Discord /"+config.BotBackup, func(ctx context.Context) error {
+				var err error
+				if path, err = a.Backup.Create(ctx); err != nil {
+					_ = a.Notification.SendError(ctx, a.Notification.T("notify.backup_failed", "error", err))
+					return err
+				}
+				_ = a.Notification.SendSuccess(ctx, a.Notification.T("notify.backup_created", "path", path))
+				return nil
+			})
+			if err := backup(ctx); err != nil {
+				return "", err
+			}
+			return a.Notification.T("bot.backup_created", "name", filepath.Base(path)), nil
+		},
+		config.BotUpdateMods: func(ctx context.Context) (string, error) {
+			return a.Notification.T("bot.mods_updated"), updateMods(ctx)
+		},
+	}
+}
+
+// webhookActions wires the predefined webhook actions to the services.
+func webhookActions(a *app) map[string]api.Action {
+	return map[string]api.Action{
+		config.ActionUpdateMods: func(ctx context.Context) error {
+			return autoUpdateMods(ctx, a, false)
+		},
+		config.ActionBackupCreate: func(ctx context.Context) error {
+			path, err := a.Backup.Create(ctx)
+			if err != nil {
+				_ = a.Notification.SendError(ctx, a.Notification.T("notify.backup_failed", "error", err))
+				return err
+			}
+			_ = a.Notification.SendSuccess(ctx, a.Notification.T("notify.backup_created", "path", path))
+			return nil
+		},
+		config.ActionRestart: func(ctx context.Context) error {
+			// Like `server restart`: deferred while players are online and
+			// cancellable with `server restart --cancel`.
+			if err := warnedRestart(ctx, a); err != nil {
+				return err
+			}
+			_ = a.Notification.SendSuccess(ctx, a.Notification.T("notify.restarted"))
+			return nil
+		},
+	}
+}
+
+// autoUpdateMods runs an unattended mod update: pre-update backup, update,
+// and a Discord digest. With restart set the server is restarted onto the
+// updates and rolled back if it fails to boot. Failed mods are reported as
+// ErrModUpdatesFailed.
+func autoUpdateMods(ctx context.Context, a *app, restart bool) (err error) {
+	report := startReport(domain.OpModUpdate)
+	defer func() { finishReport(a, report, err) }()
+	var backup string
+	if a.Config.Backup.Enabled {
+		if backup, err = a.Backup.CreatePreUpdate(ctx); err != nil && !errors.Is(err, domain.ErrBackupsDisabled) {
+			_ = a.Notification.SendError(ctx, a.Notification.T("notify.pre_update_failed", "error", err))
+			return err
+		}
+	}
+	if err := a.Mods.SaveRollback(ctx, backup); err != nil {
+		return fmt.Errorf("saving mods for rollback: %w", err)
+	}
+	result, err := a.Mods.UpdateAll(ctx, false)
+	if err != nil {
+		return err
+	}
+	report.Mods = result
+	if err := a.Notification.SendModDigest(ctx, result); err != nil {
+		a.Logger.Warn("Mod update notification failed", zap.Error(err))
+	}
+	if restart {
+		if err := restartAfterUpdate(ctx, a, result, backup); err != nil {
+			return err
+		}
+	}
+	if len(result.FailedMods) > 0 {
+		names := slices.Sorted(maps.Keys(result.FailedMods))
+		return fmt.Errorf("%w: %s", domain.ErrModUpdatesFailed, strings.Join(names, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestGatedRefusesOutsideWindow(t *testing.T) {
+	cfg := config.DefaultConfig()
+	tomorrow := time.Now().Add(24 * time.Hour).Weekday()
+	cfg.Maintenance.Weekdays = []string{[]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}[tomorrow]}
+	a := &app{Config: cfg, Logger: zap.NewNop(), Terminal: newTerminal()}
+	approvals := service.NewApprovals(cfg, a.Logger, nil)
+
+	ran := false
+	action := gated(a, approvals, config.ActionRestart, "test", func(context.Context) error {
+		ran = true
+		return nil
+	})
+	if err := action(context.Background()); !errors.Is(err, domain.ErrOutsideWindow) {
+		t.Errorf("gated outside the window = %v, want ErrOutsideWindow", err)
+	}
+	if ran {
+		t.Error("action ran outside the maintenance window")
+	}
+
+	cfg.Maintenance.Weekdays = nil
+	if err := action(context.Background()); err != nil || !ran {
+		t.Errorf("gated inside the window = %v, ran %v; want the action to run", err, ran)
+	}
+}
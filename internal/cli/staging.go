@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var (
+	stagingUpdate  bool
+	stagingProfile string
+)
+
+func init() {
+	modsUpdateCmd.Flags().BoolVar(&stagingUpdate, "staging", false, "try the updates on a staging copy first, then offer to apply them here")
+	modsUpdateCmd.Flags().StringVar(&stagingProfile, "staging-profile", "", "[fleet] profile to stage on instead of a copy under paths.cache (implies --staging)")
+	modsUpdateCmd.MarkFlagsMutuallyExclusive("staging", "check")
+	modsUpdateCmd.MarkFlagsMutuallyExclusive("staging-profile", "check")
+}
+
+// stagedUpdate clones this server into a staging profile, updates the
+// mods there and boots it headless. Only once it logs "Done" is the user
+// offered the same mod versions here, installed from the staging manifest
+// like any update: after a pre-update backup and a saved rollback set, all
+// at once, and restarted onto with --restart.
+func stagedUpdate(ctx context.Context, a *app, report *domain.Report) error {
+	st := service.StagingConfig(a.Config)
+	if stagingProfile != "" {
+		profile, err := loadProfile(a.Config, stagingProfile)
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if profile.Host != "" {
+			return withExitCode(ExitConfig, fmt.Errorf("staging profile %s sets host; stage on a local profile", stagingProfile))
+		}
+		st = profile
+	}
+	staging := newApp(st)
+	defer staging.Close()
+	service.UseTracer(a.Tracer)
+
+	if status, err := staging.Server.Status(ctx); err != nil {
+		return err
+	} else if status.IsRunning {
+		return fmt.Errorf("staging server in %s is running; stop it first", st.Paths.Server)
+	}
+
+	a.Terminal.Step(1, 4, a.Terminal.T("mods.staging_copy"))
+	if _, err := a.Backup.CloneInto(ctx, st, domain.CloneOptions{Name: "staging"}); err != nil {
+		return err
+	}
+
+	a.Terminal.Step(2, 4, a.Terminal.T("mods.staging_update"))
+	result, err := staging.Mods.UpdateSelected(ctx, forceUpdate, domain.ModFilter{Only: onlyMods, Exclude: excludeMods})
+	if err != nil {
+		return err
+	}
+	displayModResults(a, result)
+	if len(result.FailedMods) > 0 {
+		return fmt.Errorf("%w on staging: %d mod(s); production untouched", domain.ErrModUpdatesFailed, len(result.FailedMods))
+	}
+	if len(result.UpdatedMods) == 0 {
+		a.Terminal.Success(a.Terminal.T("mods.staging_none"))
+		return nil
+	}
+	if a.Config.DryRun {
+		a.Terminal.Info(a.Terminal.T("mods.staging_dry_run", "count", len(result.UpdatedMods)))
+		return nil
+	}
+
+	a.Terminal.Step(3, 4, a.Terminal.T("mods.staging_start"))
+	if err := bootStaging(ctx, staging); err != nil {
+		displayStartupError(a, err)
+		_ = a.Notification.SendError(ctx, a.Notification.T("notify.staging_failed", "error", err))
+		return fmt.Errorf("staging server did not start with the updated mods; production untouched: %w", err)
+	}
+	a.Terminal.Success(a.Terminal.T("mods.staging_started"))
+
+	a.Terminal.Step(4, 4, a.Terminal.T("mods.staging_apply"))
+	if err := confirm(a, a.Terminal.T("mods.staging_confirm", "count", len(result.UpdatedMods))); err != nil {
+		return err
+	}
+	manifest, err := staging.Mods.Export()
+	if err != nil {
+		return err
+	}
+	var backup string
+	if !noBackup && a.Config.Backup.Enabled {
+		if path, err := a.Backup.CreatePreUpdate(ctx); err != nil && !errors.Is(err, domain.ErrBackupsDisabled) {
+			return err
+		} else if path != "" {
+			backup = path
+			a.Terminal.Success(a.Terminal.T("backup.created", "path", path))
+		}
+	}
+	if err := a.Mods.SaveRollback(ctx, backup); err != nil {
+		return fmt.Errorf("saving mods for rollback: %w", err)
+	}
+	applied, _, err := a.Mods.Import(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	report.Mods = applied
+	displayModResults(a, applied)
+	if err := a.Notification.SendModDigest(ctx, applied); err != nil {
+		a.Terminal.Warning(a.Terminal.T("mods.notify_failed", "error", err))
+	}
+	if len(applied.FailedMods) > 0 {
+		return fmt.Errorf("%w: %d of %d", domain.ErrModUpdatesFailed, len(applied.FailedMods), len(manifest.Mods))
+	}
+	if restartAfter {
+		return restartAfterUpdate(ctx, a, applied, backup)
+	}
+	return nil
+}
+
+// bootStaging starts the staging server, which returns once it is ready,
+// and stops it again.
+func bootStaging(ctx context.Context, staging *app) error {
+	if err := staging.Server.Start(ctx); err != nil {
+		_ = staging.Server.Stop(context.WithoutCancel(ctx))
+		return err
+	}
+	if err := staging.Server.Stop(context.WithoutCancel(ctx)); err != nil {
+		return fmt.Errorf("stopping staging server: %w", err)
+	}
+	return nil
+}
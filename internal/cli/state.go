@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+var stateJSON bool
+
+func init() {
+	rootCmd.AddCommand(stateCmd)
+	stateCmd.Flags().BoolVar(&stateJSON, "json", false, "print raw state as JSON")
+}
+
+const timeFormat = "2006-01-02 15:04:05"
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Inspect persisted operational state",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		a := appFrom(cmd)
+		st, err := a.State.Load()
+		if err != nil {
+			return err
+		}
+		if stateJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(st)
+		}
+		displayState(a, st)
+		return nil
+	},
+}
+
+func displayState(a *app, st *domain.State) {
+	a.Terminal.Infof("State file: %s", a.State.Path())
+
+	a.Terminal.Section("Last Successful Operations")
+	if len(st.LastSuccess) == 0 {
+		a.Terminal.Println("  none recorded")
+	}
+	for _, op := range slices.Sorted(maps.Keys(st.LastSuccess)) {
+		a.Terminal.Printf("  %-12s %s\n", op, st.LastSuccess[op].Format(timeFormat))
+	}
+
+	a.Terminal.Section(fmt.Sprintf("Mod Lockfile (%d)", len(st.Mods)))
+	if len(st.Mods) > 0 {
+		rows := make([][]string, 0, len(st.Mods))
+		for _, p := range slices.Sorted(maps.Keys(st.Mods)) {
+			m := st.Mods[p]
+			rows = append(rows, []string{m.Project, m.Version, m.Filename, m.InstalledAt.Format(timeFormat)})
+		}
+		a.Terminal.Table([]string{"Project", "Version", "File", "Installed"}, rows)
+	}
+
+	a.Terminal.Section(fmt.Sprintf("Backup Index (%d)", len(st.Backups)))
+	for _, b := range st.Backups {
+		a.Terminal.Printf("  %s  %s  %s\n", b.CreatedAt.Format(timeFormat), b.Name, domain.FormatSize(b.Size))
+	}
+
+	a.Terminal.Section(fmt.Sprintf("Crash History (%d)", len(st.Crashes)))
+	for _, c := range st.Crashes {
+		a.Terminal.Printf("  %s  %s\n", c.At.Format(timeFormat), c.Reason)
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+var statsJSON bool
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+	statsCmd.Flags().BoolVar(&statsJSON, "json", false, "print the statistics as JSON")
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show world size, region and chunk counts, players and total playtime",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		stats, err := a.Server.Stats(ctx)
+		if err != nil {
+			return err
+		}
+		if statsJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(stats)
+		}
+		displayStats(a, stats)
+		return nil
+	},
+}
+
+func displayStats(a *app, s *domain.WorldStats) {
+	a.Terminal.Section("World " + s.Level)
+	a.Terminal.Printf("  %-12s: %s\n", "Size", domain.FormatSize(s.WorldBytes))
+	a.Terminal.Printf("  %-12s: %d\n", "Players", s.Players)
+	a.Terminal.Printf("  %-12s: %s\n", "Playtime", formatPlaytime(s.Playtime.Hours()))
+	if len(s.Dimensions) == 0 {
+		a.Terminal.Warning("No dimension folders found in " + a.Config.Paths.Server)
+		return
+	}
+	rows := make([][]string, 0, len(s.Dimensions))
+	for _, d := range s.Dimensions {
+		rows = append(rows, []string{d.Name, d.Path, domain.FormatSize(d.Bytes),
+			strconv.Itoa(d.Regions), strconv.Itoa(d.Chunks), strconv.Itoa(d.EntityChunks)})
+	}
+	a.Terminal.Table([]string{"Dimension", "Folder", "Size", "Regions", "Chunks", "Entity chunks"}, rows)
+}
+
+// formatPlaytime renders hours as "12.5 h", or days for long totals.
+func formatPlaytime(hours float64) string {
+	if hours >= 48 {
+		return fmt.Sprintf("%.1f days", hours/24)
+	}
+	return fmt.Sprintf("%.1f h", hours)
+}
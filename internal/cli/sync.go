@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+var syncSkipUpdate bool
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().BoolVar(&syncSkipUpdate, "skip-update", false, "apply the config without installing or updating mods")
+}
+
+var syncCmd = &cobra.Command{
+	Use:         "sync",
+	Short:       "Pull config from the [sync] git repository, apply it and install its pinned mods or update them",
+	Annotations: disruptive,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		sync := service.NewSync(a.Config, a.Logger)
+
+		a.Terminal.Infof("Pulling %s (%s)...", a.Config.Sync.Repo, a.Config.Sync.Branch)
+		rev, err := sync.Pull(ctx)
+		if err != nil {
+			return err
+		}
+		a.Terminal.Successf("At commit %s", rev)
+
+		next, err := sync.Apply()
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if a.Config.DryRun {
+			a.Terminal.Infof("Dry run: %s would be written to %s", a.Config.Sync.ConfigFile, next.Path)
+		} else {
+			a.Terminal.Successf("Applied %s to %s", a.Config.Sync.ConfigFile, next.Path)
+		}
+		manifest, err := sync.Manifest()
+		if err != nil {
+			return withExitCode(ExitConfig, err)
+		}
+		if syncSkipUpdate {
+			return nil
+		}
+
+		synced := newApp(next)
+		defer synced.Close()
+		if manifest != nil {
+			a.Terminal.Infof("Installing the mod set pinned in %s...", a.Config.Sync.ManifestFile)
+			if err := importSynced(ctx, synced, manifest); err != nil {
+				return err
+			}
+			a.Terminal.Successf("Mods match %s", a.Config.Sync.ManifestFile)
+			return nil
+		}
+		a.Terminal.Info("Updating mods...")
+		if err := autoUpdateMods(ctx, synced, false); err != nil {
+			return err
+		}
+		a.Terminal.Success("Mods are up to date with the synced config")
+		return nil
+	},
+}
+
+// importSynced installs the mod set manifest pins like `mods import`: after
+// a pre-update backup and a saved rollback set, recording new sources in
+// the config and reporting the result in a digest. Failed mods are
+// reported as ErrModUpdatesFailed.
+func importSynced(ctx context.Context, a *app, manifest *domain.ModManifest) (err error) {
+	mc := a.Config.Minecraft
+	if manifest.MinecraftVersion != mc.Version || manifest.Modloader != mc.Modloader {
+		return withExitCode(ExitConfig, fmt.Errorf("%s targets %s %s but the synced config runs %s %s",
+			a.Config.Sync.ManifestFile, manifest.Modloader, manifest.MinecraftVersion, mc.Modloader, mc.Version))
+	}
+	report := startReport(domain.OpModUpdate)
+	defer func() { finishReport(a, report, err) }()
+	var backup string
+	if a.Config.Backup.Enabled {
+		if backup, err = a.Backup.CreatePreUpdate(ctx); err != nil && !errors.Is(err, domain.ErrBackupsDisabled) {
+			return err
+		}
+	}
+	if err := a.Mods.SaveRollback(ctx, backup); err != nil {
+		return fmt.Errorf("saving mods for rollback: %w", err)
+	}
+	result, added, err := a.Mods.Import(ctx, manifest)
+	if err != nil {
+		return err
+	}
+	report.Mods = result
+	displayModResults(a, result)
+	if err := saveModSources(a, len(added)); err != nil {
+		return err
+	}
+	if err := a.Notification.SendModDigest(ctx, result); err != nil {
+		a.Terminal.Warning(a.Terminal.T("mods.notify_failed", "error", err))
+	}
+	if len(result.FailedMods) > 0 {
+		return fmt.Errorf("%w: %d of %d", domain.ErrModUpdatesFailed, len(result.FailedMods), len(manifest.Mods))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/service"
+)
+
+var watchRestart bool
+
+func init() {
+	modsCmd.AddCommand(modsWatchCmd)
+	modsWatchCmd.Flags().BoolVar(&watchRestart, "restart", false, "restart onto applied updates, rolling back automatically if the server fails to boot")
+}
+
+var modsWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Poll Modrinth for new mod releases and apply them in the maintenance window",
+	Long: `Watch checks the configured mods for new releases every mods.watch_interval
+minutes until interrupted. New releases are applied as soon as the
+maintenance window allows: a pre-update backup, the update, and a Discord
+digest with the changelogs. Releases found outside the window wait for it.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		interval := time.Duration(a.Config.Mods.WatchInterval) * time.Minute
+		a.Terminal.Infof("Watching %d mod(s) for new releases every %s", len(a.Config.Mods.ModrinthSources), interval)
+
+		check := *a.Config
+		check.DryRun = true
+		checker := service.NewMods(&check, a.Logger)
+		approvals := service.NewApprovals(a.Config, a.Logger, a.Notification)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := watchOnce(ctx, a, checker, approvals); err != nil && ctx.Err() == nil {
+				a.Terminal.Warningf("Mod watch: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	},
+}
+
+// watchOnce looks for new releases with checker, a dry-run mod manager, and
+// applies them if the maintenance window is open and approvals allow.
+func watchOnce(ctx context.Context, a *app, checker *service.Mods, approvals *service.Approvals) error {
+	pending, err := checker.UpdateAll(ctx, false)
+	if err != nil {
+		return err
+	}
+	if len(pending.UpdatedMods) == 0 {
+		a.Logger.Debug("No new mod releases")
+		return nil
+	}
+	if !a.Config.Maintenance.Allows(time.Now()) {
+		a.Terminal.Infof("%d new release(s) waiting for the maintenance window: %s",
+			len(pending.UpdatedMods), describeChanges(pending))
+		return nil
+	}
+	if err := approvals.Request(ctx, config.ActionUpdateMods, "mods watch: "+describeChanges(pending)); err != nil {
+		return err
+	}
+	a.Terminal.Infof("Applying %d new release(s): %s", len(pending.UpdatedMods), describeChanges(pending))
+	a.Logger.Info("Applying new mod releases", zap.Strings("mods", pending.UpdatedMods))
+	return autoUpdateMods(ctx, a, watchRestart)
+}
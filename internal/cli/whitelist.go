@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/service"
+)
+
+func init() {
+	rootCmd.AddCommand(whitelistCmd)
+	whitelistCmd.AddCommand(whitelistSyncCmd)
+}
+
+var whitelistCmd = &cobra.Command{
+	Use:   "whitelist",
+	Short: "Whitelist management",
+}
+
+var whitelistSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Make whitelist.json match the allowlist in whitelist.source",
+	Long: `Sync downloads the allowlist in whitelist.source (a plain list, a CSV file, a
+GitHub Gist or a Google Sheet), resolves the names to UUIDs, adds and removes
+players in whitelist.json to match and reloads the whitelist of a running
+server. With --dry-run it only shows the changes.`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		if a.Config.Whitelist.Source == "" {
+			return withExitCode(ExitConfig, errors.New("whitelist.source is not set"))
+		}
+		res, err := service.NewWhitelist(a.Config, a.Logger, a.Server).Sync(ctx)
+		if err != nil {
+			return err
+		}
+		for _, name := range res.Added {
+			a.Terminal.Printf("  + %s\n", name)
+		}
+		for _, name := range res.Removed {
+			a.Terminal.Printf("  - %s\n", name)
+		}
+		if len(res.Unresolved) > 0 {
+			a.Terminal.Warningf("Skipped entries that are not Minecraft players: %s", strings.Join(res.Unresolved, ", "))
+		}
+		switch {
+		case len(res.Added) == 0 && len(res.Removed) == 0:
+			a.Terminal.Success("Whitelist is already up to date")
+		case a.Config.DryRun:
+			a.Terminal.Infof("Dry run: would add %d and remove %d player(s)", len(res.Added), len(res.Removed))
+		case res.Reloaded:
+			a.Terminal.Successf("Added %d and removed %d player(s); whitelist reloaded", len(res.Added), len(res.Removed))
+		default:
+			a.Terminal.Successf("Added %d and removed %d player(s); applies when the server starts", len(res.Added), len(res.Removed))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"craftops/internal/domain"
+)
+
+var (
+	blockCoords   bool
+	scanDimension string
+	scanNBT       bool
+	scanJSON      bool
+
+	pregenRadius    int
+	pregenCenter    []int
+	pregenDimension string
+	pregenBorder    bool
+	pregenCancel    bool
+)
+
+func init() {
+	rootCmd.AddCommand(worldCmd)
+	worldCmd.AddCommand(worldRestoreRegionCmd)
+	worldRestoreRegionCmd.Flags().BoolVar(&blockCoords, "blocks", false, "treat x and z as block coordinates instead of region coordinates")
+	worldCmd.AddCommand(worldScanCmd)
+	worldScanCmd.Flags().StringVar(&scanDimension, "dimension", "", "scan only this dimension (overworld, nether, end or namespace:path)")
+	worldScanCmd.Flags().BoolVar(&scanNBT, "nbt", false, "also decompress each chunk and check its NBT root (slower)")
+	worldScanCmd.Flags().BoolVar(&scanJSON, "json", false, "print the scan result as JSON")
+	worldCmd.AddCommand(worldPregenCmd)
+	worldPregenCmd.Flags().IntVar(&pregenRadius, "radius", 0, "pregenerate this many blocks around the center (schedules a new job)")
+	worldPregenCmd.Flags().IntSliceVar(&pregenCenter, "center", []int{0, 0}, "center block x,z")
+	worldPregenCmd.Flags().StringVar(&pregenDimension, "dimension", "overworld", "dimension to pregenerate (overworld, nether, end or namespace:path)")
+	worldPregenCmd.Flags().BoolVar(&pregenBorder, "border", false, "also set the world border to the pregenerated square")
+	worldPregenCmd.Flags().BoolVar(&pregenCancel, "cancel", false, "cancel the current job")
+	worldPregenCmd.MarkFlagsMutuallyExclusive("radius", "cancel")
+}
+
+var worldCmd = &cobra.Command{
+	Use:   "world",
+	Short: "World data management",
+}
+
+var worldRestoreRegionCmd = &cobra.Command{
+	Use:   "restore-region <backup> <dimension> <x> <z>",
+	Short: "Restore a single region file set from a backup (server must be stopped)",
+	Long: "Restores r.<x>.<z>.mca from the region, entities and poi folders of a dimension\n" +
+		"(overworld, nether, end or namespace:path) without touching the rest of the world.",
+	Args: cobra.ExactArgs(4),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		x, errX := strconv.Atoi(args[2])
+		z, errZ := strconv.Atoi(args[3])
+		if err := errors.Join(errX, errZ); err != nil {
+			return fmt.Errorf("invalid coordinates: %w", err)
+		}
+		if blockCoords {
+			x, z = x>>9, z>>9
+		}
+
+		status, err := a.Server.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if status.IsRunning {
+			return errors.New("server is running: stop it before restoring world data")
+		}
+
+		if err := confirm(a, fmt.Sprintf("Overwrite region %d,%d of %s with the copy from %s?", x, z, args[1], args[0])); err != nil {
+			return err
+		}
+		files, err := a.Backup.RestoreRegion(ctx, args[0], args[1], x, z)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			a.Terminal.Printf("   %s\n", f)
+		}
+		a.Terminal.Successf("Restored region %d,%d (%d file(s)) from %s", x, z, len(files), args[0])
+		return nil
+	},
+}
+
+var worldScanCmd = &cobra.Command{
+	Use:   "scan",
+	Short: "Check region files for corrupted chunks",
+	Long: "Reads every .mca file of the region, entities and poi folders and checks the\n" +
+		"chunk location table and each chunk's length and compression. Corrupted chunks are\n" +
+		"listed with their chunk coordinates; restore them with `world restore-region`.\n" +
+		"Exits with code 3 when problems are found.",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		scan, err := a.Server.ScanRegions(ctx, scanDimension, scanNBT)
+		if err != nil {
+			return err
+		}
+		if scanJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(scan); err != nil {
+				return err
+			}
+		} else {
+			displayRegionScan(a, scan)
+		}
+		if len(scan.Issues) > 0 {
+			return withExitCode(ExitHealth, fmt.Errorf("%d region problem(s) found", len(scan.Issues)))
+		}
+		return nil
+	},
+}
+
+func displayRegionScan(a *app, scan *domain.RegionScan) {
+	if len(scan.Issues) == 0 {
+		a.Terminal.Successf("Scanned %d region file(s) with %d chunk(s): no problems found", scan.Files, scan.Chunks)
+		return
+	}
+	rows := make([][]string, 0, len(scan.Issues))
+	for _, i := range scan.Issues {
+		chunk, region := "-", "-"
+		if i.Chunk {
+			chunk = fmt.Sprintf("%d,%d", i.X, i.Z)
+			region = fmt.Sprintf("%d,%d", i.X>>5, i.Z>>5)
+		}
+		rows = append(rows, []string{i.File, chunk, region, i.Problem})
+	}
+	a.Terminal.Table([]string{"File", "Chunk", "Region", "Problem"}, rows)
+	a.Terminal.Warningf("%d problem(s) in %d region file(s) with %d chunk(s)", len(scan.Issues), scan.Files, scan.Chunks)
+}
+
+var worldPregenCmd = &cobra.Command{
+	Use:   "pregen",
+	Short: "Schedule chunk pregeneration in off-peak batches, or show its progress",
+	Long: `With --radius, pregen schedules a job that ` + "`craftops serve`" + ` works through with the
+Chunky mod: batches of pregen.batch_minutes run inside the pregen window
+(pregen.hours) while the server is up, and pause when the window closes or
+more than pregen.max_players are online. Progress is kept in the state file,
+so the job resumes across server and craftops restarts. Without flags it
+shows the current job.`,
+	Example: `  craftops world pregen --radius 5000 --border
+  craftops world pregen --radius 2000 --dimension nether --center 100,-200
+  craftops world pregen`,
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ctx, a := cmd.Context(), appFrom(cmd)
+		switch {
+		case pregenCancel:
+			if err := a.Server.CancelPregen(ctx); err != nil {
+				return err
+			}
+			a.Terminal.Success("Pregeneration job cancelled")
+			return nil
+		case pregenRadius != 0:
+			if len(pregenCenter) != 2 {
+				return errors.New("--center takes x,z")
+			}
+			job := domain.PregenJob{Dimension: pregenDimension, CenterX: pregenCenter[0], CenterZ: pregenCenter[1],
+				Radius: pregenRadius, Border: pregenBorder}
+			if err := a.Server.PlanPregen(job); err != nil {
+				return err
+			}
+			a.Terminal.Successf("Scheduled pregeneration of %s, radius %d around %d,%d", pregenDimension, pregenRadius, job.CenterX, job.CenterZ)
+			a.Terminal.Infof("craftops serve runs %d-minute batches during pregen.hours (%s)", a.Config.Pregen.BatchMinutes, a.Config.Pregen.Hours)
+			return nil
+		}
+
+		job, err := a.Server.Pregen()
+		if err != nil {
+			return err
+		}
+		if job == nil {
+			a.Terminal.Info("No pregeneration job; schedule one with --radius")
+			return nil
+		}
+		state := "waiting for the pregen window"
+		switch {
+		case job.FinishedAt != nil:
+			state = "finished " + job.FinishedAt.Local().Format(timeFormat)
+		case job.Running:
+			state = "batch running since " + job.BatchStart.Local().Format(timeFormat)
+		case job.Started:
+			state = "paused"
+		}
+		a.Terminal.Table([]string{"Dimension", "Center", "Radius", "Progress", "Batches", "State"}, [][]string{{
+			job.Dimension, fmt.Sprintf("%d,%d", job.CenterX, job.CenterZ), strconv.Itoa(job.Radius),
+			fmt.Sprintf("%.2f%% (%d chunks)", job.Percent, job.Chunks), strconv.Itoa(job.Batches), state,
+		}})
+		return nil
+	},
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Aliases maps a name to craftops command lines that `craftops run <name>`
+// runs in order, stopping at the first that fails:
+//
+//	[aliases]
+//	nightly = ["backup create --tag nightly", "mods update"]
+//
+// A line is split into arguments like a shell would, so quoted arguments
+// may hold spaces; a leading "craftops" is optional. An alias may not run
+// another alias.
+type Aliases map[string][]string
+
+var aliasName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+func (a Aliases) validate() error {
+	for name, lines := range a {
+		if !aliasName.MatchString(name) {
+			return fmt.Errorf("invalid alias name: %s. Must be lowercase letters, digits, - and _", name)
+		}
+		if len(lines) == 0 {
+			return fmt.Errorf("alias %s has no commands", name)
+		}
+		for _, line := range lines {
+			if _, err := a.Command(name, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Command splits one command line of alias name into arguments.
+func (a Aliases) Command(name, line string) ([]string, error) {
+	args, err := SplitCommandLine(line)
+	if err != nil {
+		return nil, fmt.Errorf("alias %s: %q: %w", name, line, err)
+	}
+	if len(args) > 0 && args[0] == "craftops" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("alias %s has an empty command", name)
+	}
+	return args, nil
+}
+
+// SplitCommandLine splits line into arguments at unquoted whitespace.
+// Quotes group words into one argument, single quotes literally, and a
+// backslash outside single quotes takes the next character as it is.
+func SplitCommandLine(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnnouncementsConfig lists recurring in-game messages sent by `serve`.
+// Timezone is an IANA name for the cron expressions; empty uses the host's
+// local time.
+type AnnouncementsConfig struct {
+	Timezone  string         `toml:"timezone"`
+	Schedules []Announcement `toml:"schedule"`
+}
+
+// Announcement sends the next of Messages, in rotation, each time Cron
+// matches. Color, when set, sends it with tellraw in that color instead of
+// say.
+type Announcement struct {
+	Cron     string   `toml:"cron"`
+	Messages []string `toml:"messages"`
+	Color    string   `toml:"color"`
+}
+
+func (a AnnouncementsConfig) validate() error {
+	if _, err := a.Location(); err != nil {
+		return fmt.Errorf("invalid announcements timezone: %w", err)
+	}
+	for _, s := range a.Schedules {
+		if _, err := ParseCron(s.Cron); err != nil {
+			return err
+		}
+		if len(s.Messages) == 0 {
+			return fmt.Errorf("announcement %q has no messages", s.Cron)
+		}
+	}
+	return nil
+}
+
+// Location returns the timezone the schedules are evaluated in.
+func (a AnnouncementsConfig) Location() (*time.Location, error) {
+	if a.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(a.Timezone)
+}
+
+// Cron is a five-field cron expression (minute hour day-of-month month
+// day-of-week) with *, lists, ranges and steps. Each field is a bit set of
+// the values it matches.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool
+}
+
+var cronFields = []struct {
+	name     string
+	min, max int
+}{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseCron parses a five-field cron expression such as "*/30 9-23 * * *".
+func ParseCron(expr string) (Cron, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return Cron{}, fmt.Errorf("invalid cron expression: %q. Use five fields: minute hour day month weekday", expr)
+	}
+	var sets [5]uint64
+	for i, f := range fields {
+		set, err := parseCronField(f, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return Cron{}, fmt.Errorf("invalid cron %s %q in %q: %w", cronFields[i].name, f, expr, err)
+		}
+		sets[i] = set
+	}
+	if sets[4]&(1<<7) != 0 { // 7 is Sunday too
+		sets[4] |= 1
+	}
+	return Cron{
+		minute: sets[0], hour: sets[1], dom: sets[2], month: sets[3], dow: sets[4],
+		anyDom: fields[2] == "*", anyDow: fields[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var set uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return 0, errors.New("step must be a positive number")
+			}
+			step = n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return 0, fmt.Errorf("%q is not a number", a)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return 0, fmt.Errorf("%q is not a number", b)
+				}
+			} else if hasStep {
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return 0, fmt.Errorf("values must be within %d-%d", lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// Matches reports whether c fires in the minute of t. As in cron, when both
+// day of month and day of week are restricted either one matching is enough.
+func (c Cron) Matches(t time.Time) bool {
+	if c.minute&(1<<t.Minute()) == 0 || c.hour&(1<<t.Hour()) == 0 || c.month&(1<<int(t.Month())) == 0 {
+		return false
+	}
+	dom := c.dom&(1<<t.Day()) != 0
+	dow := c.dow&(1<<int(t.Weekday())) != 0
+	switch {
+	case c.anyDom && c.anyDow:
+		return true
+	case c.anyDom:
+		return dow
+	case c.anyDow:
+		return dom
+	}
+	return dom || dow
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ApprovalsConfig holds operations triggered without a person at the
+// keyboard (webhooks, Discord slash commands, `mods watch`) until someone
+// approves them. Operations maps an operation (update-mods, backup-create,
+// restart) to the minutes to wait for a decision; unlisted operations run at
+// once. A request is posted to notifications.discord_webhook, where members
+// holding a discord_bot admin role approve it by reacting ✅ or reject it
+// with ❌ (read with the bot token in discord_bot.guild_id). Requests made
+// by `serve` can also be decided on its API by POST /approvals/<id>/approve
+// or /reject, signed with Secret over "POST <path>\n" and the body, or sent
+// with an admin api token; `mods watch` has no API and needs the reactions.
+type ApprovalsConfig struct {
+	Operations map[string]int `toml:"operations"`
+	Secret     string         `toml:"secret"`
+}
+
+func (a ApprovalsConfig) validate(bot DiscordBotConfig, tokens []APITokenConfig) error {
+	actions := []string{ActionUpdateMods, ActionBackupCreate, ActionRestart}
+	for op, minutes := range a.Operations {
+		if !slices.Contains(actions, op) {
+			return fmt.Errorf("invalid approvals operation: %s. Must be one of %v", op, actions)
+		}
+		if minutes < 1 {
+			return fmt.Errorf("invalid approvals timeout for %s: %d. Must be at least 1 minute", op, minutes)
+		}
+	}
+	admin := slices.ContainsFunc(tokens, func(t APITokenConfig) bool { return t.Role == RoleAdmin })
+	if len(a.Operations) > 0 && a.Secret == "" && !admin && (!bot.Enabled || bot.GuildID == "") {
+		return errors.New("approvals need a secret, an admin api token or discord_bot with guild_id to be decided")
+	}
+	return nil
+}
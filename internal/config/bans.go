@@ -0,0 +1,10 @@
+package config
+
+// BansConfig controls `craftops bans`. With Shared, the servers of a
+// network share one ban list: `bans add` and `bans remove` also apply to
+// every local server in [fleet], and `bans sync` gives each of them the bans
+// of all the others, so a ban issued in-game on one server holds on every
+// server. Pardons spread only through `bans remove`, since sync merges.
+type BansConfig struct {
+	Shared bool `toml:"shared"`
+}
@@ -2,7 +2,9 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
@@ -13,16 +15,56 @@ import (
 
 // Config is the top-level application configuration.
 type Config struct {
-	Debug  bool `toml:"debug"`
-	DryRun bool `toml:"dry_run"`
+	// SchemaVersion is the layout the file was written in (see
+	// MigrateFile); loading migrates older files in memory.
+	SchemaVersion int `toml:"schema_version"`
 
-	Minecraft     MinecraftConfig    `toml:"minecraft"`
-	Paths         PathsConfig        `toml:"paths"`
-	Server        ServerConfig       `toml:"server"`
-	Mods          ModsConfig         `toml:"mods"`
-	Backup        BackupConfig       `toml:"backup"`
-	Notifications NotificationConfig `toml:"notifications"`
-	Logging       LoggingConfig      `toml:"logging"`
+	Debug   bool `toml:"debug"`
+	DryRun  bool `toml:"dry_run"`
+	Offline bool `toml:"offline"`
+	Quiet   bool `toml:"quiet"`   // console shows errors only
+	Verbose bool `toml:"verbose"` // console logs at debug level
+
+	// Host runs every command on this SSH destination ("user@mc1") instead
+	// of locally; paths below are paths on that host. RemoteBinary is a
+	// craftops already installed there; empty uploads this binary.
+	Host         string `toml:"host"`
+	RemoteBinary string `toml:"remote_binary"`
+
+	Minecraft     MinecraftConfig     `toml:"minecraft"`
+	Paths         PathsConfig         `toml:"paths"`
+	Server        ServerConfig        `toml:"server"`
+	Mods          ModsConfig          `toml:"mods"`
+	Backup        BackupConfig        `toml:"backup"`
+	Notifications NotificationConfig  `toml:"notifications"`
+	Logging       LoggingConfig       `toml:"logging"`
+	Network       NetworkConfig       `toml:"network"`
+	Maintenance   MaintenanceConfig   `toml:"maintenance"`
+	API           APIConfig           `toml:"api"`
+	Sync          SyncConfig          `toml:"sync"`
+	Telemetry     TelemetryConfig     `toml:"telemetry"`
+	Health        HealthConfig        `toml:"health"`
+	Proxy         ProxyConfig         `toml:"proxy"`
+	Fleet         FleetConfig         `toml:"fleet"`
+	Announcements AnnouncementsConfig `toml:"announcements"`
+	Watchdog      WatchdogConfig      `toml:"watchdog"`
+	DiscordBot    DiscordBotConfig    `toml:"discord_bot"`
+	Approvals     ApprovalsConfig     `toml:"approvals"`
+	Templates     TemplatesConfig     `toml:"templates"`
+	Whitelist     WhitelistConfig     `toml:"whitelist"`
+	Bans          BansConfig          `toml:"bans"`
+	Pregen        PregenConfig        `toml:"pregen"`
+	UI            UIConfig            `toml:"ui"`
+	Aliases       Aliases             `toml:"aliases"`
+
+	// Path is the file the config was loaded from, empty for defaults.
+	Path string `toml:"-"`
+	// Migrated notes the changes made migrating Path on load; `config
+	// migrate` writes them to the file.
+	Migrated []string `toml:"-"`
+	// Unknown lists keys in Path that no setting reads, typos most likely
+	// (see Suggest).
+	Unknown []string `toml:"-"`
 }
 
 // MinecraftConfig specifies game version and mod loader.
@@ -37,52 +79,302 @@ type PathsConfig struct {
 	Mods    string `toml:"mods"`
 	Backups string `toml:"backups"`
 	Logs    string `toml:"logs"`
+	Cache   string `toml:"cache"`
+	State   string `toml:"state"`
 }
 
-// ServerConfig holds JVM flags and lifecycle settings.
+// ServerConfig holds JVM flags and lifecycle settings. Start waits up to
+// StartupTimeout seconds for the session to come up, then up to
+// ReadyTimeout more for the "Done" log line and an open port (0 reports the
+// start as soon as the session is up). With ForceStop set, a
+// stop that exceeds MaxStopWait escalates to a second stop command, SIGTERM
+// and SIGKILL, waiting EscalationWait seconds after each step. PreStartCommands
+// and PostStopCommands run through sh in the server directory; Restart waits
+// RestartDelay seconds between stop and start. JavaPath selects the java
+// binary; otherwise JavaVersion picks a discovered JDK of that major version.
+// Memory and FlagsPreset expand into JVM flags unless JavaFlags is set.
+// StartCommand, run through sh in the server directory, replaces the java
+// command line entirely (e.g. "./run.sh nogui"); java and flag settings then
+// do not apply.
+// RestartWhenEmpty defers restarts while RestartPlayerThreshold or more
+// players are online, for at most MaxDeferMinutes. Resources constrains the
+// launched java process and Diagnostics adds GC logging and JFR to it.
 type ServerConfig struct {
 	JarName        string   `toml:"jar_name"`
+	StartCommand   string   `toml:"start_command"`
+	JavaPath       string   `toml:"java_path"`
+	JavaVersion    int      `toml:"java_version"`
 	JavaFlags      []string `toml:"java_flags"`
+	FlagsPreset    string   `toml:"flags_preset"`
+	Memory         string   `toml:"memory"`
 	StopCommand    string   `toml:"stop_command"`
 	MaxStopWait    int      `toml:"max_stop_wait"`
 	StartupTimeout int      `toml:"startup_timeout"`
+	ReadyTimeout   int      `toml:"ready_timeout"`
 	SessionName    string   `toml:"session_name"`
+	ForceStop      bool     `toml:"force_stop"`
+	EscalationWait int      `toml:"escalation_wait"`
+
+	PreStartCommands []string `toml:"pre_start_commands"`
+	PostStopCommands []string `toml:"post_stop_commands"`
+	RestartDelay     int      `toml:"restart_delay"`
+
+	RestartWhenEmpty       bool `toml:"restart_when_empty"`
+	RestartPlayerThreshold int  `toml:"restart_player_threshold"`
+	MaxDeferMinutes        int  `toml:"max_defer_minutes"`
+
+	Resources   ResourcesConfig   `toml:"resources"`
+	Diagnostics DiagnosticsConfig `toml:"diagnostics"`
 }
 
-// ModsConfig controls mod update behavior.
+// ModsConfig controls mod update behavior. With Strict set, an update moves
+// jars that are not the locked file of a configured source into
+// QuarantineDir, so the mods directory converges to the declared set.
+// AdvisoryLists are URLs of lists flagging known-bad mods, one Modrinth
+// project ID, slug or jar SHA-1 per line followed by an optional reason;
+// an update warns about flagged mods and, with BlockFlagged, does not
+// install them.
 type ModsConfig struct {
 	ConcurrentDownloads int      `toml:"concurrent_downloads"`
 	MaxRetries          int      `toml:"max_retries"`
 	RetryDelay          float64  `toml:"retry_delay"`
 	Timeout             int      `toml:"timeout"`
+	IdleTimeout         int      `toml:"idle_timeout"` // seconds a download may go without data; 0 disables
 	ModrinthSources     []string `toml:"modrinth_sources"`
+	DownloadMirrors     []string `toml:"download_mirrors"`
+	Strict              bool     `toml:"strict"`
+	QuarantineDir       string   `toml:"quarantine_dir"`     // empty: mods.quarantine next to the mods dir
+	UserAgentContact    string   `toml:"user_agent_contact"` // email or URL appended to the User-Agent
+	ModrinthToken       string   `toml:"modrinth_token"`     // personal access token for higher rate limits
+	ApplyPolicy         string   `toml:"apply_policy"`       // atomic | partial
+	Layout              string   `toml:"layout"`             // files | symlinks
+	TargetSubdir        string   `toml:"target_subdir"`      // e.g. "{mc_version}"; jars go in paths.mods/<subdir>
+	WatchInterval       int      `toml:"watch_interval"`     // minutes between `mods watch` polls
+	AdvisoryLists       []string `toml:"advisory_lists"`
+	BlockFlagged        bool     `toml:"block_flagged"`
+}
+
+// ModsDir is the directory mod jars are installed in: paths.mods, or the
+// mods.target_subdir below it with {mc_version} and {modloader} expanded.
+func (c *Config) ModsDir() string {
+	if c.Mods.TargetSubdir == "" {
+		return c.Paths.Mods
+	}
+	sub := strings.NewReplacer(
+		"{mc_version}", c.Minecraft.Version,
+		"{modloader}", c.Minecraft.Modloader,
+	).Replace(c.Mods.TargetSubdir)
+	return filepath.Join(c.Paths.Mods, filepath.FromSlash(sub))
 }
 
-// BackupConfig controls backup creation and retention.
+// Mod apply policies: swap in the downloaded jars only when every update
+// succeeded, or install whatever did succeed.
+const (
+	ModsApplyAtomic  = "atomic"
+	ModsApplyPartial = "partial"
+)
+
+// Mod layouts: jars as files in the mods directory, or symlinks into the
+// artifact cache under paths.cache so every version stays on disk.
+const (
+	ModsLayoutFiles    = "files"
+	ModsLayoutSymlinks = "symlinks"
+)
+
+// DefaultBackupNameTemplate reproduces the historical archive naming.
+const DefaultBackupNameTemplate = "minecraft_backup_{timestamp}"
+
+// Backup destinations.
+const (
+	BackupDestLocal  = "local"
+	BackupDestRemote = "remote"
+	BackupDestBoth   = "both"
+)
+
+// Backup modes: compressed tarballs, or hardlink-deduplicated directory trees.
+const (
+	BackupModeArchive  = "archive"
+	BackupModeSnapshot = "snapshot"
+)
+
+// BackupConfig controls backup creation and retention. IncludePaths are extra
+// directories archived whole alongside the server dir, less ExcludePatterns
+// matched within each; IncludePatterns, when set, restrict the server dir's
+// files to matching paths. Destination "remote" or "both"
+// streams the archive into RemoteCommand's stdin. Mode "snapshot" writes
+// uncompressed trees that hardlink unchanged files to the previous snapshot.
+// Health checks warn, and `serve` alerts, once the last successful backup is
+// older than MaxAgeHours (0 never does). MaxReadMBps caps how fast a backup
+// reads the server directory, in MB/s (0 is unlimited), so a backup of a
+// live server on a slow disk does not starve it. Once the backups
+// filesystem is PruneAbovePercent full (0 disables this), a new backup first
+// deletes the oldest backups until it is below that again, keeping the
+// newest backup, those created with --protect and those whose names match
+// ProtectPatterns. Retention keeps the newest MaxBackups of each tag, with
+// protected backups on top.
 type BackupConfig struct {
-	Enabled          bool     `toml:"enabled"`
-	MaxBackups       int      `toml:"max_backups"`
-	CompressionLevel int      `toml:"compression_level"`
-	IncludeLogs      bool     `toml:"include_logs"`
-	ExcludePatterns  []string `toml:"exclude_patterns"`
+	Enabled           bool     `toml:"enabled"`
+	Mode              string   `toml:"mode"`
+	NameTemplate      string   `toml:"name_template"`
+	MaxBackups        int      `toml:"max_backups"`
+	CompressionLevel  int      `toml:"compression_level"`
+	IncludeLogs       bool     `toml:"include_logs"`
+	ExcludePatterns   []string `toml:"exclude_patterns"`
+	IncludePaths      []string `toml:"include_paths"`
+	IncludePatterns   []string `toml:"include_patterns"`
+	Destination       string   `toml:"destination"`
+	RemoteCommand     string   `toml:"remote_command"`
+	MaxAgeHours       int      `toml:"max_age_hours"`
+	MaxReadMBps       int      `toml:"max_read_mbps"`
+	PruneAbovePercent int      `toml:"prune_above_percent"`
+	ProtectPatterns   []string `toml:"protect_patterns"`
 }
 
-// NotificationConfig controls Discord webhook alerts.
+// NotificationConfig controls Discord webhook alerts. InGameWarnings also
+// broadcasts restart warnings in chat and counts down the final minute on the
+// action bar every CountdownInterval seconds. RestartWarning has {minutes}
+// filled in; empty uses the message of the [ui] language.
 type NotificationConfig struct {
 	DiscordWebhook       string `toml:"discord_webhook"`
 	Timeout              int    `toml:"timeout"`
 	WarningIntervals     []int  `toml:"warning_intervals"`
-	WarningMessage       string `toml:"warning_message"`
+	RestartWarning       string `toml:"restart_warning"`
 	SuccessNotifications bool   `toml:"success_notifications"`
 	ErrorNotifications   bool   `toml:"error_notifications"`
+	InGameWarnings       bool   `toml:"ingame_warnings"`
+	CountdownInterval    int    `toml:"countdown_interval"`
 }
 
-// LoggingConfig controls log output.
+// LoggingConfig controls log output. The file log rotates once it exceeds
+// MaxSizeMB; MaxBackups and MaxAgeDays bound the rotated files (0 = no limit).
 type LoggingConfig struct {
 	Level          string `toml:"level"`
 	Format         string `toml:"format"`
 	FileEnabled    bool   `toml:"file_enabled"`
 	ConsoleEnabled bool   `toml:"console_enabled"`
+	MaxSizeMB      int    `toml:"max_size_mb"`
+	MaxBackups     int    `toml:"max_backups"`
+	MaxAgeDays     int    `toml:"max_age_days"`
+	Compress       bool   `toml:"compress"`
+}
+
+// NetworkConfig applies to every outbound HTTP client (Modrinth, Discord).
+type NetworkConfig struct {
+	Proxy    string `toml:"proxy"`     // http://, https:// or socks5:// URL; empty uses HTTP(S)_PROXY env
+	CABundle string `toml:"ca_bundle"` // PEM file appended to the system trust store
+}
+
+// TelemetryConfig enables OpenTelemetry spans for update-mods, backups and
+// restarts, exported to an OTLP/HTTP collector at Endpoint when a command ends.
+type TelemetryConfig struct {
+	Enabled     bool              `toml:"enabled"`
+	Endpoint    string            `toml:"endpoint"` // base URL; spans go to <endpoint>/v1/traces
+	Headers     map[string]string `toml:"headers"`  // e.g. an API key for a hosted collector
+	ServiceName string            `toml:"service_name"`
+}
+
+// HealthConfig fits health-check to a deployment. Ignore drops checks by
+// name; Severity caps a check's status, e.g. {"Discord webhook" = "ok"}
+// reports a missing webhook as passing. Names match case-insensitively.
+type HealthConfig struct {
+	Ignore   []string          `toml:"ignore"`
+	Severity map[string]string `toml:"severity"` // check name -> "ok" | "warn" | "error"
+}
+
+// FleetConfig lists the config files of the servers `craftops fleet`
+// manages from this host, one per server. Entries may be glob patterns.
+// Each local server needs its own paths.state.
+// Parallel bounds how many servers are worked on at once.
+type FleetConfig struct {
+	Configs  []string `toml:"configs"`
+	Parallel int      `toml:"parallel"`
+}
+
+// Webhook actions available to [[api.webhooks]].
+const (
+	ActionUpdateMods   = "update-mods"
+	ActionBackupCreate = "backup-create"
+	ActionRestart      = "restart"
+)
+
+// Roles an API token or Discord role can hold. Each may do everything the
+// ones before it may: viewers read status and metrics, operators also back
+// up and restart, admins also update mods and decide approvals.
+const (
+	RoleViewer   = "viewer"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+// Roles lists the roles from least to most privileged.
+var Roles = []string{RoleViewer, RoleOperator, RoleAdmin}
+
+// APIConfig controls `craftops serve`. ReadOnly caps every credential the
+// API accepts, webhook secrets and slash commands included, at viewer.
+type APIConfig struct {
+	Listen   string           `toml:"listen"`
+	ReadOnly bool             `toml:"read_only"`
+	Webhooks []WebhookConfig  `toml:"webhooks"`
+	Tokens   []APITokenConfig `toml:"tokens"`
+	TLS      APITLSConfig     `toml:"tls"`
+}
+
+// APITLSConfig serves the API over HTTPS with the PEM certificate and key
+// in Cert and Key, or with a self-signed certificate kept in paths.state
+// when SelfSigned is set. ClientCA (PEM) makes every route but /hooks and
+// /discord/interactions, which carry their own signatures, and the
+// /healthz and /readyz probes require a client certificate it issued.
+type APITLSConfig struct {
+	Cert       string `toml:"cert"`
+	Key        string `toml:"key"`
+	SelfSigned bool   `toml:"self_signed"`
+	ClientCA   string `toml:"client_ca"`
+}
+
+// Enabled reports whether the API is served over TLS.
+func (t APITLSConfig) Enabled() bool { return t.SelfSigned || t.Cert != "" }
+
+func (t APITLSConfig) validate() error {
+	if (t.Cert == "") != (t.Key == "") {
+		return errors.New("api tls requires both cert and key")
+	}
+	if t.SelfSigned && t.Cert != "" {
+		return errors.New("api tls takes either cert and key or self_signed, not both")
+	}
+	if t.ClientCA != "" && !t.Enabled() {
+		return errors.New("api tls client_ca requires cert and key or self_signed")
+	}
+	return nil
+}
+
+// APITokenConfig is a bearer token (Authorization: Bearer <token>) for the
+// API, allowed what Role allows. Name labels it in logs.
+type APITokenConfig struct {
+	Name  string `toml:"name"`
+	Token string `toml:"token"`
+	Role  string `toml:"role"`
+}
+
+// WebhookConfig maps POST /hooks/<name>, signed with Secret (HMAC-SHA256),
+// to a predefined action.
+type WebhookConfig struct {
+	Name   string `toml:"name"`
+	Action string `toml:"action"`
+	Secret string `toml:"secret"`
+}
+
+// SyncConfig controls `craftops sync`. Repo is cloned into Dir (by default
+// a directory per config file) and the sections of ConfigFile (relative to
+// the repository root) replace the local ones. The local [paths], [api] and
+// [sync] sections are kept, so one repository can hold a config per server.
+// When the repository has ManifestFile, a `mods export` manifest, sync
+// installs the exact mod set it pins instead of the latest releases.
+type SyncConfig struct {
+	Repo         string `toml:"repo"`
+	Branch       string `toml:"branch"`
+	ConfigFile   string `toml:"config_file"`
+	ManifestFile string `toml:"manifest_file"`
+	Dir          string `toml:"dir"`
 }
 
 // DefaultConfig returns production-ready defaults.
@@ -94,6 +386,7 @@ func DefaultConfig() *Config {
 	serverPath := filepath.Join(homeDir, "minecraft", "server")
 
 	return &Config{
+		SchemaVersion: SchemaVersion,
 		Minecraft: MinecraftConfig{
 			Version:   "1.20.1",
 			Modloader: "fabric",
@@ -103,30 +396,46 @@ func DefaultConfig() *Config {
 			Mods:    filepath.Join(serverPath, "mods"),
 			Backups: filepath.Join(homeDir, "minecraft", "backups"),
 			Logs:    filepath.Join(homeDir, ".local", "share", "craftops", "logs"),
+			Cache:   filepath.Join(homeDir, ".local", "share", "craftops", "cache"),
+			State:   filepath.Join(homeDir, ".local", "share", "craftops"),
 		},
 		Server: ServerConfig{
-			JarName: "server.jar",
-			JavaFlags: []string{
-				"-Xms4G", "-Xmx4G", "-XX:+UseG1GC",
-				"-XX:+ParallelRefProcEnabled", "-XX:+UnlockExperimentalVMOptions",
-				"-XX:+DisableExplicitGC", "-XX:+AlwaysPreTouch",
-			},
+			JarName:        "server.jar",
+			FlagsPreset:    FlagsPresetDefault,
+			Memory:         "4G",
 			StopCommand:    "stop",
 			MaxStopWait:    300,
 			StartupTimeout: 120,
+			ReadyTimeout:   300,
 			SessionName:    "minecraft",
+			EscalationWait: 15,
+			RestartDelay:   2,
+
+			RestartPlayerThreshold: 1,
+			MaxDeferMinutes:        60,
+
+			Diagnostics: DiagnosticsConfig{GCLogFiles: 5, GCLogSizeMB: 20, FlightRecorderHours: 6},
 		},
 		Mods: ModsConfig{
 			ConcurrentDownloads: 5,
 			MaxRetries:          3,
 			RetryDelay:          2.0,
+			IdleTimeout:         15,
 			Timeout:             30,
 			ModrinthSources:     []string{},
+			ApplyPolicy:         ModsApplyAtomic,
+			Layout:              ModsLayoutFiles,
+			WatchInterval:       60,
+			BlockFlagged:        true,
 		},
 		Backup: BackupConfig{
 			Enabled:          true,
+			Mode:             BackupModeArchive,
+			NameTemplate:     DefaultBackupNameTemplate,
+			Destination:      BackupDestLocal,
 			MaxBackups:       5,
 			CompressionLevel: 6,
+			MaxAgeHours:      48,
 			ExcludePatterns: []string{
 				"*.log", "*.log.*", "cache/", "temp/",
 				".DS_Store", "Thumbs.db",
@@ -135,15 +444,60 @@ func DefaultConfig() *Config {
 		Notifications: NotificationConfig{
 			Timeout:              30,
 			WarningIntervals:     []int{15, 10, 5, 1},
-			WarningMessage:       "Server will restart in {minutes} minute(s) for mod updates",
 			SuccessNotifications: true,
 			ErrorNotifications:   true,
+			InGameWarnings:       true,
+			CountdownInterval:    10,
+		},
+		API: APIConfig{
+			Listen: "127.0.0.1:8765",
+		},
+		Telemetry: TelemetryConfig{
+			Endpoint:    "http://localhost:4318",
+			ServiceName: "craftops",
+		},
+		Sync: SyncConfig{
+			Branch:       "main",
+			ConfigFile:   "config.toml",
+			ManifestFile: "modlist.json",
+		},
+		Maintenance: MaintenanceConfig{
+			Action: MaintenanceRefuse,
+		},
+		Fleet: FleetConfig{
+			Configs:  []string{},
+			Parallel: 2,
+		},
+		Watchdog: WatchdogConfig{
+			Probe:           ProbePing,
+			Interval:        30,
+			PingTimeout:     10,
+			LogStaleMinutes: 10,
+			Failures:        3,
+			Restart:         true,
+		},
+		Pregen: PregenConfig{
+			Hours:        "02:00-07:00",
+			BatchMinutes: 30,
+			RestMinutes:  15,
+			MaxPlayers:   -1,
+		},
+		Templates: TemplatesConfig{
+			ApplyOnStart: true,
+			Values:       map[string]any{},
+		},
+		Proxy: ProxyConfig{
+			Type:     ProxyVelocity,
+			Address:  "127.0.0.1:25575",
+			Fallback: "lobby",
 		},
 		Logging: LoggingConfig{
 			Level:          "INFO",
 			Format:         "json",
 			FileEnabled:    true,
 			ConsoleEnabled: true,
+			MaxSizeMB:      10,
+			MaxBackups:     5,
 		},
 	}
 }
@@ -156,15 +510,26 @@ func LoadConfig(configPath string) (*Config, error) {
 		configPath = findDefaultConfig()
 	}
 	if configPath != "" {
-		if _, err := toml.DecodeFile(configPath, config); err != nil {
+		data, err := os.ReadFile(configPath) //nolint:gosec // user-supplied config path
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", configPath, err)
+		}
+		data, config.Migrated, err = MigrateFile(data)
+		if err != nil {
 			return nil, fmt.Errorf("failed to load config file %s: %w", configPath, err)
 		}
+		md, err := toml.Decode(string(data), config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", configPath, err)
+		}
+		config.Unknown = unknownKeys(md)
 	}
 
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
 	}
 
+	config.Path = configPath
 	return config, nil
 }
 
@@ -200,6 +565,186 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log format: %s. Must be one of %v", c.Logging.Format, validFormats)
 	}
 	c.Logging.Format = format
+	if c.Logging.MaxSizeMB < 0 || c.Logging.MaxBackups < 0 || c.Logging.MaxAgeDays < 0 {
+		return errors.New("logging max_size_mb, max_backups and max_age_days must not be negative")
+	}
+
+	if t := c.Backup.NameTemplate; t != "" && !strings.Contains(t, "{timestamp}") &&
+		(!strings.Contains(t, "{date}") || !strings.Contains(t, "{time}")) {
+		return fmt.Errorf("invalid backup name_template: %s. Must contain {timestamp} or both {date} and {time}", t)
+	}
+	// The fixed start is how List tells this server's backups from others'
+	// in a shared paths.backups.
+	if head := strings.TrimLeft(c.Backup.NameTemplate, "_-. "); strings.HasPrefix(head, "{") && !strings.HasPrefix(head, "{server}") {
+		return fmt.Errorf("invalid backup name_template: %s. Must start with fixed text or {server}", c.Backup.NameTemplate)
+	}
+
+	switch c.Server.FlagsPreset {
+	case "":
+		c.Server.FlagsPreset = FlagsPresetDefault
+	case FlagsPresetDefault, FlagsPresetAikar, FlagsPresetNone:
+	default:
+		return fmt.Errorf("invalid server flags_preset: %s. Must be one of [default aikar none]", c.Server.FlagsPreset)
+	}
+	if c.Server.Memory != "" && memoryMB(c.Server.Memory) == 0 {
+		return fmt.Errorf("invalid server memory: %s. Use a size such as 4G or 6144M", c.Server.Memory)
+	}
+	if c.Server.ReadyTimeout < 0 {
+		return errors.New("server ready_timeout must not be negative")
+	}
+	if c.Server.EscalationWait < 1 {
+		return errors.New("server escalation_wait must be at least 1 second")
+	}
+
+	switch c.Mods.ApplyPolicy {
+	case "":
+		c.Mods.ApplyPolicy = ModsApplyAtomic
+	case ModsApplyAtomic, ModsApplyPartial:
+	default:
+		return fmt.Errorf("invalid mods apply_policy: %s. Must be one of [atomic partial]", c.Mods.ApplyPolicy)
+	}
+	if sub := c.Mods.TargetSubdir; sub != "" && (filepath.IsAbs(sub) ||
+		!strings.HasPrefix(filepath.Clean(c.ModsDir()), filepath.Clean(c.Paths.Mods)+string(filepath.Separator))) {
+		return fmt.Errorf("invalid mods target_subdir: %s. Must be a relative path inside paths.mods", sub)
+	}
+	if c.Mods.WatchInterval < 5 {
+		return errors.New("mods watch_interval must be at least 5 minutes")
+	}
+	for _, list := range c.Mods.AdvisoryLists {
+		if u, err := url.Parse(list); err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid mods advisory list: %s. Must be an http:// or https:// URL", list)
+		}
+	}
+	switch c.Mods.Layout {
+	case "":
+		c.Mods.Layout = ModsLayoutFiles
+	case ModsLayoutFiles:
+	case ModsLayoutSymlinks:
+		if c.Paths.Cache == "" {
+			return errors.New("mods layout symlinks requires paths.cache")
+		}
+	default:
+		return fmt.Errorf("invalid mods layout: %s. Must be one of [files symlinks]", c.Mods.Layout)
+	}
+
+	switch c.Backup.Mode {
+	case "":
+		c.Backup.Mode = BackupModeArchive
+	case BackupModeArchive, BackupModeSnapshot:
+	default:
+		return fmt.Errorf("invalid backup mode: %s. Must be one of [archive snapshot]", c.Backup.Mode)
+	}
+
+	switch c.Backup.Destination {
+	case "":
+		c.Backup.Destination = BackupDestLocal
+	case BackupDestLocal:
+	case BackupDestRemote, BackupDestBoth:
+		if strings.TrimSpace(c.Backup.RemoteCommand) == "" {
+			return fmt.Errorf("backup destination %q requires backup.remote_command", c.Backup.Destination)
+		}
+	default:
+		return fmt.Errorf("invalid backup destination: %s. Must be one of [local remote both]", c.Backup.Destination)
+	}
+	if c.Backup.Mode == BackupModeSnapshot && c.Backup.Destination != BackupDestLocal {
+		return errors.New("backup mode snapshot only supports the local destination")
+	}
+	if c.Backup.MaxAgeHours < 0 {
+		return errors.New("backup max_age_hours must not be negative")
+	}
+	if c.Backup.MaxReadMBps < 0 {
+		return errors.New("backup max_read_mbps must not be negative")
+	}
+	if p := c.Backup.PruneAbovePercent; p < 0 || p > 99 {
+		return fmt.Errorf("invalid backup prune_above_percent: %d. Must be between 0 and 99", p)
+	}
+
+	if err := c.Server.Resources.validate(); err != nil {
+		return err
+	}
+	if err := c.Server.Diagnostics.validate(); err != nil {
+		return err
+	}
+	if err := c.Maintenance.validate(); err != nil {
+		return err
+	}
+	if err := c.Announcements.validate(); err != nil {
+		return err
+	}
+	if err := c.Watchdog.validate(); err != nil {
+		return err
+	}
+	if err := c.Pregen.validate(); err != nil {
+		return err
+	}
+	if err := c.UI.validate(); err != nil {
+		return err
+	}
+	if err := c.Aliases.validate(); err != nil {
+		return err
+	}
+	if err := c.Proxy.validate(); err != nil {
+		return err
+	}
+	if c.Fleet.Parallel < 1 {
+		return fmt.Errorf("invalid fleet parallel: %d. Must be at least 1", c.Fleet.Parallel)
+	}
+
+	actions := []string{ActionUpdateMods, ActionBackupCreate, ActionRestart}
+	for _, h := range c.API.Webhooks {
+		if h.Name == "" || h.Secret == "" {
+			return errors.New("api webhooks require a name and a secret")
+		}
+		if !slices.Contains(actions, h.Action) {
+			return fmt.Errorf("invalid webhook action: %s. Must be one of %v", h.Action, actions)
+		}
+	}
+	if err := c.API.TLS.validate(); err != nil {
+		return err
+	}
+	tokens := map[string]bool{}
+	for _, t := range c.API.Tokens {
+		if t.Name == "" || t.Token == "" {
+			return errors.New("api tokens require a name and a token")
+		}
+		if tokens[t.Token] {
+			return fmt.Errorf("api token %s reuses the token of another entry", t.Name)
+		}
+		tokens[t.Token] = true
+		if !slices.Contains(Roles, t.Role) {
+			return fmt.Errorf("invalid api token role: %s. Must be one of %v", t.Role, Roles)
+		}
+	}
+
+	if err := c.DiscordBot.validate(); err != nil {
+		return err
+	}
+	if err := c.Approvals.validate(c.DiscordBot, c.API.Tokens); err != nil {
+		return err
+	}
+	if err := c.Whitelist.validate(); err != nil {
+		return err
+	}
+
+	if c.Telemetry.Enabled {
+		u, err := url.Parse(c.Telemetry.Endpoint)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("invalid telemetry endpoint: %s. Must be an http:// or https:// URL", c.Telemetry.Endpoint)
+		}
+	}
+
+	for name, sev := range c.Health.Severity {
+		if !slices.Contains([]string{"ok", "warn", "error"}, strings.ToLower(sev)) {
+			return fmt.Errorf("invalid health severity for %q: %s. Must be one of [ok warn error]", name, sev)
+		}
+	}
+
+	if c.Network.Proxy != "" {
+		u, err := url.Parse(c.Network.Proxy)
+		if err != nil || u.Host == "" || !slices.Contains([]string{"http", "https", "socks5"}, u.Scheme) {
+			return fmt.Errorf("invalid network proxy: %s. Must be an http://, https:// or socks5:// URL", c.Network.Proxy)
+		}
+	}
 	return nil
 }
 
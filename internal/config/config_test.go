@@ -4,7 +4,9 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -82,6 +84,93 @@ func TestValidation(t *testing.T) {
 		{"invalid log format", func(c *Config) { c.Logging.Format = "xml" }, true},
 		{"valid log level debug", func(c *Config) { c.Logging.Level = "debug" }, false},
 		{"valid format text", func(c *Config) { c.Logging.Format = "text" }, false},
+		{"backup template with timestamp", func(c *Config) { c.Backup.NameTemplate = "{server}_{tag}_{timestamp}" }, false},
+		{"backup template with date and time", func(c *Config) { c.Backup.NameTemplate = "mc-{date}-{time}" }, false},
+		{"backup template without fixed start", func(c *Config) { c.Backup.NameTemplate = "{timestamp}_{server}" }, true},
+		{"backup template starting with tag", func(c *Config) { c.Backup.NameTemplate = "_{tag}_{server}_{timestamp}" }, true},
+		{"backup template not unique", func(c *Config) { c.Backup.NameTemplate = "{server}_{date}" }, true},
+		{"remote backup without command", func(c *Config) { c.Backup.Destination = "remote" }, true},
+		{"negative backup read limit", func(c *Config) { c.Backup.MaxReadMBps = -1 }, true},
+		{"backup prune limit at 100", func(c *Config) { c.Backup.PruneAbovePercent = 100 }, true},
+		{"remote backup with command", func(c *Config) {
+			c.Backup.Destination = "both"
+			c.Backup.RemoteCommand = "aws s3 cp - s3://bucket/{name}"
+		}, false},
+		{"snapshot mode", func(c *Config) { c.Backup.Mode = "snapshot" }, false},
+		{"invalid backup mode", func(c *Config) { c.Backup.Mode = "zip" }, true},
+		{"snapshot with remote", func(c *Config) {
+			c.Backup.Mode = "snapshot"
+			c.Backup.Destination = "remote"
+			c.Backup.RemoteCommand = "cat"
+		}, true},
+		{"invalid backup destination", func(c *Config) { c.Backup.Destination = "tape" }, true},
+		{"zero escalation wait", func(c *Config) { c.Server.EscalationWait = 0 }, true},
+		{"invalid mods apply policy", func(c *Config) { c.Mods.ApplyPolicy = "some" }, true},
+		{"invalid mods layout", func(c *Config) { c.Mods.Layout = "hardlinks" }, true},
+		{"invalid mods advisory list", func(c *Config) { c.Mods.AdvisoryLists = []string{"ftp://example.com/list"} }, true},
+		{"ui language", func(c *Config) { c.UI.Language = "pt_BR" }, false},
+		{"aliases", func(c *Config) {
+			c.Aliases = Aliases{"nightly": {"backup create --tag nightly", "craftops mods update"}}
+		}, false},
+		{"invalid alias name", func(c *Config) { c.Aliases = Aliases{"Nightly!": {"mods update"}} }, true},
+		{"alias without commands", func(c *Config) { c.Aliases = Aliases{"nightly": nil} }, true},
+		{"alias with open quote", func(c *Config) { c.Aliases = Aliases{"nightly": {`backup create --tag "night`}} }, true},
+		{"invalid ui language", func(c *Config) { c.UI.Language = "../de" }, true},
+		{"mods target subdir", func(c *Config) { c.Mods.TargetSubdir = "{modloader}/{mc_version}" }, false},
+		{"announcement without messages", func(c *Config) {
+			c.Announcements.Schedules = []Announcement{{Cron: "0 * * * *"}}
+		}, true},
+		{"announcement with bad cron", func(c *Config) {
+			c.Announcements.Schedules = []Announcement{{Cron: "hourly", Messages: []string{"hi"}}}
+		}, true},
+		{"mods watch interval too short", func(c *Config) { c.Mods.WatchInterval = 1 }, true},
+		{"mods target subdir outside mods", func(c *Config) { c.Mods.TargetSubdir = "../{mc_version}" }, true},
+		{"symlinks without cache", func(c *Config) { c.Mods.Layout, c.Paths.Cache = "symlinks", "" }, true},
+		{"valid socks proxy", func(c *Config) { c.Network.Proxy = "socks5://127.0.0.1:1080" }, false},
+		{"invalid proxy scheme", func(c *Config) { c.Network.Proxy = "ftp://proxy:21" }, true},
+		{"proxy without host", func(c *Config) { c.Network.Proxy = "http://" }, true},
+		{"health severity", func(c *Config) { c.Health.Severity = map[string]string{"Discord webhook": "OK"} }, false},
+		{"invalid health severity", func(c *Config) { c.Health.Severity = map[string]string{"GNU screen": "info"} }, true},
+		{"invalid fleet parallel", func(c *Config) { c.Fleet.Parallel = 0 }, true},
+		{"watchdog log probe", func(c *Config) { c.Watchdog.Enabled, c.Watchdog.Probe = true, "log" }, false},
+		{"discord bot without roles", func(c *Config) {
+			c.DiscordBot = DiscordBotConfig{Enabled: true, ApplicationID: "1", Token: "t", PublicKey: "abababababababababababababababababababababababababababababababab"}
+		}, true},
+		{"discord bot with unknown role", func(c *Config) {
+			c.DiscordBot = DiscordBotConfig{Enabled: true, ApplicationID: "1", Token: "t", PublicKey: "abababababababababababababababababababababababababababababababab",
+				Roles: map[string]string{"2": "owner"}}
+		}, true},
+		{"discord bot with short key", func(c *Config) {
+			c.DiscordBot = DiscordBotConfig{Enabled: true, ApplicationID: "1", Token: "t", PublicKey: "abcd", AllowedRoles: []string{"2"}}
+		}, true},
+		{"approval with secret", func(c *Config) {
+			c.Approvals = ApprovalsConfig{Operations: map[string]int{"restart": 10}, Secret: "s"}
+		}, false},
+		{"approval for unknown operation", func(c *Config) {
+			c.Approvals = ApprovalsConfig{Operations: map[string]int{"stop": 10}, Secret: "s"}
+		}, true},
+		{"approval nobody can decide", func(c *Config) { c.Approvals.Operations = map[string]int{"restart": 10} }, true},
+		{"approval decided by an admin token", func(c *Config) {
+			c.Approvals.Operations = map[string]int{"restart": 10}
+			c.API.Tokens = []APITokenConfig{{Name: "ops", Token: "t", Role: RoleAdmin}}
+		}, false},
+		{"api tls cert without key", func(c *Config) { c.API.TLS.Cert = "api.crt" }, true},
+		{"api tls client ca without tls", func(c *Config) { c.API.TLS.ClientCA = "ca.crt" }, true},
+		{"api tls self-signed with client ca", func(c *Config) { c.API.TLS = APITLSConfig{SelfSigned: true, ClientCA: "ca.crt"} }, false},
+		{"api token without a role", func(c *Config) { c.API.Tokens = []APITokenConfig{{Name: "ops", Token: "t"}} }, true},
+		{"api tokens sharing a token", func(c *Config) {
+			c.API.Tokens = []APITokenConfig{{Name: "a", Token: "t", Role: RoleViewer}, {Name: "b", Token: "t", Role: RoleAdmin}}
+		}, true},
+		{"invalid watchdog probe", func(c *Config) { c.Watchdog.Probe = "rcon" }, true},
+		{"watchdog without failures", func(c *Config) { c.Watchdog.Enabled, c.Watchdog.Failures = true, 0 }, true},
+		{"invalid pregen hours", func(c *Config) { c.Pregen.Hours = "late" }, true},
+		{"pregen without batch length", func(c *Config) { c.Pregen.BatchMinutes = 0 }, true},
+		{"invalid proxy type", func(c *Config) { c.Proxy.Type = "waterfall" }, true},
+		{"proxy without password", func(c *Config) { c.Proxy.Enabled, c.Proxy.ServerName = true, "survival" }, true},
+		{"proxy fallback without server name", func(c *Config) { c.Proxy.Enabled, c.Proxy.Password = true, "pw" }, true},
+		{"proxy", func(c *Config) {
+			c.Proxy.Enabled, c.Proxy.Type, c.Proxy.Password, c.Proxy.ServerName = true, "BungeeCord", "pw", "survival"
+		}, false},
 	}
 
 	for _, tt := range tests {
@@ -137,3 +226,240 @@ func TestLoadConfig_RoundTrip(t *testing.T) {
 		t.Errorf("expected log level INFO after round-trip, got %q", loaded.Logging.Level)
 	}
 }
+
+func TestJVMFlags(t *testing.T) {
+	s := DefaultConfig().Server
+	flags := s.JVMFlags()
+	if !slices.Contains(flags, "-Xmx4G") || !slices.Contains(flags, "-XX:+UseG1GC") {
+		t.Errorf("default flags = %v", flags)
+	}
+
+	s.FlagsPreset, s.Memory = FlagsPresetAikar, "16G"
+	flags = s.JVMFlags()
+	if !slices.Contains(flags, "-Xms16G") || !slices.Contains(flags, "-XX:G1HeapRegionSize=16M") {
+		t.Errorf("aikar flags for 16G = %v", flags)
+	}
+
+	s.JavaFlags = []string{"-Xmx2G"}
+	if flags = s.JVMFlags(); !slices.Equal(flags, []string{"-Xmx2G"}) {
+		t.Errorf("explicit java_flags should be used verbatim, got %v", flags)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Server.Memory = "lots"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for invalid memory size")
+	}
+}
+
+func TestDiagnosticsFlags(t *testing.T) {
+	d := DefaultConfig().Server.Diagnostics
+	if flags := d.Flags("/var/log/craftops"); len(flags) != 0 {
+		t.Errorf("diagnostics are off by default, got %v", flags)
+	}
+	d.GCLog, d.FlightRecorder = true, true
+	flags := d.Flags("/var/log/craftops")
+	want := []string{
+		"-Xlog:gc*:file=/var/log/craftops/gc.log:time,uptime,level,tags:filecount=5,filesize=20m",
+		"-XX:FlightRecorderOptions=repository=/var/log/craftops/jfr",
+		"-XX:StartFlightRecording=name=craftops,disk=true,maxage=6h,dumponexit=true,filename=/var/log/craftops/jfr/exit.jfr",
+	}
+	if !slices.Equal(flags, want) {
+		t.Errorf("flags = %v", flags)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Server.Diagnostics.GCLog, cfg.Server.Diagnostics.GCLogFiles = true, 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for gc_log without rotation files")
+	}
+}
+
+func TestResourcesLaunchPrefix(t *testing.T) {
+	r := ResourcesConfig{}
+	if p := r.LaunchPrefix("craftops-mc"); len(p) != 0 {
+		t.Errorf("empty config prefix = %v", p)
+	}
+	r = ResourcesConfig{CPUAffinity: "0-3", Nice: 5, Scope: ScopeUser, MemoryMax: "8G", CPUQuota: "200%"}
+	want := []string{
+		"systemd-run", "--scope", "--collect", "--quiet", "--unit", "craftops-mc", "--user",
+		"-p", "MemoryMax=8G", "-p", "CPUQuota=200%", "--",
+		"nice", "-n", "5", "taskset", "-c", "0-3",
+	}
+	if p := r.LaunchPrefix("craftops-mc"); !slices.Equal(p, want) {
+		t.Errorf("prefix = %v", p)
+	}
+	if tools := r.LaunchTools(); !slices.Equal(tools, []string{"systemd-run", "nice", "taskset"}) {
+		t.Errorf("tools = %v", tools)
+	}
+
+	for _, bad := range []ResourcesConfig{
+		{CPUAffinity: "0-"},
+		{Nice: 20},
+		{Scope: "machine"},
+		{MemoryMax: "8G"},
+		{Scope: ScopeSystem, MemoryMax: "lots"},
+		{Scope: ScopeSystem, CPUQuota: "2"},
+	} {
+		cfg := DefaultConfig()
+		cfg.Server.Resources = bad
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for %+v", bad)
+		}
+	}
+}
+
+func TestMaintenanceWindow(t *testing.T) {
+	m := MaintenanceConfig{Weekdays: []string{"sat"}, Hours: "23:00-02:00", Timezone: "UTC"}
+	at := func(s string) time.Time {
+		ts, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	tests := []struct {
+		when string
+		want bool
+	}{
+		{"2024-06-01T23:30:00Z", true},  // Saturday night
+		{"2024-06-02T01:59:00Z", true},  // early Sunday, Saturday's window
+		{"2024-06-02T02:00:00Z", false}, // window closed
+		{"2024-06-02T23:30:00Z", false}, // Sunday night
+	}
+	for _, tt := range tests {
+		if got := m.Allows(at(tt.when)); got != tt.want {
+			t.Errorf("Allows(%s) = %v, want %v", tt.when, got, tt.want)
+		}
+	}
+	if next := m.NextOpen(at("2024-06-02T12:00:00Z")); !next.Equal(at("2024-06-08T23:00:00Z")) {
+		t.Errorf("NextOpen = %s", next)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Maintenance.Hours = "late"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for malformed maintenance hours")
+	}
+}
+
+func TestProxyCommands(t *testing.T) {
+	p := ProxyConfig{Type: ProxyBungeeCord, ServerName: "survival", Fallback: "lobby"}
+	if got := p.Broadcast("Restarting"); got != "alert Restarting" {
+		t.Errorf("Broadcast = %q", got)
+	}
+	if got := p.Send(); got != "send survival lobby" {
+		t.Errorf("Send = %q", got)
+	}
+	p.Type, p.BroadcastCommand = ProxyVelocity, "bc [{server}] {message}"
+	if got := p.Broadcast("Back online"); got != "bc [survival] Back online" {
+		t.Errorf("Broadcast override = %q", got)
+	}
+}
+
+func TestCron(t *testing.T) {
+	mon9 := time.Date(2026, 6, 1, 9, 30, 0, 0, time.UTC) // a Monday
+	tests := []struct {
+		expr string
+		at   time.Time
+		want bool
+	}{
+		{"* * * * *", mon9, true},
+		{"*/15 * * * *", mon9, true},
+		{"*/20 * * * *", mon9, false},
+		{"30 9-17 * * 1-5", mon9, true},
+		{"30 9 * * 0,6", mon9, false},
+		{"30 9 1 * 0", mon9, true}, // day of month or weekday
+		{"30 9 2 * 0", mon9, false},
+		{"30 9 * * 7", mon9.AddDate(0, 0, 6), true}, // 7 is Sunday
+		{"0 12 * 1-5 *", mon9, false},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := c.Matches(tt.at); got != tt.want {
+			t.Errorf("%q matches %s = %v, want %v", tt.expr, tt.at.Format(time.RFC1123), got, tt.want)
+		}
+	}
+	for _, bad := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(bad); err == nil {
+			t.Errorf("ParseCron(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"backup create --tag nightly", []string{"backup", "create", "--tag", "nightly"}},
+		{`  mods info  "fabric api" `, []string{"mods", "info", "fabric api"}},
+		{`server console 'say "hi" all'`, []string{"server", "console", `say "hi" all`}},
+		{`a\ b "" c\"d`, []string{"a b", "", `c"d`}},
+	}
+	for _, tt := range tests {
+		got, err := SplitCommandLine(tt.line)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("SplitCommandLine(%q) = %q, %v; want %q", tt.line, got, err, tt.want)
+		}
+	}
+	if _, err := SplitCommandLine(`say 'unterminated`); err == nil {
+		t.Error("unterminated quote accepted")
+	}
+}
+
+func TestSetFileKey_EditsInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	orig := `schema_version = 1
+# server settings
+[server]
+jar_name = "custom.jar" # not the default
+
+[mods]
+# pinned for the event
+modrinth_sources = [
+  "sodium", # renderer
+  "lithium",
+] # keep sorted
+concurrent_downloads = 3
+`
+	if err := os.WriteFile(path, []byte(orig), 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetFileKey(path, "mods", "modrinth_sources", []string{"sodium", "lithium", "ferrite-core"}); err != nil {
+		t.Fatalf("SetFileKey: %v", err)
+	}
+	want := strings.Replace(orig, `modrinth_sources = [
+  "sodium", # renderer
+  "lithium",
+] # keep sorted`, `modrinth_sources = ["sodium", "lithium", "ferrite-core"] # keep sorted`, 1)
+	if data, _ := os.ReadFile(path); string(data) != want {
+		t.Errorf("file after edit:\n%s\nwant:\n%s", data, want)
+	}
+
+	if err := SetFileKey(path, "server", "session_name", "mc"); err != nil {
+		t.Fatalf("SetFileKey: %v", err)
+	}
+	if err := SetFileKey(path, "backup", "max_backups", 3); err != nil {
+		t.Fatalf("SetFileKey: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "[server]\nsession_name = \"mc\"\njar_name") ||
+		!strings.HasSuffix(string(data), "\n[backup]\nmax_backups = 3\n") {
+		t.Errorf("missing key or table not added in place:\n%s", data)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.SessionName != "mc" || cfg.Backup.MaxBackups != 3 || len(cfg.Mods.ModrinthSources) != 3 {
+		t.Errorf("loaded config: session %q, max backups %d, sources %v",
+			cfg.Server.SessionName, cfg.Backup.MaxBackups, cfg.Mods.ModrinthSources)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Slash commands answered by the Discord bot.
+const (
+	BotStatus     = "status"
+	BotRestart    = "restart"
+	BotBackup     = "backup"
+	BotUpdateMods = "update-mods"
+)
+
+// DiscordBotConfig lets `serve` answer slash commands from a Discord
+// application. Discord posts interactions to /discord/interactions on the
+// API, which must be reachable over HTTPS as the application's Interactions
+// Endpoint URL; PublicKey (hex, from the developer portal) verifies them.
+// Token, the bot token, registers the commands in GuildID, or globally when
+// it is empty. Members holding one of AllowedRoles (role IDs) may run all
+// of them; Roles maps further role IDs to a craftops role (viewer,
+// operator, admin) limiting what their members may run.
+type DiscordBotConfig struct {
+	Enabled       bool              `toml:"enabled"`
+	ApplicationID string            `toml:"application_id"`
+	PublicKey     string            `toml:"public_key"`
+	Token         string            `toml:"token"`
+	GuildID       string            `toml:"guild_id"`
+	AllowedRoles  []string          `toml:"allowed_roles"`
+	Roles         map[string]string `toml:"roles"`
+}
+
+func (d DiscordBotConfig) validate() error {
+	if !d.Enabled {
+		return nil
+	}
+	if d.ApplicationID == "" || d.Token == "" {
+		return errors.New("discord_bot requires application_id and token")
+	}
+	if key, err := hex.DecodeString(d.PublicKey); err != nil || len(key) != 32 {
+		return fmt.Errorf("invalid discord_bot public_key: %q. Must be the 64-character hex key of the application", d.PublicKey)
+	}
+	if len(d.AllowedRoles) == 0 && len(d.Roles) == 0 {
+		return errors.New("discord_bot requires at least one allowed_roles or roles entry")
+	}
+	for id, role := range d.Roles {
+		if !slices.Contains(Roles, role) {
+			return fmt.Errorf("invalid discord_bot role for %s: %s. Must be one of %v", id, role, Roles)
+		}
+	}
+	return nil
+}
+
+// RoleOf returns the most privileged craftops role the Discord roles
+// memberRoles grant, or "" when they grant none.
+func (d DiscordBotConfig) RoleOf(memberRoles []string) string {
+	best := -1
+	for _, id := range memberRoles {
+		if slices.Contains(d.AllowedRoles, id) {
+			return RoleAdmin
+		}
+		if i := slices.Index(Roles, d.Roles[id]); i > best {
+			best = i
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return Roles[best]
+}
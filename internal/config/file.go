@@ -0,0 +1,217 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// PatchFile decodes the TOML config in data, upgraded to SchemaVersion,
+// lets update change its tables and returns it re-encoded. Keys update
+// leaves alone keep the values the file has, not the defaults and
+// command-line overrides a loaded Config carries. Like a migration, it
+// drops comments.
+func PatchFile(data []byte, update func(raw map[string]any)) ([]byte, error) {
+	raw, err := decodeTables(data)
+	if err != nil {
+		return nil, err
+	}
+	update(raw)
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadTables decodes the config file at path, upgraded to SchemaVersion,
+// into its keys and tables.
+func ReadTables(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-supplied config path
+	if err != nil {
+		return nil, err
+	}
+	return decodeTables(data)
+}
+
+func decodeTables(data []byte) (map[string]any, error) {
+	data, _, err := MigrateFile(data)
+	if err != nil {
+		return nil, err
+	}
+	raw := map[string]any{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// SetFileKey sets key in table of the config file at path to value,
+// editing the file's text in place: comments, key order and layout outside
+// that one assignment stay as they are. A missing key is added under the
+// table header and a missing table at the end of the file. A file with an
+// older schema is migrated first, which, as always, drops its comments.
+func SetFileKey(path, table, key string, value any) error {
+	data, err := os.ReadFile(path) //nolint:gosec // user-supplied config path
+	if err != nil {
+		return fmt.Errorf("failed to update config file: %w", err)
+	}
+	data, _, err = MigrateFile(data)
+	if err == nil {
+		data, err = setKeyText(data, table, key, value)
+	}
+	if err == nil {
+		_, err = toml.Decode(string(data), &map[string]any{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update config file %s: %w", path, err)
+	}
+	return WriteFile(path, data)
+}
+
+// setKeyText replaces the assignment of key in table within the TOML text
+// data, keeping its indentation and trailing comment, or inserts one.
+func setKeyText(data []byte, table, key string, value any) ([]byte, error) {
+	var line bytes.Buffer
+	if err := toml.NewEncoder(&line).Encode(map[string]any{key: value}); err != nil {
+		return nil, err
+	}
+	assignment := bytes.TrimSpace(line.Bytes())
+
+	current, headerEnd := "", -1
+	for start := 0; start < len(data); {
+		end := lineEnd(data, start)
+		text := bytes.TrimSpace(data[start:end])
+		switch {
+		case bytes.HasPrefix(text, []byte("[")):
+			current = tableName(text)
+			if current == table && headerEnd < 0 {
+				headerEnd = end
+			}
+		case bytes.HasPrefix(text, []byte("#")):
+		case bytes.IndexByte(text, '=') >= 0:
+			valueEnd, comment := valueExtent(data, start+bytes.IndexByte(data[start:end], '=')+1)
+			if current == table && assignsKey(text, key) {
+				indent := data[start : start+bytes.Index(data[start:end], text)]
+				out := slices.Concat(data[:start], indent, assignment)
+				if comment >= 0 {
+					out = slices.Concat(out, []byte(" "), data[comment:valueEnd])
+				}
+				return slices.Concat(out, data[valueEnd:]), nil
+			}
+			end = valueEnd
+		}
+		start = end + 1
+	}
+
+	if headerEnd >= 0 {
+		if headerEnd == len(data) {
+			return slices.Concat(data, []byte("\n"), assignment, []byte("\n")), nil
+		}
+		return slices.Concat(data[:headerEnd+1], assignment, []byte("\n"), data[headerEnd+1:]), nil
+	}
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = slices.Concat(data, []byte("\n"))
+	}
+	return slices.Concat(data, []byte("\n["+table+"]\n"), assignment, []byte("\n")), nil
+}
+
+// lineEnd returns the index of the newline ending the line at start, or
+// len(data) for the last line.
+func lineEnd(data []byte, start int) int {
+	if i := bytes.IndexByte(data[start:], '\n'); i >= 0 {
+		return start + i
+	}
+	return len(data)
+}
+
+// tableName returns the name in a [table] or [[table]] header line.
+func tableName(header []byte) string {
+	name, _, _ := strings.Cut(string(header), "#")
+	name = strings.Trim(strings.TrimSpace(name), "[]")
+	return strings.Trim(strings.TrimSpace(name), `"'`)
+}
+
+// assignsKey reports whether the trimmed line assigns key.
+func assignsKey(text []byte, key string) bool {
+	name, _, ok := strings.Cut(string(text), "=")
+	return ok && strings.Trim(strings.TrimSpace(name), `"'`) == key
+}
+
+// valueExtent scans the value starting at from, which may span lines
+// inside brackets or multi-line strings, and returns the end of its last
+// line and where a comment trailing it starts (-1 without one).
+func valueExtent(data []byte, from int) (end, comment int) {
+	depth, comment := 0, -1
+	for i := from; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '\n':
+			if depth == 0 {
+				return i, comment
+			}
+			comment = -1
+		case '#':
+			comment = i
+			i = lineEnd(data, i) - 1
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case '"', '\'':
+			i = stringEnd(data, i)
+		}
+	}
+	return len(data), comment
+}
+
+// stringEnd returns the index of the quote closing the string that opens
+// at start, handling triple-quoted and, for ", escaped quotes.
+func stringEnd(data []byte, start int) int {
+	q := data[start]
+	delim := []byte{q}
+	if bytes.HasPrefix(data[start:], []byte{q, q, q}) {
+		delim = []byte{q, q, q}
+	}
+	for i := start + len(delim); i < len(data); i++ {
+		switch {
+		case q == '"' && data[i] == '\\':
+			i++
+		case bytes.HasPrefix(data[i:], delim):
+			return i + len(delim) - 1
+		}
+	}
+	return len(data) - 1
+}
+
+// WriteFile replaces the config file at path with data through a temporary
+// file, keeping the file's permissions.
+func WriteFile(path string, data []byte) error {
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-config-*")
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
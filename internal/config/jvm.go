@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// JVM flag presets selectable with server.flags_preset.
+const (
+	FlagsPresetDefault = "default"
+	FlagsPresetAikar   = "aikar"
+	FlagsPresetNone    = "none"
+)
+
+var defaultFlags = []string{
+	"-XX:+UseG1GC", "-XX:+ParallelRefProcEnabled", "-XX:+UnlockExperimentalVMOptions",
+	"-XX:+DisableExplicitGC", "-XX:+AlwaysPreTouch",
+}
+
+// aikarFlags are the G1 tuning flags from https://mcflags.emc.gs.
+var aikarFlags = []string{
+	"-XX:+UseG1GC", "-XX:+ParallelRefProcEnabled", "-XX:MaxGCPauseMillis=200",
+	"-XX:+UnlockExperimentalVMOptions", "-XX:+DisableExplicitGC", "-XX:+AlwaysPreTouch",
+	"-XX:G1HeapWastePercent=5", "-XX:G1MixedGCCountTarget=4", "-XX:G1MixedGCLiveThresholdPercent=90",
+	"-XX:G1RSetUpdatingPauseTimePercent=5", "-XX:SurvivorRatio=32", "-XX:+PerfDisableSharedMem",
+	"-XX:MaxTenuringThreshold=1", "-Dusing.aikars.flags=https://mcflags.emc.gs", "-Daikars.new.flags=true",
+}
+
+// aikarSizing returns the heap-size dependent part of Aikar's flags, which
+// change above 12 GB.
+func aikarSizing(memoryMB int) []string {
+	if memoryMB > 12*1024 {
+		return []string{
+			"-XX:G1NewSizePercent=40", "-XX:G1MaxNewSizePercent=50", "-XX:G1HeapRegionSize=16M",
+			"-XX:G1ReservePercent=15", "-XX:InitiatingHeapOccupancyPercent=20",
+		}
+	}
+	return []string{
+		"-XX:G1NewSizePercent=30", "-XX:G1MaxNewSizePercent=40", "-XX:G1HeapRegionSize=8M",
+		"-XX:G1ReservePercent=20", "-XX:InitiatingHeapOccupancyPercent=15",
+	}
+}
+
+// JVMFlags returns the flags the server is launched with. Non-empty
+// java_flags are used verbatim; otherwise memory becomes -Xms/-Xmx followed
+// by the flags_preset expansion.
+func (s ServerConfig) JVMFlags() []string {
+	if len(s.JavaFlags) > 0 {
+		return s.JavaFlags
+	}
+	var flags []string
+	if s.Memory != "" {
+		flags = append(flags, "-Xms"+s.Memory, "-Xmx"+s.Memory)
+	}
+	switch s.FlagsPreset {
+	case FlagsPresetAikar:
+		flags = append(flags, aikarFlags...)
+		flags = append(flags, aikarSizing(memoryMB(s.Memory))...)
+	case FlagsPresetNone:
+	default:
+		flags = append(flags, defaultFlags...)
+	}
+	return flags
+}
+
+// GCLogFile is the unified GC log written to paths.logs with gc_log on;
+// rotated files get a numeric suffix.
+const GCLogFile = "gc.log"
+
+// DiagnosticsConfig adds JVM diagnostics to the launch flags (Java 11+).
+// GCLog writes GC events to GCLogFile in the logs directory, rotated over
+// GCLogFiles files of GCLogSizeMB each. FlightRecorder keeps a continuous
+// JFR recording of the last FlightRecorderHours under jfr/ there and dumps
+// it to jfr/exit.jfr when the server stops. DumpOnHang saves a thread dump
+// to dumps/ there before a forced stop escalates past the stop command.
+type DiagnosticsConfig struct {
+	GCLog               bool `toml:"gc_log"`
+	GCLogFiles          int  `toml:"gc_log_files"`
+	GCLogSizeMB         int  `toml:"gc_log_size_mb"`
+	FlightRecorder      bool `toml:"flight_recorder"`
+	FlightRecorderHours int  `toml:"flight_recorder_hours"`
+	DumpOnHang          bool `toml:"dump_on_hang"`
+}
+
+func (d DiagnosticsConfig) validate() error {
+	if d.GCLog && (d.GCLogFiles < 1 || d.GCLogSizeMB < 1) {
+		return fmt.Errorf("invalid server gc_log_files/gc_log_size_mb: %d/%d. Both must be at least 1", d.GCLogFiles, d.GCLogSizeMB)
+	}
+	if d.FlightRecorder && d.FlightRecorderHours < 1 {
+		return fmt.Errorf("invalid server flight_recorder_hours: %d. Must be at least 1", d.FlightRecorderHours)
+	}
+	return nil
+}
+
+// Flags returns the JVM flags for the enabled diagnostics, writing into
+// logsDir.
+func (d DiagnosticsConfig) Flags(logsDir string) []string {
+	var flags []string
+	if d.GCLog {
+		flags = append(flags, fmt.Sprintf("-Xlog:gc*:file=%s:time,uptime,level,tags:filecount=%d,filesize=%dm",
+			filepath.Join(logsDir, GCLogFile), d.GCLogFiles, d.GCLogSizeMB))
+	}
+	if d.FlightRecorder {
+		dir := filepath.Join(logsDir, "jfr")
+		flags = append(flags,
+			"-XX:FlightRecorderOptions=repository="+dir,
+			fmt.Sprintf("-XX:StartFlightRecording=name=craftops,disk=true,maxage=%dh,dumponexit=true,filename=%s",
+				d.FlightRecorderHours, filepath.Join(dir, "exit.jfr")))
+	}
+	return flags
+}
+
+// memoryMB parses a JVM size such as "8G" or "6144M"; 0 if invalid.
+func memoryMB(size string) int {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	if len(size) < 2 {
+		return 0
+	}
+	n := 0
+	for _, r := range size[:len(size)-1] {
+		if r < '0' || r > '9' {
+			return 0
+		}
+		n = n*10 + int(r-'0')
+	}
+	switch size[len(size)-1] {
+	case 'G':
+		return n * 1024
+	case 'M':
+		return n
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// unknownKeys returns the keys of a decoded file that no setting reads,
+// leaving out those inside an unknown table.
+func unknownKeys(md toml.MetaData) []string {
+	var keys []string
+	for _, k := range md.Undecoded() {
+		key := k.String()
+		if len(keys) > 0 && strings.HasPrefix(key, keys[len(keys)-1]+".") {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Suggest returns the known key closest to an unknown one in the same
+// table, such as mods.modrinth_sources for mods.modrinth_source, or "" when
+// none is close enough to be a typo.
+func Suggest(key string) string {
+	table, name := "", key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		table, name = key[:i], key[i+1:]
+	}
+	t := reflect.TypeFor[Config]()
+	if table != "" {
+		for part := range strings.SplitSeq(table, ".") {
+			field, ok := tomlField(t, part)
+			if !ok {
+				return ""
+			}
+			t = field.Type
+			for t.Kind() == reflect.Slice || t.Kind() == reflect.Pointer {
+				t = t.Elem()
+			}
+			if t.Kind() != reflect.Struct {
+				return ""
+			}
+		}
+	}
+	best, bestDist := "", len(name)/3+1
+	for i := range t.NumField() {
+		tag := tomlTag(t.Field(i))
+		if tag == "" {
+			continue
+		}
+		if d := editDistance(name, tag); d <= bestDist && (best == "" || d < bestDist) {
+			best, bestDist = tag, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	if table != "" {
+		return table + "." + best
+	}
+	return best
+}
+
+// tomlField returns the field of struct t decoded from key.
+func tomlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		if tomlTag(t.Field(i)) == key {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// tomlTag returns the key a field is decoded from, "" for none.
+func tomlTag(f reflect.StructField) string {
+	tag, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	if tag == "-" || !f.IsExported() {
+		return ""
+	}
+	return tag
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
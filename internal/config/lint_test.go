@@ -0,0 +1,38 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadConfig_UnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	data := "[mods]\nmodrinth_source = []\n[backupz]\nmax_backups = 3\n[backupz.nested]\nx = 1\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if want := []string{"backupz", "mods.modrinth_source"}; !slices.Equal(cfg.Unknown, want) {
+		t.Errorf("Unknown = %v, want %v", cfg.Unknown, want)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	for key, want := range map[string]string{
+		"mods.modrinth_source":   "mods.modrinth_sources",
+		"backup.max_backup":      "backup.max_backups",
+		"notifcations":           "notifications",
+		"api.webhooks.secert":    "api.webhooks.secret",
+		"mods.something_else":    "",
+		"nosuchtable.max_backup": "",
+	} {
+		if got := Suggest(key); got != want {
+			t.Errorf("Suggest(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
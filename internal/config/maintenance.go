@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Maintenance window actions for work requested outside the window.
+const (
+	MaintenanceRefuse = "refuse"
+	MaintenanceDefer  = "defer"
+)
+
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// MaintenanceConfig restricts disruptive operations run with
+// --respect-window, and every action `serve` runs, to the given weekdays and "HH:MM-HH:MM" hours (which may
+// wrap past midnight). Empty weekdays or hours mean no restriction on that
+// axis. Timezone is an IANA name; empty uses the host's local time.
+type MaintenanceConfig struct {
+	Weekdays []string `toml:"weekdays"`
+	Hours    string   `toml:"hours"`
+	Timezone string   `toml:"timezone"`
+	Action   string   `toml:"action"`
+}
+
+func (m MaintenanceConfig) validate() error {
+	for _, d := range m.Weekdays {
+		if !slices.Contains(weekdayNames, strings.ToLower(d)) {
+			return fmt.Errorf("invalid maintenance weekday: %s. Must be one of %v", d, weekdayNames)
+		}
+	}
+	if _, _, err := m.hourRange(); err != nil {
+		return err
+	}
+	if _, err := m.location(); err != nil {
+		return fmt.Errorf("invalid maintenance timezone: %w", err)
+	}
+	switch m.Action {
+	case "", MaintenanceRefuse, MaintenanceDefer:
+		return nil
+	}
+	return fmt.Errorf("invalid maintenance action: %s. Must be one of [refuse defer]", m.Action)
+}
+
+// hourRange returns the window as minutes since midnight.
+func (m MaintenanceConfig) hourRange() (start, end int, err error) {
+	if m.Hours == "" {
+		return 0, 24 * 60, nil
+	}
+	from, to, ok := strings.Cut(m.Hours, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid maintenance hours: %s. Use HH:MM-HH:MM", m.Hours)
+	}
+	parse := func(s string) (int, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("invalid maintenance hours: %s. Use HH:MM-HH:MM", m.Hours)
+		}
+		return t.Hour()*60 + t.Minute(), nil
+	}
+	if start, err = parse(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parse(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func (m MaintenanceConfig) location() (*time.Location, error) {
+	if m.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(m.Timezone)
+}
+
+// Allows reports whether t falls inside the maintenance window.
+func (m MaintenanceConfig) Allows(t time.Time) bool {
+	start, end, err := m.hourRange()
+	if err != nil {
+		return false
+	}
+	if loc, err := m.location(); err == nil {
+		t = t.In(loc)
+	}
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	var inHours bool
+	switch {
+	case start < end:
+		inHours = minute >= start && minute < end
+	case start > end: // wraps midnight; the early part belongs to the previous day's window
+		inHours = minute >= start || minute < end
+		if minute < end {
+			day = (day + 6) % 7
+		}
+	default:
+		inHours = true
+	}
+	if !inHours {
+		return false
+	}
+	if len(m.Weekdays) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(m.Weekdays, func(d string) bool { return strings.EqualFold(d, weekdayNames[day]) })
+}
+
+// NextOpen returns the first minute at or after t inside the window, or the
+// zero time if none occurs within a week.
+func (m MaintenanceConfig) NextOpen(t time.Time) time.Time {
+	t = t.Truncate(time.Minute)
+	for i := 0; i <= 7*24*60; i++ {
+		if m.Allows(t) {
+			return t
+		}
+		t = t.Add(time.Minute)
+	}
+	return time.Time{}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+)
+
+// SchemaVersion is the layout of the config files this build writes,
+// recorded in them as schema_version. Files without one predate it (0).
+const SchemaVersion = 1
+
+// migrations upgrade a decoded config file one schema version at a time:
+// migrations[i] turns version i into i+1 and returns a note per change.
+var migrations = []func(raw map[string]any) []string{
+	// 0 → 1: notifications also carry disk space and approval warnings, so
+	// the restart warning says what it is.
+	func(raw map[string]any) []string {
+		return renameKey(raw, "notifications", "warning_message", "restart_warning")
+	},
+}
+
+// MigrateFile upgrades the TOML config in data to SchemaVersion. It returns
+// the file re-encoded with schema_version set and a note per key changed,
+// or data unchanged when it is already current. Comments do not survive a
+// migration.
+func MigrateFile(data []byte) ([]byte, []string, error) {
+	raw := map[string]any{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return nil, nil, err
+	}
+	version, err := schemaVersion(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	if version == SchemaVersion {
+		return data, nil, nil
+	}
+	var notes []string
+	for v := version; v < SchemaVersion; v++ {
+		notes = append(notes, migrations[v](raw)...)
+	}
+	raw["schema_version"] = SchemaVersion
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(raw); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), notes, nil
+}
+
+// schemaVersion reads schema_version from a decoded config file, refusing
+// files written for a newer craftops.
+func schemaVersion(raw map[string]any) (int, error) {
+	v, ok := raw["schema_version"]
+	if !ok {
+		return 0, nil
+	}
+	n, ok := v.(int64)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("invalid schema_version: %v. Must be a whole number", v)
+	}
+	if n > SchemaVersion {
+		return 0, fmt.Errorf("config schema_version %d is newer than this craftops supports (%d); upgrade craftops", n, SchemaVersion)
+	}
+	return int(n), nil
+}
+
+// renameKey moves table.from to table.to. A value already at table.to wins
+// and table.from is dropped.
+func renameKey(raw map[string]any, table, from, to string) []string {
+	t, ok := raw[table].(map[string]any)
+	if !ok {
+		return nil
+	}
+	v, ok := t[from]
+	if !ok {
+		return nil
+	}
+	delete(t, from)
+	if _, ok := t[to]; ok {
+		return []string{fmt.Sprintf("%s.%s dropped: %s.%s is set", table, from, table, to)}
+	}
+	t[to] = v
+	return []string{fmt.Sprintf("%s.%s renamed to %s.%s", table, from, table, to)}
+}
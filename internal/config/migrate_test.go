@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig_MigratesLegacyKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	legacy := "[notifications]\nwarning_message = \"Restarting in {minutes}m\"\n"
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Notifications.RestartWarning != "Restarting in {minutes}m" {
+		t.Errorf("restart_warning = %q, want the legacy warning_message", cfg.Notifications.RestartWarning)
+	}
+	if cfg.SchemaVersion != SchemaVersion || len(cfg.Migrated) != 1 {
+		t.Errorf("schema version %d, migrated %v", cfg.SchemaVersion, cfg.Migrated)
+	}
+}
+
+func TestMigrateFile(t *testing.T) {
+	out, notes, err := MigrateFile([]byte("[notifications]\nwarning_message = \"old\"\nrestart_warning = \"new\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 || !strings.Contains(notes[0], "dropped") {
+		t.Errorf("notes = %v, want the legacy key dropped", notes)
+	}
+	if strings.Contains(string(out), "warning_message") || !strings.Contains(string(out), "schema_version = 1") {
+		t.Errorf("migrated file:\n%s", out)
+	}
+
+	current := []byte("schema_version = 1\n[notifications]\nrestart_warning = \"new\"\n")
+	if out, notes, err := MigrateFile(current); err != nil || string(out) != string(current) || notes != nil {
+		t.Errorf("current file changed: %q %v %v", out, notes, err)
+	}
+	if _, _, err := MigrateFile([]byte("schema_version = 99\n")); err == nil {
+		t.Error("newer schema_version accepted")
+	}
+}
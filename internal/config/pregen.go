@@ -0,0 +1,35 @@
+package config
+
+import "fmt"
+
+// PregenConfig schedules the batches of a `craftops world pregen` job, which
+// `craftops serve` drives through the Chunky mod's console commands. Batches
+// of BatchMinutes, each RestMinutes after the previous one ended its time,
+// run only inside the Weekdays and "HH:MM-HH:MM" Hours
+// (which may wrap past midnight; empty means any time, as for
+// [maintenance]) and, unless MaxPlayers is negative, while at most
+// MaxPlayers players are online. Timezone is an IANA name; empty uses the
+// host's local time.
+type PregenConfig struct {
+	Weekdays     []string `toml:"weekdays"`
+	Hours        string   `toml:"hours"`
+	Timezone     string   `toml:"timezone"`
+	BatchMinutes int      `toml:"batch_minutes"`
+	RestMinutes  int      `toml:"rest_minutes"`
+	MaxPlayers   int      `toml:"max_players"`
+}
+
+// Window is the off-peak window batches run in.
+func (p PregenConfig) Window() MaintenanceConfig {
+	return MaintenanceConfig{Weekdays: p.Weekdays, Hours: p.Hours, Timezone: p.Timezone}
+}
+
+func (p PregenConfig) validate() error {
+	if err := p.Window().validate(); err != nil {
+		return fmt.Errorf("invalid pregen window: %w", err)
+	}
+	if p.BatchMinutes < 1 || p.RestMinutes < 0 {
+		return fmt.Errorf("invalid pregen batch_minutes/rest_minutes: %d/%d. Must be at least 1/0", p.BatchMinutes, p.RestMinutes)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Proxy types for [proxy].
+const (
+	ProxyVelocity   = "velocity"
+	ProxyBungeeCord = "bungeecord"
+)
+
+// ProxyConfig connects craftops to the Velocity or BungeeCord proxy in front
+// of this backend through an RCON plugin on the proxy (e.g. VelocityRcon,
+// BungeeRcon). ServerName is this backend's name in the proxy's server list;
+// Fallback is where players wait during a restart. BroadcastCommand and
+// SendCommand override the per-type defaults and may use {message},
+// {server} and {fallback}.
+type ProxyConfig struct {
+	Enabled          bool   `toml:"enabled"`
+	Type             string `toml:"type"`
+	Address          string `toml:"address"`
+	Password         string `toml:"password"`
+	ServerName       string `toml:"server_name"`
+	Fallback         string `toml:"fallback"`
+	BroadcastCommand string `toml:"broadcast_command"`
+	SendCommand      string `toml:"send_command"`
+}
+
+func (p *ProxyConfig) validate() error {
+	p.Type = strings.ToLower(p.Type)
+	switch p.Type {
+	case ProxyVelocity, ProxyBungeeCord:
+	default:
+		return fmt.Errorf("invalid proxy type: %s. Must be one of [velocity bungeecord]", p.Type)
+	}
+	if p.Enabled && (p.Address == "" || p.Password == "") {
+		return errors.New("proxy requires proxy.address and proxy.password when enabled")
+	}
+	if p.Enabled && p.Fallback != "" && p.ServerName == "" {
+		return errors.New("proxy.fallback requires proxy.server_name")
+	}
+	return nil
+}
+
+// Broadcast returns the command that shows message to every player on the
+// network.
+func (p ProxyConfig) Broadcast(message string) string {
+	tmpl := p.BroadcastCommand
+	if tmpl == "" {
+		tmpl = "broadcast {message}"
+		if p.Type == ProxyBungeeCord {
+			tmpl = "alert {message}"
+		}
+	}
+	return p.expand(tmpl, message)
+}
+
+// Send returns the command that moves every player on this backend to the
+// fallback server.
+func (p ProxyConfig) Send() string {
+	tmpl := p.SendCommand
+	if tmpl == "" {
+		tmpl = "send {server} {fallback}"
+	}
+	return p.expand(tmpl, "")
+}
+
+func (p ProxyConfig) expand(tmpl, message string) string {
+	return strings.NewReplacer("{message}", message, "{server}", p.ServerName, "{fallback}", p.Fallback).Replace(tmpl)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// systemd scope kinds for ResourcesConfig.Scope.
+const (
+	ScopeSystem = "system"
+	ScopeUser   = "user"
+)
+
+var (
+	cpuListPattern   = regexp.MustCompile(`^\d+(-\d+)?(,\d+(-\d+)?)*$`)
+	memoryMaxPattern = regexp.MustCompile(`^(\d+[KMGT]?|infinity)$`)
+	cpuQuotaPattern  = regexp.MustCompile(`^\d+%$`)
+)
+
+// ResourcesConfig constrains the java process started by `server start`.
+// CPUAffinity is a taskset CPU list such as "0-3" or "0,2,4"; Nice is a
+// niceness from -20 to 19 (0 leaves it unchanged; below 0 needs root).
+// Scope runs the server in a transient systemd scope, of the system or the
+// user manager, so MemoryMax (e.g. "8G") and CPUQuota (e.g. "200%") can be
+// applied through its cgroup, optionally inside Slice.
+type ResourcesConfig struct {
+	CPUAffinity string `toml:"cpu_affinity"`
+	Nice        int    `toml:"nice"`
+	Scope       string `toml:"scope"` // "" | system | user
+	Slice       string `toml:"slice"`
+	MemoryMax   string `toml:"memory_max"`
+	CPUQuota    string `toml:"cpu_quota"`
+}
+
+func (r ResourcesConfig) validate() error {
+	if r.CPUAffinity != "" && !cpuListPattern.MatchString(r.CPUAffinity) {
+		return fmt.Errorf("invalid server cpu_affinity: %s. Use a CPU list such as 0-3 or 0,2,4", r.CPUAffinity)
+	}
+	if r.Nice < -20 || r.Nice > 19 {
+		return fmt.Errorf("invalid server nice: %d. Must be between -20 and 19", r.Nice)
+	}
+	switch r.Scope {
+	case "", ScopeSystem, ScopeUser:
+	default:
+		return fmt.Errorf("invalid server scope: %s. Must be one of [system user]", r.Scope)
+	}
+	if r.Scope == "" && (r.Slice != "" || r.MemoryMax != "" || r.CPUQuota != "") {
+		return errors.New("server slice, memory_max and cpu_quota need scope set to system or user")
+	}
+	if r.MemoryMax != "" && !memoryMaxPattern.MatchString(r.MemoryMax) {
+		return fmt.Errorf("invalid server memory_max: %s. Use a size such as 8G or infinity", r.MemoryMax)
+	}
+	if r.CPUQuota != "" && !cpuQuotaPattern.MatchString(r.CPUQuota) {
+		return fmt.Errorf("invalid server cpu_quota: %s. Use a percentage such as 200%%", r.CPUQuota)
+	}
+	return nil
+}
+
+// LaunchPrefix returns the command, if any, that the java command line is
+// appended to: systemd-run for the scope, then nice, then taskset. Each
+// execs the next, so the java process keeps the PID screen started. unit
+// names the scope.
+func (r ResourcesConfig) LaunchPrefix(unit string) []string {
+	var prefix []string
+	if r.Scope != "" {
+		prefix = append(prefix, "systemd-run", "--scope", "--collect", "--quiet", "--unit", unit)
+		if r.Scope == ScopeUser {
+			prefix = append(prefix, "--user")
+		}
+		if r.Slice != "" {
+			prefix = append(prefix, "--slice", r.Slice)
+		}
+		if r.MemoryMax != "" {
+			prefix = append(prefix, "-p", "MemoryMax="+r.MemoryMax)
+		}
+		if r.CPUQuota != "" {
+			prefix = append(prefix, "-p", "CPUQuota="+r.CPUQuota)
+		}
+		prefix = append(prefix, "--")
+	}
+	if r.Nice != 0 {
+		prefix = append(prefix, "nice", "-n", strconv.Itoa(r.Nice))
+	}
+	if r.CPUAffinity != "" {
+		prefix = append(prefix, "taskset", "-c", r.CPUAffinity)
+	}
+	return prefix
+}
+
+// LaunchTools lists the executables LaunchPrefix needs.
+func (r ResourcesConfig) LaunchTools() []string {
+	var tools []string
+	if r.Scope != "" {
+		tools = append(tools, "systemd-run")
+	}
+	if r.Nice != 0 {
+		tools = append(tools, "nice")
+	}
+	if r.CPUAffinity != "" {
+		tools = append(tools, "taskset")
+	}
+	return tools
+}
@@ -0,0 +1,17 @@
+package config
+
+// TemplatesConfig provisions server files from Go templates. Every file
+// ending in .tmpl under Dir is rendered with text/template and written, minus
+// the suffix, to the same relative path in paths.server, so
+// server.properties.tmpl becomes server.properties and
+// config/paper-global.yml.tmpl becomes config/paper-global.yml. Templates see
+// Values as .Values and the loaded config as .Config; a key missing from
+// Values is an error rather than an empty string. Rendering runs on
+// `craftops server init` and, with ApplyOnStart, before every start; files
+// whose content would not change are left untouched. An empty Dir disables
+// templates.
+type TemplatesConfig struct {
+	Dir          string         `toml:"dir"`
+	ApplyOnStart bool           `toml:"apply_on_start"`
+	Values       map[string]any `toml:"values"`
+}
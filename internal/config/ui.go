@@ -0,0 +1,28 @@
+package config
+
+import (
+	"fmt"
+
+	"craftops/internal/i18n"
+)
+
+// UIConfig selects the language of notifications and of the output of
+// server start/stop/restart/status, mods update, backup create and health;
+// other commands print English.
+// Language is a code such as "de" or "pt_BR"; empty follows LANG (and
+// LC_ALL, LC_MESSAGES). MessagesDir holds <language>.toml files layered
+// over the built-in catalogs, to add a translation or reword a message.
+// DesktopNotifications pops up a native notification (notify-send or
+// osascript) when a long backup or mod update run from a terminal ends.
+type UIConfig struct {
+	Language             string `toml:"language"`
+	MessagesDir          string `toml:"messages_dir"`
+	DesktopNotifications bool   `toml:"desktop_notifications"`
+}
+
+func (u UIConfig) validate() error {
+	if u.Language != "" && !i18n.ValidLanguage(u.Language) {
+		return fmt.Errorf("invalid ui language: %s. Must be a code such as en, de or pt_BR", u.Language)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import "fmt"
+
+// Watchdog liveness probes for WatchdogConfig.Probe.
+const (
+	ProbePing = "ping"
+	ProbeLog  = "log"
+)
+
+// WatchdogConfig makes `serve` check a running server for hangs every
+// Interval seconds. The ping probe sends a server list ping and fails when
+// no answer arrives within PingTimeout seconds; the log probe fails when
+// logs/latest.log has not been written for LogStaleMinutes, which only suits
+// servers that log regularly. After Failures consecutive failed probes the
+// server is declared hung: a thread dump is saved and, with Restart set, it
+// is force-restarted. A server that is not running is a crash, not a hang,
+// and is left alone, as is one younger than server.startup_timeout
+// plus server.ready_timeout.
+type WatchdogConfig struct {
+	Enabled         bool   `toml:"enabled"`
+	Probe           string `toml:"probe"`
+	Interval        int    `toml:"interval"`
+	PingTimeout     int    `toml:"ping_timeout"`
+	LogStaleMinutes int    `toml:"log_stale_minutes"`
+	Failures        int    `toml:"failures"`
+	Restart         bool   `toml:"restart"`
+}
+
+func (w WatchdogConfig) validate() error {
+	switch w.Probe {
+	case ProbePing, ProbeLog:
+	default:
+		return fmt.Errorf("invalid watchdog probe: %s. Must be one of [ping log]", w.Probe)
+	}
+	if !w.Enabled {
+		return nil
+	}
+	if w.Interval < 1 || w.PingTimeout < 1 || w.LogStaleMinutes < 1 || w.Failures < 1 {
+		return fmt.Errorf("invalid watchdog interval/ping_timeout/log_stale_minutes/failures: %d/%d/%d/%d. All must be at least 1",
+			w.Interval, w.PingTimeout, w.LogStaleMinutes, w.Failures)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+)
+
+// WhitelistConfig is the allowlist `craftops whitelist sync` mirrors into
+// whitelist.json. Source is fetched over HTTP(S): a plain list with one
+// player name per line, a CSV file, a GitHub Gist (its page URL is fetched
+// raw) or a Google Sheet (its edit URL is fetched as CSV export). Column
+// names the CSV header holding the player names; empty uses the first
+// column. Headers are sent with the request, e.g. an Authorization header
+// for a private Gist.
+type WhitelistConfig struct {
+	Source  string            `toml:"source"`
+	Column  string            `toml:"column"`
+	Headers map[string]string `toml:"headers"`
+}
+
+func (w WhitelistConfig) validate() error {
+	if w.Source == "" {
+		return nil
+	}
+	u, err := url.Parse(w.Source)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("invalid whitelist source: %s. Must be an http:// or https:// URL", w.Source)
+	}
+	return nil
+}
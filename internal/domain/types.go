@@ -2,9 +2,14 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -13,9 +18,10 @@ type HealthStatus string
 
 // Health status values.
 const (
-	StatusOK    HealthStatus = "OK"
-	StatusWarn  HealthStatus = "WARN"
-	StatusError HealthStatus = "ERROR"
+	StatusOK      HealthStatus = "OK"
+	StatusWarn    HealthStatus = "WARN"
+	StatusError   HealthStatus = "ERROR"
+	StatusSkipped HealthStatus = "SKIPPED"
 )
 
 // HealthCheck is the result of a single diagnostic check.
@@ -26,19 +32,52 @@ type HealthCheck struct {
 }
 
 // ServerStatus describes whether the Minecraft server process is active.
+// Process fields are zero when the server is stopped or the java process
+// could not be resolved. Unmanaged marks a java process serving the server
+// directory without its screen session; Adopted means craftops may stop it.
 type ServerStatus struct {
-	IsRunning   bool      `json:"is_running"`
-	SessionName string    `json:"session_name,omitempty"`
-	CheckedAt   time.Time `json:"checked_at"`
+	IsRunning   bool          `json:"is_running"`
+	Unmanaged   bool          `json:"unmanaged,omitempty"`
+	Adopted     bool          `json:"adopted,omitempty"`
+	SessionName string        `json:"session_name,omitempty"`
+	CheckedAt   time.Time     `json:"checked_at"`
+	PID         int           `json:"pid,omitempty"`
+	CPUPercent  float64       `json:"cpu_percent,omitempty"`
+	MemoryRSS   int64         `json:"rss_bytes,omitempty"`
+	Uptime      time.Duration `json:"uptime_ns,omitempty"`
+	Port        int           `json:"port,omitempty"`
+	PortOpen    bool          `json:"port_open,omitempty"`
+	// LastSuccess is State.LastSuccess: when each operation last succeeded.
+	LastSuccess map[string]time.Time `json:"last_success,omitempty"`
+}
+
+// PerfSample is a tick-health reading. MSPT is milliseconds per tick (median
+// for spark, mean otherwise); TPS values are zero when a source lacks them.
+type PerfSample struct {
+	TPS1m     float64   `json:"tps_1m"`
+	TPS5m     float64   `json:"tps_5m,omitempty"`
+	TPS15m    float64   `json:"tps_15m,omitempty"`
+	MSPT      float64   `json:"mspt,omitempty"`
+	Source    string    `json:"source"`
+	SampledAt time.Time `json:"sampled_at"`
 }
 
 // ModInfo holds metadata for a mod version from Modrinth.
 type ModInfo struct {
-	VersionID   string `json:"version_id"`
-	Version     string `json:"version_number"`
-	DownloadURL string `json:"download_url"`
-	Filename    string `json:"filename"`
-	ProjectName string `json:"project_name"`
+	VersionID   string   `json:"version_id"`
+	Version     string   `json:"version_number"`
+	DownloadURL string   `json:"download_url"`
+	MirrorURLs  []string `json:"mirror_urls,omitempty"`
+	Filename    string   `json:"filename"`
+	SHA1        string   `json:"sha1,omitempty"`
+	Size        int64    `json:"size,omitempty"`
+	ProjectName string   `json:"project_name"`
+	Changelog   string   `json:"changelog,omitempty"`
+}
+
+// CandidateURLs returns the primary download URL followed by any mirrors.
+func (m *ModInfo) CandidateURLs() []string {
+	return append([]string{m.DownloadURL}, m.MirrorURLs...)
 }
 
 // ModUpdateResult aggregates outcomes of a bulk mod update.
@@ -46,6 +85,546 @@ type ModUpdateResult struct {
 	UpdatedMods []string          `json:"updated_mods"`
 	FailedMods  map[string]string `json:"failed_mods"`
 	SkippedMods []string          `json:"skipped_mods"`
+	Changes     []ModChange       `json:"changes,omitempty"`
+	// Incompatible lists mods with no build for the configured loader and
+	// game version; ForeignJars are unmanaged jars built for another loader.
+	Incompatible []string `json:"incompatible,omitempty"`
+	// Blocked lists mods not installed because an advisory list flags them
+	// (mods.block_flagged).
+	Blocked     []string `json:"blocked,omitempty"`
+	ForeignJars []string `json:"foreign_jars,omitempty"`
+	// Quarantined lists undeclared jars moved aside by mods.strict.
+	Quarantined []string `json:"quarantined,omitempty"`
+	// Mods has one entry per mod with its versions, size, timing and
+	// failure; the name lists above are summaries of it.
+	Mods []ModDetail `json:"mods,omitempty"`
+	// Diff is how the jars in the mods directory changed; nil on a dry run.
+	Diff *ModsDiff `json:"diff,omitempty"`
+	// Advisories warn about configured mods and installed jars.
+	Advisories []ModAdvisory `json:"advisories,omitempty"`
+}
+
+// Advisory kinds: the Modrinth project status, or a match in an advisory
+// list.
+const (
+	AdvisoryArchived = "archived"
+	AdvisoryUnlisted = "unlisted"
+	AdvisoryWithheld = "withheld"
+	AdvisoryFlagged  = "flagged"
+)
+
+// ModAdvisory warns about a mod: its Modrinth project is archived,
+// unlisted or withheld, or an advisory list (mods.advisory_lists) flags the
+// project or, for File, an installed jar's hash.
+type ModAdvisory struct {
+	Mod    string `json:"mod"`
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+	File   string `json:"file,omitempty"`
+}
+
+// Record adds d to the result and to the summary list for its outcome.
+func (r *ModUpdateResult) Record(d ModDetail) {
+	r.Mods = append(r.Mods, d)
+	switch d.Outcome {
+	case ModFailed:
+		r.FailedMods[d.Name] = d.Error
+		switch d.ErrorKind {
+		case ModErrIncompatible:
+			r.Incompatible = append(r.Incompatible, d.Name)
+		case ModErrFlagged:
+			r.Blocked = append(r.Blocked, d.Name)
+		}
+	case ModUpdated:
+		r.UpdatedMods = append(r.UpdatedMods, d.Name)
+		r.Changes = append(r.Changes, ModChange{Name: d.Name, From: d.From, To: d.To})
+	default:
+		r.SkippedMods = append(r.SkippedMods, d.Name)
+	}
+}
+
+// Details returns Mods sorted by name. Results saved before per-mod details
+// existed are rebuilt from Changes and FailedMods, without sizes or timings.
+func (r *ModUpdateResult) Details() []ModDetail {
+	details := slices.Clone(r.Mods)
+	if len(details) == 0 {
+		for _, c := range r.Changes {
+			details = append(details, ModDetail{Name: c.Name, Outcome: ModUpdated, From: c.From, To: c.To})
+		}
+		for name, msg := range r.FailedMods {
+			details = append(details, ModDetail{Name: name, Outcome: ModFailed, Error: msg, ErrorKind: ModErrOther})
+		}
+	}
+	slices.SortFunc(details, func(a, b ModDetail) int { return strings.Compare(a.Name, b.Name) })
+	return details
+}
+
+// ModOutcome is what an update did to one mod.
+type ModOutcome string
+
+// Mod outcomes.
+const (
+	ModUpdated ModOutcome = "updated"
+	ModFailed  ModOutcome = "failed"
+	ModSkipped ModOutcome = "skipped"
+)
+
+// ModErrorKind classifies why a mod failed to update.
+type ModErrorKind string
+
+// Mod error kinds.
+const (
+	ModErrIncompatible ModErrorKind = "incompatible"
+	ModErrNotFound     ModErrorKind = "not_found"
+	ModErrRateLimited  ModErrorKind = "rate_limited"
+	ModErrAPI          ModErrorKind = "api"
+	ModErrChecksum     ModErrorKind = "checksum"
+	ModErrOffline      ModErrorKind = "offline"
+	ModErrStalled      ModErrorKind = "stalled"
+	ModErrDiskFull     ModErrorKind = "disk_full"
+	ModErrNotApplied   ModErrorKind = "not_applied"
+	ModErrCancelled    ModErrorKind = "cancelled"
+	ModErrFlagged      ModErrorKind = "flagged"
+	ModErrOther        ModErrorKind = "other"
+)
+
+// ModDetail is one mod's result in an update. From and To are the versions
+// before and after; Bytes is the size of the jar installed (0 when nothing
+// was written) and Duration the time spent on the mod.
+type ModDetail struct {
+	Name      string        `json:"name"`
+	Outcome   ModOutcome    `json:"outcome"`
+	From      string        `json:"from,omitempty"`
+	To        string        `json:"to,omitempty"`
+	Bytes     int64         `json:"bytes,omitempty"`
+	Duration  time.Duration `json:"duration_ns"`
+	Error     string        `json:"error,omitempty"`
+	ErrorKind ModErrorKind  `json:"error_kind,omitempty"`
+	Changelog string        `json:"changelog,omitempty"`
+}
+
+// Versions describes the version change as "1.0 → 2.0", or "→ 2.0" for a
+// first install or a reinstall of the same version.
+func (d ModDetail) Versions() string {
+	if d.From == "" || d.From == d.To {
+		return "→ " + d.To
+	}
+	return d.From + " → " + d.To
+}
+
+// ClassifyModError returns the kind of a mod update error.
+func ClassifyModError(err error) ModErrorKind {
+	var apiErr *APIError
+	switch {
+	case errors.Is(err, ErrUpdateNotApplied):
+		return ModErrNotApplied
+	case errors.Is(err, ErrNoCompatibleBuild):
+		return ModErrIncompatible
+	case errors.Is(err, ErrHashMismatch):
+		return ModErrChecksum
+	case errors.Is(err, ErrOffline):
+		return ModErrOffline
+	case errors.Is(err, ErrDownloadStalled):
+		return ModErrStalled
+	case errors.Is(err, ErrInsufficientSpace):
+		return ModErrDiskFull
+	case errors.Is(err, ErrModFlagged):
+		return ModErrFlagged
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ModErrCancelled
+	case errors.As(err, &apiErr) && apiErr.StatusCode == 404:
+		return ModErrNotFound
+	case errors.As(err, &apiErr) && apiErr.StatusCode == 429:
+		return ModErrRateLimited
+	case errors.As(err, &apiErr):
+		return ModErrAPI
+	}
+	return ModErrOther
+}
+
+// ModChange is a mod whose installed version changed. From is empty for a
+// first install.
+type ModChange struct {
+	Name string `json:"name"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+}
+
+// JarFile is a jar in the mods directory.
+type JarFile struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// JarUpdate is a jar replaced by another for the same mod. Mod is the
+// lockfile source, or the file name for a jar rewritten in place.
+type JarUpdate struct {
+	Mod  string  `json:"mod"`
+	From JarFile `json:"from"`
+	To   JarFile `json:"to"`
+}
+
+// ModsDiff is the mods directory before and after an update, and the jars
+// the update added, removed and replaced.
+type ModsDiff struct {
+	Before  []JarFile   `json:"before"`
+	After   []JarFile   `json:"after"`
+	Added   []JarFile   `json:"added,omitempty"`
+	Removed []JarFile   `json:"removed,omitempty"`
+	Updated []JarUpdate `json:"updated,omitempty"`
+}
+
+// Empty reports whether no jar changed.
+func (d *ModsDiff) Empty() bool {
+	return d == nil || len(d.Added)+len(d.Removed)+len(d.Updated) == 0
+}
+
+// Lines describes each change on a line: "+ added.jar (1.0 MB)",
+// "- removed.jar (1.0 MB)" or "~ old.jar → new.jar (1.0 MB → 1.1 MB)".
+func (d *ModsDiff) Lines() []string {
+	var lines []string
+	for _, u := range d.Updated {
+		jar := u.From.Name
+		if u.To.Name != u.From.Name {
+			jar += " → " + u.To.Name
+		}
+		lines = append(lines, fmt.Sprintf("~ %s (%s → %s)", jar, FormatSize(u.From.Size), FormatSize(u.To.Size)))
+	}
+	for _, j := range d.Added {
+		lines = append(lines, fmt.Sprintf("+ %s (%s)", j.Name, FormatSize(j.Size)))
+	}
+	for _, j := range d.Removed {
+		lines = append(lines, fmt.Sprintf("- %s (%s)", j.Name, FormatSize(j.Size)))
+	}
+	return lines
+}
+
+// DiffJars compares two listings of the mods directory. A jar that left
+// and one that arrived count as an update when the lockfile moved a source
+// from the first to the second (lockBefore and lockAfter); so does a jar
+// whose size or modification time changed under the same name.
+func DiffJars(before, after []JarFile, lockBefore, lockAfter map[string]LockedMod) *ModsDiff {
+	d := &ModsDiff{Before: before, After: after}
+	old := make(map[string]JarFile, len(before))
+	for _, j := range before {
+		old[j.Name] = j
+	}
+	added := map[string]JarFile{}
+	for _, j := range after {
+		prev, ok := old[j.Name]
+		delete(old, j.Name)
+		switch {
+		case !ok:
+			added[j.Name] = j
+		case prev.Size != j.Size || !prev.Modified.Equal(j.Modified):
+			d.Updated = append(d.Updated, JarUpdate{Mod: j.Name, From: prev, To: j})
+		}
+	}
+	for _, src := range slices.Sorted(maps.Keys(lockAfter)) {
+		from, to := old[lockBefore[src].Filename], added[lockAfter[src].Filename]
+		if from.Name == "" || to.Name == "" {
+			continue
+		}
+		d.Updated = append(d.Updated, JarUpdate{Mod: src, From: from, To: to})
+		delete(old, from.Name)
+		delete(added, to.Name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(added)) {
+		d.Added = append(d.Added, added[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(old)) {
+		d.Removed = append(d.Removed, old[name])
+	}
+	return d
+}
+
+// IntegrityStatus classifies a file found by `mods verify`.
+type IntegrityStatus string
+
+// Integrity statuses.
+const (
+	IntegrityOK        IntegrityStatus = "ok"
+	IntegrityMissing   IntegrityStatus = "missing"
+	IntegrityModified  IntegrityStatus = "modified"
+	IntegrityCorrupt   IntegrityStatus = "corrupt"
+	IntegrityPartial   IntegrityStatus = "partial"
+	IntegrityUntracked IntegrityStatus = "untracked"
+)
+
+// ModIntegrity is the verification result for one mod file.
+type ModIntegrity struct {
+	Project  string          `json:"project,omitempty"`
+	Filename string          `json:"filename"`
+	Status   IntegrityStatus `json:"status"`
+	Detail   string          `json:"detail,omitempty"`
+}
+
+// ModManifest is a shareable, exact mod set written by `mods export` and
+// installed by `mods import`.
+type ModManifest struct {
+	MinecraftVersion string        `json:"minecraft_version"`
+	Modloader        string        `json:"modloader"`
+	Mods             []ManifestMod `json:"mods"`
+}
+
+// ManifestMod pins one Modrinth project to an exact file.
+type ManifestMod struct {
+	Slug      string `json:"slug"`
+	VersionID string `json:"version_id"`
+	Version   string `json:"version"`
+	Filename  string `json:"filename"`
+	SHA1      string `json:"sha1,omitempty"`
+}
+
+// PackOptions names a client pack and pins its mod loader version.
+type PackOptions struct {
+	Name          string
+	Version       string
+	LoaderVersion string
+}
+
+// ClientPack describes a generated Modrinth .mrpack. Excluded maps a slug or
+// jar filename to the reason it was left out.
+type ClientPack struct {
+	Path     string            `json:"path"`
+	Included []string          `json:"included"`
+	Excluded map[string]string `json:"excluded,omitempty"`
+}
+
+// ModProject is the Modrinth metadata of a mod, for auditing what a server
+// ships: its license, the sides it runs on and where it is developed.
+// Version and Filename come from the lockfile and are empty until the mod
+// is installed; ID is empty for a source Modrinth does not know.
+type ModProject struct {
+	Slug        string            `json:"slug"`
+	ID          string            `json:"id,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	License     string            `json:"license,omitempty"` // SPDX identifier, or LicenseRef-*
+	LicenseURL  string            `json:"license_url,omitempty"`
+	ClientSide  string            `json:"client_side,omitempty"` // required | optional | unsupported | unknown
+	ServerSide  string            `json:"server_side,omitempty"`
+	Status      string            `json:"status,omitempty"`
+	Downloads   int64             `json:"downloads,omitempty"`
+	Links       map[string]string `json:"links,omitempty"` // page, source, issues, wiki, discord
+	Version     string            `json:"version,omitempty"`
+	Filename    string            `json:"filename,omitempty"`
+}
+
+// Notes lists what an admin auditing the mod should look at: mods that do
+// nothing on a server, licenses that restrict redistribution and projects
+// that are no longer maintained or listed.
+func (p ModProject) Notes() []string {
+	if p.ID == "" {
+		return []string{"not found on Modrinth"}
+	}
+	var notes []string
+	if p.ServerSide == "unsupported" {
+		notes = append(notes, "client-only: not needed on the server")
+	}
+	switch {
+	case p.License == "LicenseRef-All-Rights-Reserved":
+		notes = append(notes, "all rights reserved: check before redistributing")
+	case p.License == "" || strings.HasPrefix(p.License, "LicenseRef-"):
+		notes = append(notes, "custom or unknown license")
+	}
+	if p.Status == AdvisoryArchived || p.Status == AdvisoryUnlisted || p.Status == AdvisoryWithheld {
+		notes = append(notes, p.Status)
+	}
+	return notes
+}
+
+// ModSearchHit is a Modrinth project found by `mods search`. Declared is
+// set when it already is a mod source.
+type ModSearchHit struct {
+	Slug        string `json:"slug"`
+	ID          string `json:"project_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Author      string `json:"author"`
+	Downloads   int64  `json:"downloads"`
+	ClientSide  string `json:"client_side"`
+	ServerSide  string `json:"server_side"`
+	License     string `json:"license"`
+	Declared    bool   `json:"declared"`
+}
+
+// CacheGCResult summarizes a `cache gc` run. Profiles are the mods
+// directories whose jars were kept.
+type CacheGCResult struct {
+	Removed  int      `json:"removed"`
+	Kept     int      `json:"kept"`
+	Freed    int64    `json:"freed_bytes"`
+	Profiles []string `json:"profiles"`
+}
+
+// CloneOptions adjusts the copy `clone` makes: Port is the copy's
+// server-port (0 keeps the target's, else the source's plus one) and Name
+// its motd.
+type CloneOptions struct {
+	Name string
+	Port int
+}
+
+// CloneResult summarizes a `clone` run. PreviousDir is where the target's
+// old server directory was moved, empty if it had none.
+type CloneResult struct {
+	ServerDir   string `json:"server_dir"`
+	PreviousDir string `json:"previous_dir,omitempty"`
+	Files       int    `json:"files"`
+	Mods        int    `json:"mods"`
+	Port        int    `json:"port"`
+}
+
+// WhitelistSyncResult summarizes a `whitelist sync` run: the players added
+// to and removed from whitelist.json, source entries that are not valid
+// player names or have no Mojang account, and whether the running server
+// reloaded the list.
+type WhitelistSyncResult struct {
+	Added      []string `json:"added"`
+	Removed    []string `json:"removed"`
+	Unresolved []string `json:"unresolved,omitempty"`
+	Reloaded   bool     `json:"reloaded"`
+}
+
+// BanEntry is one entry of banned-players.json (UUID and Name) or
+// banned-ips.json (IP), in the server's own format.
+type BanEntry struct {
+	UUID    string `json:"uuid,omitempty"`
+	Name    string `json:"name,omitempty"`
+	IP      string `json:"ip,omitempty"`
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
+
+// Target is the banned player's name or the banned IP address.
+func (e BanEntry) Target() string {
+	if e.IP != "" {
+		return e.IP
+	}
+	return e.Name
+}
+
+// BanList is a server's player and IP bans.
+type BanList struct {
+	Players []BanEntry `json:"players"`
+	IPs     []BanEntry `json:"ips"`
+}
+
+// BanListOf is a BanList holding only e.
+func BanListOf(e BanEntry) *BanList {
+	if e.IP != "" {
+		return &BanList{IPs: []BanEntry{e}}
+	}
+	return &BanList{Players: []BanEntry{e}}
+}
+
+// WorldStats is a capacity overview of the server's worlds and players.
+// WorldBytes covers every dimension folder; Playtime sums the play_time
+// statistic of all players.
+type WorldStats struct {
+	Level      string           `json:"level"`
+	WorldBytes int64            `json:"world_bytes"`
+	Dimensions []DimensionStats `json:"dimensions"`
+	Players    int              `json:"players"`
+	Playtime   time.Duration    `json:"playtime_ns"`
+}
+
+// DimensionStats counts one dimension's region files and the chunks stored
+// in them. Bytes is the size of its region, entities and poi folders.
+type DimensionStats struct {
+	Name          string `json:"name"`
+	Path          string `json:"path"`
+	Bytes         int64  `json:"bytes"`
+	Regions       int    `json:"regions"`
+	Chunks        int    `json:"chunks"`
+	EntityRegions int    `json:"entity_regions"`
+	EntityChunks  int    `json:"entity_chunks"`
+}
+
+// GCReport summarizes the stop-the-world pauses in a JVM GC log. Uptime is
+// the JVM uptime at the last logged event.
+type GCReport struct {
+	File    string        `json:"file"`
+	Uptime  time.Duration `json:"uptime"`
+	Pauses  int           `json:"pauses"`
+	Total   time.Duration `json:"total"`
+	Mean    time.Duration `json:"mean"`
+	P95     time.Duration `json:"p95"`
+	Max     time.Duration `json:"max"`
+	Kinds   []GCPauseKind `json:"kinds"`
+	Longest []GCPause     `json:"longest"`
+}
+
+// GCPauseKind totals the pauses of one kind, such as "Pause Young".
+type GCPauseKind struct {
+	Kind  string        `json:"kind"`
+	Count int           `json:"count"`
+	Total time.Duration `json:"total"`
+	Max   time.Duration `json:"max"`
+}
+
+// GCPause is one logged pause. At is its timestamp, or the JVM uptime when
+// the log has no wall-clock time; Heap is the before->after(capacity) sizes.
+type GCPause struct {
+	At       string        `json:"at"`
+	Kind     string        `json:"kind"`
+	Heap     string        `json:"heap,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RegionScan is the result of checking a world's region files. Files and
+// Chunks count what was checked.
+type RegionScan struct {
+	Files  int          `json:"files"`
+	Chunks int          `json:"chunks"`
+	Issues []ChunkIssue `json:"issues"`
+}
+
+// ChunkIssue is a damaged chunk, or a whole region file when Chunk is
+// false. File is relative to the server directory; X and Z are chunk
+// coordinates.
+type ChunkIssue struct {
+	File    string `json:"file"`
+	Chunk   bool   `json:"chunk"`
+	X       int    `json:"x"`
+	Z       int    `json:"z"`
+	Problem string `json:"problem"`
+}
+
+// ModFilter narrows a mod update. Entries match a project slug or an
+// installed filename (case-insensitive, shell globs allowed).
+type ModFilter struct {
+	Only    []string
+	Exclude []string
+}
+
+// IsEmpty reports whether the filter accepts every mod.
+func (f ModFilter) IsEmpty() bool { return len(f.Only) == 0 && len(f.Exclude) == 0 }
+
+// Match reports whether a mod with the given slug and filename is selected.
+func (f ModFilter) Match(slug, filename string) bool {
+	matches := func(patterns []string) bool {
+		for _, p := range patterns {
+			p = strings.ToLower(p)
+			for _, name := range []string{strings.ToLower(slug), strings.ToLower(filename)} {
+				if name == "" {
+					continue
+				}
+				if ok, _ := path.Match(p, name); ok || p == name {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if len(f.Only) > 0 && !matches(f.Only) {
+		return false
+	}
+	return !matches(f.Exclude)
 }
 
 // InstalledMod represents a .jar file in the mods directory.
@@ -56,12 +635,52 @@ type InstalledMod struct {
 	Modified time.Time `json:"modified"`
 }
 
+// BackupOptions adjusts one backup run. Tag fills {tag} in the name
+// template and groups the backup for retention; Protect keeps it from
+// max_backups and disk pressure pruning; Include, when set, replaces backup.include_patterns; a non-zero
+// CompressionLevel overrides backup.compression_level. Progress is called
+// after each file is written.
+type BackupOptions struct {
+	Tag              string
+	Protect          bool
+	Include          []string
+	CompressionLevel int
+	Progress         func(BackupProgress)
+}
+
+// BackupEstimate is what a backup would archive. Ratio is the compressed
+// to uncompressed size of the archive named RatioFrom (0 without one) and
+// Projected is Bytes scaled by it.
+type BackupEstimate struct {
+	Files     int     `json:"files"`
+	Bytes     int64   `json:"bytes"`
+	Ratio     float64 `json:"ratio,omitempty"`
+	RatioFrom string  `json:"ratio_from,omitempty"`
+	Projected int64   `json:"projected_bytes"`
+}
+
+// BackupProgress is a running count of the files written by a backup.
+type BackupProgress struct {
+	Files   int
+	Bytes   int64
+	Current string // slash-separated path of the last file written
+}
+
 // BackupInfo holds metadata for a backup archive.
 type BackupInfo struct {
 	Name      string    `json:"name"`
 	Path      string    `json:"path"`
 	CreatedAt time.Time `json:"created_at"`
 	Size      int64     `json:"size_bytes"`
+	Snapshot  bool      `json:"snapshot,omitempty"`
+}
+
+// BackupEntry is one file or directory inside a backup.
+type BackupEntry struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size_bytes"`
+	Modified time.Time `json:"modified"`
+	IsDir    bool      `json:"is_dir,omitempty"`
 }
 
 // FormatSize returns a human-readable file size (e.g. "4.2 MB").
@@ -81,6 +700,19 @@ func FormatSize(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "kMGTPE"[exp])
 }
 
+// FormatAge returns how long ago something happened (e.g. "3h ago").
+func FormatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+}
+
 // CheckPath verifies if a path exists and is a directory.
 func CheckPath(name, path string) HealthCheck {
 	info, err := os.Stat(path)
@@ -97,6 +729,26 @@ func CheckPath(name, path string) HealthCheck {
 var (
 	ErrServerJarNotFound = errors.New("server JAR file not found")
 	ErrBackupsDisabled   = errors.New("backups are disabled")
+	ErrServerTimeout     = errors.New("server state change timed out")
+	ErrModUpdatesFailed  = errors.New("one or more mods failed to update")
+	ErrOffline           = errors.New("not available offline")
+	ErrPortInUse         = errors.New("server port already in use")
+	ErrServerUnmanaged   = errors.New("server is running outside its screen session")
+	ErrOutsideWindow     = errors.New("outside maintenance window")
+	ErrRestartCancelled  = errors.New("restart cancelled")
+	ErrSyncNotConfigured = errors.New("sync.repo is not configured")
+	ErrAborted           = errors.New("aborted")
+	ErrNoCompatibleBuild = errors.New("no compatible versions found")
+	ErrModFlagged        = errors.New("flagged by an advisory list")
+	ErrHashMismatch      = errors.New("downloaded file does not match the expected sha1")
+	ErrProxyDisabled     = errors.New("proxy is not enabled ([proxy] enabled = true)")
+	ErrDownloadStalled   = errors.New("download stalled")
+	ErrInsufficientSpace = errors.New("not enough disk space")
+	ErrUpdateNotApplied  = errors.New("update not applied")
+	ErrApprovalRejected  = errors.New("operation rejected")
+	ErrApprovalTimeout   = errors.New("operation not approved in time")
+	ErrApprovalNotFound  = errors.New("no pending approval with that id")
+	ErrNotBanned         = errors.New("not banned")
 )
 
 // APIError captures details from a failed HTTP API call.
@@ -104,6 +756,8 @@ type APIError struct {
 	URL        string
 	StatusCode int
 	Message    string
+	// RetryAfter is the server-requested wait before retrying (0 if none).
+	RetryAfter time.Duration
 }
 
 // Error implements the error interface.
@@ -118,3 +772,175 @@ func (e *APIError) Error() string {
 func (e *APIError) IsRetryable() bool {
 	return e.StatusCode >= 500 || e.StatusCode == 429
 }
+
+// StartupError explains a server that did not come up in time.
+type StartupError struct {
+	Err         error
+	Cause       string   // classified cause, empty if unrecognised
+	Hint        string   // suggested fix for Cause
+	LogTail     []string // last lines of logs/latest.log
+	CrashReport string   // path of a crash report written during startup
+}
+
+// Error implements the error interface.
+func (e *StartupError) Error() string {
+	msg := e.Err.Error()
+	if e.Cause != "" {
+		msg += ": " + e.Cause
+		if e.Hint != "" {
+			msg += " (" + e.Hint + ")"
+		}
+	}
+	if e.CrashReport != "" {
+		msg += "; crash report: " + e.CrashReport
+	}
+	return msg
+}
+
+// Unwrap returns the underlying timeout error.
+func (e *StartupError) Unwrap() error { return e.Err }
+
+// Report is the machine-readable record of one operation run.
+type Report struct {
+	Operation  string           `json:"operation"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	DurationMS int64            `json:"duration_ms"`
+	Error      string           `json:"error,omitempty"`
+	Mods       *ModUpdateResult `json:"mods,omitempty"`
+	Backup     *BackupInfo      `json:"backup,omitempty"`
+	Requests   *RequestStats    `json:"requests,omitempty"`
+}
+
+// RequestStats counts outbound HTTP traffic. Failures are transport errors
+// and 4xx/5xx responses; Retries are repeated attempts after a failure.
+type RequestStats struct {
+	Requests        int64 `json:"requests"`
+	Failures        int64 `json:"failures"`
+	Retries         int64 `json:"retries"`
+	BytesDownloaded int64 `json:"bytes_downloaded"`
+}
+
+// Sub returns the traffic counted since an earlier snapshot.
+func (s RequestStats) Sub(earlier RequestStats) RequestStats {
+	return RequestStats{
+		Requests:        s.Requests - earlier.Requests,
+		Failures:        s.Failures - earlier.Failures,
+		Retries:         s.Retries - earlier.Retries,
+		BytesDownloaded: s.BytesDownloaded - earlier.BytesDownloaded,
+	}
+}
+
+// Operation names recorded in State.LastSuccess.
+const (
+	OpModUpdate = "mod_update"
+	OpBackup    = "backup"
+	OpStart     = "start"
+	OpStop      = "stop"
+	OpRestart   = "restart"
+)
+
+// Event types recorded in the event log besides the operations above.
+const (
+	EventCrash = "crash"
+	EventError = "error"
+)
+
+// Event is an event log entry: a successful operation, a crash or hang, or
+// an error that was alerted.
+type Event struct {
+	At      time.Time `json:"at"`
+	Type    string    `json:"type"`
+	Message string    `json:"message,omitempty"`
+}
+
+// BotReply is the result of a deferred Discord slash command. Token, the
+// interaction's, can edit the deferred response only for a while after
+// Received; later replies go to ChannelID as a new message.
+type BotReply struct {
+	Token     string
+	ChannelID string
+	Received  time.Time
+	Title     string
+	Message   string
+	OK        bool
+}
+
+// TagPreModUpdate tags the backup taken before a mod update.
+const TagPreModUpdate = "pre-modupdate"
+
+// State is operational metadata persisted between runs.
+type State struct {
+	Mods        map[string]LockedMod `json:"mods"`
+	Backups     []BackupRecord       `json:"backups"`
+	LastSuccess map[string]time.Time `json:"last_success"`
+	Crashes     []CrashRecord        `json:"crashes"`
+	AdoptedPID  int                  `json:"adopted_pid,omitempty"`
+
+	PendingRestart *PendingRestart `json:"pending_restart,omitempty"`
+	Pregen         *PregenJob      `json:"pregen,omitempty"`
+	ModRollback    *ModRollback    `json:"mod_rollback,omitempty"`
+}
+
+// ModRollback links the mod set saved for `mods rollback` to the backup
+// taken just before it, by the path CreatePreUpdate returned; Backup is
+// empty when none was taken.
+type ModRollback struct {
+	SavedAt time.Time `json:"saved_at"`
+	Backup  string    `json:"backup,omitempty"`
+}
+
+// PregenJob is a `world pregen` job: Chunky generates Dimension out to
+// Radius blocks around (CenterX, CenterZ) in batches. Started is set once
+// the Chunky task exists, so later batches continue it; Running while a
+// batch is under way, with LogOffset the size of latest.log when it began.
+// Chunks and Percent are the last progress Chunky logged.
+type PregenJob struct {
+	Dimension  string     `json:"dimension"`
+	CenterX    int        `json:"center_x"`
+	CenterZ    int        `json:"center_z"`
+	Radius     int        `json:"radius"`
+	Border     bool       `json:"border"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Started    bool       `json:"started"`
+	Running    bool       `json:"running"`
+	BatchStart time.Time  `json:"batch_start"`
+	LogOffset  int64      `json:"log_offset"`
+	Batches    int        `json:"batches"`
+	Chunks     int64      `json:"chunks"`
+	Percent    float64    `json:"percent"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// PendingRestart is a warned restart counting down in process PID.
+type PendingRestart struct {
+	At  time.Time `json:"at"`
+	PID int       `json:"pid"`
+}
+
+// LockedMod is a lockfile entry: the exact file installed for a mod source.
+type LockedMod struct {
+	Project     string    `json:"project"`
+	VersionID   string    `json:"version_id"`
+	Version     string    `json:"version"`
+	Filename    string    `json:"filename"`
+	SHA1        string    `json:"sha1,omitempty"`
+	Loader      string    `json:"loader,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// BackupRecord indexes a backup created by craftops.
+type BackupRecord struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size_bytes"`
+	Tag       string    `json:"tag,omitempty"`
+	Protected bool      `json:"protected,omitempty"`
+}
+
+// CrashRecord notes a failed start or unexpected server exit.
+type CrashRecord struct {
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason"`
+}
@@ -1,10 +1,15 @@
 package domain
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestAPIError_IsRetryable(t *testing.T) {
@@ -87,3 +92,112 @@ func TestFormatSize(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatAge(t *testing.T) {
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{30 * time.Second, "just now"},
+		{90 * time.Minute, "1h ago"},
+		{47 * time.Hour, "47h ago"},
+		{72 * time.Hour, "3d ago"},
+	}
+	for _, tt := range tests {
+		if got := FormatAge(tt.age); got != tt.want {
+			t.Errorf("FormatAge(%s) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyModError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ModErrorKind
+	}{
+		{fmt.Errorf("wrap: %w", ErrNoCompatibleBuild), ModErrIncompatible},
+		{ErrHashMismatch, ModErrChecksum},
+		{&APIError{StatusCode: 404}, ModErrNotFound},
+		{fmt.Errorf("fetch: %w", &APIError{StatusCode: 429}), ModErrRateLimited},
+		{&APIError{StatusCode: 502}, ModErrAPI},
+		{context.DeadlineExceeded, ModErrCancelled},
+		{errors.New("disk full"), ModErrOther},
+	}
+	for _, tt := range tests {
+		if got := ClassifyModError(tt.err); got != tt.want {
+			t.Errorf("ClassifyModError(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestModUpdateResult_RecordAndDetails(t *testing.T) {
+	r := &ModUpdateResult{FailedMods: map[string]string{}}
+	r.Record(ModDetail{Name: "sodium", Outcome: ModUpdated, From: "0.5", To: "0.6", Bytes: 10})
+	r.Record(ModDetail{Name: "iris", Outcome: ModFailed, Error: "boom", ErrorKind: ModErrIncompatible})
+	r.Record(ModDetail{Name: "lithium", Outcome: ModSkipped})
+	if len(r.UpdatedMods) != 1 || r.FailedMods["iris"] != "boom" || len(r.SkippedMods) != 1 ||
+		len(r.Changes) != 1 || len(r.Incompatible) != 1 {
+		t.Errorf("summaries not filled: %+v", r)
+	}
+	if d := r.Details(); len(d) != 3 || d[0].Name != "iris" || d[2].Versions() != "0.5 → 0.6" {
+		t.Errorf("Details() = %+v", d)
+	}
+
+	// Results saved before per-mod details are rebuilt from the summaries.
+	old := &ModUpdateResult{Changes: []ModChange{{Name: "sodium", To: "0.6"}}, FailedMods: map[string]string{"iris": "boom"}}
+	if d := old.Details(); len(d) != 2 || d[1].Versions() != "→ 0.6" || d[0].Outcome != ModFailed {
+		t.Errorf("legacy Details() = %+v", d)
+	}
+}
+
+func TestModFilter_Match(t *testing.T) {
+	tests := []struct {
+		filter         ModFilter
+		slug, filename string
+		want           bool
+	}{
+		{ModFilter{}, "sodium", "", true},
+		{ModFilter{Only: []string{"sodium", "lithium"}}, "lithium", "", true},
+		{ModFilter{Only: []string{"sodium"}}, "lithium", "", false},
+		{ModFilter{Only: []string{"Sodium-*.jar"}}, "AANobbMI", "sodium-0.5.8.jar", true},
+		{ModFilter{Exclude: []string{"sodium"}}, "sodium", "", false},
+		{ModFilter{Only: []string{"sodium", "lithium"}, Exclude: []string{"lithium"}}, "lithium", "", false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(tt.slug, tt.filename); got != tt.want {
+			t.Errorf("%+v.Match(%q, %q) = %v, want %v", tt.filter, tt.slug, tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestDiffJars(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	before := []JarFile{
+		{Name: "sodium-0.5.jar", Size: 1000, Modified: t0},
+		{Name: "lithium.jar", Size: 500, Modified: t0},
+		{Name: "old.jar", Size: 10, Modified: t0},
+		{Name: "same.jar", Size: 7, Modified: t0},
+	}
+	after := []JarFile{
+		{Name: "sodium-0.6.jar", Size: 1200, Modified: t0},
+		{Name: "lithium.jar", Size: 600, Modified: t0.Add(time.Minute)},
+		{Name: "new.jar", Size: 20, Modified: t0},
+		{Name: "same.jar", Size: 7, Modified: t0},
+	}
+	lockBefore := map[string]LockedMod{"sodium": {Filename: "sodium-0.5.jar"}}
+	lockAfter := map[string]LockedMod{"sodium": {Filename: "sodium-0.6.jar"}, "new": {Filename: "new.jar"}}
+
+	d := DiffJars(before, after, lockBefore, lockAfter)
+	want := []string{
+		"~ lithium.jar (500 B → 600 B)",
+		"~ sodium-0.5.jar → sodium-0.6.jar (1.0 kB → 1.2 kB)",
+		"+ new.jar (20 B)",
+		"- old.jar (10 B)",
+	}
+	if got := d.Lines(); !slices.Equal(got, want) {
+		t.Errorf("Lines() =\n%q\nwant\n%q", got, want)
+	}
+	if d.Empty() || !DiffJars(before, before, nil, nil).Empty() {
+		t.Error("Empty() wrong")
+	}
+}
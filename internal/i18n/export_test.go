@@ -0,0 +1,9 @@
+package i18n
+
+import (
+	"maps"
+	"slices"
+)
+
+// Keys lists the messages c defines itself, sorted.
+func (c *Catalog) Keys() []string { return slices.Sorted(maps.Keys(c.messages)) }
@@ -0,0 +1,232 @@
+// Package i18n translates user-facing messages: terminal output and
+// notifications. Catalogs are TOML files of messages with {name}
+// placeholders, keyed by table and name ("backup.created"). English is
+// built in and fills any key a translation lacks.
+package i18n
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Default is the language every catalog falls back to.
+const Default = "en"
+
+//go:embed locales/*.toml
+var locales embed.FS
+
+// Catalog holds the messages of one language over the English ones. A nil
+// Catalog translates to English.
+type Catalog struct {
+	language string
+	messages map[string]string
+}
+
+var english = mustParse(Default)
+
+// English returns the built-in English catalog.
+func English() *Catalog { return &Catalog{language: Default, messages: english} }
+
+// Load returns the catalog for language, or for the locale environment
+// (LC_ALL, LC_MESSAGES, LANG) when it is empty. A <language>.toml in dir
+// is layered over the built-in catalog of that language, so it can add a
+// language or adjust a few messages. Regional variants such as pt_BR fall
+// back to pt. An unknown language is an error when asked for, and English
+// when it comes from the environment.
+func Load(language, dir string) (*Catalog, error) {
+	if language == "" {
+		env := FromEnv()
+		if env == "" || !ValidLanguage(env) {
+			env = Default
+		}
+		c, err := Load(env, dir)
+		if err != nil && env != Default {
+			return Load(Default, dir)
+		}
+		return c, err
+	}
+	if !ValidLanguage(language) {
+		return nil, fmt.Errorf("invalid language: %s. Must be a code such as en, de or pt_BR", language)
+	}
+	var lastErr error
+	for _, lang := range candidates(language) {
+		if lang == Default && dir == "" {
+			return English(), nil
+		}
+		c, err := load(lang, dir)
+		if err == nil {
+			return c, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	if language == Default {
+		return English(), nil
+	}
+	return nil, fmt.Errorf("no messages for language %q (built in: %s): %w",
+		language, strings.Join(Languages(), ", "), lastErr)
+}
+
+// load layers dir/<lang>.toml over the built-in <lang>.toml, either of
+// which may be missing but not both.
+func load(lang, dir string) (*Catalog, error) {
+	c := &Catalog{language: lang, messages: map[string]string{}}
+	found := false
+	if data, err := locales.ReadFile("locales/" + lang + ".toml"); err == nil {
+		if err := parse(data, c.messages); err != nil {
+			return nil, fmt.Errorf("built-in %s messages: %w", lang, err)
+		}
+		found = true
+	}
+	if dir != "" {
+		path := filepath.Join(dir, lang+".toml")
+		data, err := os.ReadFile(path) //nolint:gosec // ui.messages_dir from config
+		switch {
+		case err == nil:
+			if err := parse(data, c.messages); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			found = true
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%s.toml: %w", lang, fs.ErrNotExist)
+	}
+	return c, nil
+}
+
+// FromEnv returns the language of the locale environment, "" for none or
+// the C/POSIX locale.
+func FromEnv() string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		v, _, _ = strings.Cut(v, ".") // de_DE.UTF-8
+		v, _, _ = strings.Cut(v, "@") // sr_RS@latin
+		if v == "C" || v == "POSIX" {
+			return ""
+		}
+		return v
+	}
+	return ""
+}
+
+// ValidLanguage reports whether language looks like a language code such
+// as en, de or pt_BR, which keeps it a plain file name.
+func ValidLanguage(language string) bool {
+	if language == "" || len(language) > 16 {
+		return false
+	}
+	for _, r := range language {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// candidates lists the catalogs to try for language: as given, then its
+// primary subtag.
+func candidates(language string) []string {
+	language = strings.ReplaceAll(language, "-", "_")
+	primary, _, _ := strings.Cut(language, "_")
+	out := []string{language}
+	if primary = strings.ToLower(primary); primary != language {
+		out = append(out, primary)
+	}
+	return out
+}
+
+// Languages lists the built-in languages.
+func Languages() []string {
+	entries, _ := locales.ReadDir("locales")
+	langs := make([]string, 0, len(entries))
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".toml"))
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Language returns the catalog's language.
+func (c *Catalog) Language() string {
+	if c == nil {
+		return Default
+	}
+	return c.language
+}
+
+// T returns the message for key with each {name} replaced by the value
+// following name in args, such as T("backup.created", "path", p). Keys the
+// catalog lacks come from English; unknown keys are returned as they are.
+func (c *Catalog) T(key string, args ...any) string {
+	msg, ok := "", false
+	if c != nil {
+		msg, ok = c.messages[key]
+	}
+	if !ok {
+		if msg, ok = english[key]; !ok {
+			msg = key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, len(args))
+	for i := 0; i+1 < len(args); i += 2 {
+		pairs = append(pairs, "{"+fmt.Sprint(args[i])+"}", fmt.Sprint(args[i+1]))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// parse decodes a catalog file into messages, keyed by dotted table path.
+func parse(data []byte, messages map[string]string) error {
+	raw := map[string]any{}
+	if _, err := toml.Decode(string(data), &raw); err != nil {
+		return err
+	}
+	return flatten("", raw, messages)
+}
+
+func flatten(prefix string, raw map[string]any, messages map[string]string) error {
+	for k, v := range raw {
+		key := prefix + k
+		switch v := v.(type) {
+		case string:
+			messages[key] = v
+		case map[string]any:
+			if err := flatten(key+".", v, messages); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %s is not a string", key)
+		}
+	}
+	return nil
+}
+
+func mustParse(lang string) map[string]string {
+	data, err := locales.ReadFile("locales/" + lang + ".toml")
+	if err != nil {
+		panic(err)
+	}
+	messages := map[string]string{}
+	if err := parse(data, messages); err != nil {
+		panic(fmt.Sprintf("built-in %s messages: %v", lang, err))
+	}
+	return messages
+}
@@ -0,0 +1,120 @@
+package i18n_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"craftops/internal/i18n"
+)
+
+func TestLoad_Builtin(t *testing.T) {
+	c, err := i18n.Load("de_DE", "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if c.Language() != "de" {
+		t.Errorf("Language() = %q, want de (from de_DE)", c.Language())
+	}
+	if got := c.T("backup.created", "path", "/b/x.tar.gz"); got != "Backup erstellt: /b/x.tar.gz" {
+		t.Errorf("T(backup.created) = %q", got)
+	}
+	if got := c.T("no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key = %q, want the key", got)
+	}
+
+	if _, err := i18n.Load("xx", ""); err == nil {
+		t.Error("Load(xx) succeeded without a catalog")
+	}
+	if _, err := i18n.Load("../de", ""); err == nil {
+		t.Error("Load accepted a path as language")
+	}
+}
+
+func TestLoad_FromEnv(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_AT.UTF-8")
+	c, err := i18n.Load("", "")
+	if err != nil || c.Language() != "de" {
+		t.Fatalf("Load from LANG = %v, %v; want de", c.Language(), err)
+	}
+
+	t.Setenv("LANG", "fr_FR.UTF-8")
+	c, err = i18n.Load("", "")
+	if err != nil || c.Language() != i18n.Default {
+		t.Errorf("unknown LANG = %v, %v; want English without error", c.Language(), err)
+	}
+
+	t.Setenv("LANG", "C.UTF-8")
+	if got := i18n.FromEnv(); got != "" {
+		t.Errorf("FromEnv() = %q for the C locale", got)
+	}
+}
+
+func TestLoad_UserMessages(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("fr.toml", "[backup]\ncreated = \"Sauvegarde créée : {path}\"\n")
+	write("de.toml", "[restart]\ndone = \"Server läuft wieder\"\n")
+
+	fr, err := i18n.Load("fr", dir)
+	if err != nil {
+		t.Fatalf("Load(fr): %v", err)
+	}
+	if got := fr.T("backup.created", "path", "x"); got != "Sauvegarde créée : x" {
+		t.Errorf("fr backup.created = %q", got)
+	}
+	if got := fr.T("restart.done"); got != "Server restarted" {
+		t.Errorf("missing fr key = %q, want English", got)
+	}
+
+	de, err := i18n.Load("de", dir)
+	if err != nil {
+		t.Fatalf("Load(de): %v", err)
+	}
+	if de.T("restart.done") != "Server läuft wieder" || de.T("restart.aborted") != "Neustart abgebrochen" {
+		t.Errorf("user de.toml not layered over the built-in one: %q, %q", de.T("restart.done"), de.T("restart.aborted"))
+	}
+
+	write("es.toml", "[backup]\ncreated = 3\n")
+	if _, err := i18n.Load("es", dir); err == nil {
+		t.Error("Load accepted a message that is not a string")
+	}
+}
+
+func TestCatalog_TranslationsMatchEnglish(t *testing.T) {
+	english := i18n.English()
+	for _, lang := range i18n.Languages() {
+		c, err := i18n.Load(lang, "")
+		if err != nil {
+			t.Fatalf("Load(%s): %v", lang, err)
+		}
+		for _, key := range c.Keys() {
+			if english.T(key) == key {
+				t.Errorf("%s: %s is not an English message", lang, key)
+			}
+		}
+	}
+}
+
+func TestCatalog_GermanIsComplete(t *testing.T) {
+	de, err := i18n.Load("de", "")
+	if err != nil {
+		t.Fatalf("Load(de): %v", err)
+	}
+	have := map[string]bool{}
+	for _, key := range de.Keys() {
+		have[key] = true
+	}
+	for _, key := range i18n.English().Keys() {
+		if !have[key] {
+			t.Errorf("de lacks %s, so its command prints mixed languages", key)
+		}
+	}
+}
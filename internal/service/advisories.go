@@ -0,0 +1,131 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// maxAdvisoryList bounds how much of an advisory list is read.
+const maxAdvisoryList = 4 << 20
+
+// projectStatusAdvisories are the Modrinth project statuses worth a
+// warning, with the reason given.
+var projectStatusAdvisories = map[string]string{
+	domain.AdvisoryArchived: "archived by its author; it gets no more updates",
+	domain.AdvisoryUnlisted: "unlisted on Modrinth",
+	domain.AdvisoryWithheld: "withheld by Modrinth moderation",
+}
+
+// checkAdvisories warns about the projects of sources that are archived,
+// unlisted or withheld, and about sources and installed jars an advisory
+// list flags. blocked holds the flagged sources not to install, with
+// mods.block_flagged, and the reason.
+func (m *Mods) checkAdvisories(ctx context.Context, sources []string, projects map[string]modrinthProject) (advisories []domain.ModAdvisory, blocked map[string]string) {
+	ids := make(map[string]string, len(sources)) // project ID or slug → source
+	for _, src := range sources {
+		if id, err := parseProjectID(src); err == nil {
+			ids[id] = src
+		}
+	}
+	flagged := m.advisoryLists(ctx)
+
+	blocked = map[string]string{}
+	for _, id := range slices.Sorted(maps.Keys(ids)) {
+		src, p := ids[id], projects[id]
+		if reason, ok := projectStatusAdvisories[p.Status]; ok {
+			advisories = append(advisories, domain.ModAdvisory{Mod: id, Kind: p.Status, Reason: reason})
+		}
+		reason, ok := flagged[strings.ToLower(id)]
+		if !ok && p.ID != "" {
+			if reason, ok = flagged[strings.ToLower(p.ID)]; !ok {
+				reason, ok = flagged[strings.ToLower(p.Slug)]
+			}
+		}
+		if ok {
+			advisories = append(advisories, domain.ModAdvisory{Mod: id, Kind: domain.AdvisoryFlagged, Reason: reason})
+			if m.cfg.Mods.BlockFlagged {
+				blocked[src] = reason
+			}
+		}
+	}
+	if len(flagged) > 0 {
+		files, _ := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
+		sums := hashFiles(files)
+		for _, file := range files {
+			if reason, ok := flagged[sums[file]]; ok {
+				name := filepath.Base(file)
+				advisories = append(advisories, domain.ModAdvisory{Mod: name, Kind: domain.AdvisoryFlagged, Reason: reason, File: name})
+			}
+		}
+	}
+	for _, a := range advisories {
+		m.logger.Warn("Mod advisory", zap.String("mod", a.Mod), zap.String("kind", a.Kind),
+			zap.String("reason", a.Reason), zap.String("file", a.File))
+	}
+	return advisories, blocked
+}
+
+// advisoryLists fetches mods.advisory_lists into one map from lowercased
+// project ID, slug or SHA-1 to the reason given. A list that cannot be
+// fetched is skipped with a warning.
+func (m *Mods) advisoryLists(ctx context.Context) map[string]string {
+	flagged := map[string]string{}
+	if m.cfg.Offline {
+		return flagged
+	}
+	for _, list := range m.cfg.Mods.AdvisoryLists {
+		if err := m.fetchAdvisoryList(ctx, list, flagged); err != nil {
+			m.logger.Warn("Advisory list not checked", zap.String("url", list), zap.Error(err))
+		}
+	}
+	return flagged
+}
+
+func (m *Mods) fetchAdvisoryList(ctx context.Context, list string, into map[string]string) error {
+	return m.withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, list, nil)
+		if err != nil {
+			return err
+		}
+		m.setHeaders(req)
+		resp, err := m.client.Do(req) //nolint:gosec // URL from mods.advisory_lists
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return &domain.APIError{URL: list, StatusCode: resp.StatusCode, Message: "advisory list unavailable",
+				RetryAfter: parseRetryAfter(resp.Header)}
+		}
+		parseAdvisoryList(io.LimitReader(resp.Body, maxAdvisoryList), list, into)
+		return nil
+	})
+}
+
+// parseAdvisoryList reads "<project id|slug|sha1> [reason]" lines, skipping
+// blanks and # comments. Entries without a reason name the list.
+func parseAdvisoryList(r io.Reader, list string, into map[string]string) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, reason, _ := strings.Cut(line, " ")
+		if reason = strings.TrimSpace(reason); reason == "" {
+			reason = fmt.Sprintf("listed in %s", list)
+		}
+		into[strings.ToLower(key)] = reason
+	}
+}
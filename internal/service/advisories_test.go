@@ -0,0 +1,83 @@
+package service_test
+
+import (
+	"crypto/sha1" //nolint:gosec // Modrinth identifies files by SHA-1
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestMods_UpdateAll_Advisories(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Mods, "shady.jar", "SHADY")
+	sum := sha1.Sum([]byte("SHADY")) //nolint:gosec // matches Modrinth's file hashes
+	list := fmt.Sprintf("# community list\nlithium credential stealer\n\n%s\n", hex.EncodeToString(sum[:]))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/projects":
+			_ = json.NewEncoder(w).Encode([]map[string]any{
+				{"id": "AANobbMI", "slug": "sodium", "status": "archived"},
+				{"id": "gvQqBUqZ", "slug": "lithium", "status": "approved"},
+			})
+		case r.URL.Path == "/lists/advisories.txt":
+			_, _ = w.Write([]byte(list))
+		case strings.HasPrefix(r.URL.Path, "/v2/project/"):
+			name := strings.Split(r.URL.Path, "/")[3] + ".jar"
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture(name, "http://"+r.Host+"/files/"+name))
+		default:
+			_, _ = w.Write([]byte("JAR"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"sodium", "lithium"}
+	cfg.Mods.AdvisoryLists = []string{srv.URL + "/lists/advisories.txt"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []domain.ModAdvisory{
+		{Mod: "lithium", Kind: domain.AdvisoryFlagged, Reason: "credential stealer"},
+		{Mod: "sodium", Kind: domain.AdvisoryArchived, Reason: "archived by its author; it gets no more updates"},
+		{Mod: "shady.jar", Kind: domain.AdvisoryFlagged, Reason: "listed in " + cfg.Mods.AdvisoryLists[0], File: "shady.jar"},
+	}
+	if len(result.Advisories) != len(want) {
+		t.Fatalf("Advisories = %+v, want %+v", result.Advisories, want)
+	}
+	for i := range want {
+		if result.Advisories[i] != want[i] {
+			t.Errorf("Advisories[%d] = %+v, want %+v", i, result.Advisories[i], want[i])
+		}
+	}
+	if len(result.Blocked) != 1 || result.Blocked[0] != "lithium" {
+		t.Errorf("Blocked = %v, want the flagged mod (updated=%v failed=%v)", result.Blocked, result.UpdatedMods, result.FailedMods)
+	}
+	for _, d := range result.Mods {
+		if d.Name == "lithium" && d.ErrorKind != domain.ModErrFlagged {
+			t.Errorf("lithium ErrorKind = %q, want %q", d.ErrorKind, domain.ModErrFlagged)
+		}
+	}
+	if len(result.UpdatedMods) != 1 || result.UpdatedMods[0] != "sodium" {
+		t.Errorf("UpdatedMods = %v, want archived mods still updated (%+v)", result.UpdatedMods, result.Mods)
+	}
+
+	cfg.Mods.BlockFlagged = false
+	result, err = service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.FailedMods) != 0 || len(result.Blocked) != 0 {
+		t.Errorf("FailedMods = %v, want flagged mods only warned about without block_flagged", result.FailedMods)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+)
+
+// Announcer sends the [announcements] messages to the server console when
+// their cron schedules fire.
+type Announcer struct {
+	cfg     *config.Config
+	logger  *zap.Logger
+	console Console
+	next    []int // per schedule, the message to send next
+}
+
+// NewAnnouncer creates an announcer; attach a console with UseConsole.
+func NewAnnouncer(cfg *config.Config, logger *zap.Logger) *Announcer {
+	return &Announcer{cfg: cfg, logger: logger, next: make([]int, len(cfg.Announcements.Schedules))}
+}
+
+// UseConsole sets where announcements are sent.
+func (a *Announcer) UseConsole(c Console) { a.console = c }
+
+// Run fires the schedules at the start of each matching minute until ctx is
+// done. It returns at once when there are no schedules.
+func (a *Announcer) Run(ctx context.Context) {
+	if a == nil || len(a.cfg.Announcements.Schedules) == 0 || a.console == nil {
+		return
+	}
+	for {
+		at := time.Now().Truncate(time.Minute).Add(time.Minute)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(at)):
+			a.fire(ctx, at)
+		}
+	}
+}
+
+// fire sends the next message of every schedule matching the minute of at.
+// A message the server did not take (e.g. it is stopped) is retried on the
+// schedule's next run.
+func (a *Announcer) fire(ctx context.Context, at time.Time) {
+	if loc, err := a.cfg.Announcements.Location(); err == nil {
+		at = at.In(loc)
+	}
+	for i, s := range a.cfg.Announcements.Schedules {
+		cron, err := config.ParseCron(s.Cron)
+		if err != nil || !cron.Matches(at) {
+			continue
+		}
+		msg := s.Messages[a.next[i]%len(s.Messages)]
+		command := "say " + msg
+		if s.Color != "" {
+			command = "tellraw @a " + textComponent(msg, s.Color)
+		}
+		if a.cfg.DryRun {
+			a.logger.Info("Dry run: Would announce", zap.String("message", msg))
+		} else if err := a.console.SendConsole(ctx, command); err != nil {
+			a.logger.Debug("Announcement not sent", zap.String("cron", s.Cron), zap.Error(err))
+			continue
+		}
+		a.next[i]++
+	}
+}
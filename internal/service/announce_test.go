@@ -0,0 +1,38 @@
+package service_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/service"
+)
+
+func TestAnnouncer_RotatesMessagesOnSchedule(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Announcements = config.AnnouncementsConfig{
+		Timezone: "UTC",
+		Schedules: []config.Announcement{
+			{Cron: "*/30 * * * *", Messages: []string{"Vote for us", "Join our Discord"}},
+			{Cron: "0 12 * * *", Messages: []string{"Noon"}, Color: "gold"},
+		},
+	}
+	console := &fakeConsole{}
+	a := service.NewAnnouncer(cfg, logger)
+	a.UseConsole(console)
+
+	base := time.Date(2026, 5, 1, 11, 0, 0, 0, time.UTC)
+	for _, at := range []time.Duration{0, 15 * time.Minute, 30 * time.Minute, time.Hour} {
+		a.Fire(ctx, base.Add(at))
+	}
+	want := []string{
+		"say Vote for us",
+		"say Join our Discord",
+		"say Vote for us",
+		`tellraw @a {"color":"gold","text":"Noon"}`,
+	}
+	if !slices.Equal(console.cmds, want) {
+		t.Errorf("commands = %q, want %q", console.cmds, want)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// Reactions that decide an approval request on Discord.
+const (
+	approveEmoji = "✅"
+	rejectEmoji  = "❌"
+)
+
+// approvalPoll is how often the reactions on a request are read.
+var approvalPoll = 5 * time.Second
+
+// Approvals holds automatically triggered operations until they are
+// approved on Discord or through the API (see config.ApprovalsConfig).
+type Approvals struct {
+	cfg    *config.Config
+	logger *zap.Logger
+	notify *Notification
+	bot    *DiscordBot // nil unless reactions can be read
+	api    bool        // this process serves POST /approvals
+
+	mu      sync.Mutex
+	pending map[string]chan bool
+}
+
+// NewApprovals creates the approval gate; requests are posted through n.
+func NewApprovals(cfg *config.Config, logger *zap.Logger, n *Notification) *Approvals {
+	a := &Approvals{cfg: cfg, logger: logger, notify: n, pending: map[string]chan bool{}}
+	if cfg.DiscordBot.Enabled && cfg.DiscordBot.GuildID != "" {
+		a.bot = NewDiscordBot(cfg, logger)
+	}
+	return a
+}
+
+// ServeAPI marks requests as decidable through this process's API, so they
+// advertise POST /approvals/<id>. Only `serve` runs the API; ids requested
+// elsewhere exist only in that process.
+func (a *Approvals) ServeAPI() { a.api = true }
+
+// Request blocks until op, triggered by source (e.g. "webhook github"), is
+// approved, and fails with ErrApprovalRejected or ErrApprovalTimeout
+// otherwise. Operations approvals.operations does not list, and dry runs,
+// proceed at once.
+func (a *Approvals) Request(ctx context.Context, op, source string) error {
+	minutes := a.cfg.Approvals.Operations[op]
+	if minutes == 0 || a.cfg.DryRun {
+		return nil
+	}
+	if a.bot == nil && !a.api {
+		return fmt.Errorf("%s needs approval, which outside `craftops serve` only Discord reactions (discord_bot.guild_id) can give", op)
+	}
+	timeout := time.Duration(minutes) * time.Minute
+	id := approvalID()
+	decision := make(chan bool, 1)
+	a.mu.Lock()
+	a.pending[id] = decision
+	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		delete(a.pending, id)
+		a.mu.Unlock()
+	}()
+	a.logger.Info("Waiting for approval", zap.String("operation", op), zap.String("source", source),
+		zap.String("id", id), zap.Duration("timeout", timeout))
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var msg struct {
+		ID        string `json:"id"`
+		ChannelID string `json:"channel_id"`
+	}
+	text := a.notify.T("notify.approval_text", "source", source, "timeout", timeout)
+	if a.bot != nil {
+		text += "\n" + a.notify.T("notify.approval_react", "approve", approveEmoji, "reject", rejectEmoji)
+	}
+	if a.api && (a.cfg.Approvals.Secret != "" || len(a.cfg.API.Tokens) > 0) {
+		text += "\n" + a.notify.T("notify.approval_api", "id", id)
+	}
+	embed := discordEmbed{Title: a.notify.T("notify.approval_title", "operation", op), Description: text, Color: colorOrange}
+	if err := a.notify.postEmbed(ctx, embed, &msg); err != nil {
+		a.logger.Warn("Posting approval request failed", zap.Error(err))
+	} else if a.bot != nil && msg.ID != "" {
+		go a.watchReactions(watchCtx, id, msg.ChannelID, msg.ID)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case ok := <-decision:
+		if ok {
+			a.logger.Info("Operation approved", zap.String("operation", op), zap.String("id", id))
+			return nil
+		}
+		err := fmt.Errorf("%s: %w", op, domain.ErrApprovalRejected)
+		_ = a.notify.SendError(ctx, a.notify.T("notify.approval_rejected", "operation", op, "source", source))
+		return err
+	case <-timer.C:
+		_ = a.notify.SendError(ctx, a.notify.T("notify.approval_timeout", "operation", op, "source", source, "timeout", timeout))
+		return fmt.Errorf("%s: %w (%s)", op, domain.ErrApprovalTimeout, timeout)
+	}
+}
+
+// Decide approves or rejects the pending request id.
+func (a *Approvals) Decide(id string, approve bool) error {
+	a.mu.Lock()
+	decision, ok := a.pending[id]
+	a.mu.Unlock()
+	if !ok {
+		return domain.ErrApprovalNotFound
+	}
+	select {
+	case decision <- approve:
+	default: // already decided
+	}
+	return nil
+}
+
+// watchReactions decides request id from the reactions of allowed members
+// on its Discord message, rejection taking precedence, until ctx is done.
+func (a *Approvals) watchReactions(ctx context.Context, id, channelID, messageID string) {
+	for _, emoji := range []string{approveEmoji, rejectEmoji} {
+		if err := a.bot.React(ctx, channelID, messageID, emoji); err != nil {
+			a.logger.Debug("Adding approval reaction failed", zap.Error(err))
+		}
+	}
+	ticker := time.NewTicker(approvalPoll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, emoji := range []string{rejectEmoji, approveEmoji} {
+			users, err := a.bot.ReactedAllowed(ctx, channelID, messageID, emoji)
+			if err != nil {
+				a.logger.Warn("Reading approval reactions failed", zap.Error(err))
+				break
+			}
+			if len(users) > 0 {
+				a.logger.Info("Approval decided on Discord", zap.String("id", id), zap.String("reaction", emoji), zap.Strings("users", users))
+				_ = a.Decide(id, emoji == approveEmoji)
+				return
+			}
+		}
+	}
+}
+
+// approvalID returns a short random request ID.
+func approvalID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,26 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	"craftops/internal/config"
+	"craftops/internal/service"
+)
+
+func TestApprovals_RequestWithoutAPIOrReactions(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Approvals.Operations = map[string]int{config.ActionUpdateMods: 30}
+	cfg.Approvals.Secret = "s3cret"
+	approvals := service.NewApprovals(cfg, logger, service.NewNotification(cfg, logger))
+
+	// Outside serve nothing could decide the request, so it fails at once
+	// instead of waiting out the 30 minutes.
+	err := approvals.Request(ctx, config.ActionUpdateMods, "mods watch")
+	if err == nil || !strings.Contains(err.Error(), "discord_bot.guild_id") {
+		t.Errorf("Request = %v, want an error naming Discord reactions", err)
+	}
+	if err := approvals.Request(ctx, config.ActionRestart, "mods watch"); err != nil {
+		t.Errorf("Request for an operation without approval = %v", err)
+	}
+}
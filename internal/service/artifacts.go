@@ -0,0 +1,297 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// profilesFile lists the mods directories sharing the artifact cache.
+const profilesFile = "profiles.json"
+
+// artifactDir is the content-addressed jar cache. Each download is stored
+// once under its SHA-1 (artifacts/ab/abcdef….jar) and hardlinked into every
+// mods directory that needs it, so server profiles sharing paths.cache fetch
+// a jar only once. With mods.layout "symlinks" the mods directory holds
+// symlinks into it instead.
+func (m *Mods) artifactDir() string {
+	return artifactRoot(m.cfg)
+}
+
+func artifactRoot(cfg *config.Config) string {
+	if cfg.Paths.Cache == "" {
+		return ""
+	}
+	return filepath.Join(cfg.Paths.Cache, "artifacts")
+}
+
+func (m *Mods) artifactPath(sum string) string {
+	return filepath.Join(m.artifactDir(), sum[:2], sum+".jar")
+}
+
+// storeArtifact adds a downloaded jar to the artifact cache and returns its
+// path there, or "" if it could not be cached.
+func (m *Mods) storeArtifact(jarPath string) string {
+	if m.artifactDir() == "" {
+		return ""
+	}
+	sum, err := fileSHA1(jarPath)
+	if err != nil {
+		m.logger.Debug("Failed to hash artifact", zap.String("file", jarPath), zap.Error(err))
+		return ""
+	}
+	dst := m.artifactPath(sum)
+	if _, err := os.Stat(dst); err != nil {
+		if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+			m.logger.Debug("Artifact cache unavailable", zap.Error(err))
+			return ""
+		}
+		if err := linkOrCopy(jarPath, dst); err != nil {
+			m.logger.Debug("Failed to cache artifact", zap.String("file", jarPath), zap.Error(err))
+			return ""
+		}
+	}
+	m.registerProfile()
+	return dst
+}
+
+// placeArtifact puts the cached jar at dst: a symlink under the symlinks
+// layout, otherwise a hardlink or copy.
+func (m *Mods) placeArtifact(src, dst string) error {
+	if m.cfg.Mods.Layout != config.ModsLayoutSymlinks {
+		return linkOrCopy(src, dst)
+	}
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return err
+	}
+	_ = os.Remove(dst)
+	return os.Symlink(abs, dst)
+}
+
+// installFromArtifacts links the cached jar for info into place. Without a
+// hash from the API it falls back to the lockfile entry for the filename.
+func (m *Mods) installFromArtifacts(info *domain.ModInfo, finalPath string) (bool, error) {
+	if m.artifactDir() == "" {
+		return false, fmt.Errorf("%s: %w", info.Filename, domain.ErrOffline)
+	}
+	sum := info.SHA1
+	if sum == "" {
+		if st, err := m.state.Load(); err == nil {
+			for _, lm := range st.Mods {
+				if lm.Filename == info.Filename {
+					sum = lm.SHA1
+				}
+			}
+		}
+	}
+	if len(sum) < 2 {
+		return false, fmt.Errorf("%s has no known hash: %w", info.Filename, domain.ErrOffline)
+	}
+	src := m.artifactPath(sum)
+	if _, err := os.Stat(src); err != nil {
+		return false, fmt.Errorf("%s not in artifact cache: %w", info.Filename, domain.ErrOffline)
+	}
+	if err := m.placeArtifact(src, finalPath); err != nil {
+		return false, err
+	}
+	m.registerProfile()
+	m.logger.Info("Installed mod from artifact cache", zap.String("filename", info.Filename))
+	return true, nil
+}
+
+// registerProfile records this mods directory as a user of the cache so
+// GCArtifacts keeps the jars it references.
+func (m *Mods) registerProfile() {
+	m.registerOnce.Do(func() {
+		dir, err := filepath.Abs(m.cfg.ModsDir())
+		if err != nil {
+			return
+		}
+		profiles := m.profiles()
+		if slices.Contains(profiles, dir) {
+			return
+		}
+		if err := m.saveProfiles(append(profiles, dir)); err != nil {
+			m.logger.Debug("Failed to register mods directory with artifact cache", zap.Error(err))
+		}
+	})
+}
+
+func (m *Mods) profiles() []string {
+	data, err := os.ReadFile(filepath.Join(m.artifactDir(), profilesFile))
+	if err != nil {
+		return nil
+	}
+	var profiles []string
+	_ = json.Unmarshal(data, &profiles)
+	return profiles
+}
+
+func (m *Mods) saveProfiles(profiles []string) error {
+	slices.Sort(profiles)
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(m.artifactDir(), ".tmp-profiles-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(m.artifactDir(), profilesFile))
+}
+
+// GCArtifacts removes cached jars that no registered mods directory uses
+// any more, and those left by the old by-filename layout. Directories that
+// no longer exist are unregistered.
+func (m *Mods) GCArtifacts() (*domain.CacheGCResult, error) {
+	res := &domain.CacheGCResult{}
+	dir := m.artifactDir()
+	if dir == "" {
+		return res, nil
+	}
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return res, nil
+	}
+	// This profile's jars count as referenced even if it never used the cache.
+	if _, err := os.Stat(m.cfg.ModsDir()); err == nil {
+		m.registerProfile()
+	}
+
+	var jars []string
+	var live []string
+	for _, p := range m.profiles() {
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		live = append(live, p)
+		found, _ := filepath.Glob(filepath.Join(p, "*.jar"))
+		jars = append(jars, found...)
+		// Jars saved for rollback may be symlinks into the cache too.
+		found, _ = filepath.Glob(filepath.Join(filepath.Dir(p), "mods.rollback", "*.jar"))
+		jars = append(jars, found...)
+	}
+	found, _ := filepath.Glob(filepath.Join(m.rollbackDir(), "*.jar"))
+	jars = append(jars, found...)
+	res.Profiles = live
+	referenced := make(map[string]bool)
+	for _, sum := range hashFiles(jars) {
+		referenced[sum] = true
+	}
+
+	artifacts, _ := filepath.Glob(filepath.Join(dir, "*", "*.jar"))
+	// Jars cached by filename before the cache was content-addressed are
+	// never looked up again.
+	legacy, _ := filepath.Glob(filepath.Join(dir, "*.jar"))
+	for _, path := range append(artifacts, legacy...) {
+		sum := strings.TrimSuffix(filepath.Base(path), ".jar")
+		if referenced[sum] {
+			res.Kept++
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if !m.cfg.DryRun {
+			if err := os.Remove(path); err != nil {
+				m.logger.Warn("Failed to remove artifact", zap.String("path", path), zap.Error(err))
+				continue
+			}
+			_ = os.Remove(filepath.Dir(path)) // only succeeds once the shard is empty
+		}
+		res.Removed++
+		res.Freed += info.Size()
+	}
+	if !m.cfg.DryRun && len(live) != len(m.profiles()) {
+		if err := m.saveProfiles(live); err != nil {
+			return res, err
+		}
+	}
+	m.logger.Info("Artifact cache pruned", zap.Int("removed", res.Removed), zap.Int("kept", res.Kept),
+		zap.Int64("freed_bytes", res.Freed), zap.Bool("dry_run", m.cfg.DryRun))
+	return res, nil
+}
+
+// hashFiles returns the SHA-1 of each readable path, hashing on one worker
+// per CPU.
+func hashFiles(paths []string) map[string]string {
+	sums := make(map[string]string, len(paths))
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(runtime.GOMAXPROCS(0))
+	for _, p := range paths {
+		g.Go(func() error {
+			sum, err := fileSHA1(p)
+			if err != nil {
+				return nil
+			}
+			mu.Lock()
+			sums[p] = sum
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return sums
+}
+
+// linkOrCopy hardlinks src to dst, copying when the link cannot be made
+// (e.g. across filesystems). dst is replaced if it exists.
+func linkOrCopy(src, dst string) error {
+	_ = os.Remove(dst)
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src) //nolint:gosec // path from cache or mods directory
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// checkSHA1 fails if the file at path does not match the hash the API
+// published for info. Mods without a published hash pass.
+func checkSHA1(path string, info *domain.ModInfo) error {
+	if info.SHA1 == "" {
+		return nil
+	}
+	sum, err := fileSHA1(path)
+	if err != nil {
+		return err
+	}
+	if sum != info.SHA1 {
+		return fmt.Errorf("%w: %s", domain.ErrHashMismatch, info.Filename)
+	}
+	return nil
+}
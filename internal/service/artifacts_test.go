@@ -0,0 +1,155 @@
+package service_test
+
+import (
+	"crypto/sha1" //nolint:gosec // Modrinth identifies files by SHA-1
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"craftops/internal/config"
+	"craftops/internal/service"
+)
+
+func TestMods_ArtifactCacheSharedAcrossProfiles(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	jar := []byte("SHARED_JAR")
+	sum := sha1.Sum(jar) //nolint:gosec
+	hash := hex.EncodeToString(sum[:])
+
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/sodium/version":
+			_ = json.NewEncoder(w).Encode([]map[string]any{{
+				"id": "v1", "version_number": "1.0.0",
+				"files": []map[string]any{{
+					"filename": "sodium.jar",
+					"url":      "http://" + r.Host + "/dl/sodium.jar",
+					"hashes":   map[string]string{"sha1": hash},
+				}},
+			}})
+		case "/dl/sodium.jar":
+			downloads.Add(1)
+			_, _ = w.Write(jar)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 0
+
+	if _, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second profile with its own mods dir and state shares the cache.
+	other := *cfg
+	other.Paths.Mods = filepath.Join(t.TempDir(), "mods")
+	other.Paths.State = filepath.Join(t.TempDir(), "state")
+	res, err := service.NewModsWithBaseURL(&other, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil || len(res.UpdatedMods) != 1 {
+		t.Fatalf("second profile: %v, %+v", err, res)
+	}
+	if n := downloads.Load(); n != 1 {
+		t.Errorf("jar downloaded %d times, want 1", n)
+	}
+	if data, _ := os.ReadFile(filepath.Join(other.Paths.Mods, "sodium.jar")); string(data) != "SHARED_JAR" {
+		t.Errorf("second profile jar = %q", data)
+	}
+
+	svc := service.NewMods(cfg, logger)
+	if gc, err := svc.GCArtifacts(); err != nil || gc.Removed != 0 || gc.Kept != 1 {
+		t.Fatalf("GC while referenced = %+v, %v", gc, err)
+	}
+
+	_ = os.Remove(filepath.Join(cfg.Paths.Mods, "sodium.jar"))
+	_ = os.RemoveAll(other.Paths.Mods)
+	// A jar cached by filename before the cache was content-addressed.
+	legacy := writeFile(t, filepath.Join(cfg.Paths.Cache, "artifacts"), "old.jar", "OLD")
+	gc, err := svc.GCArtifacts()
+	if err != nil || gc.Removed != 2 || gc.Freed != int64(len(jar)+len("OLD")) {
+		t.Fatalf("GC after removal = %+v, %v", gc, err)
+	}
+	if len(gc.Profiles) != 1 {
+		t.Errorf("missing mods dir should be unregistered, profiles = %v", gc.Profiles)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("legacy artifact not removed: %v", err)
+	}
+}
+
+func TestMods_SymlinkLayout(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	var version atomic.Int32
+	version.Store(1)
+	var downloads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		v := fmt.Sprintf("%d.0", version.Load())
+		switch r.URL.Path {
+		case "/v2/project/sodium/version":
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("sodium-"+v+".jar", "http://"+r.Host+"/dl/"+v))
+		case "/dl/" + v:
+			downloads.Add(1)
+			_, _ = w.Write([]byte("SODIUM " + v))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	cfg.Paths.Mods = filepath.Join(cfg.Paths.Server, "mods")
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Layout = config.ModsLayoutSymlinks
+	mods := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+
+	if _, err := mods.UpdateAll(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := mods.SaveRollback(ctx, ""); err != nil {
+		t.Fatal(err)
+	}
+	version.Store(2)
+	if _, err := mods.UpdateAll(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	jar := filepath.Join(cfg.Paths.Mods, "sodium-2.0.jar")
+	if target, err := os.Readlink(jar); err != nil || !strings.HasPrefix(target, cfg.Paths.Cache) {
+		t.Fatalf("sodium-2.0.jar should link into the cache, got %q (%v)", target, err)
+	}
+
+	if gc, err := mods.GCArtifacts(); err != nil || gc.Removed != 0 {
+		t.Errorf("GC removed jars still linked from the rollback set: %+v, %v", gc, err)
+	}
+
+	if _, err := mods.Rollback(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(filepath.Join(cfg.Paths.Mods, "sodium-1.0.jar")); err != nil || string(data) != "SODIUM 1.0" {
+		t.Fatalf("rolled back jar = %q, %v", data, err)
+	}
+	if fi, _ := os.Lstat(filepath.Join(cfg.Paths.Mods, "sodium-1.0.jar")); fi == nil || fi.Mode()&os.ModeSymlink == 0 {
+		t.Error("rolled back jar should still be a symlink")
+	}
+	if n := downloads.Load(); n != 2 {
+		t.Errorf("downloads = %d, want 2", n)
+	}
+
+	cfg.Backup.Enabled = true
+	backups := service.NewBackup(cfg, logger)
+	path, err := backups.Create(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := backups.Contents(ctx, filepath.Base(path), "mods/sodium-1.0.jar")
+	if err != nil || len(entries) != 1 || entries[0].Size != int64(len("SODIUM 1.0")) {
+		t.Errorf("backup should hold the linked jar, got %+v (%v)", entries, err)
+	}
+}
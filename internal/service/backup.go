@@ -3,6 +3,7 @@ package service
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
@@ -10,6 +11,7 @@ import (
 	"io"
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -24,23 +26,127 @@ import (
 
 const (
 	backupTimeFormat = "20060102_150405"
-	backupPrefix     = "minecraft_backup_"
 	backupExt        = ".tar.gz"
 )
 
 // Backup manages compressed server archives with retention.
 type Backup struct {
-	cfg    *config.Config
-	logger *zap.Logger
+	cfg      *config.Config
+	logger   *zap.Logger
+	state    *StateStore
+	progress *backupProgress
+	notify   *Notification // nil unless attached with UseNotification
 }
 
 // NewBackup creates a backup manager.
 func NewBackup(cfg *config.Config, logger *zap.Logger) *Backup {
-	return &Backup{cfg: cfg, logger: logger}
+	return &Backup{cfg: cfg, logger: logger, state: NewStateStore(cfg)}
 }
 
+// UseNotification sets where disk pressure pruning is reported.
+func (b *Backup) UseNotification(n *Notification) { b.notify = n }
+
 // Create generates a compressed tarball of the server directory.
 func (b *Backup) Create(ctx context.Context) (string, error) {
+	return b.CreateWith(ctx, domain.BackupOptions{})
+}
+
+// CreateTagged is Create with a tag substituted for {tag} in the name
+// template. Retention keeps max_backups of each tag.
+func (b *Backup) CreateTagged(ctx context.Context, tag string) (string, error) {
+	return b.CreateWith(ctx, domain.BackupOptions{Tag: tag})
+}
+
+// CreateWith is Create adjusted by opts. It stops between files, and
+// between chunks of a large file, once ctx is done.
+func (b *Backup) CreateWith(ctx context.Context, opts domain.BackupOptions) (string, error) {
+	return b.with(opts).create(ctx, opts)
+}
+
+// with returns a copy of b adjusted by opts.
+func (b *Backup) with(opts domain.BackupOptions) *Backup {
+	run := *b
+	if len(opts.Include) > 0 || opts.CompressionLevel != 0 {
+		cfg := *b.cfg
+		if len(opts.Include) > 0 {
+			cfg.Backup.IncludePatterns = opts.Include
+		}
+		if opts.CompressionLevel != 0 {
+			cfg.Backup.CompressionLevel = opts.CompressionLevel
+		}
+		run.cfg = &cfg
+	}
+	if opts.Progress != nil {
+		run.progress = &backupProgress{report: opts.Progress}
+	}
+	return &run
+}
+
+// Estimate walks the files CreateWith would archive, applying the same
+// exclusions, without writing anything. The projected size scales the
+// total by the compression ratio of the newest archive; with none, or in
+// snapshot mode, it is the uncompressed total.
+func (b *Backup) Estimate(ctx context.Context, opts domain.BackupOptions) (*domain.BackupEstimate, error) {
+	run := b.with(opts)
+	if check := domain.CheckPath("Server", run.cfg.Paths.Server); check.Status != domain.StatusOK {
+		return nil, fmt.Errorf("%s: %s", check.Name, check.Message)
+	}
+	est := &domain.BackupEstimate{}
+	err := run.walkFiles(ctx, func(_, _ string, info fs.FileInfo) error {
+		if !info.IsDir() {
+			est.Files++
+			est.Bytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	est.Projected = est.Bytes
+	if run.cfg.Backup.Mode != config.BackupModeSnapshot {
+		est.Ratio, est.RatioFrom = run.lastRatio()
+		if est.Ratio > 0 {
+			est.Projected = int64(float64(est.Bytes) * est.Ratio)
+		}
+	}
+	return est, nil
+}
+
+// lastRatio returns the archive to content size ratio of the newest archive
+// backup that has a catalog, and its name.
+func (b *Backup) lastRatio() (float64, string) {
+	backups, _ := b.List()
+	for _, bk := range backups {
+		if bk.Snapshot {
+			continue
+		}
+		entries, err := readIndex(indexPath(bk.Path))
+		if err != nil {
+			continue
+		}
+		var raw int64
+		for _, e := range entries {
+			if !e.IsDir {
+				raw += e.Size
+			}
+		}
+		if raw > 0 {
+			return float64(bk.Size) / float64(raw), bk.Name
+		}
+	}
+	return 0, ""
+}
+
+func (b *Backup) create(ctx context.Context, opts domain.BackupOptions) (path string, err error) {
+	ctx, span := startSpan(ctx, "backup.create", "backup.mode", b.cfg.Backup.Mode)
+	ctx = withReadLimit(ctx, b.cfg.Backup.MaxReadMBps)
+	defer func() {
+		if info, statErr := os.Stat(path); statErr == nil && !info.IsDir() {
+			span.set("backup.size_bytes", info.Size())
+		}
+		span.set("backup.path", path)
+		span.finish(err)
+	}()
 	if !b.cfg.Backup.Enabled {
 		b.logger.Info("Backups are disabled")
 		return "", domain.ErrBackupsDisabled
@@ -58,17 +164,46 @@ func (b *Backup) Create(ctx context.Context) (string, error) {
 	if err := os.MkdirAll(b.cfg.Paths.Backups, 0o750); err != nil {
 		return "", fmt.Errorf("failed to create backup directory: %w", err)
 	}
+	b.relieveDiskPressure(ctx)
 
-	backupPath, err := b.createArchive(ctx)
+	create := b.createArchive
+	if b.cfg.Backup.Mode == config.BackupModeSnapshot {
+		create = b.createSnapshot
+	}
+	backupPath, err := create(ctx, opts.Tag)
 	if err != nil {
 		return "", err
 	}
 
-	b.cleanup()
+	b.record(backupPath, opts.Tag, opts.Protect)
+	if !strings.HasPrefix(backupPath, remotePrefix) {
+		b.cleanup()
+	}
 	return backupPath, nil
 }
 
-// List returns metadata for all backup archives, newest first.
+// Delete removes the named backup archive and its index entry.
+func (b *Backup) Delete(name string) error {
+	backups, err := b.List()
+	if err != nil {
+		return err
+	}
+	for _, bk := range backups {
+		if bk.Name == name {
+			if err := removeBackup(bk); err != nil {
+				return fmt.Errorf("failed to delete backup: %w", err)
+			}
+			b.forget(name)
+			return nil
+		}
+	}
+	return fmt.Errorf("backup not found: %s", name)
+}
+
+// List returns metadata for this server's backup archives and snapshots,
+// newest first: those named with its name_template prefix and those its
+// backup index records, which keeps archives named under an earlier
+// template.
 func (b *Backup) List() ([]domain.BackupInfo, error) {
 	files, err := os.ReadDir(b.cfg.Paths.Backups)
 	if err != nil {
@@ -78,9 +213,23 @@ func (b *Backup) List() ([]domain.BackupInfo, error) {
 		return nil, fmt.Errorf("failed to list backups: %w", err)
 	}
 
+	prefix := b.namePrefix()
+	indexed, err := b.index()
+	if err != nil {
+		b.logger.Warn("Backup index unreadable; listing by name_template prefix only", zap.Error(err))
+	}
 	backups := make([]domain.BackupInfo, 0, len(files))
 	for _, entry := range files {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), backupExt) {
+		if _, ok := indexed[entry.Name()]; !ok && (prefix == "" || !strings.HasPrefix(entry.Name(), prefix)) {
+			continue
+		}
+		if entry.IsDir() {
+			if snap, ok := b.snapshotInfo(entry.Name()); ok {
+				backups = append(backups, snap)
+			}
+			continue
+		}
+		if !strings.HasSuffix(entry.Name(), backupExt) {
 			continue
 		}
 		info, err := entry.Info()
@@ -113,22 +262,69 @@ func (b *Backup) HealthCheck(_ context.Context) []domain.HealthCheck {
 	} else {
 		retentionCheck = domain.HealthCheck{Name: "Backup retention", Status: domain.StatusOK, Message: fmt.Sprintf("Keeping %d backups", b.cfg.Backup.MaxBackups)}
 	}
-	return []domain.HealthCheck{
+	checks := []domain.HealthCheck{
 		domain.CheckPath("Backup directory", b.cfg.Paths.Backups),
 		retentionCheck,
+		b.state.lastSuccessCheck("Last backup", domain.OpBackup, time.Duration(b.cfg.Backup.MaxAgeHours)*time.Hour),
+	}
+	for _, dir := range b.cfg.Backup.IncludePaths {
+		checks = append(checks, domain.CheckPath("Backup include "+filepath.Base(dir), dir))
+	}
+	if dest := b.cfg.Backup.Destination; dest == config.BackupDestRemote || dest == config.BackupDestBoth {
+		checks = append(checks, b.checkRemote())
 	}
+	return checks
 }
 
-func (b *Backup) createArchive(ctx context.Context) (string, error) {
-	timestamp := time.Now().Format(backupTimeFormat)
-	backupName := backupPrefix + timestamp + backupExt
-	backupPath := filepath.Join(b.cfg.Paths.Backups, backupName)
+func (b *Backup) checkRemote() domain.HealthCheck {
+	fields := strings.Fields(b.cfg.Backup.RemoteCommand)
+	if len(fields) == 0 {
+		return domain.HealthCheck{Name: "Backup remote", Status: domain.StatusError, Message: "remote_command not set"}
+	}
+	if _, err := exec.LookPath(fields[0]); err != nil {
+		return domain.HealthCheck{Name: "Backup remote", Status: domain.StatusError, Message: fields[0] + " not found in PATH"}
+	}
+	return domain.HealthCheck{Name: "Backup remote", Status: domain.StatusOK, Message: "Streams via " + fields[0]}
+}
 
-	b.logger.Info("Creating backup", zap.String("name", backupName))
+func (b *Backup) createArchive(ctx context.Context, tag string) (string, error) {
+	backupName := b.baseName(time.Now(), tag) + backupExt
+	backupPath := filepath.Join(b.cfg.Paths.Backups, backupName)
+	dest := b.cfg.Backup.Destination
+	local := dest != config.BackupDestRemote
+	remote := dest == config.BackupDestRemote || dest == config.BackupDestBoth
+
+	b.logger.Info("Creating backup", zap.String("name", backupName), zap.String("destination", dest))
+
+	var sinks []io.Writer
+	var file *os.File
+	var upload *remoteUpload
+	abort := func() {
+		if file != nil {
+			_ = file.Close()
+			_ = os.Remove(backupPath)
+		}
+		if upload != nil {
+			upload.abort()
+		}
+	}
 
-	file, err := os.Create(backupPath) //nolint:gosec
-	if err != nil {
-		return "", err
+	if local {
+		f, err := os.Create(backupPath) //nolint:gosec
+		if err != nil {
+			return "", err
+		}
+		file = f
+		sinks = append(sinks, file)
+	}
+	if remote {
+		u, err := b.startRemoteUpload(ctx, backupName)
+		if err != nil {
+			abort()
+			return "", err
+		}
+		upload = u
+		sinks = append(sinks, upload)
 	}
 
 	gzLevel := b.cfg.Backup.CompressionLevel
@@ -136,31 +332,50 @@ func (b *Backup) createArchive(ctx context.Context) (string, error) {
 		gzLevel = gzip.DefaultCompression
 	}
 
-	gzWriter, err := gzip.NewWriterLevel(file, gzLevel)
+	gzWriter, err := gzip.NewWriterLevel(io.MultiWriter(sinks...), gzLevel)
 	if err != nil {
+		abort()
 		return "", err
 	}
 	tarWriter := tar.NewWriter(gzWriter)
 
-	if err := b.addFiles(ctx, tarWriter); err != nil {
+	entries, err := b.addFiles(ctx, tarWriter)
+	if err != nil {
 		_ = tarWriter.Close()
 		_ = gzWriter.Close()
-		_ = file.Close()
-		_ = os.Remove(backupPath)
+		abort()
 		return "", err
 	}
 
 	if err := tarWriter.Close(); err != nil {
 		_ = gzWriter.Close()
-		_ = file.Close()
-		_ = os.Remove(backupPath)
+		abort()
 		return "", fmt.Errorf("finalizing tar: %w", err)
 	}
 	if err := gzWriter.Close(); err != nil {
-		_ = file.Close()
-		_ = os.Remove(backupPath)
+		abort()
 		return "", fmt.Errorf("finalizing gzip: %w", err)
 	}
+	if upload != nil {
+		err := upload.finish()
+		upload = nil
+		switch {
+		case err != nil && !local:
+			return "", err
+		case err != nil:
+			// The local archive is complete; only the copy is missing.
+			b.logger.Warn("Backup kept locally but not uploaded", zap.String("name", backupName), zap.Error(err))
+			if b.notify != nil {
+				_ = b.notify.SendWarning(ctx, b.notify.T("notify.upload_failed_title"),
+					b.notify.T("notify.upload_failed", "name", backupName, "dir", b.cfg.Paths.Backups, "error", err))
+			}
+		default:
+			b.logger.Info("Backup streamed to remote", zap.String("name", backupName))
+		}
+	}
+	if !local {
+		return remotePrefix + backupName, nil
+	}
 	if err := file.Close(); err != nil {
 		_ = os.Remove(backupPath)
 		return "", fmt.Errorf("closing backup file: %w", err)
@@ -172,45 +387,183 @@ func (b *Backup) createArchive(ctx context.Context) (string, error) {
 		return "", errors.New("backup file empty or not created")
 	}
 
+	if err := writeIndex(indexPath(backupPath), entries); err != nil {
+		b.logger.Warn("Failed to write backup index", zap.String("name", backupName), zap.Error(err))
+	}
+
 	b.logger.Info("Backup created", zap.String("name", backupName), zap.Int64("size", info.Size()))
 	return backupPath, nil
 }
 
-func (b *Backup) addFiles(ctx context.Context, tw *tar.Writer) error {
-	return filepath.WalkDir(b.cfg.Paths.Server, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if ctx.Err() != nil {
-			return ctx.Err()
-		}
+// remotePrefix marks a Create result that exists only at the remote destination.
+const remotePrefix = "remote:"
+
+// RemoteOnly reports whether a Create result exists only at the remote
+// destination, so it cannot be restored from here.
+func RemoteOnly(path string) bool { return strings.HasPrefix(path, remotePrefix) }
+
+// remoteUpload is a running backup.remote_command consuming the archive on
+// stdin. Writes never fail: the first error is kept for finish, so a
+// command that dies early does not also fail the local archive.
+type remoteUpload struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stderr *bytes.Buffer
+	err    error
+}
 
-		relPath, err := filepath.Rel(b.cfg.Paths.Server, path)
-		if err != nil {
-			return err
+// startRemoteUpload launches backup.remote_command via sh with {name} replaced
+// by the shell-quoted archive name, e.g. `ssh host "cat > /srv/{name}"` or
+// `aws s3 cp - s3://bucket/{name}`. The name is also exported as
+// CRAFTOPS_BACKUP_NAME.
+func (b *Backup) startRemoteUpload(ctx context.Context, name string) (*remoteUpload, error) {
+	quoted := "'" + strings.ReplaceAll(name, "'", `'\''`) + "'"
+	cmdline := strings.ReplaceAll(b.cfg.Backup.RemoteCommand, "{name}", quoted)
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", cmdline) //nolint:gosec // command from user config
+	cmd.Env = append(os.Environ(), "CRAFTOPS_BACKUP_NAME="+name)
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start remote backup command: %w", err)
+	}
+	return &remoteUpload{cmd: cmd, stdin: stdin, stderr: stderr}, nil
+}
+
+func (u *remoteUpload) Write(p []byte) (int, error) {
+	if u.err == nil {
+		_, u.err = u.stdin.Write(p)
+	}
+	return len(p), nil
+}
+
+func (u *remoteUpload) finish() error {
+	_ = u.stdin.Close()
+	if err := u.cmd.Wait(); err != nil {
+		return fmt.Errorf("remote backup command failed: %w: %s", err, strings.TrimSpace(u.stderr.String()))
+	}
+	if u.err != nil {
+		return fmt.Errorf("remote backup command stopped reading: %w", u.err)
+	}
+	return nil
+}
+
+func (u *remoteUpload) abort() {
+	_ = u.stdin.Close()
+	if u.cmd.Process != nil {
+		_ = u.cmd.Process.Kill()
+	}
+	_ = u.cmd.Wait()
+}
+
+// baseName expands backup.name_template (without extension). Placeholders
+// that expand to nothing (e.g. an empty {tag}) do not leave doubled
+// separators. A name already taken in paths.backups, say by a tagged backup
+// in the same second under a template without {tag}, gets a counter.
+func (b *Backup) baseName(now time.Time, tag string) string {
+	tmpl := b.cfg.Backup.NameTemplate
+	if tmpl == "" {
+		tmpl = config.DefaultBackupNameTemplate
+	}
+	name := strings.NewReplacer(
+		"{server}", b.cfg.Server.SessionName,
+		"{timestamp}", now.Format(backupTimeFormat),
+		"{date}", now.Format("20060102"),
+		"{time}", now.Format("150405"),
+		"{tag}", tag,
+		"{mc_version}", b.cfg.Minecraft.Version,
+		"{modloader}", b.cfg.Minecraft.Modloader,
+	).Replace(tmpl)
+	name = strings.Trim(cleanName(name), "_-.")
+	taken := func(n string) bool {
+		for _, p := range []string{n, n + backupExt} {
+			if _, err := os.Lstat(filepath.Join(b.cfg.Paths.Backups, p)); err == nil {
+				return true
+			}
 		}
+		return false
+	}
+	for i, base := 2, name; taken(name); i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return name
+}
 
-		if d.Type()&fs.ModeSymlink != 0 {
-			return nil
+// namePrefix is the start of every name baseName renders for this server:
+// the template up to its first placeholder that changes between backups.
+// List only counts entries with it or in the backup index, so servers
+// sharing paths.backups under different templates never see, or prune,
+// each other's backups. Config validation keeps it non-empty; an empty
+// prefix claims no entries by name.
+func (b *Backup) namePrefix() string {
+	tmpl := b.cfg.Backup.NameTemplate
+	if tmpl == "" {
+		tmpl = config.DefaultBackupNameTemplate
+	}
+	for _, p := range []string{"{timestamp}", "{date}", "{time}", "{tag}", "{mc_version}", "{modloader}"} {
+		if i := strings.Index(tmpl, p); i >= 0 {
+			tmpl = tmpl[:i]
 		}
+	}
+	head := strings.ReplaceAll(tmpl, "{server}", b.cfg.Server.SessionName)
+	return strings.TrimLeft(cleanName(head), "_-.")
+}
 
-		info, err := d.Info()
-		if err != nil {
-			return err
+// cleanName replaces path separators and spaces in a rendered name with
+// underscores and collapses doubled separators.
+func cleanName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	for _, dup := range []string{"__", "--", ".."} {
+		for strings.Contains(name, dup) {
+			name = strings.ReplaceAll(name, dup, dup[:1])
 		}
+	}
+	return name
+}
 
-		if b.shouldExclude(relPath, d.IsDir()) {
-			if d.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
+// backupRoot is a directory archived under prefix inside the tarball.
+type backupRoot struct {
+	dir    string
+	prefix string
+}
+
+// roots returns the server directory followed by backup.include_paths, each
+// extra path archived under "_extra/<basename>".
+func (b *Backup) roots() []backupRoot {
+	roots := []backupRoot{{dir: b.cfg.Paths.Server}}
+	seen := map[string]int{}
+	for _, dir := range b.cfg.Backup.IncludePaths {
+		base := filepath.Base(filepath.Clean(dir))
+		if n := seen[base]; n > 0 {
+			base = fmt.Sprintf("%s_%d", base, n)
 		}
+		seen[filepath.Base(filepath.Clean(dir))]++
+		roots = append(roots, backupRoot{dir: dir, prefix: "_extra/" + base})
+	}
+	return roots
+}
 
+// addFiles writes the backup set into tw and returns a catalog of its entries.
+func (b *Backup) addFiles(ctx context.Context, tw *tar.Writer) ([]domain.BackupEntry, error) {
+	var entries []domain.BackupEntry
+	err := b.walkFiles(ctx, func(path, name string, info fs.FileInfo) error {
+		entries = append(entries, domain.BackupEntry{
+			Name: name, Size: info.Size(), Modified: info.ModTime(), IsDir: info.IsDir(),
+		})
 		header, err := tar.FileInfoHeader(info, "")
 		if err != nil {
 			return err
 		}
-		header.Name = relPath
+		header.Name = name
 
 		if err := tw.WriteHeader(header); err != nil {
 			return err
@@ -224,15 +577,212 @@ func (b *Backup) addFiles(ctx context.Context, tw *tar.Writer) error {
 			return err
 		}
 		defer func() { _ = f.Close() }()
-		_, err = io.Copy(tw, f)
-		return err
+		if _, err := copyContext(ctx, tw, f); err != nil {
+			return err
+		}
+		b.progress.add(name, info.Size())
+		return nil
+	})
+	return entries, err
+}
+
+// backupProgress feeds BackupOptions.Progress during one backup run. A nil
+// *backupProgress ignores updates.
+type backupProgress struct {
+	report func(domain.BackupProgress)
+	done   domain.BackupProgress
+}
+
+func (p *backupProgress) add(name string, size int64) {
+	if p == nil {
+		return
+	}
+	p.done.Files++
+	p.done.Bytes += size
+	p.done.Current = name
+	p.report(p.done)
+}
+
+// copyChunk is how much copyContext copies between checks of ctx.
+const copyChunk = 4 << 20
+
+// copyContext is io.Copy that gives up between chunks once ctx is done, so
+// a single huge region file cannot hold up cancellation. It paces itself to
+// the read limit carried by ctx, if any.
+func copyContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	limit, _ := ctx.Value(readLimitKey{}).(*readLimit)
+	var written int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+		n, err := io.CopyN(dst, src, copyChunk)
+		written += n
+		if werr := limit.wait(ctx, n); werr != nil {
+			return written, werr
+		}
+		if errors.Is(err, io.EOF) {
+			return written, nil
+		}
+		if err != nil {
+			return written, err
+		}
+	}
+}
+
+// readLimit spreads the reads of one backup run so they average at most
+// bytesPerSec, sleeping after each chunk until its share of time is up.
+type readLimit struct {
+	bytesPerSec float64
+	next        time.Time
+}
+
+type readLimitKey struct{}
+
+// withReadLimit returns ctx carrying a limit of mbps MB/s for copyContext;
+// 0 leaves ctx unlimited.
+func withReadLimit(ctx context.Context, mbps int) context.Context {
+	if mbps <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, readLimitKey{}, &readLimit{bytesPerSec: float64(mbps) * (1 << 20)})
+}
+
+// wait accounts for n bytes just read and sleeps until the next read is
+// due. Idle time is not banked, so a run never bursts above the limit. A
+// nil *readLimit never waits.
+func (l *readLimit) wait(ctx context.Context, n int64) error {
+	if l == nil || n == 0 {
+		return nil
+	}
+	if now := time.Now(); l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(float64(n) / l.bytesPerSec * float64(time.Second)))
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(l.next)):
+		return nil
+	}
+}
+
+// walkFiles calls visit for every directory and file selected for backup
+// across all roots, with name being its slash-separated path in the backup.
+// The server root itself is visited with name ".".
+func (b *Backup) walkFiles(ctx context.Context, visit func(path, name string, info fs.FileInfo) error) error {
+	for _, root := range b.roots() {
+		if err := b.walkRoot(ctx, root, visit); err != nil {
+			return fmt.Errorf("archiving %s: %w", root.dir, err)
+		}
+	}
+	return nil
+}
+
+func (b *Backup) walkRoot(ctx context.Context, root backupRoot, visit func(path, name string, info fs.FileInfo) error) error {
+	var ignore ignoreRules
+	if root.prefix == "" {
+		ignore = loadIgnore(root.dir)
+	}
+	return filepath.WalkDir(root.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		relPath, err := filepath.Rel(root.dir, path)
+		if err != nil {
+			return err
+		}
+
+		var info fs.FileInfo
+		if d.Type()&fs.ModeSymlink != 0 {
+			// Symlinked jars of the mods symlinks layout are archived
+			// as the files they point to; other links are skipped.
+			if info = b.artifactLink(path); info == nil {
+				return nil
+			}
+		} else if info, err = d.Info(); err != nil {
+			return err
+		}
+
+		// include_patterns and include_logs describe the server directory;
+		// an include_paths root is archived whole, less exclude_patterns.
+		server := root.prefix == ""
+		if b.shouldExclude(relPath, d.IsDir(), server) || ignore.excluded(filepath.ToSlash(relPath), d.IsDir()) ||
+			b.inFlight(path, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if server && !d.IsDir() && !b.shouldInclude(relPath) {
+			return nil
+		}
+
+		name := filepath.ToSlash(relPath)
+		if root.prefix != "" {
+			name = root.prefix
+			if relPath != "." {
+				name += "/" + filepath.ToSlash(relPath)
+			}
+		}
+		return visit(path, name, info)
 	})
 }
 
+// inFlight reports whether path is part of a mod update still in progress:
+// the staging directory or a partial download in the mods directory.
+func (b *Backup) inFlight(path string, isDir bool) bool {
+	if isDir {
+		return filepath.Clean(path) == filepath.Clean(modsStagingDir(b.cfg))
+	}
+	return filepath.Dir(path) == filepath.Clean(b.cfg.ModsDir()) && strings.HasPrefix(filepath.Base(path), ".tmp-")
+}
+
+// artifactLink returns the file info of the jar a symlink at path points
+// to when that jar is in the artifact cache, and nil otherwise.
+func (b *Backup) artifactLink(path string) fs.FileInfo {
+	root := artifactRoot(b.cfg)
+	if root == "" {
+		return nil
+	}
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil
+	}
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil || !within(root, target) {
+		return nil
+	}
+	info, err := os.Stat(target)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil
+	}
+	return info
+}
+
+// shouldInclude applies backup.include_patterns as an allowlist for files.
+// With no patterns configured every file is included.
+func (b *Backup) shouldInclude(relPath string) bool {
+	if len(b.cfg.Backup.IncludePatterns) == 0 {
+		return true
+	}
+	for _, pattern := range b.cfg.Backup.IncludePatterns {
+		if matched, _ := doublestar.Match(pattern, filepath.ToSlash(relPath)); matched {
+			return true
+		}
+	}
+	return false
+}
+
 // shouldExclude checks patterns using doublestar glob. Appends trailing slash
-// for directories so patterns like "cache/" match correctly.
-func (b *Backup) shouldExclude(relPath string, isDir bool) bool {
-	if !b.cfg.Backup.IncludeLogs && (relPath == "logs" || strings.HasPrefix(relPath, "logs/")) {
+// for directories so patterns like "cache/" match correctly. The server
+// directory's logs are excluded unless include_logs is set.
+func (b *Backup) shouldExclude(relPath string, isDir, server bool) bool {
+	if server && !b.cfg.Backup.IncludeLogs && (relPath == "logs" || strings.HasPrefix(relPath, "logs/")) {
 		return true
 	}
 	matchPath := relPath
@@ -252,6 +802,9 @@ func (b *Backup) shouldExclude(relPath string, isDir bool) bool {
 	return false
 }
 
+// cleanup removes the oldest backups beyond max_backups of each tag, so
+// frequent untagged backups never push out the nightly or pre-update ones.
+// Protected backups are kept and do not count toward it.
 func (b *Backup) cleanup() {
 	backups, err := b.List()
 	if err != nil {
@@ -261,11 +814,163 @@ func (b *Backup) cleanup() {
 	if len(backups) <= b.cfg.Backup.MaxBackups {
 		return
 	}
-	for _, old := range backups[b.cfg.Backup.MaxBackups:] {
-		if err := os.Remove(old.Path); err != nil {
+	index, err := b.index()
+	if err != nil {
+		b.logger.Warn("Not removing old backups: cannot tell which are protected", zap.Error(err))
+		return
+	}
+	kept := map[string]int{}
+	for _, old := range backups {
+		if b.protected(index, old.Name) {
+			continue
+		}
+		if tag := index[old.Name].Tag; kept[tag] < b.cfg.Backup.MaxBackups {
+			kept[tag]++
+			continue
+		}
+		if err := removeBackup(old); err != nil {
 			b.logger.Warn("Failed to remove old backup", zap.String("name", old.Name), zap.Error(err))
 		} else {
+			b.forget(old.Name)
 			b.logger.Info("Removed old backup", zap.String("name", old.Name))
 		}
 	}
 }
+
+// relieveDiskPressure deletes the oldest unprotected backups while the
+// backups filesystem is at least backup.prune_above_percent full, and
+// reports what it removed, or that it could not get below the threshold.
+func (b *Backup) relieveDiskPressure(ctx context.Context) {
+	limit := float64(b.cfg.Backup.PruneAbovePercent)
+	dir := b.cfg.Paths.Backups
+	fill, err := diskFill(dir)
+	if limit == 0 || err != nil || fill < limit {
+		return
+	}
+	backups, err := b.List()
+	if err != nil {
+		b.logger.Warn("Failed to list backups for disk pressure pruning", zap.Error(err))
+		return
+	}
+	index, err := b.index()
+	if err != nil {
+		b.logger.Warn("Not pruning backups: cannot tell which are protected", zap.Error(err))
+		return
+	}
+	var removed []string
+	var freed int64
+	for i := len(backups) - 1; i > 0 && fill >= limit; i-- { // oldest first, never the newest
+		bk := backups[i]
+		if b.protected(index, bk.Name) {
+			continue
+		}
+		if err := removeBackup(bk); err != nil {
+			b.logger.Warn("Failed to remove backup under disk pressure", zap.String("name", bk.Name), zap.Error(err))
+			continue
+		}
+		b.forget(bk.Name)
+		removed, freed = append(removed, bk.Name), freed+bk.Size
+		b.logger.Warn("Removed backup under disk pressure", zap.String("name", bk.Name), zap.Float64("fill_percent", fill))
+		if fill, err = diskFill(dir); err != nil {
+			break
+		}
+	}
+
+	if b.notify == nil {
+		return
+	}
+	if fill >= limit {
+		_ = b.notify.SendError(ctx, b.notify.T("notify.disk_full", "dir", dir, "percent", fmt.Sprintf("%.0f", fill),
+			"limit", b.cfg.Backup.PruneAbovePercent, "count", len(removed)))
+		return
+	}
+	_ = b.notify.SendWarning(ctx, b.notify.T("notify.pruned_title"), b.notify.T("notify.pruned",
+		"dir", dir, "limit", b.cfg.Backup.PruneAbovePercent, "count", len(removed), "size", domain.FormatSize(freed),
+		"names", strings.Join(removed, "\n")))
+}
+
+// index returns the state backup index by name.
+func (b *Backup) index() (map[string]domain.BackupRecord, error) {
+	st, err := b.state.Load()
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[string]domain.BackupRecord, len(st.Backups))
+	for _, r := range st.Backups {
+		index[r.Name] = r
+	}
+	return index, nil
+}
+
+// protected reports whether retention and disk pressure pruning must keep
+// a backup: one created with --protect or matching backup.protect_patterns.
+func (b *Backup) protected(index map[string]domain.BackupRecord, name string) bool {
+	return index[name].Protected || slices.ContainsFunc(b.cfg.Backup.ProtectPatterns, func(pattern string) bool {
+		matched, _ := doublestar.Match(pattern, name)
+		return matched
+	})
+}
+
+// record adds a new archive to the state backup index and stamps the
+// backup success. Remote-only backups ("remote:<name>") are indexed
+// without a size; the newest max_backups of them are kept, since pruning
+// the remote side is up to the remote command.
+func (b *Backup) record(path, tag string, protect bool) {
+	name, remote := strings.CutPrefix(path, remotePrefix)
+	name = filepath.Base(name)
+	var size int64
+	if info, err := os.Stat(path); err == nil && !remote {
+		size = info.Size()
+		if snap, ok := b.snapshotInfo(name); ok && info.IsDir() {
+			size = snap.Size
+		}
+	}
+	err := b.state.Update(func(st *domain.State) {
+		st.Backups = append(st.Backups, domain.BackupRecord{
+			Name:      name,
+			Path:      path,
+			CreatedAt: time.Now(),
+			Size:      size,
+			Tag:       tag,
+			Protected: protect,
+		})
+		if remote {
+			st.Backups = pruneRemoteRecords(st.Backups, max(b.cfg.Backup.MaxBackups, 1))
+		}
+		st.LastSuccess[domain.OpBackup] = time.Now()
+	})
+	if err == nil {
+		msg := fmt.Sprintf("%s (%s)", name, domain.FormatSize(size))
+		if remote {
+			msg = path
+		}
+		err = b.state.RecordEvent(domain.OpBackup, msg)
+	}
+	if err != nil {
+		b.logger.Warn("Failed to record backup in state", zap.Error(err))
+	}
+}
+
+// pruneRemoteRecords drops all but the newest keep remote-only records.
+func pruneRemoteRecords(records []domain.BackupRecord, keep int) []domain.BackupRecord {
+	remote := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(records[i].Path, remotePrefix) {
+			continue
+		}
+		if remote++; remote > keep {
+			records = slices.Delete(records, i, i+1)
+		}
+	}
+	return records
+}
+
+// forget drops a removed archive from the state backup index.
+func (b *Backup) forget(name string) {
+	err := b.state.Update(func(st *domain.State) {
+		st.Backups = slices.DeleteFunc(st.Backups, func(r domain.BackupRecord) bool { return r.Name == name })
+	})
+	if err != nil {
+		b.logger.Warn("Failed to update backup index", zap.Error(err))
+	}
+}
@@ -3,9 +3,15 @@ package service_test
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -122,6 +128,123 @@ func TestBackup_Retention(t *testing.T) {
 	}
 }
 
+func TestBackup_RetentionKeepsProtected(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.MaxBackups = 1
+	svc := service.NewBackup(cfg, logger)
+	writeFile(t, cfg.Paths.Server, "x.txt", "x")
+
+	age := func(p string, d time.Duration) {
+		ts := time.Now().Add(-d)
+		_ = os.Chtimes(p, ts, ts)
+	}
+	protected, err := svc.CreateWith(ctx, domain.BackupOptions{Protect: true})
+	if err != nil {
+		t.Fatalf("CreateWith: %v", err)
+	}
+	age(protected, 2*time.Hour)
+	var preUpdate string
+	for i := range 2 {
+		if preUpdate, err = svc.CreatePreUpdate(ctx); err != nil {
+			t.Fatalf("CreatePreUpdate: %v", err)
+		}
+		age(preUpdate, time.Duration(2-i)*time.Hour-time.Minute)
+	}
+	var newest string
+	for i := range 3 {
+		if newest, err = svc.Create(ctx); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		age(newest, time.Duration(3-i)*time.Minute)
+	}
+
+	backups, err := svc.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, bk := range backups {
+		names = append(names, bk.Name)
+	}
+	slices.Sort(names)
+	want := []string{filepath.Base(protected), filepath.Base(preUpdate), filepath.Base(newest)}
+	slices.Sort(want)
+	if !slices.Equal(names, want) {
+		t.Errorf("backups after retention = %v, want the protected one and the newest of each tag %v", names, want)
+	}
+}
+
+func TestBackup_DiskPressurePrunes(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.MaxBackups = 10
+	cfg.Backup.PruneAbovePercent = 70
+	cfg.Backup.ProtectPatterns = []string{"*_keep*"}
+	cfg.Backup.NameTemplate = "bk_{timestamp}"
+	var alerts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Embeds []struct{ Title, Description string } `json:"embeds"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		for _, e := range body.Embeds {
+			alerts = append(alerts, e.Title+": "+e.Description)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	cfg.Notifications.DiscordWebhook = srv.URL
+	// Every backup takes a fifth of the volume.
+	t.Cleanup(service.SetDiskFill(func(dir string) float64 {
+		archives, _ := filepath.Glob(filepath.Join(dir, "*.tar.gz"))
+		return float64(len(archives)) * 20
+	}))
+
+	names := []string{"bk_a_tagged.tar.gz", "bk_b_keep.tar.gz", "bk_c.tar.gz", "bk_d.tar.gz", "bk_e.tar.gz"}
+	now := time.Now()
+	for i, name := range names {
+		p := writeFile(t, cfg.Paths.Backups, name, name)
+		ts := now.Add(time.Duration(i-len(names)) * time.Hour)
+		_ = os.Chtimes(p, ts, ts)
+	}
+	_ = service.NewStateStore(cfg).Update(func(st *domain.State) {
+		st.Backups = append(st.Backups, domain.BackupRecord{Name: "bk_a_tagged.tar.gz", Tag: "tagged", Protected: true})
+	})
+	_ = os.WriteFile(filepath.Join(cfg.Paths.Server, "x.txt"), []byte("x"), 0o600)
+	svc := service.NewBackup(cfg, logger)
+	svc.UseNotification(service.NewNotification(cfg, logger))
+
+	created, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	backups, _ := svc.List()
+	var left []string
+	for _, bk := range backups {
+		left = append(left, bk.Name)
+	}
+	slices.Sort(left)
+	want := []string{"bk_a_tagged.tar.gz", "bk_b_keep.tar.gz", "bk_e.tar.gz", filepath.Base(created)}
+	slices.Sort(want)
+	if !slices.Equal(left, want) {
+		t.Errorf("backups left = %v, want %v", left, want)
+	}
+	if len(alerts) != 1 || !strings.Contains(alerts[0], "bk_c.tar.gz") || !strings.Contains(alerts[0], "bk_d.tar.gz") {
+		t.Errorf("alerts = %q", alerts)
+	}
+
+	// Nothing left to prune: the next backup still runs, with an alert.
+	alerts = nil
+	cfg.Backup.ProtectPatterns = []string{"*"}
+	if _, err := svc.Create(ctx); err != nil {
+		t.Fatalf("second Create: %v", err)
+	}
+	if len(alerts) != 1 || !strings.Contains(alerts[0], "protected") {
+		t.Errorf("alerts = %q, want one about protected backups", alerts)
+	}
+}
+
 func TestBackup_HealthCheck_Disabled(t *testing.T) {
 	cfg, logger, ctx := setup(t)
 	cfg.Backup.Enabled = false
@@ -157,6 +280,77 @@ func TestBackup_HealthCheck_Enabled(t *testing.T) {
 	}
 }
 
+func TestBackup_HealthCheck_LastBackup(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.MaxAgeHours = 24
+	svc := service.NewBackup(cfg, logger)
+	last := func() domain.HealthCheck {
+		for _, c := range svc.HealthCheck(ctx) {
+			if c.Name == "Last backup" {
+				return c
+			}
+		}
+		t.Fatal("expected 'Last backup' health check")
+		return domain.HealthCheck{}
+	}
+
+	if c := last(); c.Status != domain.StatusWarn {
+		t.Errorf("no backup yet: got %s (%s), want WARN", c.Status, c.Message)
+	}
+	store := service.NewStateStore(cfg)
+	_ = store.Update(func(st *domain.State) { st.LastSuccess[domain.OpBackup] = time.Now().Add(-30 * time.Hour) })
+	if c := last(); c.Status != domain.StatusWarn || !strings.Contains(c.Message, "30h ago") {
+		t.Errorf("stale backup: got %s (%s)", c.Status, c.Message)
+	}
+	_ = store.RecordSuccess(domain.OpBackup)
+	if c := last(); c.Status != domain.StatusOK {
+		t.Errorf("fresh backup: got %s (%s), want OK", c.Status, c.Message)
+	}
+}
+
+func TestBackup_CreateWith_OptionsAndProgress(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	writeFile(t, cfg.Paths.Server, "world/level.dat", "level")
+	writeFile(t, cfg.Paths.Server, "server.properties", "motd=hi")
+	svc := service.NewBackup(cfg, logger)
+
+	var seen []domain.BackupProgress
+	path, err := svc.CreateWith(ctx, domain.BackupOptions{
+		Tag:              "pre",
+		Include:          []string{"world/**"},
+		CompressionLevel: 1,
+		Progress:         func(p domain.BackupProgress) { seen = append(seen, p) },
+	})
+	if err != nil {
+		t.Fatalf("CreateWith: %v", err)
+	}
+	if len(seen) != 1 || seen[0].Current != "world/level.dat" || seen[0].Bytes != 5 {
+		t.Errorf("progress = %+v, want one update for world/level.dat", seen)
+	}
+	entries, err := svc.Contents(ctx, filepath.Base(path), "server.properties")
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Include override ignored: %v (%v)", entries, err)
+	}
+	if len(cfg.Backup.IncludePatterns) != 0 || cfg.Backup.CompressionLevel == 1 {
+		t.Error("CreateWith modified the config")
+	}
+}
+
+func TestCopyContext_StopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n, err := service.CopyContext(ctx, io.Discard, strings.NewReader("data"))
+	if !errors.Is(err, context.Canceled) || n != 0 {
+		t.Errorf("CopyContext = %d, %v; want context.Canceled", n, err)
+	}
+	n, err = service.CopyContext(context.Background(), io.Discard, strings.NewReader(strings.Repeat("x", 5<<20)))
+	if err != nil || n != 5<<20 {
+		t.Errorf("CopyContext = %d, %v", n, err)
+	}
+}
+
 func TestBackup_Create_InvalidServerDir(t *testing.T) {
 	cfg, logger, ctx := setup(t)
 	cfg.Backup.Enabled = true
@@ -238,3 +432,266 @@ func TestBackup_ExcludePatterns(t *testing.T) {
 		t.Error("data.txt should be present in archive")
 	}
 }
+
+func TestBackup_MaxReadMBps(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.MaxReadMBps = 1
+	writeFile(t, cfg.Paths.Server, "world/a.dat", strings.Repeat("a", 256<<10))
+	writeFile(t, cfg.Paths.Server, "world/b.dat", strings.Repeat("b", 256<<10))
+
+	start := time.Now()
+	if _, err := service.NewBackup(cfg, logger).Create(ctx); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// 512 KiB at 1 MB/s paces the run to about half a second.
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("throttled backup took %s", elapsed)
+	}
+}
+
+func TestBackup_Estimate(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.ExcludePatterns = []string{"cache/**"}
+	writeFile(t, cfg.Paths.Server, "world/level.dat", strings.Repeat("a", 4000))
+	writeFile(t, cfg.Paths.Server, "world/region/r.0.0.mca", strings.Repeat("b", 6000))
+	writeFile(t, cfg.Paths.Server, "cache/big.bin", strings.Repeat("c", 50000))
+	svc := service.NewBackup(cfg, logger)
+
+	est, err := svc.Estimate(ctx, domain.BackupOptions{})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if est.Files != 2 || est.Bytes != 10000 || est.Ratio != 0 || est.Projected != 10000 {
+		t.Errorf("first estimate = %+v", est)
+	}
+	if backups, _ := svc.List(); len(backups) != 0 {
+		t.Fatalf("Estimate wrote a backup: %v", backups)
+	}
+
+	path, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	est, err = svc.Estimate(ctx, domain.BackupOptions{Include: []string{"world/region/**"}})
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if est.Files != 1 || est.Bytes != 6000 || est.RatioFrom != filepath.Base(path) {
+		t.Errorf("second estimate = %+v", est)
+	}
+	if est.Ratio <= 0 || est.Ratio >= 1 || est.Projected >= est.Bytes {
+		t.Errorf("repetitive files should project a smaller archive: %+v", est)
+	}
+}
+
+func TestBackup_IgnoreFile(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	writeFile(t, cfg.Paths.Server, ".craftopsignore", `# dynmap renders are rebuilt on demand
+plugins/dynmap/web/tiles/
+*.mcpr
+!keep.mcpr
+/debug
+\#notes
+`)
+	for _, f := range []string{
+		"plugins/dynmap/web/tiles/world/0_0.png", "plugins/dynmap/configuration.txt",
+		"replays/a.mcpr", "replays/keep.mcpr", "debug/profile.txt", "world/debug/x.dat",
+		"#notes", "world/level.dat",
+	} {
+		writeFile(t, cfg.Paths.Server, f, "x")
+	}
+	svc := service.NewBackup(cfg, logger)
+	path, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	entries, err := svc.Contents(ctx, filepath.Base(path), "")
+	if err != nil {
+		t.Fatalf("Contents: %v", err)
+	}
+	got := map[string]bool{}
+	for _, e := range entries {
+		got[e.Name] = true
+	}
+	for _, name := range []string{".craftopsignore", "plugins/dynmap/configuration.txt", "replays/keep.mcpr", "world/debug/x.dat", "world/level.dat"} {
+		if !got[name] {
+			t.Errorf("%s missing from backup", name)
+		}
+	}
+	for _, name := range []string{"plugins/dynmap/web/tiles", "replays/a.mcpr", "debug", "#notes"} {
+		if got[name] {
+			t.Errorf("%s should be ignored", name)
+		}
+	}
+}
+
+func TestBackup_NameTemplate(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Server.SessionName = "survival"
+	cfg.Minecraft.Version = "1.21.1"
+	writeFile(t, cfg.Paths.Server, "level.dat", "x")
+	svc := service.NewBackup(cfg, logger)
+
+	cfg.Backup.NameTemplate = "{server}_{tag}_{mc_version}_{timestamp}"
+	path, err := svc.CreateTagged(ctx, "nightly")
+	if err != nil {
+		t.Fatalf("CreateTagged: %v", err)
+	}
+	if name := filepath.Base(path); !strings.HasPrefix(name, "survival_nightly_1.21.1_") || !strings.HasSuffix(name, ".tar.gz") {
+		t.Errorf("unexpected tagged name %q", name)
+	}
+
+	cfg.Backup.NameTemplate = "{tag}_{server}_{timestamp}"
+	path, err = svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if name := filepath.Base(path); !strings.HasPrefix(name, "survival_2") {
+		t.Errorf("empty tag should not leave separators, got %q", name)
+	}
+}
+
+func TestBackup_ListOnlyOwnPrefix(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.MaxBackups = 1
+	cfg.Backup.NameTemplate = "{server}_{timestamp}"
+	cfg.Server.SessionName = "survival"
+	writeFile(t, cfg.Paths.Server, "level.dat", "x")
+	other := []string{"creative_20250101_000000.tar.gz", "creative_20250102_000000.tar.gz"}
+	for _, name := range other {
+		writeFile(t, cfg.Paths.Backups, name, name)
+	}
+	svc := service.NewBackup(cfg, logger)
+
+	created, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	backups, err := svc.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 || backups[0].Name != filepath.Base(created) {
+		t.Errorf("List = %v, want only %s", backups, filepath.Base(created))
+	}
+	for _, name := range other {
+		if _, err := os.Stat(filepath.Join(cfg.Paths.Backups, name)); err != nil {
+			t.Errorf("retention removed another server's backup %s: %v", name, err)
+		}
+	}
+
+	// A new template keeps the archives the index records under the old one.
+	cfg.Backup.NameTemplate = "mc_{server}_{timestamp}"
+	if backups, err = svc.List(); err != nil || len(backups) != 1 || backups[0].Name != filepath.Base(created) {
+		t.Errorf("List after a template change = %v, %v; want %s", backups, err, filepath.Base(created))
+	}
+}
+
+func TestBackup_Create_RemoteStream(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	remoteDir := t.TempDir()
+	cfg.Backup.Enabled = true
+	cfg.Backup.Destination = "remote"
+	cfg.Backup.RemoteCommand = "cat > " + remoteDir + "/{name}"
+	writeFile(t, cfg.Paths.Server, "level.dat", "world")
+
+	path, err := service.NewBackup(cfg, logger).Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	name := strings.TrimPrefix(path, "remote:")
+	if name == path {
+		t.Fatalf("expected remote: result, got %q", path)
+	}
+	if info, err := os.Stat(filepath.Join(remoteDir, name)); err != nil || info.Size() == 0 {
+		t.Errorf("remote archive missing or empty: %v", err)
+	}
+	if entries, _ := os.ReadDir(cfg.Paths.Backups); len(entries) != 0 {
+		t.Errorf("remote-only backup should not write locally, found %d files", len(entries))
+	}
+	st, err := service.NewStateStore(cfg).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.LastSuccess[domain.OpBackup].IsZero() {
+		t.Error("remote-only backup did not record a backup success")
+	}
+	if len(st.Backups) != 1 || st.Backups[0].Name != name || st.Backups[0].Path != path {
+		t.Errorf("backup index = %+v, want the remote backup", st.Backups)
+	}
+}
+
+func TestBackup_Create_RemoteFailureKeepsLocal(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.Destination = "both"
+	cfg.Backup.RemoteCommand = "echo denied >&2; exit 3"
+	writeFile(t, cfg.Paths.Server, "level.dat", "world")
+
+	path, err := service.NewBackup(cfg, logger).Create(ctx)
+	if err != nil {
+		t.Fatalf("a failed upload should not fail the local backup: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Errorf("local archive missing after a failed upload: %v", err)
+	}
+	if names := archiveNames(t, path); !slices.Contains(names, "level.dat") {
+		t.Errorf("local archive incomplete: %v", names)
+	}
+}
+
+func TestBackup_IncludePathsAndPatterns(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	proxyDir := filepath.Join(t.TempDir(), "velocity")
+	writeFile(t, proxyDir, "velocity.toml", "bind")
+	writeFile(t, proxyDir, "logs/latest.log", "proxy log")
+	writeFile(t, proxyDir, "plugins/x.tmp", "tmp")
+	writeFile(t, cfg.Paths.Server, "world/level.dat", "w")
+	writeFile(t, cfg.Paths.Server, "server.properties", "p")
+	writeFile(t, cfg.Paths.Server, "logs/latest.log", "server log")
+	cfg.Backup.IncludePaths = []string{proxyDir}
+	cfg.Backup.IncludePatterns = []string{"world/**", "*.toml", "logs/**"}
+	cfg.Backup.ExcludePatterns = []string{"**/*.tmp"}
+
+	path, err := service.NewBackup(cfg, logger).Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	names := archiveNames(t, path)
+	has := func(want string) bool { return slices.Contains(names, want) }
+	if !has("world/level.dat") {
+		t.Errorf("allowlisted world file missing: %v", names)
+	}
+	if !has("_extra/velocity/velocity.toml") {
+		t.Errorf("include path file missing: %v", names)
+	}
+	if has("server.properties") {
+		t.Errorf("file outside include_patterns archived: %v", names)
+	}
+	if has("logs/latest.log") {
+		t.Errorf("server logs archived without include_logs: %v", names)
+	}
+
+	// An include path is archived whole, logs included, whatever the
+	// server-relative patterns say; exclude_patterns still apply within it.
+	cfg.Backup.IncludePatterns = []string{"world/**"}
+	path, err = service.NewBackup(cfg, logger).Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	names = archiveNames(t, path)
+	for _, want := range []string{"_extra/velocity/velocity.toml", "_extra/velocity/logs/latest.log"} {
+		if !has(want) {
+			t.Errorf("%s missing with include_patterns = [\"world/**\"]: %v", want, names)
+		}
+	}
+	if has("_extra/velocity/plugins/x.tmp") {
+		t.Errorf("exclude_patterns not applied to the include path: %v", names)
+	}
+}
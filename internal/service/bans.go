@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// banTimeFormat is how the server writes created and expires.
+const banTimeFormat = "2006-01-02 15:04:05 -0700"
+
+// Bans manages a server's banned-players.json and banned-ips.json. While
+// the server runs, changes go through its ban and pardon commands so that
+// they take effect at once and are not overwritten; otherwise the files are
+// edited directly.
+type Bans struct {
+	cfg    *config.Config
+	logger *zap.Logger
+	client *http.Client
+	server *Server
+}
+
+// NewBans creates the ban list manager for srv.
+func NewBans(cfg *config.Config, logger *zap.Logger, srv *Server) *Bans {
+	client, err := newHTTPClient(cfg, time.Duration(cfg.Mods.Timeout)*time.Second)
+	if err != nil {
+		logger.Warn("Network settings not applied to ban list client", zap.Error(err))
+	}
+	return &Bans{cfg: cfg, logger: logger, client: client, server: srv}
+}
+
+// List reads the server's player and IP bans.
+func (b *Bans) List() (*domain.BanList, error) {
+	list := &domain.BanList{}
+	for file, entries := range map[string]*[]domain.BanEntry{
+		"banned-players.json": &list.Players,
+		"banned-ips.json":     &list.IPs,
+	} {
+		data, err := os.ReadFile(filepath.Join(b.cfg.Paths.Server, file)) //nolint:gosec // server dir from config
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, entries); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+	}
+	return list, nil
+}
+
+// Add bans target, a player name or an IP address. On a stopped server the
+// name is resolved to its UUID first.
+func (b *Bans) Add(ctx context.Context, target, reason string) (*domain.BanEntry, error) {
+	entry := domain.BanEntry{
+		Created: time.Now().Format(banTimeFormat),
+		Source:  "craftops",
+		Expires: "forever",
+		Reason:  reason,
+	}
+	if entry.Reason == "" {
+		entry.Reason = "Banned by an operator."
+	}
+	if net.ParseIP(target) != nil {
+		entry.IP = target
+	} else if !playerNamePattern.MatchString(target) {
+		return nil, fmt.Errorf("%q is neither a player name nor an IP address", target)
+	} else {
+		entry.Name = target
+	}
+	added, err := b.Apply(ctx, domain.BanListOf(entry))
+	if err != nil {
+		return nil, err
+	}
+	if len(added) == 0 {
+		return nil, fmt.Errorf("%s is already banned", target)
+	}
+	return &entry, nil
+}
+
+// Remove pardons target, a player name or UUID or an IP address.
+func (b *Bans) Remove(ctx context.Context, target string) error {
+	list, err := b.List()
+	if err != nil {
+		return err
+	}
+	isIP := net.ParseIP(target) != nil
+	entries, file, command := &list.Players, "banned-players.json", "pardon"
+	if isIP {
+		entries, file, command = &list.IPs, "banned-ips.json", "pardon-ip"
+	}
+	kept := (*entries)[:0]
+	var found *domain.BanEntry
+	for _, e := range *entries {
+		if found == nil && (strings.EqualFold(e.Target(), target) || !isIP && strings.EqualFold(e.UUID, target)) {
+			found = &e
+			continue
+		}
+		kept = append(kept, e)
+	}
+	if found == nil {
+		return fmt.Errorf("%s: %w", target, domain.ErrNotBanned)
+	}
+	if b.cfg.DryRun {
+		b.logger.Info("Dry run: Would pardon", zap.String("target", found.Target()))
+		return nil
+	}
+	live, err := b.live(ctx)
+	if err != nil {
+		return err
+	}
+	if live {
+		return b.server.SendConsole(ctx, command+" "+found.Target())
+	}
+	*entries = kept
+	return b.write(file, *entries)
+}
+
+// Apply adds the bans in list this server lacks, matching players by UUID
+// (or name) and IPs by address, and returns their targets. Players are
+// resolved to UUIDs before being written to a stopped server's file.
+func (b *Bans) Apply(ctx context.Context, list *domain.BanList) ([]string, error) {
+	current, err := b.List()
+	if err != nil {
+		return nil, err
+	}
+	banned := map[string]bool{}
+	for _, e := range current.Players {
+		banned[strings.ToLower(e.UUID)], banned[strings.ToLower(e.Name)] = true, true
+	}
+	for _, e := range current.IPs {
+		banned[e.IP] = true
+	}
+	var players, ips []domain.BanEntry
+	for _, e := range list.Players {
+		if !banned[strings.ToLower(e.Name)] && (e.UUID == "" || !banned[strings.ToLower(e.UUID)]) {
+			players, banned[strings.ToLower(e.Name)] = append(players, e), true
+		}
+	}
+	for _, e := range list.IPs {
+		if !banned[e.IP] {
+			ips, banned[e.IP] = append(ips, e), true
+		}
+	}
+	var added []string
+	for _, e := range append(players, ips...) {
+		added = append(added, e.Target())
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	if b.cfg.DryRun {
+		b.logger.Info("Dry run: Would ban", zap.Strings("targets", added))
+		return added, nil
+	}
+
+	live, err := b.live(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if live {
+		for _, e := range players {
+			if err := b.server.SendConsole(ctx, strings.TrimSpace("ban "+e.Name+" "+e.Reason)); err != nil {
+				return nil, err
+			}
+		}
+		for _, e := range ips {
+			if err := b.server.SendConsole(ctx, strings.TrimSpace("ban-ip "+e.IP+" "+e.Reason)); err != nil {
+				return nil, err
+			}
+		}
+		b.logger.Info("Bans sent to the server console", zap.Strings("targets", added))
+		return added, nil
+	}
+
+	if len(players) > 0 {
+		var missing []string
+		for _, e := range players {
+			if e.UUID == "" {
+				missing = append(missing, e.Name)
+			}
+		}
+		resolved, unresolved, err := resolvePlayers(ctx, b.client, b.cfg.Paths.Server, missing, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(unresolved) > 0 {
+			return nil, fmt.Errorf("no Minecraft account named %s", strings.Join(unresolved, ", "))
+		}
+		uuids := map[string]playerEntry{}
+		for _, p := range resolved {
+			uuids[strings.ToLower(p.Name)] = p
+		}
+		for i, e := range players {
+			if p, ok := uuids[strings.ToLower(e.Name)]; ok && e.UUID == "" {
+				players[i].UUID, players[i].Name = p.UUID, p.Name
+			}
+		}
+		if err := b.write("banned-players.json", append(current.Players, players...)); err != nil {
+			return nil, err
+		}
+	}
+	if len(ips) > 0 {
+		if err := b.write("banned-ips.json", append(current.IPs, ips...)); err != nil {
+			return nil, err
+		}
+	}
+	b.logger.Info("Ban lists updated", zap.Strings("targets", added))
+	return added, nil
+}
+
+// MergeBans combines ban lists, keeping the first entry for each player
+// (by UUID, or name) and IP.
+func MergeBans(lists ...*domain.BanList) *domain.BanList {
+	merged := &domain.BanList{}
+	seen := map[string]bool{}
+	for _, l := range lists {
+		for _, e := range l.Players {
+			key := strings.ToLower(e.UUID)
+			if key == "" {
+				key = strings.ToLower(e.Name)
+			}
+			if !seen[key] {
+				seen[key] = true
+				merged.Players = append(merged.Players, e)
+			}
+		}
+		for _, e := range l.IPs {
+			if !seen[e.IP] {
+				seen[e.IP] = true
+				merged.IPs = append(merged.IPs, e)
+			}
+		}
+	}
+	return merged
+}
+
+// live reports whether the server runs, so that bans must go through its
+// console. A server running outside screen has no console to use and would
+// overwrite edited files, so it is an error.
+func (b *Bans) live(ctx context.Context) (bool, error) {
+	status, err := b.server.Status(ctx)
+	if err != nil {
+		return false, err
+	}
+	if status.Unmanaged {
+		return false, fmt.Errorf("%w: run `craftops server adopt` before changing bans", domain.ErrServerUnmanaged)
+	}
+	return status.IsRunning, nil
+}
+
+func (b *Bans) write(file string, entries []domain.BanEntry) error {
+	if entries == nil {
+		entries = []domain.BanEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(b.cfg.Paths.Server, file), append(data, '\n'), 0o640)
+}
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestBans(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Server, "server.properties", "online-mode=false\n")
+	bans := service.NewBans(cfg, logger, service.NewServer(cfg, logger))
+
+	if _, err := bans.Add(ctx, "Griefer", "tnt"); err != nil {
+		t.Fatalf("Add player: %v", err)
+	}
+	if _, err := bans.Add(ctx, "203.0.113.7", ""); err != nil {
+		t.Fatalf("Add IP: %v", err)
+	}
+	if _, err := bans.Add(ctx, "griefer", ""); err == nil {
+		t.Error("banned the same player twice")
+	}
+	if _, err := bans.Add(ctx, "not a name", ""); err == nil {
+		t.Error("accepted an invalid target")
+	}
+	list, err := bans.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list.Players) != 1 || list.Players[0].UUID != service.OfflineUUID("Griefer") || list.Players[0].Reason != "tnt" {
+		t.Errorf("players = %+v", list.Players)
+	}
+	if len(list.IPs) != 1 || list.IPs[0].IP != "203.0.113.7" {
+		t.Errorf("ips = %+v", list.IPs)
+	}
+
+	// A second server gets the first one's bans on top of its own.
+	other, _, _ := setup(t)
+	writeFile(t, other.Paths.Server, "banned-players.json",
+		`[{"uuid":"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa","name":"Spammer","created":"2024-01-01 00:00:00 +0000","source":"Server","expires":"forever","reason":"spam"}]`)
+	otherBans := service.NewBans(other, logger, service.NewServer(other, logger))
+	otherList, err := otherBans.List()
+	if err != nil {
+		t.Fatalf("List other: %v", err)
+	}
+	merged := service.MergeBans(list, otherList)
+	added, err := otherBans.Apply(ctx, merged)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if !slices.Equal(added, []string{"Griefer", "203.0.113.7"}) {
+		t.Errorf("added = %v", added)
+	}
+	if added, _ := otherBans.Apply(ctx, merged); len(added) != 0 {
+		t.Errorf("second Apply added %v", added)
+	}
+	if added, _ := bans.Apply(ctx, merged); !slices.Equal(added, []string{"Spammer"}) {
+		t.Errorf("Apply to first server added %v", added)
+	}
+
+	if err := bans.Remove(ctx, "griefer"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if err := bans.Remove(ctx, "griefer"); !errors.Is(err, domain.ErrNotBanned) {
+		t.Errorf("second Remove = %v, want ErrNotBanned", err)
+	}
+	if err := bans.Remove(ctx, "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"); err != nil {
+		t.Errorf("Remove by UUID: %v", err)
+	}
+	if list, _ = bans.List(); len(list.Players) != 0 || len(list.IPs) != 1 {
+		t.Errorf("after Remove: %+v", list)
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"craftops/internal/domain"
+)
+
+// indexPath is the catalog sidecar stored next to an archive.
+func indexPath(archive string) string {
+	return strings.TrimSuffix(archive, backupExt) + ".index.json"
+}
+
+func writeIndex(path string, entries []domain.BackupEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// removeBackup deletes a backup and its catalog sidecar.
+func removeBackup(bk domain.BackupInfo) error {
+	if err := os.RemoveAll(bk.Path); err != nil {
+		return err
+	}
+	if !bk.Snapshot {
+		_ = os.Remove(indexPath(bk.Path))
+	}
+	return nil
+}
+
+// find returns the backup called name.
+func (b *Backup) find(name string) (domain.BackupInfo, error) {
+	backups, err := b.List()
+	if err != nil {
+		return domain.BackupInfo{}, err
+	}
+	for _, bk := range backups {
+		if bk.Name == name {
+			return bk, nil
+		}
+	}
+	return domain.BackupInfo{}, fmt.Errorf("backup not found: %s", name)
+}
+
+// Contents lists entries of the named backup under prefix ("" for all). It
+// reads the catalog written at create time and falls back to scanning the
+// archive when the catalog is missing.
+func (b *Backup) Contents(ctx context.Context, name, prefix string) ([]domain.BackupEntry, error) {
+	bk, err := b.find(name)
+	if err != nil {
+		return nil, err
+	}
+	prefix = strings.Trim(path.Clean("/"+filepath.ToSlash(prefix)), "/")
+
+	var entries []domain.BackupEntry
+	switch {
+	case bk.Snapshot:
+		entries, err = scanSnapshot(ctx, bk.Path)
+	default:
+		entries, err = readIndex(indexPath(bk.Path))
+		if err != nil {
+			entries, err = scanArchive(ctx, bk.Path, nil)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	matched := entries[:0]
+	for _, e := range entries {
+		if e.Name != "." && underPrefix(e.Name, prefix) {
+			matched = append(matched, e)
+		}
+	}
+	return matched, nil
+}
+
+// Extract copies entries at target (a file, or a directory and everything
+// under it) from the named backup into destDir, preserving relative paths.
+// It returns the number of files written.
+func (b *Backup) Extract(ctx context.Context, name, target, destDir string) (int, error) {
+	bk, err := b.find(name)
+	if err != nil {
+		return 0, err
+	}
+	target = strings.Trim(path.Clean("/"+filepath.ToSlash(target)), "/")
+	if target == "" {
+		return 0, errors.New("extract path must not be empty")
+	}
+	n, err := b.extract(ctx, bk, target, destDir)
+	if err != nil {
+		return n, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("%s not found in backup %s", target, name)
+	}
+	return n, nil
+}
+
+// extract writes the files of bk under target into destDir. An empty
+// target selects the whole server directory, leaving out include_paths.
+func (b *Backup) extract(ctx context.Context, bk domain.BackupInfo, target, destDir string) (int, error) {
+	var n int
+	write := func(name string, mode fs.FileMode, r io.Reader) error {
+		dst := filepath.Join(destDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+			return err
+		}
+		// A jar in place may link into the artifact cache; replace, not overwrite it.
+		if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		f, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600) //nolint:gosec
+		if err != nil {
+			return err
+		}
+		if _, err := copyContext(ctx, f, r); err != nil {
+			_ = f.Close()
+			return err
+		}
+		n++
+		return f.Close()
+	}
+	selected := func(name string) bool {
+		if target == "" {
+			return name != snapshotMarker && !underPrefix(name, "_extra")
+		}
+		return underPrefix(name, target)
+	}
+
+	var err error
+	if bk.Snapshot {
+		err = filepath.WalkDir(filepath.Join(bk.Path, filepath.FromSlash(target)), func(p string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			rel, _ := filepath.Rel(bk.Path, p)
+			if !selected(filepath.ToSlash(rel)) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			src, err := os.Open(p) //nolint:gosec // path inside snapshot directory
+			if err != nil {
+				return err
+			}
+			defer func() { _ = src.Close() }()
+			return write(filepath.ToSlash(rel), info.Mode(), src)
+		})
+	} else {
+		_, err = scanArchive(ctx, bk.Path, func(hdr *tar.Header, r io.Reader) error {
+			name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+			if hdr.Typeflag != tar.TypeReg || !selected(name) {
+				return nil
+			}
+			return write(name, hdr.FileInfo().Mode(), r)
+		})
+	}
+	return n, err
+}
+
+func underPrefix(name, prefix string) bool {
+	return prefix == "" || name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+func readIndex(path string) ([]domain.BackupEntry, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // sidecar next to archive
+	if err != nil {
+		return nil, err
+	}
+	var entries []domain.BackupEntry
+	return entries, json.Unmarshal(data, &entries)
+}
+
+// scanArchive lists a .tar.gz, calling visit (if set) for each entry with a
+// reader positioned at its content.
+func scanArchive(ctx context.Context, archive string, visit func(*tar.Header, io.Reader) error) ([]domain.BackupEntry, error) {
+	f, err := os.Open(archive) //nolint:gosec // path from backup listing
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filepath.Base(archive), err)
+	}
+	tr := tar.NewReader(gz)
+
+	var entries []domain.BackupEntry
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", filepath.Base(archive), err)
+		}
+		entries = append(entries, domain.BackupEntry{
+			Name:     hdr.Name,
+			Size:     hdr.Size,
+			Modified: hdr.ModTime,
+			IsDir:    hdr.Typeflag == tar.TypeDir,
+		})
+		if visit != nil {
+			if err := visit(hdr, tr); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+func scanSnapshot(ctx context.Context, dir string) ([]domain.BackupEntry, error) {
+	var entries []domain.BackupEntry
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		rel, _ := filepath.Rel(dir, p)
+		if rel == snapshotMarker {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, domain.BackupEntry{
+			Name:     filepath.ToSlash(rel),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+			IsDir:    d.IsDir(),
+		})
+		return nil
+	})
+	return entries, err
+}
@@ -0,0 +1,126 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestBackup_ContentsAndExtract(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	writeFile(t, cfg.Paths.Server, "world/region/r.0.0.mca", "region")
+	writeFile(t, cfg.Paths.Server, "server.properties", "motd=hi")
+	svc := service.NewBackup(cfg, logger)
+
+	path, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	name := filepath.Base(path)
+
+	entries, err := svc.Contents(ctx, name, "world/")
+	if err != nil {
+		t.Fatalf("Contents: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if len(names) != 3 || names[len(names)-1] != "world/region/r.0.0.mca" {
+		t.Errorf("unexpected world entries: %v", names)
+	}
+
+	// Without the index the archive is scanned instead.
+	_ = os.Remove(strings.TrimSuffix(path, ".tar.gz") + ".index.json")
+	all, err := svc.Contents(ctx, name, "")
+	if err != nil || len(all) != 4 {
+		t.Fatalf("Contents by scan = %d entries (%v), want 4", len(all), err)
+	}
+
+	dest := t.TempDir()
+	// An existing target linked into a cache is replaced, not written through.
+	cached := writeFile(t, t.TempDir(), "cached", "cached")
+	_ = os.MkdirAll(filepath.Join(dest, "world/region"), 0o750)
+	if err := os.Symlink(cached, filepath.Join(dest, "world/region/r.0.0.mca")); err != nil {
+		t.Fatal(err)
+	}
+	n, err := svc.Extract(ctx, name, "world/region/r.0.0.mca", dest)
+	if err != nil || n != 1 {
+		t.Fatalf("Extract = %d, %v", n, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dest, "world/region/r.0.0.mca")); string(data) != "region" {
+		t.Errorf("extracted content = %q", data)
+	}
+	if data, _ := os.ReadFile(cached); string(data) != "cached" {
+		t.Errorf("extract wrote through the symlink: cache now %q", data)
+	}
+	if _, err := svc.Extract(ctx, name, "missing.dat", dest); err == nil {
+		t.Error("expected error extracting a path not in the backup")
+	}
+}
+
+func TestBackup_RestoreRegion(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	writeFile(t, cfg.Paths.Server, "server.properties", "level-name=survival\n")
+	region := writeFile(t, cfg.Paths.Server, "survival/DIM-1/region/r.-1.2.mca", "good")
+	entities := writeFile(t, cfg.Paths.Server, "survival/DIM-1/entities/r.-1.2.mca", "mobs")
+	other := writeFile(t, cfg.Paths.Server, "survival/DIM-1/region/r.0.0.mca", "keep")
+	svc := service.NewBackup(cfg, logger)
+
+	path, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, p := range []string{region, entities, other} {
+		_ = os.WriteFile(p, []byte("griefed"), 0o600)
+	}
+
+	files, err := svc.RestoreRegion(ctx, filepath.Base(path), "nether", -1, 2)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("RestoreRegion = %v, %v", files, err)
+	}
+	if data, _ := os.ReadFile(region); string(data) != "good" {
+		t.Errorf("region not restored: %q", data)
+	}
+	if data, _ := os.ReadFile(entities); string(data) != "mobs" {
+		t.Errorf("entities not restored: %q", data)
+	}
+	if data, _ := os.ReadFile(other); string(data) != "griefed" {
+		t.Error("neighbouring region must not be touched")
+	}
+	if _, err := svc.RestoreRegion(ctx, filepath.Base(path), "end", -1, 2); err == nil {
+		t.Error("expected error for region missing from backup")
+	}
+}
+
+func TestBackup_RestorePlayer(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	const uuid = "069a79f4-44e9-4726-a5be-fca90e38aaf5"
+	writeFile(t, cfg.Paths.Server, "usercache.json", `[{"name":"Notch","uuid":"`+uuid+`"}]`)
+	inv := writeFile(t, cfg.Paths.Server, "world/playerdata/"+uuid+".dat", "full inventory")
+	writeFile(t, cfg.Paths.Server, "world/stats/"+uuid+".json", "{}")
+	svc := service.NewBackup(cfg, logger)
+
+	path, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	_ = os.WriteFile(inv, []byte("empty"), 0o600)
+
+	files, err := svc.RestorePlayer(ctx, filepath.Base(path), "notch")
+	if err != nil || len(files) != 2 {
+		t.Fatalf("RestorePlayer = %v, %v", files, err)
+	}
+	if data, _ := os.ReadFile(inv); string(data) != "full inventory" {
+		t.Errorf("playerdata not restored: %q", data)
+	}
+	if _, err := svc.RestorePlayer(ctx, filepath.Base(path), "Herobrine"); err == nil {
+		t.Error("expected error for unknown player name")
+	}
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// CloneInto copies this server into target's paths, e.g. to make a staging
+// copy of survival for trying mod updates. The backup set (minus
+// include_paths) is copied next to target's server directory and swapped in
+// once complete; the old directory is kept as <dir>.pre-clone. The copy
+// gets its own ports, a motd of opts.Name and this server's mod lockfile.
+func (b *Backup) CloneInto(ctx context.Context, target *config.Config, opts domain.CloneOptions) (*domain.CloneResult, error) {
+	src, dst := b.cfg.Paths.Server, target.Paths.Server
+	if check := domain.CheckPath("Server", src); check.Status != domain.StatusOK {
+		return nil, fmt.Errorf("%s: %s", check.Name, check.Message)
+	}
+	if within(src, dst) || within(dst, src) {
+		return nil, fmt.Errorf("cannot clone %s into %s: the directories overlap", src, dst)
+	}
+
+	srcPort := serverPort(src)
+	port := opts.Port
+	if port == 0 {
+		port = srcPort + 1
+		if p, err := strconv.Atoi(serverProperties(dst)["server-port"]); err == nil && p > 0 {
+			port = p
+		}
+	}
+	res := &domain.CloneResult{ServerDir: dst, Port: port}
+	if b.cfg.DryRun {
+		b.logger.Info("Dry run: Would clone server", zap.String("from", src), zap.String("to", dst), zap.Int("port", port))
+		return res, nil
+	}
+
+	partial := filepath.Clean(dst) + ".clone-partial"
+	_ = os.RemoveAll(partial)
+	err := b.walkRoot(ctx, backupRoot{dir: src}, func(path, rel string, info fs.FileInfo) error {
+		out := filepath.Join(partial, filepath.FromSlash(rel))
+		if info.IsDir() {
+			return os.MkdirAll(out, 0o750)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		res.Files++
+		return copyFile(ctx, path, out, info)
+	})
+	if err == nil {
+		err = setProperties(filepath.Join(partial, "server.properties"), clonedProperties(src, port-srcPort, opts.Name))
+	}
+	if err == nil {
+		err = b.swapIn(partial, dst, res)
+	}
+	if err != nil {
+		_ = os.RemoveAll(partial)
+		return nil, fmt.Errorf("clone failed: %w", err)
+	}
+
+	if res.Mods, err = b.cloneMods(ctx, target); err != nil {
+		return res, fmt.Errorf("clone mods: %w", err)
+	}
+	lock := map[string]domain.LockedMod{}
+	if st, err := b.state.Load(); err == nil {
+		lock = maps.Clone(st.Mods)
+	}
+	if err := NewStateStore(target).Update(func(st *domain.State) { st.Mods = lock }); err != nil {
+		return res, fmt.Errorf("clone lockfile: %w", err)
+	}
+	b.logger.Info("Server cloned", zap.String("from", src), zap.String("to", dst),
+		zap.Int("files", res.Files), zap.Int("port", port))
+	return res, nil
+}
+
+// swapIn moves the finished copy to dst, setting any existing dst aside.
+func (b *Backup) swapIn(partial, dst string, res *domain.CloneResult) error {
+	if _, err := os.Stat(dst); err == nil {
+		res.PreviousDir = filepath.Clean(dst) + ".pre-clone"
+		if err := os.RemoveAll(res.PreviousDir); err != nil {
+			return err
+		}
+		if err := os.Rename(dst, res.PreviousDir); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return err
+	}
+	return os.Rename(partial, dst)
+}
+
+// cloneMods copies this server's jars into target's mods directory when it
+// lives outside the server directory (inside, the tree copy has them).
+func (b *Backup) cloneMods(ctx context.Context, target *config.Config) (int, error) {
+	jars, _ := filepath.Glob(filepath.Join(b.cfg.ModsDir(), "*.jar"))
+	if within(b.cfg.Paths.Server, b.cfg.Paths.Mods) && within(target.Paths.Server, target.Paths.Mods) {
+		return len(jars), nil
+	}
+	if err := os.MkdirAll(target.ModsDir(), 0o750); err != nil {
+		return 0, err
+	}
+	old, _ := filepath.Glob(filepath.Join(target.ModsDir(), "*.jar"))
+	for _, jar := range old {
+		if err := os.Remove(jar); err != nil {
+			return 0, err
+		}
+	}
+	for _, jar := range jars {
+		info, err := os.Stat(jar)
+		if err != nil {
+			return 0, err
+		}
+		if err := copyFile(ctx, jar, filepath.Join(target.ModsDir(), filepath.Base(jar)), info); err != nil {
+			return 0, err
+		}
+	}
+	return len(jars), nil
+}
+
+// clonedProperties shifts the source's ports by offset so the copy can run
+// beside it, and names it in the server list.
+func clonedProperties(src string, offset int, name string) map[string]string {
+	props := serverProperties(src)
+	values := map[string]string{"server-port": strconv.Itoa(serverPort(src) + offset)}
+	for _, key := range []string{"query.port", "rcon.port"} {
+		if p, err := strconv.Atoi(props[key]); err == nil && p > 0 {
+			values[key] = strconv.Itoa(p + offset)
+		}
+	}
+	if name != "" {
+		values["motd"] = name
+	}
+	return values
+}
+
+// within reports whether path is dir or inside it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), filepath.Clean(path))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,71 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestBackup_CloneInto(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Server, "server.properties", "# props\nmotd=Survival\nserver-port=25565\nrcon.port=25575\n")
+	writeFile(t, cfg.Paths.Server, "world/level.dat", "level")
+	writeFile(t, cfg.Paths.Server, "latest.log", "noise")
+	writeFile(t, cfg.Paths.Mods, "sodium.jar", "jar")
+	if err := service.NewStateStore(cfg).Update(func(st *domain.State) {
+		st.Mods["sodium"] = domain.LockedMod{Filename: "sodium.jar", VersionID: "v1"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tmp := t.TempDir()
+	target := config.DefaultConfig()
+	target.Paths.Server = filepath.Join(tmp, "staging")
+	target.Paths.Mods = filepath.Join(tmp, "staging-mods")
+	target.Paths.State = filepath.Join(tmp, "state")
+	writeFile(t, target.Paths.Server, "old.txt", "old")
+	writeFile(t, target.Paths.Mods, "stale.jar", "stale")
+
+	res, err := service.NewBackup(cfg, logger).CloneInto(ctx, target, domain.CloneOptions{Name: "staging"})
+	if err != nil {
+		t.Fatalf("CloneInto: %v", err)
+	}
+	if res.Port != 25566 || res.Mods != 1 || res.PreviousDir != target.Paths.Server+".pre-clone" {
+		t.Errorf("result = %+v", res)
+	}
+	if _, err := os.Stat(filepath.Join(target.Paths.Server, "world", "level.dat")); err != nil {
+		t.Errorf("world not copied: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target.Paths.Server, "latest.log")); err == nil {
+		t.Error("excluded latest.log was copied")
+	}
+	if _, err := os.Stat(filepath.Join(res.PreviousDir, "old.txt")); err != nil {
+		t.Errorf("previous directory not kept: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target.Paths.Mods, "stale.jar")); err == nil {
+		t.Error("target's old jar was kept")
+	}
+	if _, err := os.Stat(filepath.Join(target.Paths.Mods, "sodium.jar")); err != nil {
+		t.Errorf("mod not copied: %v", err)
+	}
+	props, _ := os.ReadFile(filepath.Join(target.Paths.Server, "server.properties"))
+	for _, want := range []string{"# props\n", "motd=staging\n", "server-port=25566\n", "rcon.port=25576\n"} {
+		if !strings.Contains(string(props), want) {
+			t.Errorf("server.properties missing %q:\n%s", want, props)
+		}
+	}
+	st, err := service.NewStateStore(target).Load()
+	if err != nil || st.Mods["sodium"].VersionID != "v1" {
+		t.Errorf("lockfile not cloned: %+v, %v", st, err)
+	}
+
+	target.Paths.Server = filepath.Join(cfg.Paths.Server, "nested")
+	if _, err := service.NewBackup(cfg, logger).CloneInto(ctx, target, domain.CloneOptions{}); err == nil {
+		t.Error("expected error cloning into a directory inside the source")
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"craftops/internal/domain"
+)
+
+const logTailLines = 20
+
+// startupCauses classify why a server failed to come up, checked in order
+// against the log tail and crash report.
+var startupCauses = []struct {
+	pattern *regexp.Regexp
+	cause   string
+	hint    string
+}{
+	{regexp.MustCompile(`(?i)agree to the EULA`), "EULA not accepted", "set eula=true in eula.txt"},
+	{regexp.MustCompile(`(?i)FAILED TO BIND TO PORT|Address already in use`), "port already in use", "stop the other process or change server-port in server.properties"},
+	{regexp.MustCompile(`UnsupportedClassVersionError|compiled by a more recent version of the Java Runtime|requires Java \d+`), "wrong Java version", "install the Java version this Minecraft release requires"},
+	{regexp.MustCompile(`OutOfMemoryError|Could not reserve enough space|Invalid maximum heap size`), "insufficient memory", "lower server.memory or free memory on the host"},
+	{regexp.MustCompile(`Unable to access jarfile`), "server JAR not found", "check server.jar_name"},
+	{regexp.MustCompile(`(?i)Incompatible mods? (found|set)|ModResolutionException|Mod loading has failed|Missing or unsupported mandatory dependencies|requires .+ which is missing|mixin apply failed`), "mod error", "check the log for the failing mod and update or remove it"},
+}
+
+// diagnoseStartup wraps a start timeout with the log tail, any crash report
+// written since startedAt, and a classified cause.
+func (s *Server) diagnoseStartup(err error, startedAt time.Time) error {
+	diag := &domain.StartupError{Err: err}
+	diag.LogTail = tailLines(filepath.Join(s.cfg.Paths.Server, "logs", "latest.log"), logTailLines)
+	diag.CrashReport = newestFile(filepath.Join(s.cfg.Paths.Server, "crash-reports"), startedAt)
+
+	text := strings.Join(diag.LogTail, "\n")
+	if diag.CrashReport != "" {
+		if data, err := os.ReadFile(diag.CrashReport); err == nil { //nolint:gosec // file in server dir
+			text += "\n" + string(data)
+		}
+	}
+	for _, c := range startupCauses {
+		if c.pattern.MatchString(text) {
+			diag.Cause, diag.Hint = c.cause, c.hint
+			break
+		}
+	}
+	return diag
+}
+
+// tailLines returns the last n lines of a file, or nil if unreadable.
+func tailLines(path string, n int) []string {
+	f, err := os.Open(path) //nolint:gosec // log file in server dir
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+		if len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines
+}
+
+// newestFile returns the most recently modified file in dir newer than since.
+func newestFile(dir string, since time.Time) string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var newest string
+	var newestAt time.Time
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() || info.ModTime().Before(since) {
+			continue
+		}
+		if info.ModTime().After(newestAt) {
+			newest, newestAt = filepath.Join(dir, e.Name()), info.ModTime()
+		}
+	}
+	return newest
+}
+
+// doneLine is what the server logs once it accepts players, e.g.
+// "Done (12.345s)! For help, type "help"".
+var doneLine = regexp.MustCompile(`Done \(\d+(\.\d+)?s\)!`)
+
+// logDone reports whether the log at path, written since the start, has
+// reached the "Done" line.
+func logDone(path string, since time.Time) bool {
+	if info, err := os.Stat(path); err != nil || info.ModTime().Before(since) {
+		return false
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // log file in server dir
+	return err == nil && doneLine.Match(data)
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// interactionTokenTTL is how long an interaction token can edit its
+// deferred response: Discord's 15 minutes, less a margin for the request.
+const interactionTokenTTL = 14 * time.Minute
+
+// discordAPI is the Discord REST API the bot registers commands with and
+// answers interactions through.
+var discordAPI = "https://discord.com/api/v10"
+
+// slashCommands are registered for the application by RegisterCommands.
+var slashCommands = []struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}{
+	{config.BotStatus, "Show whether the server is running and its resource usage"},
+	{config.BotRestart, "Restart the server with the configured warnings"},
+	{config.BotBackup, "Create a backup"},
+	{config.BotUpdateMods, "Back up, then update the configured mods"},
+}
+
+// DiscordBot is the outbound half of the Discord bot: it registers the
+// slash commands and delivers their results. Interactions themselves arrive
+// through the API server.
+type DiscordBot struct {
+	cfg    *config.Config
+	logger *zap.Logger
+	client *http.Client
+}
+
+// NewDiscordBot creates a Discord bot client.
+func NewDiscordBot(cfg *config.Config, logger *zap.Logger) *DiscordBot {
+	client, err := newHTTPClient(cfg, time.Duration(cfg.Notifications.Timeout)*time.Second)
+	if err != nil {
+		logger.Warn("Network settings not applied to Discord bot client", zap.Error(err))
+	}
+	return &DiscordBot{cfg: cfg, logger: logger, client: client}
+}
+
+// RegisterCommands replaces the application's slash commands in
+// discord_bot.guild_id, or globally, with the ones craftops answers.
+func (d *DiscordBot) RegisterCommands(ctx context.Context) error {
+	bot := d.cfg.DiscordBot
+	path := "/applications/" + bot.ApplicationID + "/commands"
+	if bot.GuildID != "" {
+		path = "/applications/" + bot.ApplicationID + "/guilds/" + bot.GuildID + "/commands"
+	}
+	if err := d.call(ctx, http.MethodPut, path, slashCommands, nil); err != nil {
+		return err
+	}
+	d.logger.Info("Registered Discord slash commands", zap.Int("commands", len(slashCommands)), zap.String("guild", bot.GuildID))
+	return nil
+}
+
+// Reply replaces the deferred response to the interaction with an embed,
+// green when the command succeeded and red otherwise. Once the interaction
+// token has expired, as it has after a long backup, the embed is posted to
+// the interaction's channel instead.
+func (d *DiscordBot) Reply(ctx context.Context, reply domain.BotReply) error {
+	color := colorGreen
+	if !reply.OK {
+		color = colorRed
+	}
+	embed := discordEmbed{
+		Title:       reply.Title,
+		Description: truncate(reply.Message, 2000),
+		Color:       color,
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+		Footer:      map[string]string{"text": "CraftOps"},
+	}
+	payload := discordPayload{Embeds: []discordEmbed{embed}}
+	if time.Since(reply.Received) >= interactionTokenTTL && reply.ChannelID != "" {
+		return d.call(ctx, http.MethodPost, "/channels/"+reply.ChannelID+"/messages", payload, nil)
+	}
+	path := "/webhooks/" + d.cfg.DiscordBot.ApplicationID + "/" + reply.Token + "/messages/@original"
+	return d.call(ctx, http.MethodPatch, path, payload, nil)
+}
+
+// React adds emoji to a message as the bot.
+func (d *DiscordBot) React(ctx context.Context, channelID, messageID, emoji string) error {
+	path := "/channels/" + channelID + "/messages/" + messageID + "/reactions/" + url.PathEscape(emoji) + "/@me"
+	return d.call(ctx, http.MethodPut, path, nil, nil)
+}
+
+// ReactedAllowed returns the IDs of members holding an admin role
+// (discord_bot.allowed_roles, or roles mapped to admin) who reacted to a
+// message with emoji.
+func (d *DiscordBot) ReactedAllowed(ctx context.Context, channelID, messageID, emoji string) ([]string, error) {
+	var users []struct {
+		ID  string `json:"id"`
+		Bot bool   `json:"bot"`
+	}
+	path := "/channels/" + channelID + "/messages/" + messageID + "/reactions/" + url.PathEscape(emoji)
+	if err := d.call(ctx, http.MethodGet, path, nil, &users); err != nil {
+		return nil, err
+	}
+	var allowed []string
+	for _, u := range users {
+		if u.Bot {
+			continue
+		}
+		var member struct {
+			Roles []string `json:"roles"`
+		}
+		if err := d.call(ctx, http.MethodGet, "/guilds/"+d.cfg.DiscordBot.GuildID+"/members/"+u.ID, nil, &member); err != nil {
+			return nil, err
+		}
+		if d.cfg.DiscordBot.RoleOf(member.Roles) == config.RoleAdmin {
+			allowed = append(allowed, u.ID)
+		}
+	}
+	return allowed, nil
+}
+
+// call sends payload, if any, as JSON to the Discord API and decodes the
+// response into out, if set, retrying like webhook notifications.
+func (d *DiscordBot) call(ctx context.Context, method, path string, payload, out any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	return withRetry(ctx, notifyMaxRetries, notifyRetryDelay, func() error {
+		req, err := http.NewRequestWithContext(ctx, method, discordAPI+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("Authorization", "Bot "+d.cfg.DiscordBot.Token)
+		resp, err := d.client.Do(req) //nolint:gosec // Discord API URL
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode >= 300 {
+			return &domain.APIError{
+				URL:        discordAPI + path,
+				StatusCode: resp.StatusCode,
+				Message:    "Discord API error",
+				RetryAfter: parseRetryAfter(resp.Header),
+			}
+		}
+		if out != nil {
+			return json.NewDecoder(resp.Body).Decode(out)
+		}
+		return nil
+	})
+}
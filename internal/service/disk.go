@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"syscall"
+
+	"craftops/internal/domain"
+)
+
+// diskSpace reports the bytes and inodes available to unprivileged users on
+// the filesystem holding dir. Filesystems without an inode limit report
+// math.MaxUint64 inodes. It is a variable so tests can fake a full disk.
+var diskSpace = func(dir string) (free, inodes uint64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, 0, err
+	}
+	inodes = st.Ffree
+	if st.Files == 0 {
+		inodes = math.MaxUint64
+	}
+	return st.Bavail * uint64(st.Bsize), inodes, nil //nolint:gosec // block size is positive
+}
+
+// diskFill reports how full the filesystem holding dir is, in percent of
+// the space available to unprivileged users, as df does. It is a variable
+// so tests can fake a filling disk.
+var diskFill = func(dir string) (float64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	used := st.Blocks - st.Bfree
+	if used+st.Bavail == 0 {
+		return 0, nil
+	}
+	return float64(used) * 100 / float64(used+st.Bavail), nil
+}
+
+// ensureSpace fails with ErrInsufficientSpace unless the filesystem holding
+// dir has room for need more bytes and one more file. A filesystem that
+// cannot be queried is assumed to have room.
+func ensureSpace(dir string, need int64) error {
+	free, inodes, err := diskSpace(dir)
+	if err != nil {
+		return nil
+	}
+	if inodes == 0 {
+		return fmt.Errorf("%w: no free inodes in %s", domain.ErrInsufficientSpace, dir)
+	}
+	if need > 0 && uint64(need) > free {
+		return fmt.Errorf("%w: need %s, %s free in %s", domain.ErrInsufficientSpace,
+			domain.FormatSize(need), domain.FormatSize(int64(min(free, math.MaxInt64))), dir)
+	}
+	return nil
+}
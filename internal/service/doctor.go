@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+)
+
+// Fix is a problem Doctor found and the change that resolves it. Consent
+// marks fixes that decide something on the user's behalf (accepting the
+// EULA) and should be confirmed first.
+type Fix struct {
+	Problem string
+	Action  string
+	Consent bool
+	apply   func() error
+}
+
+// Apply makes the change.
+func (f *Fix) Apply() error { return f.apply() }
+
+// Doctor finds setup problems that craftops can repair itself.
+type Doctor struct {
+	cfg    *config.Config
+	logger *zap.Logger
+}
+
+// NewDoctor creates a Doctor.
+func NewDoctor(cfg *config.Config, logger *zap.Logger) *Doctor {
+	return &Doctor{cfg: cfg, logger: logger}
+}
+
+// Diagnose returns the fixable problems, in the order they should be applied.
+func (d *Doctor) Diagnose() []*Fix {
+	var fixes []*Fix
+	p := d.cfg.Paths
+	for _, dir := range []struct{ name, path string }{
+		{"Server directory", p.Server},
+		{"Mods directory", p.Mods},
+		{"Backups directory", p.Backups},
+		{"Logs directory", p.Logs},
+		{"Cache directory", p.Cache},
+		{"State directory", p.State},
+	} {
+		if dir.path == "" {
+			continue
+		}
+		if fix := d.checkDir(dir.name, dir.path); fix != nil {
+			fixes = append(fixes, fix)
+		}
+	}
+	if fix := d.checkEULA(); fix != nil {
+		fixes = append(fixes, fix)
+	}
+	if fix := d.checkSessionName(); fix != nil {
+		fixes = append(fixes, fix)
+	}
+	return fixes
+}
+
+// checkDir reports a missing directory, or one craftops cannot write to
+// because the owner lacks rwx.
+func (d *Doctor) checkDir(name, path string) *Fix {
+	info, err := os.Stat(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return &Fix{
+			Problem: fmt.Sprintf("%s %s does not exist", name, path),
+			Action:  "create " + path,
+			apply:   func() error { return os.MkdirAll(path, 0o750) },
+		}
+	case err != nil:
+		return nil
+	case !info.IsDir():
+		return nil // not safely fixable; health-check reports it
+	}
+	if mode := info.Mode().Perm(); mode&0o700 != 0o700 {
+		return &Fix{
+			Problem: fmt.Sprintf("%s %s has mode %s; owner needs rwx", name, path, mode),
+			Action:  fmt.Sprintf("chmod %o %s", mode|0o700, path),
+			apply:   func() error { return os.Chmod(path, mode|0o700) },
+		}
+	}
+	return nil
+}
+
+// checkEULA reports a server directory without an accepted eula.txt.
+func (d *Doctor) checkEULA() *Fix {
+	if _, err := os.Stat(d.cfg.Paths.Server); err != nil {
+		return nil
+	}
+	path := filepath.Join(d.cfg.Paths.Server, "eula.txt")
+	data, err := os.ReadFile(path) //nolint:gosec // path from validated config
+	problem := "eula.txt is missing; the server exits on first start"
+	if err == nil {
+		if eulaAccepted(string(data)) {
+			return nil
+		}
+		problem = "eula.txt does not accept the Minecraft EULA"
+	}
+	return &Fix{
+		Problem: problem,
+		Action:  "write eula=true to " + path + " (https://aka.ms/MinecraftEULA)",
+		Consent: true,
+		apply: func() error {
+			return os.WriteFile(path, []byte("# Accepted via craftops doctor --fix\neula=true\n"), 0o640) //nolint:gosec
+		},
+	}
+}
+
+func eulaAccepted(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(line), "="); ok && strings.TrimSpace(k) == "eula" {
+			return strings.EqualFold(strings.TrimSpace(v), "true")
+		}
+	}
+	return false
+}
+
+// checkSessionName reports a config file without server.session_name.
+func (d *Doctor) checkSessionName() *Fix {
+	if d.cfg.Server.SessionName != "" || d.cfg.Path == "" {
+		return nil
+	}
+	return &Fix{
+		Problem: "server.session_name is empty",
+		Action:  fmt.Sprintf("set session_name = %q in %s", defaultSessionName, d.cfg.Path),
+		apply: func() error {
+			if err := config.SetFileKey(d.cfg.Path, "server", "session_name", defaultSessionName); err != nil {
+				return err
+			}
+			d.cfg.Server.SessionName = defaultSessionName
+			return nil
+		},
+	}
+}
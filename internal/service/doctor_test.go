@@ -0,0 +1,54 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestDoctor_DiagnoseAndFix(t *testing.T) {
+	cfg, logger, _ := setup(t)
+	_ = os.RemoveAll(cfg.Paths.Mods)
+	if err := os.Chmod(cfg.Paths.Backups, 0o500); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Path = writeFile(t, t.TempDir(), "config.toml", "[server]\nsession_name = \"\"\n")
+	cfg.Server.SessionName = ""
+	cfg.Debug = true // a command-line override, not in the file
+
+	doc := service.NewDoctor(cfg, logger)
+	fixes := doc.Diagnose()
+	var problems []string
+	for _, f := range fixes {
+		problems = append(problems, f.Problem)
+	}
+	joined := strings.Join(problems, "\n")
+	for _, want := range []string{"Mods directory", "Backups directory", "eula.txt is missing", "session_name"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("missing problem %q in:\n%s", want, joined)
+		}
+	}
+
+	for _, f := range fixes {
+		if err := f.Apply(); err != nil {
+			t.Fatalf("%s: %v", f.Action, err)
+		}
+	}
+	if left := doc.Diagnose(); len(left) != 0 {
+		t.Errorf("problems left after fixing: %+v", left)
+	}
+	eula, _ := os.ReadFile(filepath.Join(cfg.Paths.Server, "eula.txt")) //nolint:gosec
+	if !strings.Contains(string(eula), "eula=true") {
+		t.Errorf("eula.txt = %q", eula)
+	}
+	saved, err := os.ReadFile(cfg.Path)
+	if err != nil || !strings.Contains(string(saved), `session_name = "minecraft"`) {
+		t.Errorf("session name not saved: %v", err)
+	}
+	if strings.Contains(string(saved), "debug") {
+		t.Errorf("fix wrote the loaded config, not just session_name:\n%s", saved)
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Dump kinds for Server.Dump.
+const (
+	DumpThreads = "threads"
+	DumpHeap    = "heap"
+)
+
+// Dump captures a thread or heap dump of the running java process into
+// dumps/ under paths.logs and returns the file written. It uses jcmd from
+// the server's JDK, or jstack and jmap, so a JRE without them cannot dump.
+func (s *Server) Dump(ctx context.Context, kind string) (string, error) {
+	status, err := s.Status(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !status.IsRunning {
+		return "", errors.New("server is not running")
+	}
+	pid := s.javaProcess(ctx, status.PID)
+	if pid == 0 {
+		return "", errors.New("java process not found in the server session")
+	}
+	return s.dump(ctx, pid, kind)
+}
+
+func (s *Server) dump(ctx context.Context, pid int, kind string) (string, error) {
+	dir := filepath.Join(s.cfg.Paths.Logs, "dumps")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	stamp := time.Now().Format(backupTimeFormat)
+	p := strconv.Itoa(pid)
+
+	var file string
+	var attempts [][]string // command lines; thread dumps are read from stdout
+	switch kind {
+	case DumpThreads:
+		file = filepath.Join(dir, "threads-"+stamp+".txt")
+		attempts = [][]string{{"jcmd", p, "Thread.print", "-l"}, {"jstack", "-l", p}}
+	case DumpHeap:
+		// The JVM writes heap dumps itself, so the path must be absolute.
+		abs, err := filepath.Abs(filepath.Join(dir, "heap-"+stamp+".hprof"))
+		if err != nil {
+			return "", err
+		}
+		file = abs
+		attempts = [][]string{{"jcmd", p, "GC.heap_dump", file}, {"jmap", "-dump:live,format=b,file=" + file, p}}
+	default:
+		return "", fmt.Errorf("unknown dump kind: %s. Must be one of [threads heap]", kind)
+	}
+
+	var errs []error
+	for _, args := range attempts {
+		tool := s.jdkTool(ctx, args[0])
+		if tool == "" {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, tool, args[1:]...) //nolint:gosec // pid and path built here
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			// jcmd reports attach failures on stdout.
+			msg := strings.TrimSpace(stderr.String() + string(out))
+			errs = append(errs, fmt.Errorf("%s: %w: %s", args[0], err, msg))
+			continue
+		}
+		if kind == DumpThreads {
+			if err := os.WriteFile(file, out, 0o600); err != nil {
+				return "", err
+			}
+		}
+		s.logger.Info("Captured dump", zap.String("kind", kind), zap.Int("pid", pid), zap.String("file", file))
+		return file, nil
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no jcmd, jstack or jmap found next to java or in PATH: install a full JDK")
+	}
+	return "", errors.Join(errs...)
+}
+
+// jdkTool finds a JDK tool next to the server's java binary, falling back
+// to PATH, or returns "".
+func (s *Server) jdkTool(ctx context.Context, name string) string {
+	if java, err := s.javaBinary(ctx); err == nil {
+		if resolved, err := exec.LookPath(java); err == nil {
+			if real, err := filepath.EvalSymlinks(resolved); err == nil {
+				if tool := filepath.Join(filepath.Dir(real), name); isExecutable(tool) {
+					return tool
+				}
+			}
+		}
+	}
+	if tool, err := exec.LookPath(name); err == nil {
+		return tool
+	}
+	return ""
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0o111 != 0
+}
+
+// recordHangDump captures a thread dump of a server that ignored its stop
+// command when server.diagnostics.dump_on_hang is set.
+func (s *Server) recordHangDump(ctx context.Context, pid int) {
+	if !s.cfg.Server.Diagnostics.DumpOnHang || pid <= 0 {
+		return
+	}
+	if file, err := s.dump(ctx, pid, DumpThreads); err != nil {
+		s.logger.Warn("Hang thread dump failed", zap.Int("pid", pid), zap.Error(err))
+	} else {
+		s.logger.Warn("Server hung on stop; thread dump saved", zap.String("file", file))
+	}
+}
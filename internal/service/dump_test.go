@@ -0,0 +1,49 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestServer_Dump(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	jdk := t.TempDir()
+	cfg.Server.JavaPath = writeFile(t, jdk, "bin/java", "#!/bin/sh\n")
+	// jcmd cannot attach, so threads fall back to jstack; heap dumps are
+	// written by the JVM to the path jcmd is given.
+	writeFile(t, jdk, "bin/jcmd", "#!/bin/sh\n[ \"$2\" = GC.heap_dump ] && : > \"$3\" && exit 0\necho 'AttachNotSupportedException'; exit 1\n")
+	writeFile(t, jdk, "bin/jstack", "#!/bin/sh\necho \"Full thread dump for $2\"\n")
+	for _, tool := range []string{"java", "jcmd", "jstack"} {
+		_ = os.Chmod(filepath.Join(jdk, "bin", tool), 0o700) //nolint:gosec
+	}
+	t.Setenv("PATH", "")
+	srv := service.NewServer(cfg, logger)
+
+	file, err := srv.DumpPID(ctx, 4242, service.DumpThreads)
+	if err != nil {
+		t.Fatalf("thread dump: %v", err)
+	}
+	if dir := filepath.Join(cfg.Paths.Logs, "dumps"); filepath.Dir(file) != dir {
+		t.Errorf("dump written to %s, want under %s", file, dir)
+	}
+	if data, _ := os.ReadFile(file); !strings.Contains(string(data), "Full thread dump for 4242") { //nolint:gosec
+		t.Errorf("thread dump = %q", data)
+	}
+
+	file, err = srv.DumpPID(ctx, 4242, service.DumpHeap)
+	if err != nil {
+		t.Fatalf("heap dump: %v", err)
+	}
+	if _, err := os.Stat(file); err != nil || !strings.HasSuffix(file, ".hprof") {
+		t.Errorf("heap dump %s: %v", file, err)
+	}
+
+	cfg.Server.JavaPath = writeFile(t, t.TempDir(), "java", "#!/bin/sh\n")
+	if _, err := srv.DumpPID(ctx, 4242, service.DumpThreads); err == nil || !strings.Contains(err.Error(), "JDK") {
+		t.Errorf("expected a missing-JDK error, got %v", err)
+	}
+}
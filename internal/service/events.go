@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+const (
+	eventsFile = "events.jsonl"
+	// maxEventsBytes rotates the event log to events.jsonl.1, so the history
+	// spans between one and two files of this size.
+	maxEventsBytes = 4 << 20
+)
+
+// EventLog is the append-only history of operations, crashes and alerted
+// errors under Paths.State, one JSON event per line. A nil *EventLog is
+// valid: writes are no-ops and queries find nothing.
+type EventLog struct {
+	file   *lockedFile
+	dryRun bool
+}
+
+// NewEventLog returns the log for cfg.Paths.State, or nil if unset. Logs
+// on one file share its lock, so rotation is serialized, while each keeps
+// its own config's dry-run setting.
+func NewEventLog(cfg *config.Config) *EventLog {
+	if cfg.Paths.State == "" {
+		return nil
+	}
+	path := filepath.Join(cfg.Paths.State, eventsFile)
+	f, _ := stateFiles.LoadOrStore(path, &lockedFile{path: path})
+	return &EventLog{file: f.(*lockedFile), dryRun: cfg.DryRun}
+}
+
+// Record appends an event of type typ.
+func (l *EventLog) Record(typ, message string) error {
+	if l == nil || l.dryRun {
+		return nil
+	}
+	line, err := json.Marshal(domain.Event{At: time.Now(), Type: typ, Message: message})
+	if err != nil {
+		return err
+	}
+	l.file.mu.Lock()
+	defer l.file.mu.Unlock()
+	path := l.file.path
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open event log: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	info, serr := f.Stat()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write event log: %w", err)
+	}
+	if serr == nil && info.Size() > maxEventsBytes {
+		return os.Rename(path, path+".1")
+	}
+	return nil
+}
+
+// Query returns the events at or after since whose type is in types (any
+// type when empty), newest first. Unparsable lines are skipped.
+func (l *EventLog) Query(since time.Time, types []string) ([]domain.Event, error) {
+	if l == nil {
+		return nil, nil
+	}
+	var events []domain.Event
+	for _, path := range []string{l.file.path + ".1", l.file.path} {
+		f, err := os.Open(path) //nolint:gosec // state dir from config
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event log: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var e domain.Event
+			if json.Unmarshal(scanner.Bytes(), &e) != nil || e.At.Before(since) {
+				continue
+			}
+			if len(types) == 0 || slices.Contains(types, e.Type) {
+				events = append(events, e)
+			}
+		}
+		err = scanner.Err()
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read event log: %w", err)
+		}
+	}
+	slices.Reverse(events)
+	return events, nil
+}
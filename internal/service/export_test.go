@@ -1,8 +1,12 @@
 package service
 
 import (
+	"context"
+	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"time"
 
 	"go.uber.org/zap"
@@ -17,11 +21,18 @@ func NewModsWithBaseURL(cfg *config.Config, logger *zap.Logger, baseURL string)
 		logger: logger,
 		client: &http.Client{
 			Timeout:   time.Duration(cfg.Mods.Timeout) * time.Second,
-			Transport: &redirectTransport{base: baseURL},
+			Transport: &meteredTransport{base: &redirectTransport{base: baseURL}, logger: logger},
 		},
+		cache: newResponseCache(modrinthCacheDir(cfg)),
+		state: NewStateStore(cfg),
 	}
 }
 
+// NewHTTPClient exposes newHTTPClient for cross-package tests.
+func NewHTTPClient(cfg *config.Config, timeout time.Duration) (*http.Client, error) {
+	return newHTTPClient(cfg, timeout)
+}
+
 // ParseProjectID exposes parseProjectID for cross-package tests.
 func ParseProjectID(modURL string) (string, error) {
 	return parseProjectID(modURL)
@@ -39,3 +50,133 @@ func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error)
 	clone.Host = base.Host
 	return http.DefaultTransport.RoundTrip(clone)
 }
+
+// ParseRetryAfter exposes parseRetryAfter for cross-package tests.
+func ParseRetryAfter(h http.Header) time.Duration {
+	return parseRetryAfter(h)
+}
+
+// Backoff exposes backoff for cross-package tests.
+func Backoff(base time.Duration, attempt int) time.Duration {
+	return backoff(base, attempt)
+}
+
+// ScreenPID exposes screenPID for cross-package tests.
+func ScreenPID(output, session string) int {
+	return screenPID(output, session)
+}
+
+// ParseEtime exposes parseEtime for cross-package tests.
+func ParseEtime(s string) (time.Duration, error) {
+	return parseEtime(s)
+}
+
+// DiagnoseStartup exposes diagnoseStartup for cross-package tests.
+func DiagnoseStartup(s *Server, err error, startedAt time.Time) error {
+	return s.diagnoseStartup(err, startedAt)
+}
+
+// WaitReady exposes waitReady for cross-package tests.
+func (s *Server) WaitReady(ctx context.Context, since time.Time) error {
+	return s.waitReady(ctx, since)
+}
+
+// SetFreshnessInterval shortens WatchFreshness's polling for tests.
+func SetFreshnessInterval(d time.Duration) (restore func()) {
+	old := freshnessInterval
+	freshnessInterval = d
+	return func() { freshnessInterval = old }
+}
+
+// CopyContext exposes copyContext for cross-package tests.
+func CopyContext(ctx context.Context, dst io.Writer, src io.Reader) (int64, error) {
+	return copyContext(ctx, dst, src)
+}
+
+// CopyFile exposes copyFile for cross-package tests.
+func CopyFile(ctx context.Context, src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	return copyFile(ctx, src, dst, info)
+}
+
+// SetDiskSpace fakes the free bytes and inodes ensureSpace sees.
+func SetDiskSpace(free, inodes uint64) (restore func()) {
+	old := diskSpace
+	diskSpace = func(string) (uint64, uint64, error) { return free, inodes, nil }
+	return func() { diskSpace = old }
+}
+
+// Fire runs the schedules due at at.
+func (a *Announcer) Fire(ctx context.Context, at time.Time) { a.fire(ctx, at) }
+
+// LaunchArgs exposes launchArgs for cross-package tests.
+func LaunchArgs(serverDir, jarName string) ([]string, error) { return launchArgs(serverDir, jarName) }
+
+// DumpPID exposes dump for cross-package tests.
+func (s *Server) DumpPID(ctx context.Context, pid int, kind string) (string, error) {
+	return s.dump(ctx, pid, kind)
+}
+
+// PingServer exposes pingServer for cross-package tests.
+func PingServer(ctx context.Context, host string, port int, timeout time.Duration) (time.Duration, error) {
+	return pingServer(ctx, host, port, timeout)
+}
+
+// SetDiscordAPI points the Discord bot at a test server.
+func SetDiscordAPI(url string) (restore func()) {
+	old := discordAPI
+	discordAPI = url
+	return func() { discordAPI = old }
+}
+
+// SetApprovalPoll shortens how often approval reactions are read.
+func SetApprovalPoll(d time.Duration) (restore func()) {
+	old := approvalPoll
+	approvalPoll = d
+	return func() { approvalPoll = old }
+}
+
+// SetMojangAPI points player name lookups at a test server.
+func SetMojangAPI(url string) (restore func()) {
+	old := mojangAPI
+	mojangAPI = url
+	return func() { mojangAPI = old }
+}
+
+// AllowlistURL exposes allowlistURL for cross-package tests.
+func AllowlistURL(source string) string {
+	return allowlistURL(source)
+}
+
+// OfflineUUID exposes offlineUUID for cross-package tests.
+func OfflineUUID(name string) string {
+	return offlineUUID(name)
+}
+
+// ChunkyStatus exposes chunkyStatus for cross-package tests.
+func ChunkyStatus(path string, offset int64, dimension string) (chunks int64, percent float64, finished, ok bool) {
+	return chunkyStatus(path, offset, dimension)
+}
+
+// SetDiskFill fakes how full, in percent, diskFill reports a directory's
+// filesystem to be.
+func SetDiskFill(fill func(dir string) float64) (restore func()) {
+	old := diskFill
+	diskFill = func(dir string) (float64, error) { return fill(dir), nil }
+	return func() { diskFill = old }
+}
+
+// NewProcessStateStore returns a state store with its own in-process lock,
+// standing in for another craftops process on the same state file.
+func NewProcessStateStore(cfg *config.Config) *StateStore {
+	path := filepath.Join(cfg.Paths.State, stateFile)
+	return &StateStore{file: &lockedFile{path: path}, events: NewEventLog(cfg)}
+}
+
+// EscalateStop exposes escalateStop for cross-package tests.
+func (s *Server) EscalateStop(ctx context.Context, pid int) error {
+	return s.escalateStop(ctx, pid)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// freshnessInterval is how often WatchFreshness checks the last backup.
+var freshnessInterval = 10 * time.Minute
+
+// WatchFreshness alerts through n whenever no backup has succeeded within
+// backup.max_age_hours, until ctx is done. Each stale stretch is reported
+// once; a later backup that goes stale again is reported anew.
+func (b *Backup) WatchFreshness(ctx context.Context, n *Notification) {
+	maxAge := time.Duration(b.cfg.Backup.MaxAgeHours) * time.Hour
+	if !b.cfg.Backup.Enabled || maxAge <= 0 {
+		return
+	}
+	var (
+		alerted    bool
+		alertedFor time.Time
+	)
+	check := func() {
+		st, err := b.state.Load()
+		if err != nil {
+			b.logger.Warn("Backup freshness check failed", zap.Error(err))
+			return
+		}
+		last := st.LastSuccess[domain.OpBackup]
+		if time.Since(last) <= maxAge || (alerted && last.Equal(alertedFor)) {
+			return
+		}
+		msg := n.T("notify.backup_stale_never", "hours", b.cfg.Backup.MaxAgeHours)
+		if !last.IsZero() {
+			msg = n.T("notify.backup_stale", "hours", b.cfg.Backup.MaxAgeHours,
+				"at", last.Format("2006-01-02 15:04"), "age", domain.FormatAge(time.Since(last)))
+		}
+		b.logger.Warn("Backups are stale", zap.Time("last_backup", last), zap.Duration("max_age", maxAge))
+		if err := n.SendError(ctx, msg); err != nil {
+			b.logger.Warn("Backup freshness alert failed", zap.Error(err))
+			return
+		}
+		alerted, alertedFor = true, last
+	}
+
+	check()
+	ticker := time.NewTicker(freshnessInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
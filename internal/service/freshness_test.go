@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestBackup_WatchFreshness(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.MaxAgeHours = 24
+	defer service.SetFreshnessInterval(20 * time.Millisecond)()
+
+	var (
+		mu     sync.Mutex
+		alerts []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Embeds []struct{ Description string } `json:"embeds"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		for _, e := range body.Embeds {
+			alerts = append(alerts, e.Description)
+		}
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	cfg.Notifications.DiscordWebhook = srv.URL
+	store := service.NewStateStore(cfg)
+	_ = store.Update(func(st *domain.State) { st.LastSuccess[domain.OpBackup] = time.Now().Add(-30 * time.Hour) })
+
+	watchCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+	service.NewBackup(cfg, logger).WatchFreshness(watchCtx, service.NewNotification(cfg, logger))
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(alerts) != 1 {
+		t.Fatalf("got %d alerts, want one per stale stretch: %q", len(alerts), alerts)
+	}
+	if !strings.Contains(alerts[0], "24 hours") || !strings.Contains(alerts[0], "30h ago") {
+		t.Errorf("alert = %q", alerts[0])
+	}
+}
+
+func TestBackup_WatchFreshness_Fresh(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.MaxAgeHours = 24
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	cfg.Notifications.DiscordWebhook = srv.URL
+	_ = service.NewStateStore(cfg).RecordSuccess(domain.OpBackup)
+
+	watchCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	service.NewBackup(cfg, logger).WatchFreshness(watchCtx, service.NewNotification(cfg, logger))
+	if calls != 0 {
+		t.Errorf("fresh backup triggered %d alert(s)", calls)
+	}
+}
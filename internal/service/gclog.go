@@ -0,0 +1,102 @@
+package service
+
+import (
+	"bufio"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// maxLongestPauses is how many of the longest pauses a GC report lists.
+const maxLongestPauses = 5
+
+var (
+	// gcPauseLine matches the summary line unified logging writes under the
+	// bare gc tag for each pause, e.g.
+	// [...][12.345s][info][gc] GC(7) Pause Young (Normal) (G1 Evacuation Pause) 512M->128M(4096M) 12.345ms
+	gcPauseLine = regexp.MustCompile(`^(.*)\[gc\s*\]\s*GC\(\d+\) (Pause \w+)(.*?) ([\d.]+)ms$`)
+	gcUptime    = regexp.MustCompile(`\[(\d+(?:\.\d+)?)s\]`)
+	gcWallClock = regexp.MustCompile(`^\[(\d{4}-[^\]]+)\]`)
+	gcHeap      = regexp.MustCompile(`\d+[KMG]->\d+[KMG]\(\d+[KMG]\)`)
+)
+
+// GCReport summarizes the pauses in the current GC log written with
+// server.diagnostics.gc_log. The log restarts with each server start.
+func (s *Server) GCReport() (*domain.GCReport, error) {
+	path := filepath.Join(s.cfg.Paths.Logs, config.GCLogFile)
+	f, err := os.Open(path) //nolint:gosec // logs dir from config
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no GC log at %s: enable server.diagnostics.gc_log and restart the server", path)
+	} else if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	report := &domain.GCReport{File: path, Kinds: []domain.GCPauseKind{}, Longest: []domain.GCPause{}}
+	var pauses []domain.GCPause
+	kinds := map[string]*domain.GCPauseKind{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := sc.Text()
+		if m := gcUptime.FindStringSubmatch(line); m != nil {
+			if secs, err := strconv.ParseFloat(m[1], 64); err == nil {
+				report.Uptime = time.Duration(secs * float64(time.Second))
+			}
+		}
+		m := gcPauseLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		ms, err := strconv.ParseFloat(m[4], 64)
+		if err != nil {
+			continue
+		}
+		p := domain.GCPause{Kind: m[2], Heap: gcHeap.FindString(m[3]), Duration: time.Duration(ms * float64(time.Millisecond))}
+		if at := gcWallClock.FindStringSubmatch(m[1]); at != nil {
+			p.At = at[1]
+		} else if up := gcUptime.FindStringSubmatch(m[1]); up != nil {
+			p.At = up[1] + "s"
+		}
+		pauses = append(pauses, p)
+
+		k := kinds[p.Kind]
+		if k == nil {
+			k = &domain.GCPauseKind{Kind: p.Kind}
+			kinds[p.Kind] = k
+		}
+		k.Count++
+		k.Total += p.Duration
+		k.Max = max(k.Max, p.Duration)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(pauses) == 0 {
+		return report, nil
+	}
+
+	for _, k := range kinds {
+		report.Kinds = append(report.Kinds, *k)
+	}
+	slices.SortFunc(report.Kinds, func(a, b domain.GCPauseKind) int { return cmp.Compare(b.Total, a.Total) })
+	for _, p := range pauses {
+		report.Total += p.Duration
+	}
+	report.Pauses = len(pauses)
+	report.Mean = report.Total / time.Duration(len(pauses))
+	slices.SortStableFunc(pauses, func(a, b domain.GCPause) int { return cmp.Compare(b.Duration, a.Duration) })
+	report.Max = pauses[0].Duration
+	report.P95 = pauses[len(pauses)*5/100].Duration
+	report.Longest = pauses[:min(maxLongestPauses, len(pauses))]
+	return report, nil
+}
@@ -0,0 +1,45 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"craftops/internal/service"
+)
+
+func TestServer_GCReport(t *testing.T) {
+	cfg, logger, _ := setup(t)
+	svc := service.NewServer(cfg, logger)
+	if _, err := svc.GCReport(); err == nil || !strings.Contains(err.Error(), "gc_log") {
+		t.Errorf("missing log: err = %v", err)
+	}
+
+	writeFile(t, cfg.Paths.Logs, "gc.log", strings.Join([]string{
+		"[2024-06-01T12:00:00.000+0000][0.010s][info][gc,init] Version: 21.0.2+13 (release)",
+		"[2024-06-01T12:00:05.000+0000][5.000s][info][gc,start    ] GC(0) Pause Young (Normal) (G1 Evacuation Pause)",
+		"[2024-06-01T12:00:05.010+0000][5.010s][info][gc,phases   ] GC(0)   Evacuate Collection Set: 8.1ms",
+		"[2024-06-01T12:00:05.012+0000][5.012s][info][gc          ] GC(0) Pause Young (Normal) (G1 Evacuation Pause) 512M->128M(4096M) 12.000ms",
+		"[2024-06-01T12:01:00.000+0000][60.000s][info][gc          ] GC(1) Pause Young (Concurrent Start) (G1 Humongous Allocation) 900M->300M(4096M) 8.000ms",
+		"[2024-06-01T12:02:00.000+0000][120.000s][info][gc          ] GC(2) Pause Remark 310M->310M(4096M) 4.000ms",
+		"[2024-06-01T12:05:00.000+0000][300.000s][info][gc          ] GC(3) Pause Full (G1 Compaction Pause) 3900M->1200M(4096M) 250.500ms",
+		"[2024-06-01T12:10:00.000+0000][600.000s][info][gc,heap,exit] Heap",
+	}, "\n")+"\n")
+
+	report, err := svc.GCReport()
+	if err != nil {
+		t.Fatalf("GCReport: %v", err)
+	}
+	if report.Pauses != 4 || report.Uptime != 600*time.Second {
+		t.Errorf("pauses=%d uptime=%s", report.Pauses, report.Uptime)
+	}
+	if report.Max != 250500*time.Microsecond || report.Total != 274500*time.Microsecond {
+		t.Errorf("max=%s total=%s", report.Max, report.Total)
+	}
+	if len(report.Kinds) != 3 || report.Kinds[0].Kind != "Pause Full" || report.Kinds[1].Kind != "Pause Young" || report.Kinds[1].Count != 2 {
+		t.Errorf("kinds = %+v", report.Kinds)
+	}
+	if l := report.Longest[0]; l.At != "2024-06-01T12:05:00.000+0000" || l.Heap != "3900M->1200M(4096M)" {
+		t.Errorf("longest = %+v", l)
+	}
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// HealthProbe is one component's health checks. Name labels the result if
+// the probe times out.
+type HealthProbe struct {
+	Name string
+	Run  func(ctx context.Context) []domain.HealthCheck
+}
+
+// RunHealthChecks runs probes concurrently and returns their checks in probe
+// order. Each probe gets perCheck to finish and all share budget; a probe
+// that overruns is reported as WARN rather than waited on, and its context
+// is cancelled.
+func RunHealthChecks(ctx context.Context, perCheck, budget time.Duration, probes []HealthProbe) []domain.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	results := make([][]domain.HealthCheck, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pctx, cancel := context.WithTimeout(ctx, perCheck)
+			defer cancel()
+			done := make(chan []domain.HealthCheck, 1)
+			started := time.Now()
+			go func() { done <- p.Run(pctx) }()
+			select {
+			case checks := <-done:
+				results[i] = checks
+			case <-pctx.Done():
+				results[i] = []domain.HealthCheck{{Name: p.Name, Status: domain.StatusWarn,
+					Message: fmt.Sprintf("Timed out after %s", time.Since(started).Round(100*time.Millisecond))}}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var checks []domain.HealthCheck
+	for _, r := range results {
+		checks = append(checks, r...)
+	}
+	return checks
+}
+
+// severityRank orders statuses so Severity can cap them.
+var severityRank = map[domain.HealthStatus]int{domain.StatusOK: 0, domain.StatusWarn: 1, domain.StatusError: 2}
+
+// ApplyHealthPolicy drops ignored checks and caps the status of checks with
+// a configured severity, noting the change in the message.
+func ApplyHealthPolicy(policy config.HealthConfig, checks []domain.HealthCheck) []domain.HealthCheck {
+	out := make([]domain.HealthCheck, 0, len(checks))
+	for _, c := range checks {
+		if slices.ContainsFunc(policy.Ignore, func(n string) bool { return strings.EqualFold(n, c.Name) }) {
+			continue
+		}
+		for name, sev := range policy.Severity {
+			if !strings.EqualFold(name, c.Name) {
+				continue
+			}
+			capped := domain.HealthStatus(strings.ToUpper(sev))
+			if rank, ok := severityRank[c.Status]; ok && rank > severityRank[capped] {
+				c.Message = fmt.Sprintf("%s (%s, capped by health.severity)", c.Message, c.Status)
+				c.Status = capped
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
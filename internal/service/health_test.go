@@ -0,0 +1,70 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestRunHealthChecks_TimesOutSlowProbes(t *testing.T) {
+	ok := func(name string) func(context.Context) []domain.HealthCheck {
+		return func(context.Context) []domain.HealthCheck {
+			return []domain.HealthCheck{{Name: name, Status: domain.StatusOK}}
+		}
+	}
+	hang := func(ctx context.Context) []domain.HealthCheck {
+		<-ctx.Done()
+		time.Sleep(time.Second) // ignores cancellation for a while, like a stuck dial
+		return []domain.HealthCheck{{Name: "late", Status: domain.StatusOK}}
+	}
+
+	start := time.Now()
+	checks := service.RunHealthChecks(context.Background(), 50*time.Millisecond, time.Second, []service.HealthProbe{
+		{Name: "Paths", Run: ok("paths")},
+		{Name: "Modrinth API", Run: hang},
+		{Name: "Backup", Run: ok("backup")},
+	})
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %s, slow probe should not block the run", elapsed)
+	}
+	if len(checks) != 3 {
+		t.Fatalf("checks = %+v", checks)
+	}
+	if checks[0].Name != "paths" || checks[2].Name != "backup" {
+		t.Errorf("probe order not kept: %+v", checks)
+	}
+	if checks[1].Name != "Modrinth API" || checks[1].Status != domain.StatusWarn {
+		t.Errorf("slow probe = %+v, want WARN", checks[1])
+	}
+}
+
+func TestApplyHealthPolicy(t *testing.T) {
+	checks := []domain.HealthCheck{
+		{Name: "GNU screen", Status: domain.StatusError, Message: "Not installed"},
+		{Name: "Discord webhook", Status: domain.StatusWarn, Message: "Not configured"},
+		{Name: "Modrinth API", Status: domain.StatusError, Message: "Connection failed"},
+		{Name: "Java Runtime", Status: domain.StatusOK, Message: "21"},
+	}
+	policy := config.HealthConfig{
+		Ignore:   []string{"gnu screen"},
+		Severity: map[string]string{"Discord webhook": "ok", "Modrinth API": "warn", "Java Runtime": "warn"},
+	}
+	got := service.ApplyHealthPolicy(policy, checks)
+	if len(got) != 3 {
+		t.Fatalf("ignored check kept: %+v", got)
+	}
+	want := map[string]domain.HealthStatus{
+		"Discord webhook": domain.StatusOK,
+		"Modrinth API":    domain.StatusWarn,
+		"Java Runtime":    domain.StatusOK, // a cap never raises a status
+	}
+	for _, c := range got {
+		if c.Status != want[c.Name] {
+			t.Errorf("%s = %s, want %s", c.Name, c.Status, want[c.Name])
+		}
+	}
+}
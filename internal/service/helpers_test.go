@@ -1,7 +1,11 @@
 package service_test
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"crypto/sha1" //nolint:gosec // Modrinth identifies files by SHA-1
+	"encoding/hex"
 	"os"
 	"path/filepath"
 	"testing"
@@ -22,6 +26,8 @@ func setup(t *testing.T) (*config.Config, *zap.Logger, context.Context) {
 	cfg.Paths.Mods = filepath.Join(tmp, "mods")
 	cfg.Paths.Backups = filepath.Join(tmp, "backups")
 	cfg.Paths.Logs = filepath.Join(tmp, "logs")
+	cfg.Paths.Cache = filepath.Join(tmp, "cache")
+	cfg.Paths.State = filepath.Join(tmp, "state")
 
 	for _, p := range []string{cfg.Paths.Server, cfg.Paths.Mods, cfg.Paths.Backups, cfg.Paths.Logs} {
 		if err := os.MkdirAll(p, 0o750); err != nil {
@@ -34,3 +40,50 @@ func setup(t *testing.T) (*config.Config, *zap.Logger, context.Context) {
 
 	return cfg, zap.NewNop(), ctx
 }
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		t.Fatalf("MkdirAll(%s): %v", filepath.Dir(p), err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile(%s): %v", p, err)
+	}
+	return p
+}
+
+// archiveNames lists entry names in a .tar.gz backup.
+func archiveNames(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer f.Close() //nolint:errcheck
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+	}
+	return names
+}
+
+func sha1Hex(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		t.Fatalf("ReadFile(%s): %v", path, err)
+	}
+	sum := sha1.Sum(data) //nolint:gosec
+	return hex.EncodeToString(sum[:])
+}
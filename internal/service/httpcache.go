@@ -0,0 +1,90 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// responseCache stores API responses on disk alongside their validators
+// (ETag / Last-Modified) so repeat lookups can be answered with a 304.
+// A nil *responseCache is valid and caches nothing.
+type responseCache struct {
+	dir string
+}
+
+type cacheEntry struct {
+	URL          string          `json:"url"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	StoredAt     time.Time       `json:"stored_at"`
+	Body         json.RawMessage `json:"body"`
+}
+
+func newResponseCache(dir string) *responseCache {
+	if dir == "" {
+		return nil
+	}
+	return &responseCache{dir: dir}
+}
+
+func (c *responseCache) path(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// load returns the cached entry for url, or nil on miss or corruption.
+func (c *responseCache) load(url string) *cacheEntry {
+	if c == nil {
+		return nil
+	}
+	data, err := os.ReadFile(c.path(url))
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if json.Unmarshal(data, &e) != nil || e.URL != url {
+		return nil
+	}
+	return &e
+}
+
+// applyValidators adds conditional request headers from a cached entry.
+func (e *cacheEntry) applyValidators(req *http.Request) {
+	if e == nil {
+		return
+	}
+	if e.ETag != "" {
+		req.Header.Set("If-None-Match", e.ETag)
+	}
+	if e.LastModified != "" {
+		req.Header.Set("If-Modified-Since", e.LastModified)
+	}
+}
+
+// store saves body for url with any validators the response carried, so it can
+// serve conditional requests and offline runs. Failures are ignored: the cache
+// is an optimisation, never a source of errors.
+func (c *responseCache) store(url string, h http.Header, body []byte) {
+	if c == nil {
+		return
+	}
+	data, err := json.Marshal(cacheEntry{
+		URL:          url,
+		ETag:         h.Get("ETag"),
+		LastModified: h.Get("Last-Modified"),
+		StoredAt:     time.Now(),
+		Body:         body,
+	})
+	if err != nil || os.MkdirAll(c.dir, 0o750) != nil {
+		return
+	}
+	tmp := c.path(url) + ".tmp"
+	if os.WriteFile(tmp, data, 0o600) == nil {
+		_ = os.Rename(tmp, c.path(url))
+	}
+}
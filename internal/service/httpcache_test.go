@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestMods_ConditionalRequestUsesCache(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+
+	var notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/files/") {
+			_, _ = w.Write([]byte("JAR"))
+			return
+		}
+		if r.URL.Path == "/v2/projects" {
+			_, _ = w.Write([]byte("[]"))
+			return
+		}
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_ = json.NewEncoder(w).Encode(modrinthVersionFixture("mod.jar", "http://"+r.Host+"/files/mod.jar"))
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+	svc := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+
+	if _, err := svc.UpdateAll(ctx, false); err != nil {
+		t.Fatalf("first UpdateAll: %v", err)
+	}
+	result, err := svc.UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("second UpdateAll: %v", err)
+	}
+	if notModified.Load() != 1 {
+		t.Errorf("expected second lookup to be conditional, got %d 304s", notModified.Load())
+	}
+	if len(result.SkippedMods) != 1 {
+		t.Errorf("expected cached lookup to resolve and skip, got updated=%v failed=%v",
+			result.UpdatedMods, result.FailedMods)
+	}
+}
+
+func TestMods_DryRunSkipsInstalled(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := newMockModrinth(t, "/v2/project/sodium/version", "/files/mod-1.0.0.jar", []byte("NEW"))
+
+	cfg.DryRun = true
+	cfg.Mods.ModrinthSources = []string{"sodium", "lithium"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+	writeFile(t, cfg.Paths.Mods, "mod-1.0.0.jar", "OLD")
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll: %v", err)
+	}
+	if len(result.SkippedMods) != 1 {
+		t.Errorf("dry run should report installed mod as skipped, got %+v", result)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// newHTTPClient builds a client honoring the [network] proxy and CA settings.
+// Every outbound HTTP client should be created here so settings apply uniformly.
+// A CA bundle that fails to load is reported, but the returned client still
+// goes through the proxy, trusting only the system roots.
+func newHTTPClient(cfg *config.Config, timeout time.Duration) (*http.Client, error) {
+	transport, err := newTransport(cfg.Network)
+	if transport == nil {
+		return &http.Client{Timeout: timeout}, err
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}, err
+}
+
+// newTransport applies the proxy and CA bundle to a default transport. When
+// only the CA bundle fails it returns the proxied transport with the error.
+func newTransport(netCfg config.NetworkConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if netCfg.Proxy != "" {
+		proxyURL, err := url.Parse(netCfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if netCfg.CABundle != "" {
+		pem, err := os.ReadFile(netCfg.CABundle)
+		if err != nil {
+			return transport, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return transport, errors.New("CA bundle contains no valid certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return transport, nil
+}
+
+// CheckProxy verifies the configured proxy is reachable and the CA bundle loads.
+func CheckProxy(ctx context.Context, cfg *config.Config) domain.HealthCheck {
+	const name = "Network proxy"
+	if _, err := newTransport(cfg.Network); err != nil {
+		return domain.HealthCheck{Name: name, Status: domain.StatusError, Message: err.Error()}
+	}
+	if cfg.Network.Proxy == "" {
+		return domain.HealthCheck{Name: name, Status: domain.StatusOK, Message: "Not configured (direct or environment)"}
+	}
+	if cfg.Offline {
+		return domain.HealthCheck{Name: name, Status: domain.StatusSkipped, Message: "Offline mode"}
+	}
+
+	proxyURL, _ := url.Parse(cfg.Network.Proxy)
+	host := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := map[string]string{"http": "80", "https": "443", "socks5": "1080"}[proxyURL.Scheme]
+		host = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", host)
+	if err != nil {
+		return domain.HealthCheck{Name: name, Status: domain.StatusError, Message: "Unreachable: " + host}
+	}
+	_ = conn.Close()
+	return domain.HealthCheck{Name: name, Status: domain.StatusOK, Message: "Reachable: " + host}
+}
+
+// copyIdle copies body into dst, calling cancel with ErrDownloadStalled when
+// no bytes arrive for idle; cancel must abort the request body is read from.
+// An idle of zero only stops when ctx is done.
+func copyIdle(ctx context.Context, cancel context.CancelCauseFunc, idle time.Duration, dst io.Writer, body io.Reader) error {
+	if idle <= 0 {
+		_, err := copyContext(ctx, dst, body)
+		return err
+	}
+	timer := time.AfterFunc(idle, func() { cancel(domain.ErrDownloadStalled) })
+	defer timer.Stop()
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := body.Read(buf)
+		if n > 0 {
+			timer.Reset(idle)
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			if cause := context.Cause(ctx); errors.Is(cause, domain.ErrDownloadStalled) {
+				return fmt.Errorf("%w: no data for %s", cause, idle)
+			} else if cause != nil {
+				return cause
+			}
+			return err
+		}
+	}
+}
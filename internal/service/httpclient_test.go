@@ -0,0 +1,81 @@
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestCheckProxy_NotConfigured(t *testing.T) {
+	cfg, _, ctx := setup(t)
+	if c := service.CheckProxy(ctx, cfg); c.Status != domain.StatusOK {
+		t.Errorf("expected OK without proxy, got %s: %s", c.Status, c.Message)
+	}
+}
+
+func TestCheckProxy_Reachable(t *testing.T) {
+	cfg, _, ctx := setup(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	cfg.Network.Proxy = srv.URL
+
+	if c := service.CheckProxy(ctx, cfg); c.Status != domain.StatusOK {
+		t.Errorf("expected OK for listening proxy, got %s: %s", c.Status, c.Message)
+	}
+}
+
+func TestCheckProxy_Unreachable(t *testing.T) {
+	cfg, _, ctx := setup(t)
+	srv := httptest.NewServer(http.NotFoundHandler())
+	cfg.Network.Proxy = srv.URL
+	srv.Close()
+
+	if c := service.CheckProxy(ctx, cfg); c.Status != domain.StatusError {
+		t.Errorf("expected ERROR for closed proxy, got %s: %s", c.Status, c.Message)
+	}
+}
+
+func TestCheckProxy_BadCABundle(t *testing.T) {
+	cfg, _, ctx := setup(t)
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	_ = os.WriteFile(bundle, []byte("not a certificate"), 0o600)
+	cfg.Network.CABundle = bundle
+
+	c := service.CheckProxy(ctx, cfg)
+	if c.Status != domain.StatusError || !strings.Contains(c.Message, "no valid certificates") {
+		t.Errorf("expected CA bundle error, got %s: %s", c.Status, c.Message)
+	}
+}
+
+func TestHTTPClient_BadCABundleKeepsProxy(t *testing.T) {
+	cfg, _, _ := setup(t)
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+	}))
+	t.Cleanup(proxy.Close)
+	cfg.Network.Proxy = proxy.URL
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	_ = os.WriteFile(bundle, []byte("not a certificate"), 0o600)
+	cfg.Network.CABundle = bundle
+
+	client, err := service.NewHTTPClient(cfg, 5*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "no valid certificates") {
+		t.Errorf("expected CA bundle error, got %v", err)
+	}
+	resp, err := client.Get("http://mods.example/api")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	_ = resp.Body.Close()
+	if proxied != "http://mods.example/api" {
+		t.Errorf("request bypassed the proxy, proxy saw %q", proxied)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// ignoreFile lists per-server backup exclusions in the server directory.
+const ignoreFile = ".craftopsignore"
+
+// ignoreRule is one .craftopsignore line translated to a doublestar pattern
+// relative to the server directory.
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreRules is a parsed .craftopsignore. As in gitignore the last
+// matching rule decides, and a file under an excluded directory cannot be
+// re-included because the directory is never walked.
+type ignoreRules []ignoreRule
+
+// loadIgnore reads the .craftopsignore in dir; a missing or unreadable
+// file excludes nothing.
+func loadIgnore(dir string) ignoreRules {
+	data, err := os.ReadFile(filepath.Join(dir, ignoreFile)) //nolint:gosec // server dir from config
+	if err != nil {
+		return nil
+	}
+	return parseIgnore(string(data))
+}
+
+// parseIgnore translates gitignore syntax: # comments, ! negation, a
+// trailing / for directories only, and patterns without an inner / matching
+// at any depth.
+func parseIgnore(text string) ignoreRules {
+	var rules ignoreRules
+	for line := range strings.Lines(text) {
+		line = strings.TrimRight(line, "\r\n\t ")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r ignoreRule
+		if strings.HasPrefix(line, "!") {
+			r.negate, line = true, line[1:]
+		} else {
+			line = strings.TrimPrefix(line, `\`) // \# and \! match literally
+		}
+		if strings.HasSuffix(line, "/") {
+			r.dirOnly, line = true, strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		r.pattern = line
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// excluded reports whether relPath (slash-separated, relative to the
+// server directory) is ignored.
+func (rules ignoreRules) excluded(relPath string, isDir bool) bool {
+	if relPath == "." {
+		return false
+	}
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if matched, _ := doublestar.Match(r.pattern, relPath); matched {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
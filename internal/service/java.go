@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"craftops/internal/domain"
+)
+
+var javaVersionPattern = regexp.MustCompile(`version "(\d+)(?:\.(\d+))?`)
+
+// javaHomeGlobs are where JDKs are commonly installed.
+var javaHomeGlobs = []string{
+	"/usr/lib/jvm/*",
+	"/usr/java/*",
+	"/opt/java/*",
+	"/opt/jdk*",
+	"/Library/Java/JavaVirtualMachines/*/Contents/Home",
+	"~/.sdkman/candidates/java/*",
+	"~/.jdks/*",
+}
+
+// javaRuntime is an installed java binary and its major version.
+type javaRuntime struct {
+	Path  string
+	Major int
+}
+
+// javaBinary picks the java executable: server.java_path if set, else a
+// discovered runtime matching server.java_version, else java from PATH.
+func (s *Server) javaBinary(ctx context.Context) (string, error) {
+	if s.cfg.Server.JavaPath != "" {
+		return s.cfg.Server.JavaPath, nil
+	}
+	if want := s.cfg.Server.JavaVersion; want > 0 {
+		for _, rt := range discoverJava(ctx) {
+			if rt.Major == want {
+				return rt.Path, nil
+			}
+		}
+		return "", fmt.Errorf("no Java %d runtime found; set server.java_path", want)
+	}
+	return "java", nil
+}
+
+// discoverJava lists java runtimes from JAVA_HOME and the usual install
+// locations, newest major version first.
+func discoverJava(ctx context.Context) []javaRuntime {
+	homes := []string{os.Getenv("JAVA_HOME")}
+	home, _ := os.UserHomeDir()
+	for _, g := range javaHomeGlobs {
+		if g[0] == '~' {
+			g = filepath.Join(home, g[1:])
+		}
+		matches, _ := filepath.Glob(g)
+		homes = append(homes, matches...)
+	}
+
+	var found []javaRuntime
+	seen := map[string]bool{}
+	for _, h := range homes {
+		if h == "" {
+			continue
+		}
+		bin, err := filepath.EvalSymlinks(filepath.Join(h, "bin", "java"))
+		if err != nil || seen[bin] {
+			continue
+		}
+		seen[bin] = true
+		if major, err := javaMajor(ctx, bin); err == nil {
+			found = append(found, javaRuntime{Path: bin, Major: major})
+		}
+	}
+	slices.SortStableFunc(found, func(a, b javaRuntime) int { return b.Major - a.Major })
+	return found
+}
+
+// javaMajor runs `java -version` and returns the major version (8 for 1.8).
+func javaMajor(ctx context.Context, bin string) (int, error) {
+	out, err := exec.CommandContext(ctx, bin, "-version").CombinedOutput() //nolint:gosec // binary from config or discovery
+	if err != nil {
+		return 0, err
+	}
+	return parseJavaVersion(string(out))
+}
+
+func parseJavaVersion(out string) (int, error) {
+	m := javaVersionPattern.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("unrecognised java -version output")
+	}
+	major, _ := strconv.Atoi(m[1])
+	if major == 1 && m[2] != "" {
+		major, _ = strconv.Atoi(m[2])
+	}
+	return major, nil
+}
+
+// checkJava validates the selected runtime for the health report.
+func (s *Server) checkJava(ctx context.Context) domain.HealthCheck {
+	const name = "Java Runtime"
+	bin, err := s.javaBinary(ctx)
+	if err != nil {
+		return domain.HealthCheck{Name: name, Status: domain.StatusError, Message: err.Error()}
+	}
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return domain.HealthCheck{Name: name, Status: domain.StatusError, Message: bin + " not found"}
+	}
+	major, err := javaMajor(ctx, path)
+	if err != nil {
+		return domain.HealthCheck{Name: name, Status: domain.StatusError, Message: fmt.Sprintf("%s failed: %v", path, err)}
+	}
+	msg := fmt.Sprintf("Java %d (%s)", major, path)
+	if want := s.cfg.Server.JavaVersion; want > 0 && major != want {
+		return domain.HealthCheck{Name: name, Status: domain.StatusError, Message: fmt.Sprintf("%s, want Java %d", msg, want)}
+	}
+	return domain.HealthCheck{Name: name, Status: domain.StatusOK, Message: msg}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"craftops/internal/domain"
+)
+
+// runScriptArgs finds the @args file a Forge or NeoForge run.sh passes to java.
+var runScriptArgs = regexp.MustCompile(`@(libraries/\S+/unix_args\.txt)`)
+
+// launchArgs returns what follows the java binary and JVM flags on the
+// server's command line: -jar with server.jar_name or, for Forge and
+// NeoForge installs that have no such jar, the @args files run.sh passes.
+// user_jvm_args.txt comes after the configured flags so its settings win.
+func launchArgs(serverDir, jarName string) ([]string, error) {
+	if _, err := os.Stat(filepath.Join(serverDir, jarName)); err == nil {
+		return []string{"-jar", jarName, "nogui"}, nil
+	}
+	unixArgs := forgeArgsFile(serverDir)
+	if unixArgs == "" {
+		return nil, domain.ErrServerJarNotFound
+	}
+	var args []string
+	if _, err := os.Stat(filepath.Join(serverDir, "user_jvm_args.txt")); err == nil {
+		args = append(args, "@user_jvm_args.txt")
+	}
+	return append(args, "@"+unixArgs, "nogui"), nil
+}
+
+// forgeArgsFile returns the unix_args.txt of a Forge or NeoForge install
+// relative to serverDir, preferring the one run.sh references over the last
+// installed version, or "" when there is none.
+func forgeArgsFile(serverDir string) string {
+	if data, err := os.ReadFile(filepath.Join(serverDir, "run.sh")); err == nil { //nolint:gosec // server dir from config
+		if m := runScriptArgs.FindSubmatch(data); m != nil {
+			if _, err := os.Stat(filepath.Join(serverDir, filepath.FromSlash(string(m[1])))); err == nil {
+				return string(m[1])
+			}
+		}
+	}
+	found, _ := filepath.Glob(filepath.Join(serverDir, "libraries", "net", "*", "*", "*", "unix_args.txt"))
+	if len(found) == 0 {
+		return ""
+	}
+	rel, err := filepath.Rel(serverDir, found[len(found)-1])
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,39 @@
+package service_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestLaunchArgs(t *testing.T) {
+	cfg, _, _ := setup(t)
+	dir := cfg.Paths.Server
+	if _, err := service.LaunchArgs(dir, "server.jar"); !errors.Is(err, domain.ErrServerJarNotFound) {
+		t.Errorf("empty server dir: err = %v", err)
+	}
+
+	writeFile(t, dir, "libraries/net/minecraftforge/forge/1.20.1-47.1.0/unix_args.txt", "-cp x")
+	writeFile(t, dir, "libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt", "-cp y")
+	args, err := service.LaunchArgs(dir, "server.jar")
+	if want := []string{"@libraries/net/minecraftforge/forge/1.20.1-47.2.0/unix_args.txt", "nogui"}; err != nil || !slices.Equal(args, want) {
+		t.Errorf("without run.sh = %v, %v", args, err)
+	}
+
+	writeFile(t, dir, "user_jvm_args.txt", "-Xmx6G")
+	writeFile(t, dir, "run.sh", "#!/usr/bin/env sh\n"+
+		"java @user_jvm_args.txt @libraries/net/minecraftforge/forge/1.20.1-47.1.0/unix_args.txt \"$@\"\n")
+	args, err = service.LaunchArgs(dir, "server.jar")
+	want := []string{"@user_jvm_args.txt", "@libraries/net/minecraftforge/forge/1.20.1-47.1.0/unix_args.txt", "nogui"}
+	if err != nil || !slices.Equal(args, want) {
+		t.Errorf("with run.sh = %v, %v", args, err)
+	}
+
+	writeFile(t, dir, "server.jar", "jar")
+	if args, _ = service.LaunchArgs(dir, "server.jar"); !slices.Equal(args, []string{"-jar", "server.jar", "nogui"}) {
+		t.Errorf("with server.jar = %v", args)
+	}
+}
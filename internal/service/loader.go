@@ -0,0 +1,86 @@
+package service
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// loaderManifests maps the metadata file a jar ships to the loader it targets.
+var loaderManifests = map[string]string{
+	"fabric.mod.json":             "fabric",
+	"quilt.mod.json":              "quilt",
+	"META-INF/mods.toml":          "forge",
+	"META-INF/neoforge.mods.toml": "neoforge",
+}
+
+// loaderCompat lists the jar loaders each server loader can run. Quilt loads
+// Fabric mods; the others only their own.
+var loaderCompat = map[string][]string{
+	"fabric":   {"fabric"},
+	"quilt":    {"quilt", "fabric"},
+	"forge":    {"forge"},
+	"neoforge": {"neoforge"},
+}
+
+// jarLoaders returns the loaders a jar declares metadata for, or nil if it
+// cannot be read or declares none (libraries, plain jars).
+func jarLoaders(path string) []string {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = zr.Close() }()
+	var loaders []string
+	for _, f := range zr.File {
+		if l, ok := loaderManifests[f.Name]; ok {
+			loaders = append(loaders, l)
+		}
+	}
+	return loaders
+}
+
+// foreignJars lists jars in the mods directory built only for other loaders.
+func (m *Mods) foreignJars() []string {
+	accepts := loaderCompat[m.cfg.Minecraft.Modloader]
+	files, _ := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
+	var foreign []string
+	for _, file := range files {
+		loaders := jarLoaders(file)
+		if len(loaders) > 0 && !slices.ContainsFunc(loaders, func(l string) bool { return slices.Contains(accepts, l) }) {
+			foreign = append(foreign, filepath.Base(file))
+		}
+	}
+	if len(foreign) > 0 {
+		m.logger.Warn("Jars built for another loader in mods directory",
+			zap.String("loader", m.cfg.Minecraft.Modloader), zap.Strings("jars", foreign))
+	}
+	return foreign
+}
+
+// retiredDir is where jars for a previous loader are moved, next to the mods
+// directory so the server does not load them.
+func (m *Mods) retiredDir(loader string) string {
+	return filepath.Join(filepath.Dir(m.cfg.Paths.Mods), "mods."+loader)
+}
+
+// retireJar moves a locked jar for a previous loader out of the mods directory.
+func (m *Mods) retireJar(locked domain.LockedMod) {
+	if m.cfg.DryRun || locked.Filename == "" {
+		return
+	}
+	dir := m.retiredDir(locked.Loader)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		m.logger.Warn("Failed to create retired mods directory", zap.Error(err))
+		return
+	}
+	src := filepath.Join(m.cfg.ModsDir(), locked.Filename)
+	if err := os.Rename(src, filepath.Join(dir, locked.Filename)); err != nil && !os.IsNotExist(err) {
+		m.logger.Warn("Failed to move jar for previous loader", zap.String("file", locked.Filename), zap.Error(err))
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"craftops/internal/domain"
+)
+
+// Export builds a manifest of the exact mod set pinned in the lockfile for
+// the configured sources. Every source must be installed first.
+func (m *Mods) Export() (*domain.ModManifest, error) {
+	st, err := m.state.Load()
+	if err != nil {
+		return nil, err
+	}
+	manifest := &domain.ModManifest{
+		MinecraftVersion: m.cfg.Minecraft.Version,
+		Modloader:        m.cfg.Minecraft.Modloader,
+		Mods:             []domain.ManifestMod{},
+	}
+	var missing []string
+	for _, src := range m.cfg.Mods.ModrinthSources {
+		slug, err := parseProjectID(src)
+		if err != nil {
+			return nil, err
+		}
+		lm, ok := st.Mods[slug]
+		if !ok {
+			missing = append(missing, slug)
+			continue
+		}
+		manifest.Mods = append(manifest.Mods, domain.ManifestMod{
+			Slug:      slug,
+			VersionID: lm.VersionID,
+			Version:   lm.Version,
+			Filename:  lm.Filename,
+			SHA1:      lm.SHA1,
+		})
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("not in the lockfile yet (run `craftops mods update`): %s", strings.Join(missing, ", "))
+	}
+	slices.SortFunc(manifest.Mods, func(a, b domain.ManifestMod) int { return strings.Compare(a.Slug, b.Slug) })
+	return manifest, nil
+}
+
+// manifestSlug is what Import accepts as a project slug before declaring
+// it as a mod source.
+var manifestSlug = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// Import installs the exact versions pinned by manifest, checking each file
+// against its sha1, and declares any new projects as mod sources. It returns
+// the sources it added to the config. A manifest naming a file outside the
+// mods directory or a malformed slug is refused before anything changes.
+func (m *Mods) Import(ctx context.Context, manifest *domain.ModManifest) (*domain.ModUpdateResult, []string, error) {
+	if err := checkManifest(manifest); err != nil {
+		return nil, nil, err
+	}
+	st, err := m.state.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+	res := &domain.ModUpdateResult{
+		UpdatedMods: []string{},
+		FailedMods:  make(map[string]string),
+		SkippedMods: []string{},
+	}
+	stage := m.stagingDir()
+	if err := os.RemoveAll(stage); err != nil {
+		return res, nil, fmt.Errorf("clearing staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stage) }()
+
+	var staged []*stagedMod
+	for _, mm := range manifest.Mods {
+		if ctx.Err() != nil {
+			break
+		}
+		locked := st.Mods[mm.Slug]
+		started := time.Now()
+		s, err := m.importMod(ctx, mm, locked, stage)
+		d := domain.ModDetail{Name: mm.Slug, Outcome: domain.ModSkipped, From: locked.Version, To: mm.Version}
+		d.Duration = time.Since(started)
+		switch {
+		case err != nil:
+			d.Outcome, d.Error, d.ErrorKind = domain.ModFailed, err.Error(), domain.ClassifyModError(err)
+		case s != nil:
+			s.detail = d
+			staged = append(staged, s)
+			continue
+		}
+		res.Record(d)
+	}
+	m.applyStaged(ctx, stage, staged, res)
+	if err := ctx.Err(); err != nil {
+		return res, nil, err
+	}
+
+	declared := m.declaredSlugs()
+	var added []string
+	for _, mm := range manifest.Mods {
+		if !declared[mm.Slug] {
+			declared[mm.Slug] = true
+			added = append(added, "https://modrinth.com/mod/"+mm.Slug)
+		}
+	}
+	m.cfg.Mods.ModrinthSources = append(m.cfg.Mods.ModrinthSources, added...)
+	return res, added, nil
+}
+
+// checkManifest rejects entries of an untrusted manifest whose slug could
+// not be a Modrinth project or whose filename is not a jar directly in the
+// mods directory.
+func checkManifest(manifest *domain.ModManifest) error {
+	for _, mm := range manifest.Mods {
+		if !manifestSlug.MatchString(mm.Slug) {
+			return fmt.Errorf("manifest entry has an invalid slug %q", mm.Slug)
+		}
+		if filepath.Base(mm.Filename) != mm.Filename || !strings.HasSuffix(mm.Filename, ".jar") {
+			return fmt.Errorf("manifest entry %s has an invalid filename %q", mm.Slug, mm.Filename)
+		}
+	}
+	return nil
+}
+
+// importMod stages one pinned mod for applyStaged unless a jar with its
+// hash is already in place, replacing the previously locked jar if the
+// filename changed.
+func (m *Mods) importMod(ctx context.Context, mm domain.ManifestMod, locked domain.LockedMod, stage string) (*stagedMod, error) {
+	if mm.VersionID == "" {
+		return nil, errors.New("manifest entry has no version_id")
+	}
+	info := &domain.ModInfo{VersionID: mm.VersionID, Version: mm.Version, Filename: mm.Filename, ProjectName: mm.Slug}
+	path := filepath.Join(m.cfg.ModsDir(), mm.Filename)
+	if sum, err := fileSHA1(path); err == nil && mm.SHA1 != "" && sum == mm.SHA1 {
+		if !m.cfg.DryRun {
+			m.lock(mm.Slug, info)
+		}
+		return nil, nil
+	}
+
+	info, err := m.fetchVersion(ctx, mm.VersionID, mm.Slug)
+	if err != nil {
+		return nil, err
+	}
+	// The manifest pins the jar: downloadMod checks it before the jar is
+	// staged.
+	if mm.SHA1 != "" {
+		info.SHA1 = mm.SHA1
+	}
+	if _, err := m.downloadMod(ctx, info, true, stage); err != nil {
+		return nil, err
+	}
+	s := &stagedMod{project: mm.Slug, info: info}
+	if locked.Filename != "" && locked.Filename != info.Filename {
+		s.replace = locked.Filename
+	}
+	return s, nil
+}
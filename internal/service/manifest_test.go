@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestMods_ExportImport(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	jarPath := filepath.Join(t.TempDir(), "lithium.jar")
+	writeJar(t, jarPath, "fabric.mod.json")
+	jar, _ := os.ReadFile(jarPath) //nolint:gosec
+	sum := sha1Hex(t, jarPath)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/version/AABBccDD":
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("lithium-0.12.jar", "http://"+r.Host+"/dl/lithium.jar")[0])
+		case "/v2/version/v-bad":
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("bad.jar", "http://"+r.Host+"/dl/lithium.jar")[0])
+		case "/dl/lithium.jar":
+			_, _ = w.Write(jar)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	manifest := &domain.ModManifest{
+		MinecraftVersion: cfg.Minecraft.Version,
+		Modloader:        cfg.Minecraft.Modloader,
+		Mods: []domain.ManifestMod{
+			{Slug: "lithium", VersionID: "AABBccDD", Version: "1.0.0", Filename: "lithium-0.12.jar", SHA1: sum},
+			{Slug: "tampered", VersionID: "v-bad", Version: "1.0.0", Filename: "bad.jar", SHA1: "deadbeef"},
+		},
+	}
+	cfg.Mods.ModrinthSources = nil
+	cfg.Mods.MaxRetries = 0
+	// A working jar already at the tampered entry's filename.
+	writeFile(t, cfg.Paths.Mods, "bad.jar", "good")
+	svc := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+	// Under the default atomic policy the failure keeps every jar out.
+	res, _, err := svc.Import(ctx, manifest)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(res.UpdatedMods) != 0 || len(res.FailedMods) != 2 {
+		t.Errorf("atomic import: updated %v, failed %v", res.UpdatedMods, res.FailedMods)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, "lithium-0.12.jar")); err == nil {
+		t.Error("atomic import installed a jar although another failed")
+	}
+
+	cfg.Mods.ApplyPolicy = config.ModsApplyPartial
+	cfg.Mods.ModrinthSources = nil
+	res, added, err := svc.Import(ctx, manifest)
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if len(res.UpdatedMods) != 1 || res.UpdatedMods[0] != "lithium" {
+		t.Errorf("UpdatedMods = %v", res.UpdatedMods)
+	}
+	if msg := res.FailedMods["tampered"]; !strings.Contains(msg, domain.ErrHashMismatch.Error()) {
+		t.Errorf("hash mismatch not reported: %v", res.FailedMods)
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.Paths.Mods, "bad.jar")); string(data) != "good" {
+		t.Errorf("jar with the wrong hash replaced the installed one: %q", data)
+	}
+	if len(added) != 2 || len(cfg.Mods.ModrinthSources) != 2 {
+		t.Errorf("added = %v, sources = %v", added, cfg.Mods.ModrinthSources)
+	}
+
+	// Export only lists installed sources; drop the failed one and round-trip.
+	cfg.Mods.ModrinthSources = []string{"lithium"}
+	out, err := svc.Export()
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if len(out.Mods) != 1 || out.Mods[0] != manifest.Mods[0] {
+		t.Errorf("Export = %+v, want %+v", out.Mods, manifest.Mods[:1])
+	}
+
+	cfg.Mods.ModrinthSources = []string{"lithium", "sodium"}
+	if _, err := svc.Export(); err == nil {
+		t.Error("Export should fail while a source is not installed")
+	}
+}
+
+func TestMods_Import_RejectsUnsafeEntries(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Mods.ModrinthSources = nil
+	svc := service.NewMods(cfg, logger)
+	for _, mm := range []domain.ManifestMod{
+		{Slug: "lithium", VersionID: "v1", Filename: "../server.jar"},
+		{Slug: "lithium", VersionID: "v1", Filename: "lithium.txt"},
+		{Slug: "../lithium", VersionID: "v1", Filename: "lithium.jar"},
+		{Slug: "a/mod/b", VersionID: "v1", Filename: "lithium.jar"},
+	} {
+		manifest := &domain.ModManifest{Mods: []domain.ManifestMod{mm}}
+		if _, _, err := svc.Import(ctx, manifest); err == nil {
+			t.Errorf("Import accepted %+v", mm)
+		}
+	}
+	if len(cfg.Mods.ModrinthSources) != 0 {
+		t.Errorf("rejected manifest declared sources: %v", cfg.Mods.ModrinthSources)
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// requestStats counts outbound HTTP traffic for the life of the process.
+var requestStats struct {
+	requests, failures, retries, bytes atomic.Int64
+}
+
+// RequestStats returns the outbound HTTP traffic counted so far.
+func RequestStats() domain.RequestStats {
+	return domain.RequestStats{
+		Requests:        requestStats.requests.Load(),
+		Failures:        requestStats.failures.Load(),
+		Retries:         requestStats.retries.Load(),
+		BytesDownloaded: requestStats.bytes.Load(),
+	}
+}
+
+// meteredTransport counts every Modrinth API and download request and logs
+// it at debug level once its body is closed, when the bytes are known.
+type meteredTransport struct {
+	base   http.RoundTripper
+	logger *zap.Logger
+}
+
+func (t *meteredTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	requestStats.requests.Add(1)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		requestStats.failures.Add(1)
+		t.logger.Debug("HTTP request failed", zap.String("method", req.Method),
+			zap.String("url", redactURL(req)), zap.Duration("duration", time.Since(start)), zap.Error(err))
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		requestStats.failures.Add(1)
+	}
+	resp.Body = &meteredBody{ReadCloser: resp.Body, done: func(n int64) {
+		requestStats.bytes.Add(n)
+		t.logger.Debug("HTTP request", zap.String("method", req.Method), zap.String("url", redactURL(req)),
+			zap.Int("status", resp.StatusCode), zap.Duration("duration", time.Since(start)), zap.Int64("bytes", n))
+	}}
+	return resp, nil
+}
+
+// redactURL drops userinfo, which a mirror URL may carry.
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	return u.String()
+}
+
+type meteredBody struct {
+	io.ReadCloser
+	n    int64
+	done func(n int64)
+}
+
+func (b *meteredBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *meteredBody) Close() error {
+	if b.done != nil {
+		b.done(b.n)
+		b.done = nil
+	}
+	return b.ReadCloser.Close()
+}
+
+func transportOrDefault(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		return http.DefaultTransport
+	}
+	return rt
+}
@@ -1,14 +1,20 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha1" //nolint:gosec // Modrinth file hashes
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -20,38 +26,102 @@ import (
 	"craftops/internal/domain"
 )
 
-const userAgent = "craftops/2.0"
+const (
+	userAgent   = "dacrab/craftops/2.0"
+	modrinthAPI = "https://api.modrinth.com/v2"
+
+	// modrinthBatchSize bounds ids per bulk lookup to keep request URLs short.
+	modrinthBatchSize = 100
+)
 
 // Mods handles automated mod updates from Modrinth.
 type Mods struct {
 	cfg    *config.Config
 	logger *zap.Logger
 	client *http.Client
+	cache  *responseCache
+	state  *StateStore
+
+	registerOnce sync.Once
 }
 
 // NewMods creates a mod manager.
 func NewMods(cfg *config.Config, logger *zap.Logger) *Mods {
+	client, err := newHTTPClient(cfg, time.Duration(cfg.Mods.Timeout)*time.Second)
+	if err != nil {
+		logger.Warn("Network settings not applied to Modrinth client", zap.Error(err))
+	}
+	client.Transport = &meteredTransport{base: transportOrDefault(client.Transport), logger: logger}
 	return &Mods{
 		cfg:    cfg,
 		logger: logger,
-		client: &http.Client{Timeout: time.Duration(cfg.Mods.Timeout) * time.Second},
+		client: client,
+		cache:  newResponseCache(modrinthCacheDir(cfg)),
+		state:  NewStateStore(cfg),
+	}
+}
+
+func modrinthCacheDir(cfg *config.Config) string {
+	if cfg.Paths.Cache == "" {
+		return ""
 	}
+	return filepath.Join(cfg.Paths.Cache, "modrinth")
 }
 
 // UpdateAll downloads the latest versions of all configured mods concurrently.
+// Downloads land in a staging directory and are moved into the mods directory
+// together once all have finished; see applyStaged.
 func (m *Mods) UpdateAll(ctx context.Context, force bool) (*domain.ModUpdateResult, error) {
-	m.logger.Info("Starting mod update", zap.Bool("force", force))
+	return m.UpdateSelected(ctx, force, domain.ModFilter{})
+}
+
+// UpdateSelected is UpdateAll restricted to the sources accepted by filter.
+func (m *Mods) UpdateSelected(ctx context.Context, force bool, filter domain.ModFilter) (*domain.ModUpdateResult, error) {
+	ctx, span := startSpan(ctx, "mods.update", "force", force)
+	m.logger.Info("Starting mod update", zap.Bool("force", force),
+		zap.Strings("only", filter.Only), zap.Strings("exclude", filter.Exclude))
 	res := &domain.ModUpdateResult{
 		UpdatedMods: []string{},
 		FailedMods:  make(map[string]string),
 		SkippedMods: []string{},
 	}
 
-	sources := m.cfg.Mods.ModrinthSources
+	sources := m.selectSources(filter)
+	defer func() {
+		span.set("mods.updated", len(res.UpdatedMods))
+		span.set("mods.failed", len(res.FailedMods))
+		span.set("mods.skipped", len(res.SkippedMods))
+		span.finish(nil)
+	}()
 	if len(sources) == 0 {
 		return res, nil
 	}
 
+	projects, err := m.projects(ctx, sources)
+	if err != nil {
+		m.logger.Debug("Bulk project lookup failed", zap.Error(err))
+	}
+	advisories, blocked := m.checkAdvisories(ctx, sources, projects)
+	res.Advisories = advisories
+	sources = slices.DeleteFunc(sources, func(src string) bool {
+		reason, ok := blocked[src]
+		if ok {
+			name, _ := parseProjectID(src)
+			res.Record(domain.ModDetail{Name: name, Outcome: domain.ModFailed,
+				Error: fmt.Errorf("%w: %s", domain.ErrModFlagged, reason).Error(), ErrorKind: domain.ModErrFlagged})
+		}
+		return ok
+	})
+
+	jarsBefore, lockBefore := m.snapshot()
+	resolved, owners := m.resolveBatch(ctx, sources, projects)
+	stage := m.stagingDir()
+	if err := os.RemoveAll(stage); err != nil {
+		return res, fmt.Errorf("clearing staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(stage) }()
+
+	var staged []*stagedMod
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 	sem := semaphore.NewWeighted(int64(m.cfg.Mods.ConcurrentDownloads))
@@ -64,29 +134,71 @@ func (m *Mods) UpdateAll(ctx context.Context, force bool) (*domain.ModUpdateResu
 		go func() {
 			defer sem.Release(1)
 			defer wg.Done()
-			updated, name, err := m.updateMod(ctx, src, force)
-			if name == "" {
-				name = src
+			started := time.Now()
+			s, d, err := m.updateMod(ctx, src, force, resolved, stage)
+			d.Outcome = domain.ModSkipped
+			if d.Name == "" {
+				d.Name = src
 			}
+			d.Duration = time.Since(started)
 			mu.Lock()
 			defer mu.Unlock()
 			switch {
 			case err != nil:
-				res.FailedMods[name] = err.Error()
-			case updated:
-				res.UpdatedMods = append(res.UpdatedMods, name)
-			default:
-				res.SkippedMods = append(res.SkippedMods, name)
+				d.Outcome, d.Error, d.ErrorKind = domain.ModFailed, err.Error(), domain.ClassifyModError(err)
+			case s != nil:
+				s.detail = d
+				staged = append(staged, s)
+				return
 			}
+			res.Record(d)
 		}()
 	}
 	wg.Wait()
+	m.applyStaged(ctx, stage, staged, res)
+	switch {
+	case !m.cfg.Mods.Strict || ctx.Err() != nil:
+	case len(res.FailedMods) > 0 || !filter.IsEmpty():
+		// A failed or filtered-out mod may lack the lock entry that marks
+		// its jar as declared.
+		m.logger.Info("Skipping quarantine: not every mod was updated")
+	default:
+		res.Quarantined = m.quarantineUndeclared(projects, owners)
+	}
+	res.ForeignJars = m.foreignJars()
+	if !m.cfg.DryRun {
+		jarsAfter, lockAfter := m.snapshot()
+		res.Diff = domain.DiffJars(jarsBefore, jarsAfter, lockBefore, lockAfter)
+	}
+	if len(res.FailedMods) == 0 {
+		if err := m.state.RecordSuccess(domain.OpModUpdate); err != nil {
+			m.logger.Warn("Failed to record mod update in state", zap.Error(err))
+		}
+	}
 	return res, nil
 }
 
+// snapshot lists the jars in the mods directory and the lockfile entries,
+// for the diff of an update.
+func (m *Mods) snapshot() ([]domain.JarFile, map[string]domain.LockedMod) {
+	installed, err := m.ListInstalled()
+	if err != nil {
+		m.logger.Warn("Listing mods for the update diff failed", zap.Error(err))
+	}
+	jars := make([]domain.JarFile, 0, len(installed))
+	for _, mod := range installed {
+		jars = append(jars, domain.JarFile{Name: mod.Filename, Size: mod.Size, Modified: mod.Modified})
+	}
+	var lock map[string]domain.LockedMod
+	if st, err := m.state.Load(); err == nil {
+		lock = st.Mods
+	}
+	return jars, lock
+}
+
 // ListInstalled returns all .jar files in the mods directory.
 func (m *Mods) ListInstalled() ([]domain.InstalledMod, error) {
-	files, err := filepath.Glob(filepath.Join(m.cfg.Paths.Mods, "*.jar"))
+	files, err := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list mod files: %w", err)
 	}
@@ -108,7 +220,8 @@ func (m *Mods) ListInstalled() ([]domain.InstalledMod, error) {
 	return mods, nil
 }
 
-// HealthCheck verifies mods directory and API connectivity.
+// HealthCheck verifies mods directory and API connectivity and reports the
+// last successful update.
 func (m *Mods) HealthCheck(ctx context.Context) []domain.HealthCheck {
 	total := len(m.cfg.Mods.ModrinthSources)
 	var sourcesCheck domain.HealthCheck
@@ -120,40 +233,74 @@ func (m *Mods) HealthCheck(ctx context.Context) []domain.HealthCheck {
 	return []domain.HealthCheck{
 		domain.CheckPath("Mods directory", m.cfg.Paths.Mods),
 		sourcesCheck,
+		m.state.lastSuccessCheck("Last mod update", domain.OpModUpdate, 0),
 		m.checkAPI(ctx),
 	}
 }
 
 func (m *Mods) withRetry(ctx context.Context, op func() error) error {
-	maxRetries := m.cfg.Mods.MaxRetries
 	delay := time.Duration(m.cfg.Mods.RetryDelay * float64(time.Second))
-	var apiErr *domain.APIError
-	var err error
-	for attempt := range maxRetries + 1 {
-		if err = op(); err == nil {
-			return nil
+	return withRetry(ctx, m.cfg.Mods.MaxRetries, delay, op)
+}
+
+func (m *Mods) apiRequest(ctx context.Context, apiURL string, result any) error {
+	cached := m.cache.load(apiURL)
+	if m.cfg.Offline {
+		if cached == nil {
+			return fmt.Errorf("no cached API response: %w", domain.ErrOffline)
+		}
+		return json.Unmarshal(cached.Body, result)
+	}
+	return m.withRetry(ctx, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+		if err != nil {
+			return err
 		}
-		if errors.As(err, &apiErr) && !apiErr.IsRetryable() {
+		m.setHeaders(req)
+		cached.applyValidators(req)
+
+		resp, err := m.client.Do(req) //nolint:gosec // URL built from Modrinth API base
+		if err != nil {
 			return err
 		}
-		if attempt < maxRetries {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(delay):
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode == http.StatusNotModified && cached != nil {
+			m.logger.Debug("Modrinth response not modified", zap.String("url", apiURL))
+			return json.Unmarshal(cached.Body, result)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return &domain.APIError{
+				URL:        apiURL,
+				StatusCode: resp.StatusCode,
+				Message:    "request failed",
+				RetryAfter: parseRetryAfter(resp.Header),
 			}
 		}
-	}
-	return err
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, result); err != nil {
+			return err
+		}
+		m.cache.store(apiURL, resp.Header, body)
+		return nil
+	})
 }
 
-func (m *Mods) apiRequest(ctx context.Context, apiURL string, result any) error {
+func (m *Mods) apiPost(ctx context.Context, apiURL string, payload, result any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
 	return m.withRetry(ctx, func() error {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewReader(body))
 		if err != nil {
 			return err
 		}
-		req.Header.Set("User-Agent", userAgent)
+		m.setHeaders(req)
+		req.Header.Set("Content-Type", "application/json")
 
 		resp, err := m.client.Do(req) //nolint:gosec // URL built from Modrinth API base
 		if err != nil {
@@ -162,30 +309,47 @@ func (m *Mods) apiRequest(ctx context.Context, apiURL string, result any) error
 		defer func() { _ = resp.Body.Close() }()
 
 		if resp.StatusCode != http.StatusOK {
-			return &domain.APIError{URL: apiURL, StatusCode: resp.StatusCode, Message: "request failed"}
+			return &domain.APIError{
+				URL:        apiURL,
+				StatusCode: resp.StatusCode,
+				Message:    "request failed",
+				RetryAfter: parseRetryAfter(resp.Header),
+			}
 		}
 		return json.NewDecoder(resp.Body).Decode(result)
 	})
 }
 
-func (m *Mods) downloadMod(ctx context.Context, info *domain.ModInfo, force bool) (bool, error) {
+// downloadMod writes the jar for info into dir unless the mods directory
+// already has it and force is unset. It reports whether a jar was written.
+func (m *Mods) downloadMod(ctx context.Context, info *domain.ModInfo, force bool, dir string) (bool, error) {
+	if !force {
+		if _, err := os.Stat(filepath.Join(m.cfg.ModsDir(), info.Filename)); err == nil {
+			m.logger.Info("Mod up-to-date, skipping", zap.String("filename", info.Filename))
+			return false, nil
+		}
+	}
 	if m.cfg.DryRun {
 		m.logger.Info("Dry run: Would download mod", zap.String("filename", info.Filename))
 		return true, nil
 	}
-	if err := os.MkdirAll(m.cfg.Paths.Mods, 0o750); err != nil {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
 		return false, err
 	}
-
-	finalPath := filepath.Join(m.cfg.Paths.Mods, info.Filename)
-	if !force {
-		if _, err := os.Stat(finalPath); err == nil {
-			m.logger.Info("Mod up-to-date, skipping", zap.String("filename", info.Filename))
-			return false, nil
+	finalPath := filepath.Join(dir, info.Filename)
+	if m.cfg.Offline {
+		return m.installFromArtifacts(info, finalPath)
+	}
+	if info.SHA1 != "" {
+		if ok, _ := m.installFromArtifacts(info, finalPath); ok {
+			return true, nil
 		}
 	}
 
-	tmpFile, err := os.CreateTemp(m.cfg.Paths.Mods, ".tmp-*")
+	if err := ensureSpace(dir, info.Size); err != nil {
+		return false, err
+	}
+	tmpFile, err := os.CreateTemp(dir, ".tmp-*")
 	if err != nil {
 		return false, err
 	}
@@ -198,33 +362,24 @@ func (m *Mods) downloadMod(ctx context.Context, info *domain.ModInfo, force bool
 		}
 	}()
 
-	err = m.withRetry(ctx, func() error {
-		if _, err := tmpFile.Seek(0, 0); err != nil {
-			return err
-		}
-		if err := tmpFile.Truncate(0); err != nil {
-			return err
+	for i, candidate := range info.CandidateURLs() {
+		fetch := func() error {
+			if err := m.fetchTo(ctx, tmpFile, candidate); err != nil {
+				return err
+			}
+			return checkSHA1(tmpPath, info)
 		}
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, info.DownloadURL, nil)
-		if err != nil {
-			return err
+		if err = m.withRetry(ctx, fetch); err == nil {
+			break
 		}
-		req.Header.Set("User-Agent", userAgent)
-
-		resp, err := m.client.Do(req) //nolint:gosec // URL from Modrinth API response
-		if err != nil {
-			return err
+		if ctx.Err() != nil || errors.Is(err, domain.ErrInsufficientSpace) {
+			break
 		}
-		defer func() { _ = resp.Body.Close() }()
-
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("download failed: status %d", resp.StatusCode)
+		if i < len(info.CandidateURLs())-1 {
+			m.logger.Warn("Download failed, trying mirror",
+				zap.String("filename", info.Filename), zap.String("url", candidate), zap.Error(err))
 		}
-
-		_, err = io.Copy(tmpFile, resp.Body)
-		return err
-	})
+	}
 
 	if closeErr := tmpFile.Close(); closeErr != nil {
 		m.logger.Warn("Failed to close temporary file", zap.Error(closeErr))
@@ -239,23 +394,222 @@ func (m *Mods) downloadMod(ctx context.Context, info *domain.ModInfo, force bool
 	}
 
 	success = true
+	if cached := m.storeArtifact(finalPath); cached != "" && m.cfg.Mods.Layout == config.ModsLayoutSymlinks {
+		if err := m.placeArtifact(cached, finalPath); err != nil {
+			return false, err
+		}
+	}
 	m.logger.Info("Downloaded mod", zap.String("filename", info.Filename))
 	return true, nil
 }
 
-func (m *Mods) updateMod(ctx context.Context, modURL string, force bool) (bool, string, error) {
+// fetchTo truncates dst and fills it with the body of downloadURL. A body
+// that stops arriving for mods.idle_timeout fails with ErrDownloadStalled.
+func (m *Mods) fetchTo(ctx context.Context, dst *os.File, downloadURL string) error {
+	if _, err := dst.Seek(0, 0); err != nil {
+		return err
+	}
+	if err := dst.Truncate(0); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return err
+	}
+	m.setHeaders(req)
+
+	resp, err := m.client.Do(req) //nolint:gosec // URL from Modrinth API response
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return &domain.APIError{
+			URL:        downloadURL,
+			StatusCode: resp.StatusCode,
+			Message:    "download failed",
+			RetryAfter: parseRetryAfter(resp.Header),
+		}
+	}
+
+	if resp.ContentLength > 0 {
+		if err := ensureSpace(filepath.Dir(dst.Name()), resp.ContentLength); err != nil {
+			return err
+		}
+	}
+	return copyIdle(ctx, cancel, time.Duration(m.cfg.Mods.IdleTimeout)*time.Second, dst, resp.Body)
+}
+
+// updateMod downloads the latest version of one source into stage and
+// returns it for applyStaged, or nil if the installed jar is current. The
+// returned detail names the mod even on failure; From is the version in the
+// lockfile before.
+func (m *Mods) updateMod(ctx context.Context, modURL string, force bool, resolved map[string]*domain.ModInfo, stage string) (*stagedMod, domain.ModDetail, error) {
 	projectID, err := parseProjectID(modURL)
 	if err != nil {
-		return false, projectID, err
+		return nil, domain.ModDetail{Name: projectID}, err
+	}
+
+	var locked domain.LockedMod
+	if st, err := m.state.Load(); err == nil {
+		locked = st.Mods[projectID]
+	}
+	// A lock entry from another loader means minecraft.modloader changed:
+	// its jar must be replaced by a build for the new loader, or moved aside.
+	migrating := locked.Loader != "" && locked.Loader != m.cfg.Minecraft.Modloader
+
+	info, ok := resolved[projectID]
+	if !ok {
+		if info, err = m.fetchLatestVersion(ctx, projectID); err != nil {
+			if migrating && errors.Is(err, domain.ErrNoCompatibleBuild) {
+				m.retireJar(locked)
+				err = fmt.Errorf("%w for %s; %s build moved to %s", err, m.cfg.Minecraft.Modloader, locked.Loader, m.retiredDir(locked.Loader))
+			}
+			return nil, domain.ModDetail{Name: projectID, From: locked.Version}, err
+		}
+	}
+	detail := domain.ModDetail{Name: info.ProjectName, From: locked.Version, To: info.Version, Changelog: info.Changelog}
+
+	dctx, span := startSpan(ctx, "mods.download", "mod.project", info.ProjectName, "mod.file", info.Filename)
+	updated, err := m.downloadMod(dctx, info, force || migrating, stage)
+	span.set("mod.downloaded", updated)
+	span.finish(err)
+	if err != nil {
+		return nil, detail, err
+	}
+	if !updated {
+		m.lock(projectID, info)
+		return nil, detail, nil
+	}
+	s := &stagedMod{project: projectID, info: info}
+	if migrating && locked.Filename != info.Filename {
+		s.replace = locked.Filename
+	}
+	return s, detail, nil
+}
+
+// stagedMod is a downloaded jar waiting in the staging directory. replace
+// names the jar it supersedes under another filename, if any: the previous
+// loader's build or the version an imported manifest replaces.
+type stagedMod struct {
+	project string
+	info    *domain.ModInfo
+	replace string
+	detail  domain.ModDetail
+}
+
+// stagingDir holds the jars of an update run until applyStaged moves them
+// into place, next to the mods directory so the server does not load them.
+func (m *Mods) stagingDir() string {
+	return modsStagingDir(m.cfg)
+}
+
+func modsStagingDir(cfg *config.Config) string {
+	return filepath.Join(filepath.Dir(cfg.Paths.Mods), "mods.staging")
+}
+
+// applyStaged moves the staged jars into the mods directory and records
+// them in res. Under the atomic apply policy nothing is moved unless every
+// mod succeeded, so a failed or cancelled run leaves the previous set intact.
+// Mods with no compatible build do not block the others: they keep their
+// current jar either way.
+func (m *Mods) applyStaged(ctx context.Context, stage string, staged []*stagedMod, res *domain.ModUpdateResult) {
+	var abort error
+	if m.cfg.Mods.ApplyPolicy != config.ModsApplyPartial {
+		if n := len(res.FailedMods) - len(res.Incompatible) - len(res.Blocked); n > 0 {
+			abort = fmt.Errorf("%w: %d other mod(s) failed", domain.ErrUpdateNotApplied, n)
+		} else if err := ctx.Err(); err != nil {
+			abort = fmt.Errorf("%w: %w", domain.ErrUpdateNotApplied, err)
+		}
+	}
+	if abort != nil && len(staged) > 0 {
+		m.logger.Warn("Mod updates not applied", zap.Int("staged", len(staged)), zap.Error(abort))
 	}
+	for _, s := range staged {
+		d := s.detail
+		err := abort
+		if err == nil {
+			err = m.install(stage, s)
+		}
+		if err != nil {
+			d.Outcome, d.Error, d.ErrorKind = domain.ModFailed, err.Error(), domain.ClassifyModError(err)
+		} else {
+			d.Outcome = domain.ModUpdated
+			if fi, err := os.Stat(filepath.Join(m.cfg.ModsDir(), s.info.Filename)); err == nil && !m.cfg.DryRun {
+				d.Bytes = fi.Size()
+			}
+		}
+		res.Record(d)
+	}
+}
+
+// install moves one staged jar into the mods directory, removes the jar it
+// replaces and locks it.
+func (m *Mods) install(stage string, s *stagedMod) error {
+	if !m.cfg.DryRun {
+		if err := os.MkdirAll(m.cfg.ModsDir(), 0o750); err != nil {
+			return err
+		}
+		finalPath := filepath.Join(m.cfg.ModsDir(), s.info.Filename)
+		_ = os.Remove(finalPath)
+		if err := os.Rename(filepath.Join(stage, s.info.Filename), finalPath); err != nil {
+			return err
+		}
+		if s.replace != "" {
+			_ = os.Remove(filepath.Join(m.cfg.ModsDir(), s.replace))
+			m.logger.Info("Replaced previous jar", zap.String("old", s.replace), zap.String("new", s.info.Filename))
+		}
+	}
+	m.lock(s.project, s.info)
+	return nil
+}
 
-	info, err := m.fetchLatestVersion(ctx, projectID)
+// selectSources returns the configured sources accepted by filter, matching
+// on project slug and on the locked filename.
+func (m *Mods) selectSources(filter domain.ModFilter) []string {
+	sources := m.cfg.Mods.ModrinthSources
+	if filter.IsEmpty() {
+		return sources
+	}
+	st, err := m.state.Load()
 	if err != nil {
-		return false, projectID, err
+		m.logger.Warn("Lockfile unavailable, filtering by slug only", zap.Error(err))
+		st = newState()
+	}
+	var selected []string
+	for _, src := range sources {
+		slug, err := parseProjectID(src)
+		if err != nil {
+			slug = src
+		}
+		if filter.Match(slug, st.Mods[slug].Filename) {
+			selected = append(selected, src)
+		}
 	}
+	return selected
+}
 
-	updated, err := m.downloadMod(ctx, info, force)
-	return updated, info.ProjectName, err
+// lock records the installed file for projectID in the state lockfile.
+func (m *Mods) lock(projectID string, info *domain.ModInfo) {
+	sum, _ := fileSHA1(filepath.Join(m.cfg.ModsDir(), info.Filename))
+	err := m.state.Update(func(st *domain.State) {
+		st.Mods[projectID] = domain.LockedMod{
+			Project:     projectID,
+			VersionID:   info.VersionID,
+			Version:     info.Version,
+			Filename:    info.Filename,
+			SHA1:        sum,
+			Loader:      m.cfg.Minecraft.Modloader,
+			InstalledAt: time.Now(),
+		}
+	})
+	if err != nil {
+		m.logger.Warn("Failed to update mod lockfile", zap.String("project", projectID), zap.Error(err))
+	}
 }
 
 // parseProjectID extracts the Modrinth slug from a full URL or bare slug.
@@ -276,50 +630,176 @@ func parseProjectID(modURL string) (string, error) {
 }
 
 type modrinthFile struct {
-	URL      string `json:"url"`
-	Filename string `json:"filename"`
+	URL      string            `json:"url"`
+	Filename string            `json:"filename"`
+	Primary  bool              `json:"primary"`
+	Size     int64             `json:"size"`
+	Hashes   map[string]string `json:"hashes"`
 }
 
 type modrinthVersion struct {
 	ID            string         `json:"id"`
+	ProjectID     string         `json:"project_id"`
 	VersionNumber string         `json:"version_number"`
+	Changelog     string         `json:"changelog"`
 	Files         []modrinthFile `json:"files"`
 }
 
+// primaryFile returns the file flagged primary, or the first file.
+func (v *modrinthVersion) primaryFile() (modrinthFile, bool) {
+	for _, f := range v.Files {
+		if f.Primary {
+			return f, true
+		}
+	}
+	if len(v.Files) == 0 {
+		return modrinthFile{}, false
+	}
+	return v.Files[0], true
+}
+
+func (m *Mods) modInfo(v *modrinthVersion, projectName string) (*domain.ModInfo, error) {
+	f, ok := v.primaryFile()
+	if !ok {
+		return nil, errors.New("no files in version")
+	}
+	return &domain.ModInfo{
+		VersionID:   v.ID,
+		Version:     v.VersionNumber,
+		DownloadURL: f.URL,
+		MirrorURLs:  mirrorURLs(f.URL, m.cfg.Mods.DownloadMirrors),
+		Filename:    f.Filename,
+		SHA1:        f.Hashes["sha1"],
+		Size:        f.Size,
+		ProjectName: projectName,
+		Changelog:   v.Changelog,
+	}, nil
+}
+
+// mirrorURLs rewrites the scheme and host of downloadURL onto each mirror base,
+// keeping the path, so mirrors only need to replicate the CDN layout.
+func mirrorURLs(downloadURL string, mirrors []string) []string {
+	orig, err := url.Parse(downloadURL)
+	if err != nil || len(mirrors) == 0 {
+		return nil
+	}
+	urls := make([]string, 0, len(mirrors))
+	for _, mirror := range mirrors {
+		base, err := url.Parse(strings.TrimSuffix(mirror, "/"))
+		if err != nil || base.Host == "" || base.Host == orig.Host {
+			continue
+		}
+		u := *orig
+		u.Scheme, u.Host = base.Scheme, base.Host
+		u.Path = base.Path + orig.Path
+		urls = append(urls, u.String())
+	}
+	return urls
+}
+
 func (m *Mods) fetchLatestVersion(ctx context.Context, projectID string) (*domain.ModInfo, error) {
-	apiURL := fmt.Sprintf("https://api.modrinth.com/v2/project/%s/version?game_versions=[\"%s\"]&loaders=[\"%s\"]",
-		projectID, m.cfg.Minecraft.Version, m.cfg.Minecraft.Modloader)
+	apiURL := fmt.Sprintf("%s/project/%s/version?game_versions=[\"%s\"]&loaders=[\"%s\"]",
+		modrinthAPI, projectID, m.cfg.Minecraft.Version, m.cfg.Minecraft.Modloader)
 
 	var versions []modrinthVersion
 	if err := m.apiRequest(ctx, apiURL, &versions); err != nil {
 		return nil, err
 	}
 	if len(versions) == 0 {
-		return nil, errors.New("no compatible versions found")
+		return nil, domain.ErrNoCompatibleBuild
 	}
+	return m.modInfo(&versions[0], projectID)
+}
 
-	v := versions[0]
-	if len(v.Files) == 0 {
-		return nil, errors.New("no files in version")
+// resolveBatch resolves the latest compatible version for every configured
+// source that already has a jar installed, using the bulk project lookup and
+// one bulk call for version files by hash instead of one lookup per mod.
+// Sources it cannot resolve are absent from the result and fall back to
+// per-project lookups, so any failure here only costs efficiency. It also
+// returns the Modrinth project ID of each installed jar it recognised, by
+// SHA-1.
+func (m *Mods) resolveBatch(ctx context.Context, sources []string, projects map[string]modrinthProject) (map[string]*domain.ModInfo, map[string]string) {
+	if m.cfg.Offline {
+		return nil, nil
+	}
+	hashes := m.installedHashes()
+	if len(hashes) == 0 {
+		return nil, nil
 	}
 
-	return &domain.ModInfo{
-		VersionID:   v.ID,
-		Version:     v.VersionNumber,
-		DownloadURL: v.Files[0].URL,
-		Filename:    v.Files[0].Filename,
-		ProjectName: projectID,
-	}, nil
+	if len(projects) == 0 {
+		return nil, nil
+	}
+	var keys []string
+	for _, src := range sources {
+		if id, err := parseProjectID(src); err == nil {
+			keys = append(keys, id)
+		}
+	}
+
+	payload := map[string]any{
+		"hashes":        hashes,
+		"algorithm":     "sha1",
+		"loaders":       []string{m.cfg.Minecraft.Modloader},
+		"game_versions": []string{m.cfg.Minecraft.Version},
+	}
+	var latest map[string]modrinthVersion
+	if err := m.apiPost(ctx, modrinthAPI+"/version_files/update", payload, &latest); err != nil {
+		m.logger.Debug("Bulk version lookup failed, falling back", zap.Error(err))
+		return nil, nil
+	}
+	byProject := make(map[string]*modrinthVersion, len(latest))
+	owners := make(map[string]string, len(latest))
+	for hash, v := range latest {
+		byProject[v.ProjectID] = &v
+		owners[hash] = v.ProjectID
+	}
+
+	resolved := make(map[string]*domain.ModInfo, len(keys))
+	for _, key := range keys {
+		v, ok := byProject[projects[key].ID]
+		if !ok {
+			continue
+		}
+		if info, err := m.modInfo(v, key); err == nil {
+			resolved[key] = info
+		}
+	}
+	m.logger.Debug("Resolved mods in bulk", zap.Int("resolved", len(resolved)), zap.Int("sources", len(keys)))
+	return resolved, owners
+}
+
+// installedHashes returns the SHA-1 of every jar in the mods directory.
+func (m *Mods) installedHashes() []string {
+	files, _ := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
+	return slices.Collect(maps.Values(hashFiles(files)))
+}
+
+func fileSHA1(path string) (string, error) {
+	f, err := os.Open(path) //nolint:gosec // path from mods directory listing
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha1.New() //nolint:gosec // Modrinth identifies files by SHA-1
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 func (m *Mods) checkAPI(ctx context.Context) domain.HealthCheck {
+	if m.cfg.Offline {
+		return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusSkipped, Message: "Offline mode"}
+	}
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.modrinth.com/v2/", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, modrinthAPI+"/", nil)
 	if err != nil {
 		return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusError, Message: "Failed to build request"}
 	}
+	m.setHeaders(req)
 	resp, err := m.client.Do(req) //nolint:gosec // fixed known-good URL
 	if err != nil {
 		return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusError, Message: "Connection failed"}
@@ -329,5 +809,25 @@ func (m *Mods) checkAPI(ctx context.Context) domain.HealthCheck {
 	if resp.StatusCode != http.StatusOK {
 		return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusWarn, Message: fmt.Sprintf("Status %d", resp.StatusCode)}
 	}
-	return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusOK, Message: "Connected"}
+	if m.cfg.Mods.ModrinthToken == "" {
+		return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusOK, Message: "Connected"}
+	}
+	if err := m.apiRequest(ctx, modrinthAPI+"/user", &struct{}{}); err != nil {
+		return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusWarn, Message: "Connected, but mods.modrinth_token was rejected"}
+	}
+	return domain.HealthCheck{Name: "Modrinth API", Status: domain.StatusOK, Message: "Connected (authenticated)"}
+}
+
+// setHeaders identifies craftops to Modrinth, as its API terms ask, and
+// authenticates API calls when a token is configured. The token is never
+// sent to CDN or mirror hosts.
+func (m *Mods) setHeaders(req *http.Request) {
+	ua := userAgent
+	if contact := m.cfg.Mods.UserAgentContact; contact != "" {
+		ua += " (" + contact + ")"
+	}
+	req.Header.Set("User-Agent", ua)
+	if m.cfg.Mods.ModrinthToken != "" && strings.HasPrefix(req.URL.String(), modrinthAPI+"/") {
+		req.Header.Set("Authorization", m.cfg.Mods.ModrinthToken)
+	}
 }
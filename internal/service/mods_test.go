@@ -1,14 +1,20 @@
 package service_test
 
 import (
+	"archive/zip"
+	"bytes"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"craftops/internal/config"
+	"craftops/internal/domain"
 	"craftops/internal/service"
 )
 
@@ -93,6 +99,15 @@ func TestMods_UpdateAll_Downloads(t *testing.T) {
 	if string(data) != "FAKE_JAR_CONTENT" {
 		t.Errorf("jar content mismatch: got %q", data)
 	}
+	if len(result.Mods) != 1 {
+		t.Fatalf("expected 1 mod detail, got %+v", result.Mods)
+	}
+	if d := result.Mods[0]; d.Outcome != domain.ModUpdated || d.Bytes != int64(len(data)) || d.To == "" || d.Duration <= 0 {
+		t.Errorf("unexpected detail: %+v", d)
+	}
+	if d := result.Diff; d == nil || len(d.Added) != 1 || d.Added[0].Name != "mod-1.0.0.jar" || len(d.After) != 1 {
+		t.Errorf("unexpected diff: %+v", d)
+	}
 }
 
 func TestMods_UpdateAll_SkipsExisting(t *testing.T) {
@@ -177,6 +192,9 @@ func TestMods_UpdateAll_API404(t *testing.T) {
 	if len(result.FailedMods) != 1 {
 		t.Errorf("expected 1 failed mod for 404, got %v", result.FailedMods)
 	}
+	if len(result.Mods) != 1 || result.Mods[0].ErrorKind != domain.ModErrNotFound {
+		t.Errorf("expected a not_found detail, got %+v", result.Mods)
+	}
 }
 
 func TestMods_UpdateAll_NoCompatibleVersions(t *testing.T) {
@@ -311,3 +329,505 @@ func TestMods_HealthCheck(t *testing.T) {
 		t.Error("expected 'Mod sources' health check")
 	}
 }
+
+func TestMods_UpdateAll_BatchResolvesInstalled(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+
+	var perProject, bulk atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/v2/projects":
+			bulk.Add(1)
+			_ = json.NewEncoder(w).Encode([]map[string]any{{"id": "AANobbMI", "slug": "sodium"}})
+		case r.URL.Path == "/v2/version_files/update":
+			bulk.Add(1)
+			var body struct {
+				Hashes []string `json:"hashes"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			resp := map[string]any{}
+			for _, h := range body.Hashes {
+				resp[h] = map[string]any{
+					"id": "v2", "project_id": "AANobbMI", "version_number": "2.0.0",
+					"files": []map[string]any{{"filename": "sodium-2.0.0.jar", "url": "http://" + r.Host + "/files/sodium-2.0.0.jar", "primary": true}},
+				}
+			}
+			_ = json.NewEncoder(w).Encode(resp)
+		case r.URL.Path == "/files/sodium-2.0.0.jar":
+			_, _ = w.Write([]byte("NEW"))
+		default:
+			perProject.Add(1)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+	_ = os.WriteFile(filepath.Join(cfg.Paths.Mods, "sodium-1.0.0.jar"), []byte("OLD"), 0o600)
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if len(result.UpdatedMods) != 1 {
+		t.Fatalf("expected 1 updated mod, got updated=%v failed=%v", result.UpdatedMods, result.FailedMods)
+	}
+	if bulk.Load() != 2 || perProject.Load() != 0 {
+		t.Errorf("expected 2 bulk calls and no per-project lookups, got bulk=%d perProject=%d", bulk.Load(), perProject.Load())
+	}
+}
+
+func TestMods_UpdateAll_FallsBackToMirror(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/project/"):
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("mod.jar", "http://"+r.Host+"/files/mod.jar"))
+		case r.URL.Path == "/mirror/files/mod.jar":
+			_, _ = w.Write([]byte("FROM_MIRROR"))
+		case r.URL.Path == "/files/mod.jar":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.DownloadMirrors = []string{"http://mirror.example/mirror"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if len(result.UpdatedMods) != 1 {
+		t.Fatalf("expected mirror download to succeed, failed=%v", result.FailedMods)
+	}
+	data, _ := os.ReadFile(filepath.Join(cfg.Paths.Mods, "mod.jar"))
+	if string(data) != "FROM_MIRROR" {
+		t.Errorf("expected mirror content, got %q", data)
+	}
+}
+
+func TestMods_UpdateAll_OfflineUsesArtifactCache(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := newMockModrinth(t, "/v2/project/sodium/version", "/files/mod-1.0.0.jar", []byte("CACHED_JAR"))
+
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+	if _, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false); err != nil {
+		t.Fatalf("online UpdateAll: %v", err)
+	}
+
+	// Wipe the mods dir and go offline with the API unreachable.
+	srv.Close()
+	_ = os.Remove(filepath.Join(cfg.Paths.Mods, "mod-1.0.0.jar"))
+	cfg.Offline = true
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("offline UpdateAll: %v", err)
+	}
+	if len(result.UpdatedMods) != 1 {
+		t.Fatalf("expected offline reinstall, got failed=%v", result.FailedMods)
+	}
+	data, _ := os.ReadFile(filepath.Join(cfg.Paths.Mods, "mod-1.0.0.jar"))
+	if string(data) != "CACHED_JAR" {
+		t.Errorf("expected cached jar content, got %q", data)
+	}
+}
+
+func TestMods_UpdateAll_OfflineWithoutCache(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Offline = true
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+
+	result, err := service.NewMods(cfg, logger).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll: %v", err)
+	}
+	if !strings.Contains(result.FailedMods["sodium"], "offline") {
+		t.Errorf("expected offline failure, got %v", result.FailedMods)
+	}
+}
+
+func TestMods_HealthCheck_OfflineSkipsAPI(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Offline = true
+
+	for _, c := range service.NewMods(cfg, logger).HealthCheck(ctx) {
+		if c.Name == "Modrinth API" && c.Status != domain.StatusSkipped {
+			t.Errorf("expected SKIPPED API check offline, got %s", c.Status)
+		}
+	}
+}
+
+func writeJar(t *testing.T, path, manifest string) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if _, err := zw.Create(manifest); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMods_UpdateAll_LoaderMigration(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/ported/version":
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("ported-neoforge.jar", "http://"+r.Host+"/dl/ported.jar"))
+		case "/v2/project/stuck/version":
+			_ = json.NewEncoder(w).Encode([]map[string]any{})
+		case "/dl/ported.jar":
+			_, _ = w.Write([]byte("jar"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, name := range []string{"ported-forge.jar", "stuck-forge.jar"} {
+		writeJar(t, filepath.Join(cfg.Paths.Mods, name), "META-INF/mods.toml")
+	}
+	writeJar(t, filepath.Join(cfg.Paths.Mods, "manual-fabric.jar"), "fabric.mod.json")
+	err := service.NewStateStore(cfg).Update(func(st *domain.State) {
+		st.Mods["ported"] = domain.LockedMod{Project: "ported", Version: "1.0", Filename: "ported-forge.jar", Loader: "forge"}
+		st.Mods["stuck"] = domain.LockedMod{Project: "stuck", Version: "1.0", Filename: "stuck-forge.jar", Loader: "forge"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Minecraft.Modloader = "neoforge"
+	cfg.Mods.ModrinthSources = []string{"ported", "stuck"}
+	cfg.Mods.MaxRetries = 0
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, "ported-forge.jar")); !os.IsNotExist(err) {
+		t.Error("forge jar should be replaced by the neoforge build")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, "ported-neoforge.jar")); err != nil {
+		t.Errorf("neoforge build not installed: %v", err)
+	}
+	if len(result.Incompatible) != 1 || result.Incompatible[0] != "stuck" {
+		t.Errorf("Incompatible = %v, want [stuck]", result.Incompatible)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(cfg.Paths.Mods), "mods.forge", "stuck-forge.jar")); err != nil {
+		t.Errorf("incompatible forge jar not moved aside: %v", err)
+	}
+	if len(result.ForeignJars) != 1 || result.ForeignJars[0] != "manual-fabric.jar" {
+		t.Errorf("ForeignJars = %v, want [manual-fabric.jar]", result.ForeignJars)
+	}
+}
+
+func TestMods_UpdateAll_StrictQuarantinesUndeclared(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := newMockModrinth(t,
+		"/v2/project/fabric-api/version",
+		"/files/mod-1.0.0.jar",
+		[]byte("FAKE_JAR_CONTENT"),
+	)
+	cfg.Mods.ModrinthSources = []string{"fabric-api"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+	cfg.Mods.Strict = true
+	writeFile(t, cfg.Paths.Mods, "manual.jar", "dropped in by hand")
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if len(result.Quarantined) != 1 || result.Quarantined[0] != "manual.jar" {
+		t.Fatalf("Quarantined = %v, want [manual.jar]", result.Quarantined)
+	}
+	quarantine := filepath.Join(filepath.Dir(cfg.Paths.Mods), "mods.quarantine")
+	if _, err := os.Stat(filepath.Join(quarantine, "manual.jar")); err != nil {
+		t.Errorf("manual.jar not moved to quarantine: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, "mod-1.0.0.jar")); err != nil {
+		t.Errorf("declared jar should stay in place: %v", err)
+	}
+}
+
+func TestMods_UpdateAll_StrictSkipsQuarantineOnFailure(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := newMockModrinth(t,
+		"/v2/project/fabric-api/version",
+		"/files/mod-1.0.0.jar",
+		[]byte("FAKE_JAR_CONTENT"),
+	)
+	cfg.Mods.ModrinthSources = []string{"fabric-api", "missing"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+	cfg.Mods.Strict = true
+	// missing's jar from an earlier install, without a lock entry.
+	writeFile(t, cfg.Paths.Mods, "missing-1.0.jar", "working jar")
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if _, ok := result.FailedMods["missing"]; !ok {
+		t.Fatalf("FailedMods = %v, want missing", result.FailedMods)
+	}
+	if len(result.Quarantined) != 0 {
+		t.Errorf("Quarantined = %v after a failed update, want none", result.Quarantined)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, "missing-1.0.jar")); err != nil {
+		t.Errorf("jar of the failed mod was moved: %v", err)
+	}
+}
+
+func TestMods_UserAgentAndToken(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.UserAgentContact = "admin@example.com"
+	cfg.Mods.ModrinthToken = "mrp_secret"
+
+	var apiAuth, dlAuth, apiUA atomic.Value
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/") {
+			apiAuth.Store(r.Header.Get("Authorization"))
+			apiUA.Store(r.Header.Get("User-Agent"))
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("sodium.jar", "http://"+r.Host+"/dl/sodium.jar"))
+			return
+		}
+		dlAuth.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("JAR"))
+	}))
+	t.Cleanup(srv.Close)
+
+	if _, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := apiAuth.Load(); got != "mrp_secret" {
+		t.Errorf("API Authorization = %v, want token", got)
+	}
+	if got := dlAuth.Load(); got != "" {
+		t.Errorf("download Authorization = %q, token must not reach the CDN", got)
+	}
+	if got, _ := apiUA.Load().(string); !strings.HasSuffix(got, "(admin@example.com)") {
+		t.Errorf("User-Agent = %q, want contact suffix", got)
+	}
+}
+
+func TestMods_RequestStats(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := newMockModrinth(t, "/v2/project/sodium/version", "/files/mod-1.0.0.jar", []byte("12345678"))
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+
+	before := service.RequestStats()
+	if _, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	got := service.RequestStats().Sub(before)
+	if got.Requests != 3 {
+		t.Errorf("Requests = %d, want 3 (project lookup, version lookup and download)", got.Requests)
+	}
+	if got.BytesDownloaded < 8 {
+		t.Errorf("BytesDownloaded = %d, want at least the jar size", got.BytesDownloaded)
+	}
+}
+
+func TestMods_UpdateAll_StalledDownloadIsRetried(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/project/"):
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("mod.jar", "http://"+r.Host+"/files/mod.jar"))
+		case r.URL.Path == "/files/mod.jar" && attempts.Add(1) == 1:
+			_, _ = w.Write([]byte("PART"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done() // hang until the client gives up
+		case r.URL.Path == "/files/mod.jar":
+			_, _ = w.Write([]byte("WHOLE"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 1
+	cfg.Mods.RetryDelay = 0
+	cfg.Mods.Timeout = 30
+	cfg.Mods.IdleTimeout = 1
+
+	started := time.Now()
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if len(result.UpdatedMods) != 1 || attempts.Load() != 2 {
+		t.Fatalf("expected the stalled download to be retried, attempts=%d failed=%v", attempts.Load(), result.FailedMods)
+	}
+	if elapsed := time.Since(started); elapsed > 10*time.Second {
+		t.Errorf("stalled download took %s to give up", elapsed)
+	}
+	data, _ := os.ReadFile(filepath.Join(cfg.Paths.Mods, "mod.jar"))
+	if string(data) != "WHOLE" {
+		t.Errorf("expected the retried content, got %q", data)
+	}
+}
+
+func TestMods_UpdateAll_InsufficientSpaceFailsWithoutRetry(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v2/project/"):
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("mod.jar", "http://"+r.Host+"/files/mod.jar"))
+		case r.URL.Path == "/files/mod.jar":
+			attempts.Add(1)
+			_, _ = w.Write([]byte("WHOLE"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 2
+	cfg.Mods.RetryDelay = 0
+	t.Cleanup(service.SetDiskSpace(3, 100))
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if len(result.FailedMods) != 1 || attempts.Load() != 1 {
+		t.Fatalf("expected one failed attempt, attempts=%d result=%+v", attempts.Load(), result)
+	}
+	if d := result.Details()[0]; d.ErrorKind != domain.ModErrDiskFull {
+		t.Errorf("ErrorKind = %q, want %q", d.ErrorKind, domain.ModErrDiskFull)
+	}
+	if left, _ := filepath.Glob(filepath.Join(cfg.Paths.Mods, "*")); len(left) != 0 {
+		t.Errorf("expected nothing written to the mods dir, got %v", left)
+	}
+
+	t.Cleanup(service.SetDiskSpace(1<<30, 0))
+	result, err = service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if len(result.FailedMods) != 1 || !strings.Contains(result.Details()[0].Error, "inodes") {
+		t.Errorf("expected a free-inode failure, got %+v", result.FailedMods)
+	}
+}
+
+func TestMods_UpdateAll_AtomicApply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/good/version":
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("good-2.0.jar", "http://"+r.Host+"/dl/good.jar"))
+		case "/v2/project/bad/version":
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("bad-2.0.jar", "http://"+r.Host+"/dl/bad.jar"))
+		case "/dl/good.jar":
+			_, _ = w.Write([]byte("jar"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	for _, tc := range []struct {
+		policy    string
+		installed bool
+	}{
+		{config.ModsApplyAtomic, false},
+		{config.ModsApplyPartial, true},
+	} {
+		t.Run(tc.policy, func(t *testing.T) {
+			cfg, logger, ctx := setup(t)
+			cfg.Mods.ModrinthSources = []string{"good", "bad"}
+			cfg.Mods.MaxRetries = 0
+			cfg.Mods.ApplyPolicy = tc.policy
+
+			result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+			if err != nil {
+				t.Fatalf("UpdateAll: %v", err)
+			}
+			_, statErr := os.Stat(filepath.Join(cfg.Paths.Mods, "good-2.0.jar"))
+			if installed := statErr == nil; installed != tc.installed {
+				t.Errorf("good-2.0.jar installed = %v, want %v", installed, tc.installed)
+			}
+			if tc.installed {
+				if len(result.UpdatedMods) != 1 || len(result.FailedMods) != 1 {
+					t.Errorf("expected one update and one failure, got %+v", result)
+				}
+			} else {
+				if len(result.UpdatedMods) != 0 || len(result.FailedMods) != 2 {
+					t.Fatalf("expected both mods to fail, got %+v", result)
+				}
+				for _, d := range result.Details() {
+					if d.Name == "good" && d.ErrorKind != domain.ModErrNotApplied {
+						t.Errorf("good ErrorKind = %q, want %q", d.ErrorKind, domain.ModErrNotApplied)
+					}
+				}
+			}
+			if _, err := os.Stat(filepath.Join(filepath.Dir(cfg.Paths.Mods), "mods.staging")); !os.IsNotExist(err) {
+				t.Errorf("staging directory left behind: %v", err)
+			}
+		})
+	}
+}
+
+func TestMods_TargetSubdir(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := newMockModrinth(t, "/v2/project/sodium/version", "/files/mod-1.0.0.jar", []byte("JAR"))
+	cfg.Paths.Mods = filepath.Join(cfg.Paths.Server, "mods")
+	cfg.Minecraft.Version = "1.20.1"
+	cfg.Mods.TargetSubdir = "{mc_version}"
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 0
+	mods := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+
+	if _, err := mods.UpdateAll(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, "1.20.1", "mod-1.0.0.jar")); err != nil {
+		t.Fatalf("jar not installed in the target subdir: %v", err)
+	}
+	installed, err := mods.ListInstalled()
+	if err != nil || len(installed) != 1 {
+		t.Errorf("ListInstalled = %v, %v", installed, err)
+	}
+	report, err := mods.Verify(ctx)
+	if err != nil || len(report) != 1 || report[0].Status == domain.IntegrityMissing {
+		t.Errorf("Verify = %+v, %v", report, err)
+	}
+
+	writeFile(t, cfg.Paths.Mods, "1.20.1/.tmp-123", "partial")
+	cfg.Backup.Enabled = true
+	backups := service.NewBackup(cfg, logger)
+	path, err := backups.Create(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := backups.Contents(ctx, filepath.Base(path), "mods/1.20.1/.tmp-123"); len(entries) != 0 {
+		t.Error("partial download should not be backed up")
+	}
+	if entries, _ := backups.Contents(ctx, filepath.Base(path), "mods/1.20.1/mod-1.0.0.jar"); len(entries) != 1 {
+		t.Error("installed jar missing from backup")
+	}
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha1" //nolint:gosec // Modrinth identifies files by SHA-1
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// mrpackLoaders maps minecraft.modloader to its .mrpack dependency key.
+var mrpackLoaders = map[string]string{
+	"fabric":   "fabric-loader",
+	"quilt":    "quilt-loader",
+	"forge":    "forge",
+	"neoforge": "neoforge",
+}
+
+type mrpackIndex struct {
+	FormatVersion int               `json:"formatVersion"`
+	Game          string            `json:"game"`
+	VersionID     string            `json:"versionId"`
+	Name          string            `json:"name"`
+	Files         []mrpackFile      `json:"files"`
+	Dependencies  map[string]string `json:"dependencies"`
+}
+
+type mrpackFile struct {
+	Path      string            `json:"path"`
+	Hashes    map[string]string `json:"hashes"`
+	Env       map[string]string `json:"env"`
+	Downloads []string          `json:"downloads"`
+	FileSize  int64             `json:"fileSize"`
+}
+
+type modrinthSides struct {
+	ClientSide string `json:"client_side"`
+	ServerSide string `json:"server_side"`
+}
+
+// WriteClientPack writes a Modrinth .mrpack to dest listing the installed
+// mods that run on the client, so players can import a matching instance
+// into the Modrinth App or Prism Launcher. Server-only mods (client_side
+// "unsupported") and jars not in the lockfile are left out.
+func (m *Mods) WriteClientPack(ctx context.Context, dest string, opts domain.PackOptions) (*domain.ClientPack, error) {
+	manifest, err := m.Export()
+	if err != nil {
+		return nil, err
+	}
+	index := mrpackIndex{
+		FormatVersion: 1,
+		Game:          "minecraft",
+		VersionID:     opts.Version,
+		Name:          opts.Name,
+		Files:         []mrpackFile{},
+		Dependencies: map[string]string{
+			"minecraft":                              m.cfg.Minecraft.Version,
+			mrpackLoaders[m.cfg.Minecraft.Modloader]: opts.LoaderVersion,
+		},
+	}
+	pack := &domain.ClientPack{Path: dest, Included: []string{}, Excluded: make(map[string]string)}
+
+	tracked := make(map[string]bool, len(manifest.Mods))
+	for _, mm := range manifest.Mods {
+		tracked[mm.Filename] = true
+		var sides modrinthSides
+		if err := m.apiRequest(ctx, modrinthAPI+"/project/"+mm.Slug, &sides); err != nil {
+			return nil, fmt.Errorf("%s: %w", mm.Slug, err)
+		}
+		if sides.ClientSide == "unsupported" {
+			pack.Excluded[mm.Slug] = "server-only"
+			continue
+		}
+		info, err := m.fetchVersion(ctx, mm.VersionID, mm.Slug)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", mm.Slug, err)
+		}
+		file, err := m.mrpackFile(mm.Filename, info.DownloadURL, sides)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", mm.Slug, err)
+		}
+		index.Files = append(index.Files, file)
+		pack.Included = append(pack.Included, mm.Slug)
+	}
+
+	jars, _ := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
+	for _, jar := range jars {
+		if name := filepath.Base(jar); !tracked[name] {
+			pack.Excluded[name] = "not in the lockfile"
+		}
+	}
+
+	if m.cfg.DryRun {
+		m.logger.Info("Dry run: Would write client pack", zap.String("path", dest), zap.Int("mods", len(index.Files)))
+		return pack, nil
+	}
+	if err := writeMrpack(dest, &index); err != nil {
+		return nil, err
+	}
+	m.logger.Info("Wrote client pack", zap.String("path", dest),
+		zap.Int("included", len(pack.Included)), zap.Int("excluded", len(pack.Excluded)))
+	return pack, nil
+}
+
+// mrpackFile describes an installed jar, hashing it as the format requires.
+func (m *Mods) mrpackFile(filename, downloadURL string, sides modrinthSides) (mrpackFile, error) {
+	f, err := os.Open(filepath.Join(m.cfg.ModsDir(), filename)) //nolint:gosec // filename from lockfile
+	if err != nil {
+		return mrpackFile{}, err
+	}
+	defer func() { _ = f.Close() }()
+	h1, h512 := sha1.New(), sha512.New() //nolint:gosec
+	size, err := io.Copy(io.MultiWriter(h1, h512), f)
+	if err != nil {
+		return mrpackFile{}, err
+	}
+	return mrpackFile{
+		Path:      "mods/" + filename,
+		Hashes:    map[string]string{"sha1": hex.EncodeToString(h1.Sum(nil)), "sha512": hex.EncodeToString(h512.Sum(nil))},
+		Env:       map[string]string{"client": packEnv(sides.ClientSide), "server": packEnv(sides.ServerSide)},
+		Downloads: []string{downloadURL},
+		FileSize:  size,
+	}, nil
+}
+
+// packEnv maps a Modrinth side value onto the three the format allows;
+// "unknown" becomes optional so launchers let players decide.
+func packEnv(side string) string {
+	switch side {
+	case "required", "unsupported":
+		return side
+	default:
+		return "optional"
+	}
+}
+
+// writeMrpack zips index into a .mrpack at dest via a temporary file.
+func writeMrpack(dest string, index *mrpackIndex) (err error) {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".tmp-*.mrpack")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+	zw := zip.NewWriter(tmp)
+	w, err := zw.Create("modrinth.index.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(index); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
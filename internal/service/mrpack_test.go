@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestMods_WriteClientPack(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	sodium := filepath.Join(cfg.Paths.Mods, "sodium.jar")
+	writeJar(t, sodium, "fabric.mod.json")
+	writeJar(t, filepath.Join(cfg.Paths.Mods, "serverutils.jar"), "fabric.mod.json")
+	writeJar(t, filepath.Join(cfg.Paths.Mods, "manual.jar"), "fabric.mod.json")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/project/sodium":
+			_, _ = w.Write([]byte(`{"client_side":"required","server_side":"optional"}`))
+		case "/v2/project/serverutils":
+			_, _ = w.Write([]byte(`{"client_side":"unsupported","server_side":"required"}`))
+		case "/v2/version/v-sodium":
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("sodium.jar", "https://cdn.modrinth.com/data/x/sodium.jar")[0])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"sodium", "serverutils"}
+	err := service.NewStateStore(cfg).Update(func(st *domain.State) {
+		st.Mods["sodium"] = domain.LockedMod{Project: "sodium", VersionID: "v-sodium", Filename: "sodium.jar"}
+		st.Mods["serverutils"] = domain.LockedMod{Project: "serverutils", VersionID: "v-su", Filename: "serverutils.jar"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "pack.mrpack")
+	svc := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+	pack, err := svc.WriteClientPack(ctx, dest, domain.PackOptions{Name: "test", Version: "1", LoaderVersion: "0.16.0"})
+	if err != nil {
+		t.Fatalf("WriteClientPack: %v", err)
+	}
+	if len(pack.Included) != 1 || pack.Included[0] != "sodium" {
+		t.Errorf("Included = %v", pack.Included)
+	}
+	if pack.Excluded["serverutils"] == "" || pack.Excluded["manual.jar"] == "" {
+		t.Errorf("Excluded = %v", pack.Excluded)
+	}
+
+	zr, err := zip.OpenReader(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = zr.Close() }()
+	f, err := zr.Open("modrinth.index.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var index struct {
+		Files []struct {
+			Path   string            `json:"path"`
+			Hashes map[string]string `json:"hashes"`
+			Env    map[string]string `json:"env"`
+		} `json:"files"`
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	if err := json.NewDecoder(f).Decode(&index); err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Files) != 1 || index.Files[0].Path != "mods/sodium.jar" {
+		t.Fatalf("files = %+v", index.Files)
+	}
+	if index.Files[0].Hashes["sha1"] != sha1Hex(t, sodium) || len(index.Files[0].Hashes["sha512"]) != 128 {
+		t.Errorf("hashes = %v", index.Files[0].Hashes)
+	}
+	if index.Files[0].Env["client"] != "required" || index.Files[0].Env["server"] != "optional" {
+		t.Errorf("env = %v", index.Files[0].Env)
+	}
+	if index.Dependencies["fabric-loader"] != "0.16.0" || index.Dependencies["minecraft"] != cfg.Minecraft.Version {
+		t.Errorf("dependencies = %v", index.Dependencies)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"slices"
 	"strconv"
@@ -14,48 +16,170 @@ import (
 
 	"craftops/internal/config"
 	"craftops/internal/domain"
+	"craftops/internal/i18n"
 )
 
 const (
 	colorGreen  = 0x00FF00
 	colorRed    = 0xFF0000
 	colorOrange = 0xFFA500
+	colorBlue   = 0x3498DB
+
+	notifyMaxRetries = 2
+	notifyRetryDelay = time.Second
+
+	// maxChangelogs and changelogLength keep mod digests well inside
+	// Discord's embed limits.
+	maxChangelogs   = 5
+	changelogLength = 400
 )
 
-// Notification dispatches alerts via Discord webhooks.
+// Console delivers commands to the running server's console.
+type Console interface {
+	SendConsole(ctx context.Context, command string) error
+}
+
+// Notification dispatches alerts via Discord webhooks and, when a console is
+// attached, in game.
 type Notification struct {
 	cfg             *config.Config
 	logger          *zap.Logger
 	client          *http.Client
 	sortedIntervals []int
+	console         Console
+	proxy           *Proxy
+	events          *EventLog
+	messages        *i18n.Catalog
 }
 
 // NewNotification creates a notification dispatcher.
 func NewNotification(cfg *config.Config, logger *zap.Logger) *Notification {
 	intervals := slices.Clone(cfg.Notifications.WarningIntervals)
 	slices.SortFunc(intervals, func(a, b int) int { return b - a })
+	client, err := newHTTPClient(cfg, time.Duration(cfg.Notifications.Timeout)*time.Second)
+	if err != nil {
+		logger.Warn("Network settings not applied to Discord client", zap.Error(err))
+	}
 	return &Notification{
 		cfg:             cfg,
 		logger:          logger,
-		client:          &http.Client{Timeout: time.Duration(cfg.Notifications.Timeout) * time.Second},
+		client:          client,
 		sortedIntervals: intervals,
+		events:          NewEventLog(cfg),
 	}
 }
 
+// UseConsole attaches the server console used for in-game warnings.
+func (n *Notification) UseConsole(c Console) { n.console = c }
+
+// UseProxy attaches the network proxy, which repeats restart warnings to
+// players on every server behind it.
+func (n *Notification) UseProxy(p *Proxy) { n.proxy = p }
+
+// UseMessages sets the catalog notification titles and the built-in
+// messages are translated with; without one they are English.
+func (n *Notification) UseMessages(c *i18n.Catalog) { n.messages = c }
+
+// T translates a notification message (see i18n.Catalog.T).
+func (n *Notification) T(key string, args ...any) string { return n.messages.T(key, args...) }
+
 // SendSuccess dispatches a success alert if enabled.
 func (n *Notification) SendSuccess(ctx context.Context, message string) error {
 	if !n.cfg.Notifications.SuccessNotifications {
 		return nil
 	}
-	return n.sendDiscord(ctx, "Success", message, colorGreen)
+	return n.sendDiscord(ctx, n.T("notify.success"), message, colorGreen)
 }
 
-// SendError dispatches an error alert if enabled.
+// SendError records message in the event log and dispatches an error
+// alert if enabled.
 func (n *Notification) SendError(ctx context.Context, message string) error {
+	if err := n.events.Record(domain.EventError, message); err != nil {
+		n.logger.Warn("Failed to record error event", zap.Error(err))
+	}
+	if !n.cfg.Notifications.ErrorNotifications {
+		return nil
+	}
+	return n.sendDiscord(ctx, n.T("notify.error"), message, colorRed)
+}
+
+// SendModDigest summarizes a mod update in one embed: old→new versions of
+// updated mods, failures, advisories, the number left unchanged and the
+// changelogs of the first few updates. Runs that changed nothing are not reported.
+func (n *Notification) SendModDigest(ctx context.Context, res *domain.ModUpdateResult) error {
+	failed := len(res.FailedMods) > 0
+	if len(res.UpdatedMods) == 0 && !failed {
+		return nil
+	}
+	if (failed && !n.cfg.Notifications.ErrorNotifications) || (!failed && !n.cfg.Notifications.SuccessNotifications) {
+		return nil
+	}
+
+	embed := discordEmbed{
+		Title: n.T("notify.mod_update_title"),
+		Description: n.T("notify.mod_update_summary", "updated", len(res.UpdatedMods),
+			"failed", len(res.FailedMods), "unchanged", len(res.SkippedMods)),
+		Color: colorGreen,
+	}
+	switch {
+	case failed && len(res.UpdatedMods) == 0:
+		embed.Color = colorRed
+	case failed:
+		embed.Color = colorOrange
+	}
+
+	var updated, failures []string
+	var changelogs []discordField
+	for _, d := range res.Details() {
+		switch d.Outcome {
+		case domain.ModUpdated:
+			line := fmt.Sprintf("%s %s", d.Name, d.Versions())
+			if d.Bytes > 0 {
+				line += fmt.Sprintf(" (%s)", domain.FormatSize(d.Bytes))
+			}
+			updated = append(updated, line)
+			if log := strings.TrimSpace(d.Changelog); log != "" && len(changelogs) < maxChangelogs {
+				changelogs = append(changelogs, discordField{Name: d.Name + " " + d.To, Value: truncate(log, changelogLength)})
+			}
+		case domain.ModFailed:
+			failures = append(failures, fmt.Sprintf("%s [%s]: %s", d.Name, d.ErrorKind, d.Error))
+		}
+	}
+	if len(updated) > 0 {
+		embed.Fields = append(embed.Fields, discordField{Name: n.T("notify.mod_update_updated"), Value: strings.Join(updated, "\n")})
+	}
+	if len(failures) > 0 {
+		embed.Fields = append(embed.Fields, discordField{Name: n.T("notify.mod_update_failed"), Value: strings.Join(failures, "\n")})
+	}
+	if len(res.Advisories) > 0 {
+		lines := make([]string, 0, len(res.Advisories))
+		for _, a := range res.Advisories {
+			lines = append(lines, fmt.Sprintf("%s [%s]: %s", a.Mod, a.Kind, a.Reason))
+		}
+		embed.Fields = append(embed.Fields, discordField{Name: n.T("notify.mod_update_advisories"), Value: strings.Join(lines, "\n")})
+	}
+	if !res.Diff.Empty() {
+		embed.Fields = append(embed.Fields, discordField{Name: n.T("notify.mod_update_mods_dir"), Value: strings.Join(res.Diff.Lines(), "\n")})
+	}
+	embed.Fields = append(embed.Fields, changelogs...)
+	return n.sendEmbed(ctx, embed)
+}
+
+// SendInfo dispatches a progress alert; it follows success_notifications.
+func (n *Notification) SendInfo(ctx context.Context, title, message string) error {
+	if !n.cfg.Notifications.SuccessNotifications {
+		return nil
+	}
+	return n.sendDiscord(ctx, title, message, colorBlue)
+}
+
+// SendWarning dispatches an alert about something craftops did to prevent
+// a failure; it follows error_notifications.
+func (n *Notification) SendWarning(ctx context.Context, title, message string) error {
 	if !n.cfg.Notifications.ErrorNotifications {
 		return nil
 	}
-	return n.sendDiscord(ctx, "Error", message, colorRed)
+	return n.sendDiscord(ctx, title, message, colorOrange)
 }
 
 // SendRestartWarnings sends timed alerts before a restart.
@@ -68,8 +192,15 @@ func (n *Notification) SendRestartWarnings(ctx context.Context) error {
 	n.logger.Info("Sending restart warnings", zap.Ints("intervals", intervals))
 
 	for i, minutes := range intervals {
-		msg := strings.ReplaceAll(n.cfg.Notifications.WarningMessage, "{minutes}", strconv.Itoa(minutes))
-		if err := n.sendDiscord(ctx, "Server Restart Warning", msg, colorOrange); err != nil {
+		msg := n.T("notify.restart_warning", "minutes", minutes)
+		if custom := n.cfg.Notifications.RestartWarning; custom != "" {
+			msg = strings.ReplaceAll(custom, "{minutes}", strconv.Itoa(minutes))
+		}
+		n.inGame(ctx, "say "+msg)
+		if err := n.proxy.Broadcast(ctx, msg); err != nil {
+			n.logger.Debug("Proxy warning not sent", zap.Error(err))
+		}
+		if err := n.sendDiscord(ctx, n.T("notify.restart_warning_title"), msg, colorOrange); err != nil {
 			return err
 		}
 
@@ -84,10 +215,56 @@ func (n *Notification) SendRestartWarnings(ctx context.Context) error {
 			}
 		}
 	}
+	if n.console != nil && n.cfg.Notifications.InGameWarnings {
+		return n.countdown(ctx, time.Duration(intervals[len(intervals)-1])*time.Minute)
+	}
 	return nil
 }
 
-// HealthCheck verifies webhook configuration.
+// countdown waits out the final warning interval, showing the seconds left on
+// every player's action bar during the last minute.
+func (n *Notification) countdown(ctx context.Context, total time.Duration) error {
+	step := time.Duration(max(n.cfg.Notifications.CountdownInterval, 1)) * time.Second
+	sleep := func(d time.Duration) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return nil
+		}
+	}
+	if total > time.Minute {
+		if err := sleep(total - time.Minute); err != nil {
+			return err
+		}
+		total = time.Minute
+	}
+	n.inGame(ctx, "title @a title "+textComponent(n.T("notify.restart_in", "time", total), "gold"))
+	for left := total; left > 0; left -= step {
+		n.inGame(ctx, "title @a actionbar "+textComponent(n.T("notify.restarting_in", "seconds", int(left.Seconds())), "red"))
+		if err := sleep(min(step, left)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inGame runs a console command if in-game warnings are enabled, logging
+// rather than failing when the server cannot be reached.
+func (n *Notification) inGame(ctx context.Context, command string) {
+	if n.console == nil || !n.cfg.Notifications.InGameWarnings || n.cfg.DryRun {
+		return
+	}
+	if err := n.console.SendConsole(ctx, command); err != nil {
+		n.logger.Debug("In-game warning not sent", zap.Error(err))
+	}
+}
+
+func textComponent(text, color string) string {
+	data, _ := json.Marshal(map[string]string{"text": text, "color": color})
+	return string(data)
+}
+
 // HealthCheck verifies webhook configuration.
 func (n *Notification) HealthCheck(_ context.Context) []domain.HealthCheck {
 	webhook := n.cfg.Notifications.DiscordWebhook
@@ -115,15 +292,33 @@ type discordEmbed struct {
 	Title       string            `json:"title"`
 	Description string            `json:"description"`
 	Color       int               `json:"color"`
+	Fields      []discordField    `json:"fields,omitempty"`
 	Timestamp   string            `json:"timestamp"`
 	Footer      map[string]string `json:"footer"`
 }
 
+type discordField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
 type discordPayload struct {
 	Embeds []discordEmbed `json:"embeds"`
 }
 
 func (n *Notification) sendDiscord(ctx context.Context, title, message string, color int) error {
+	return n.sendEmbed(ctx, discordEmbed{Title: title, Description: message, Color: color})
+}
+
+func (n *Notification) sendEmbed(ctx context.Context, embed discordEmbed) error {
+	return n.postEmbed(ctx, embed, nil)
+}
+
+// postEmbed posts embed to the webhook. With out set, Discord is asked to
+// return the created message, which is decoded into it; out is left alone
+// when nothing was sent.
+func (n *Notification) postEmbed(ctx context.Context, embed discordEmbed, out any) error {
+	title := embed.Title
 	if n.cfg.Notifications.DiscordWebhook == "" {
 		n.logger.Debug("Discord webhook not configured, skipping")
 		return nil
@@ -133,46 +328,76 @@ func (n *Notification) sendDiscord(ctx context.Context, title, message string, c
 		n.logger.Info("Dry run: Would send Discord notification", zap.String("title", title))
 		return nil
 	}
-
-	if len(message) > 2000 {
-		message = message[:1997] + "..."
+	if n.cfg.Offline {
+		n.logger.Info("Offline: Discord notification not sent", zap.String("title", title))
+		return nil
 	}
 
-	payload := discordPayload{
-		Embeds: []discordEmbed{{
-			Title:       title,
-			Description: message,
-			Color:       color,
-			Timestamp:   time.Now().UTC().Format(time.RFC3339),
-			Footer:      map[string]string{"text": "CraftOps"},
-		}},
+	embed.Description = truncate(embed.Description, 2000)
+	for i := range embed.Fields {
+		embed.Fields[i].Value = truncate(embed.Fields[i].Value, 1024)
 	}
+	embed.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	embed.Footer = map[string]string{"text": "CraftOps"}
+	payload := discordPayload{Embeds: []discordEmbed{embed}}
 
 	var body bytes.Buffer
 	if err := json.NewEncoder(&body).Encode(payload); err != nil {
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.Notifications.DiscordWebhook, &body)
-	if err != nil {
-		return err
+	webhook := n.cfg.Notifications.DiscordWebhook
+	if out != nil {
+		sep := "?"
+		if strings.Contains(webhook, "?") {
+			sep = "&"
+		}
+		webhook += sep + "wait=true"
 	}
-	req.Header.Set("Content-Type", "application/json")
+	var reply []byte
+	err := withRetry(ctx, notifyMaxRetries, notifyRetryDelay, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body.Bytes()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
 
-	resp, err := n.client.Do(req) //nolint:gosec // webhook URL from user config
+		resp, err := n.client.Do(req) //nolint:gosec // webhook URL from user config
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+			return &domain.APIError{
+				URL:        n.cfg.Notifications.DiscordWebhook,
+				StatusCode: resp.StatusCode,
+				Message:    "Discord API error",
+				RetryAfter: parseRetryAfter(resp.Header),
+			}
+		}
+		if out != nil {
+			reply, err = io.ReadAll(resp.Body)
+		}
+		return err
+	})
 	if err != nil {
 		return err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return &domain.APIError{
-			URL:        n.cfg.Notifications.DiscordWebhook,
-			StatusCode: resp.StatusCode,
-			Message:    "Discord API error",
+	if out != nil {
+		if err := json.Unmarshal(reply, out); err != nil {
+			return fmt.Errorf("decoding Discord message: %w", err)
 		}
 	}
 
 	n.logger.Debug("Discord notification sent")
 	return nil
 }
+
+// truncate shortens s to at most n bytes, marking the cut with "...".
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
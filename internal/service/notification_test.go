@@ -2,9 +2,16 @@ package service_test
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
+	"craftops/internal/config"
 	"craftops/internal/domain"
 	"craftops/internal/service"
 )
@@ -142,3 +149,118 @@ func TestNotification_SendSuccess_WithWebhook_DryRun(t *testing.T) {
 		t.Errorf("SendError dry-run: %v", err)
 	}
 }
+
+func TestNotification_SendModDigest(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	var got struct {
+		Embeds []struct {
+			Color  int `json:"color"`
+			Fields []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"fields"`
+		} `json:"embeds"`
+	}
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	cfg.Notifications.DiscordWebhook = srv.URL
+	svc := service.NewNotification(cfg, logger)
+
+	if err := svc.SendModDigest(ctx, &domain.ModUpdateResult{SkippedMods: []string{"Sodium"}}); err != nil || calls != 0 {
+		t.Fatalf("unchanged run should not notify: calls=%d err=%v", calls, err)
+	}
+
+	res := &domain.ModUpdateResult{
+		UpdatedMods: []string{"Sodium"},
+		FailedMods:  map[string]string{"Lithium": "no compatible version"},
+		Changes:     []domain.ModChange{{Name: "Sodium", From: "0.5.8", To: "0.5.11"}},
+	}
+	if err := svc.SendModDigest(ctx, res); err != nil {
+		t.Fatalf("SendModDigest: %v", err)
+	}
+	if calls != 1 || len(got.Embeds) != 1 || len(got.Embeds[0].Fields) != 2 {
+		t.Fatalf("expected one embed with two fields, got %d call(s): %+v", calls, got)
+	}
+	if f := got.Embeds[0].Fields[0]; f.Value != "Sodium 0.5.8 → 0.5.11" {
+		t.Errorf("updated field = %q", f.Value)
+	}
+	if got.Embeds[0].Color != 0xFFA500 {
+		t.Errorf("partial failure color = %#x, want orange", got.Embeds[0].Color)
+	}
+}
+
+func TestNotification_SendModDigest_Changelogs(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	var got struct {
+		Embeds []struct {
+			Fields []struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"fields"`
+		} `json:"embeds"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	cfg.Notifications.DiscordWebhook = srv.URL
+
+	res := &domain.ModUpdateResult{FailedMods: map[string]string{}}
+	res.Record(domain.ModDetail{Name: "Sodium", Outcome: domain.ModUpdated, From: "0.5.8", To: "0.5.11",
+		Changelog: "  Fixed chunk flicker\n" + strings.Repeat("x", 1000)})
+	res.Record(domain.ModDetail{Name: "Lithium", Outcome: domain.ModUpdated, To: "0.12"})
+	if err := service.NewNotification(cfg, logger).SendModDigest(ctx, res); err != nil {
+		t.Fatalf("SendModDigest: %v", err)
+	}
+	if len(got.Embeds) != 1 || len(got.Embeds[0].Fields) != 2 {
+		t.Fatalf("expected the updated field and one changelog, got %+v", got)
+	}
+	f := got.Embeds[0].Fields[1]
+	if f.Name != "Sodium 0.5.11" || !strings.HasPrefix(f.Value, "Fixed chunk flicker") || len(f.Value) > 400 {
+		t.Errorf("changelog field = %q: %.40q (%d bytes)", f.Name, f.Value, len(f.Value))
+	}
+}
+
+func TestDiscordBot(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.DiscordBot = config.DiscordBotConfig{ApplicationID: "app", Token: "bot-token", GuildID: "guild"}
+	var calls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bot bot-token" {
+			t.Errorf("%s sent without the bot token", r.URL.Path)
+		}
+		if r.Method == http.MethodPatch && !strings.Contains(string(body), `"color":16711680`) {
+			t.Errorf("failed command reply should be red: %s", body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	defer service.SetDiscordAPI(srv.URL)()
+
+	bot := service.NewDiscordBot(cfg, logger)
+	if err := bot.RegisterCommands(ctx); err != nil {
+		t.Fatalf("RegisterCommands: %v", err)
+	}
+	reply := domain.BotReply{Token: "tok", ChannelID: "chan", Received: time.Now(), Title: "/backup", Message: "disk full"}
+	if err := bot.Reply(ctx, reply); err != nil {
+		t.Fatalf("Reply: %v", err)
+	}
+	// After the interaction token expires the reply becomes a channel message.
+	reply.Received = time.Now().Add(-20 * time.Minute)
+	if err := bot.Reply(ctx, reply); err != nil {
+		t.Fatalf("late Reply: %v", err)
+	}
+	want := []string{"PUT /applications/app/guilds/guild/commands", "PATCH /webhooks/app/tok/messages/@original",
+		"POST /channels/chan/messages"}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"craftops/internal/domain"
+)
+
+var (
+	colorCodes  = regexp.MustCompile(`§.`)
+	tpsLine     = regexp.MustCompile(`TPS from last ([^:]+):\s*([-\d.,*\s]+)`)
+	sparkMSPT   = regexp.MustCompile(`Tick durations[^:]*:\s*[\d.]+/([\d.]+)/`)
+	paperMSPT   = regexp.MustCompile(`tick times \(avg/min/max\)[^:]*:\s*[^\d]*([\d.]+)/`)
+	forgeTiming = regexp.MustCompile(`Overall\s*:\s*Mean tick time:\s*([\d.]+)\s*ms\.\s*Mean TPS:\s*([\d.]+)`)
+)
+
+// perfProbes are tried in order until one yields a TPS reading. Spark gives
+// both TPS and MSPT; Paper needs `tps` plus `mspt`; Forge/NeoForge report a
+// mean tick time alongside TPS.
+var perfProbes = []struct {
+	source string
+	cmds   []string
+}{
+	{"spark", []string{"spark tps"}},
+	{"paper", []string{"tps", "mspt"}},
+	{"neoforge", []string{"neoforge tps"}},
+	{"forge", []string{"forge tps"}},
+}
+
+// Perf samples tick health over RCON.
+func (s *Server) Perf(ctx context.Context) (*domain.PerfSample, error) {
+	conn, err := dialRCON(ctx, s.cfg.Paths.Server)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	for _, probe := range perfProbes {
+		var out strings.Builder
+		for _, cmd := range probe.cmds {
+			resp, err := conn.Command(cmd)
+			if err != nil {
+				return nil, err
+			}
+			out.WriteString(resp + "\n")
+		}
+		if sample, ok := parsePerf(out.String()); ok {
+			sample.Source = probe.source
+			sample.SampledAt = time.Now()
+			return sample, nil
+		}
+	}
+	return nil, errors.New("server exposes no TPS command (install spark, or use Paper/Forge/NeoForge)")
+}
+
+// parsePerf extracts TPS and MSPT from tps/mspt command output.
+func parsePerf(out string) (*domain.PerfSample, bool) {
+	out = colorCodes.ReplaceAllString(out, "")
+	sample := &domain.PerfSample{}
+	found := false
+
+	if m := tpsLine.FindStringSubmatch(out); m != nil {
+		labels := strings.Split(m[1], ",")
+		values := strings.FieldsFunc(m[2], func(r rune) bool { return r == ',' || r == ' ' || r == '\n' })
+		for i, label := range labels {
+			if i >= len(values) {
+				break
+			}
+			v, err := strconv.ParseFloat(strings.TrimLeft(values[i], "*"), 64)
+			if err != nil {
+				continue
+			}
+			switch strings.TrimSpace(label) {
+			case "1m":
+				sample.TPS1m, found = v, true
+			case "5m":
+				sample.TPS5m = v
+			case "15m":
+				sample.TPS15m = v
+			}
+		}
+	}
+	for _, re := range []*regexp.Regexp{sparkMSPT, paperMSPT} {
+		if m := re.FindStringSubmatch(out); m != nil {
+			sample.MSPT, _ = strconv.ParseFloat(m[1], 64)
+			break
+		}
+	}
+	if m := forgeTiming.FindStringSubmatch(out); m != nil {
+		sample.MSPT, _ = strconv.ParseFloat(m[1], 64)
+		sample.TPS1m, _ = strconv.ParseFloat(m[2], 64)
+		found = true
+	}
+	return sample, found
+}
@@ -0,0 +1,146 @@
+package service_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"craftops/internal/service"
+)
+
+// fakeRCON serves Source RCON on localhost, answering commands from replies
+// (unknown commands get "Unknown command") and returning the port.
+func fakeRCON(t *testing.T, password string, replies map[string]string) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	write := func(w io.Writer, id, typ int32, body string) {
+		var buf bytes.Buffer
+		_ = binary.Write(&buf, binary.LittleEndian, int32(10+len(body)))
+		_ = binary.Write(&buf, binary.LittleEndian, id)
+		_ = binary.Write(&buf, binary.LittleEndian, typ)
+		buf.WriteString(body + "\x00\x00")
+		_, _ = w.Write(buf.Bytes())
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close() //nolint:errcheck
+				for {
+					var size, id, typ int32
+					if binary.Read(conn, binary.LittleEndian, &size) != nil {
+						return
+					}
+					_ = binary.Read(conn, binary.LittleEndian, &id)
+					_ = binary.Read(conn, binary.LittleEndian, &typ)
+					body := make([]byte, size-8)
+					_, _ = io.ReadFull(conn, body)
+					cmd := string(bytes.TrimRight(body, "\x00"))
+					switch {
+					case typ == 3 && cmd != password:
+						write(conn, -1, 2, "")
+					case typ == 3:
+						write(conn, id, 2, "")
+					default:
+						reply, ok := replies[cmd]
+						if !ok {
+							reply = "Unknown command"
+						}
+						write(conn, id, 0, reply)
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer_Perf_Paper(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	port := fakeRCON(t, "secret", map[string]string{
+		"tps":  "§6TPS from last 1m, 5m, 15m: §a*20.0, §a19.8, §e17.25",
+		"mspt": "§6Server tick times §e(§7avg§e/§7min§e/§7max§e)§6 from last 5s,§6 10s,§6 1m§6:\n§6◴ §a12.3§7/§a4.0§7/§a40.1",
+	})
+	writeFile(t, cfg.Paths.Server, "server.properties",
+		"enable-rcon=true\nrcon.password=secret\nrcon.port="+strconv.Itoa(port)+"\n")
+
+	sample, err := service.NewServer(cfg, logger).Perf(ctx)
+	if err != nil {
+		t.Fatalf("Perf: %v", err)
+	}
+	if sample.Source != "paper" || sample.TPS1m != 20 || sample.TPS15m != 17.25 || sample.MSPT != 12.3 {
+		t.Errorf("unexpected sample %+v", sample)
+	}
+}
+
+func TestServer_Perf_Forge(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	port := fakeRCON(t, "pw", map[string]string{
+		"forge tps": "Dim minecraft:overworld: Mean tick time: 8.1 ms. Mean TPS: 20.000\nOverall: Mean tick time: 61.500 ms. Mean TPS: 16.260",
+	})
+	writeFile(t, cfg.Paths.Server, "server.properties",
+		"enable-rcon=true\nrcon.password=pw\nrcon.port="+strconv.Itoa(port)+"\n")
+
+	sample, err := service.NewServer(cfg, logger).Perf(ctx)
+	if err != nil {
+		t.Fatalf("Perf: %v", err)
+	}
+	if sample.Source != "forge" || sample.TPS1m != 16.26 || sample.MSPT != 61.5 {
+		t.Errorf("unexpected sample %+v", sample)
+	}
+}
+
+func TestServer_Perf_RCONErrors(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	svc := service.NewServer(cfg, logger)
+	if _, err := svc.Perf(ctx); err == nil {
+		t.Error("expected error when RCON is disabled")
+	}
+
+	port := fakeRCON(t, "right", nil)
+	writeFile(t, cfg.Paths.Server, "server.properties",
+		"enable-rcon=true\nrcon.password=wrong\nrcon.port="+strconv.Itoa(port)+"\n")
+	if _, err := svc.Perf(ctx); err == nil {
+		t.Error("expected auth failure with wrong password")
+	}
+}
+
+func TestServer_AwaitQuiet(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	port := fakeRCON(t, "pw", map[string]string{"list": "There are 3 of a max of 20 players online: a, b, c"})
+	writeFile(t, cfg.Paths.Server, "server.properties",
+		"enable-rcon=true\nrcon.password=pw\nrcon.port="+strconv.Itoa(port)+"\n")
+	svc := service.NewServer(cfg, logger)
+
+	if n, err := svc.PlayerCount(ctx); err != nil || n != 3 {
+		t.Fatalf("PlayerCount = %d, %v", n, err)
+	}
+
+	cfg.Server.RestartWhenEmpty = true
+	cfg.Server.MaxDeferMinutes = 0
+	var calls []time.Duration
+	if err := svc.AwaitQuiet(ctx, func(_ int, remaining time.Duration) { calls = append(calls, remaining) }); err != nil {
+		t.Fatalf("AwaitQuiet: %v", err)
+	}
+	if len(calls) != 1 || calls[0] != 0 {
+		t.Errorf("expected a single deadline-reached callback, got %v", calls)
+	}
+
+	cfg.Server.RestartPlayerThreshold = 5
+	calls = nil
+	if err := svc.AwaitQuiet(ctx, func(_ int, remaining time.Duration) { calls = append(calls, remaining) }); err != nil || len(calls) != 0 {
+		t.Errorf("below threshold should not defer: %v, %v", calls, err)
+	}
+}
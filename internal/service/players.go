@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // offline-mode UUIDs are defined as MD5 name UUIDs
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{4}-?[0-9a-fA-F]{12}$`)
+
+var playerNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{3,16}$`)
+
+// mojangAPI resolves player names to UUIDs.
+var mojangAPI = "https://api.minecraftservices.com"
+
+// mojangBatchSize is the most names one bulk lookup accepts.
+const mojangBatchSize = 10
+
+// resolvePlayer returns the dashed UUID for a UUID or a player name known to
+// the server's usercache.json.
+func resolvePlayer(serverDir, player string) (string, error) {
+	if uuidPattern.MatchString(player) {
+		return dashUUID(player), nil
+	}
+	data, err := os.ReadFile(filepath.Join(serverDir, "usercache.json")) //nolint:gosec // server dir from config
+	if err != nil {
+		return "", fmt.Errorf("cannot resolve player name %q without usercache.json: %w", player, err)
+	}
+	var cache []struct {
+		Name string `json:"name"`
+		UUID string `json:"uuid"`
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return "", fmt.Errorf("parsing usercache.json: %w", err)
+	}
+	for _, e := range cache {
+		if strings.EqualFold(e.Name, player) {
+			return e.UUID, nil
+		}
+	}
+	return "", fmt.Errorf("player %q not found in usercache.json", player)
+}
+
+// dashUUID formats a UUID matching uuidPattern in lower case with dashes.
+func dashUUID(id string) string {
+	u := strings.ToLower(strings.ReplaceAll(id, "-", ""))
+	return u[:8] + "-" + u[8:12] + "-" + u[12:16] + "-" + u[16:20] + "-" + u[20:]
+}
+
+// RestorePlayer copies a player's playerdata, advancements and stats files
+// from the named backup into the live world. player is a UUID or a name from
+// usercache.json. The server must be stopped. It returns the restored paths
+// relative to the server directory.
+func (b *Backup) RestorePlayer(ctx context.Context, name, player string) ([]string, error) {
+	uuid, err := resolvePlayer(b.cfg.Paths.Server, player)
+	if err != nil {
+		return nil, err
+	}
+	level := levelName(b.cfg.Paths.Server)
+	entries, err := b.Contents(ctx, name, level)
+	if err != nil {
+		return nil, err
+	}
+	want := map[string]bool{
+		path.Join(level, "playerdata", uuid+".dat"):    true,
+		path.Join(level, "advancements", uuid+".json"): true,
+		path.Join(level, "stats", uuid+".json"):        true,
+	}
+	var targets []string
+	for _, e := range entries {
+		if want[e.Name] {
+			targets = append(targets, e.Name)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no data for player %s in backup %s", uuid, name)
+	}
+	if b.cfg.DryRun {
+		b.logger.Info("Dry run: Would restore player data", zap.Strings("files", targets))
+		return targets, nil
+	}
+	for _, t := range targets {
+		if _, err := b.Extract(ctx, name, t, b.cfg.Paths.Server); err != nil {
+			return nil, fmt.Errorf("restoring %s: %w", t, err)
+		}
+	}
+	b.logger.Info("Player data restored", zap.String("backup", name), zap.String("uuid", uuid), zap.Strings("files", targets))
+	return targets, nil
+}
+
+// playerEntry is one element of whitelist.json or usercache.json.
+type playerEntry struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// resolvePlayers maps names to UUIDs for the server in serverDir. Players
+// in current or usercache.json keep their UUID; on an online-mode=false
+// server the rest get offline UUIDs, otherwise they are looked up at Mojang
+// through client. Names without an account are returned as unresolved.
+func resolvePlayers(ctx context.Context, client *http.Client, serverDir string, names []string, current []playerEntry) ([]playerEntry, []string, error) {
+	known := map[string]playerEntry{}
+	cache, _ := readPlayerList(filepath.Join(serverDir, "usercache.json"))
+	for _, e := range append(cache, current...) {
+		if e.UUID != "" && uuidPattern.MatchString(e.UUID) {
+			known[strings.ToLower(e.Name)] = playerEntry{UUID: dashUUID(e.UUID), Name: e.Name}
+		}
+	}
+	offline := serverProperties(serverDir)["online-mode"] == "false"
+
+	var lookup []string
+	for _, name := range names {
+		if _, ok := known[strings.ToLower(name)]; ok {
+			continue
+		}
+		if offline {
+			known[strings.ToLower(name)] = playerEntry{UUID: offlineUUID(name), Name: name}
+			continue
+		}
+		lookup = append(lookup, name)
+	}
+	for start := 0; start < len(lookup); start += mojangBatchSize {
+		profiles, err := lookupProfiles(ctx, client, lookup[start:min(start+mojangBatchSize, len(lookup))])
+		if err != nil {
+			return nil, nil, fmt.Errorf("resolving player names: %w", err)
+		}
+		for _, p := range profiles {
+			known[strings.ToLower(p.Name)] = p
+		}
+	}
+
+	entries := make([]playerEntry, 0, len(names))
+	var unresolved []string
+	for _, name := range names {
+		if e, ok := known[strings.ToLower(name)]; ok {
+			entries = append(entries, e)
+		} else {
+			unresolved = append(unresolved, name)
+		}
+	}
+	return entries, unresolved, nil
+}
+
+// lookupProfiles resolves up to mojangBatchSize names with Mojang's bulk
+// profile lookup; unknown names are left out of the reply.
+func lookupProfiles(ctx context.Context, client *http.Client, names []string) ([]playerEntry, error) {
+	body, err := json.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+	endpoint := mojangAPI + "/minecraft/profile/lookup/bulk/byname"
+	var profiles []struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}
+	err = withRetry(ctx, notifyMaxRetries, notifyRetryDelay, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := client.Do(req) //nolint:gosec // Mojang API URL
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return &domain.APIError{URL: endpoint, StatusCode: resp.StatusCode, Message: "Mojang API error",
+				RetryAfter: parseRetryAfter(resp.Header)}
+		}
+		return json.NewDecoder(resp.Body).Decode(&profiles)
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]playerEntry, 0, len(profiles))
+	for _, p := range profiles {
+		if uuidPattern.MatchString(p.ID) {
+			entries = append(entries, playerEntry{UUID: dashUUID(p.ID), Name: p.Name})
+		}
+	}
+	return entries, nil
+}
+
+// readPlayerList reads a whitelist.json-style list of players.
+func readPlayerList(path string) ([]playerEntry, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // server dir from config
+	if err != nil {
+		return nil, err
+	}
+	var entries []playerEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", filepath.Base(path), err)
+	}
+	return entries, nil
+}
+
+// offlineUUID is the UUID an online-mode=false server gives name.
+func offlineUUID(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name)) //nolint:gosec // see import
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	return dashUUID(hex.EncodeToString(sum[:]))
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// playerPollInterval is how often a deferred restart re-checks the player count.
+const playerPollInterval = 30 * time.Second
+
+var listPattern = regexp.MustCompile(`There are (\d+)`)
+
+// PlayerCount returns the number of online players via RCON `list`.
+func (s *Server) PlayerCount(ctx context.Context) (int, error) {
+	conn, err := dialRCON(ctx, s.cfg.Paths.Server)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = conn.Close() }()
+	out, err := conn.Command("list")
+	if err != nil {
+		return 0, err
+	}
+	m := listPattern.FindStringSubmatch(colorCodes.ReplaceAllString(out, ""))
+	if m == nil {
+		return 0, fmt.Errorf("unexpected list output: %q", out)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// AwaitQuiet delays a restart while server.restart_when_empty is set and at
+// least restart_player_threshold players are online, for at most
+// max_defer_minutes. progress is called on every check that defers, and once
+// more with remaining 0 if the deadline forces the restart. If the player
+// count cannot be read the restart proceeds.
+func (s *Server) AwaitQuiet(ctx context.Context, progress func(online int, remaining time.Duration)) error {
+	if !s.cfg.Server.RestartWhenEmpty {
+		return nil
+	}
+	threshold := max(s.cfg.Server.RestartPlayerThreshold, 1)
+	deadline := time.Now().Add(time.Duration(s.cfg.Server.MaxDeferMinutes) * time.Minute)
+	for {
+		online, err := s.PlayerCount(ctx)
+		if err != nil {
+			s.logger.Warn("Cannot read player count, restarting without waiting", zap.Error(err))
+			return nil
+		}
+		if online < threshold {
+			return nil
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			s.logger.Info("Restart deferral deadline reached", zap.Int("online", online))
+			progress(online, 0)
+			return nil
+		}
+		s.logger.Info("Deferring restart for online players", zap.Int("online", online), zap.Duration("remaining", remaining))
+		progress(online, remaining)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(min(playerPollInterval, remaining)):
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// pregenInterval is how often `serve` checks on the pregeneration job.
+var pregenInterval = time.Minute
+
+// chunkyProgress matches Chunky's progress and completion log lines, e.g.
+// "[Chunky] Task running for minecraft:overworld. Processed: 3123 chunks (1.94%), ...".
+var chunkyProgress = regexp.MustCompile(`Task (running|finished) for (\S+)\. Processed: (\d+) chunks \(([\d.]+)%\)`)
+
+// PlanPregen stores job as the pregeneration job `serve` works through,
+// replacing a finished or cancelled one.
+func (s *Server) PlanPregen(job domain.PregenJob) error {
+	if job.Radius < 1 {
+		return fmt.Errorf("invalid pregen radius: %d. Must be at least 1", job.Radius)
+	}
+	id, err := dimensionID(job.Dimension)
+	if err != nil {
+		return err
+	}
+	job.Dimension = id
+	job.CreatedAt = time.Now()
+	var busy bool
+	err = s.state.Update(func(st *domain.State) {
+		if busy = st.Pregen != nil && st.Pregen.Started && st.Pregen.FinishedAt == nil; !busy {
+			st.Pregen = &job
+		}
+	})
+	if err == nil && busy {
+		return errors.New("a pregeneration job is in progress; cancel it first")
+	}
+	return err
+}
+
+// Pregen returns the pregeneration job, nil if there is none.
+func (s *Server) Pregen() (*domain.PregenJob, error) {
+	st, err := s.state.Load()
+	if err != nil {
+		return nil, err
+	}
+	return st.Pregen, nil
+}
+
+// CancelPregen drops the pregeneration job, cancelling its Chunky task if
+// the server is running.
+func (s *Server) CancelPregen(ctx context.Context) error {
+	job, err := s.Pregen()
+	if err != nil || job == nil {
+		return err
+	}
+	if job.Started && job.FinishedAt == nil && s.consoleReady(ctx) {
+		if err := s.sendAll(ctx, "chunky cancel "+job.Dimension, "chunky confirm"); err != nil {
+			return err
+		}
+	}
+	return s.state.Update(func(st *domain.State) { st.Pregen = nil })
+}
+
+// RunPregen works through the pregeneration job until ctx is done, one
+// pregen.batch_minutes batch at a time, pregen.rest_minutes apart, while the
+// pregen window is open, the server runs and no more than pregen.max_players
+// are online. Progress is kept in the state file, so the job resumes after a
+// restart of either the server or craftops; n is told when it finishes.
+func (s *Server) RunPregen(ctx context.Context, n *Notification) {
+	ticker := time.NewTicker(pregenInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := s.pregenStep(ctx, n, time.Now()); err != nil {
+			s.logger.Warn("Pregeneration step failed", zap.Error(err))
+		}
+	}
+}
+
+// pregenStep starts, checks on or pauses the current batch. Console
+// commands go out against a snapshot of the job; its changes are then
+// applied in the state file, so a job cancelled or replaced meanwhile is
+// left as it is.
+func (s *Server) pregenStep(ctx context.Context, n *Notification, now time.Time) error {
+	job, err := s.Pregen()
+	if err != nil || job == nil || job.FinishedAt != nil {
+		return err
+	}
+	ready := s.consoleReady(ctx)
+
+	if job.Running {
+		chunks, percent := job.Chunks, job.Percent
+		logPath := filepath.Join(s.cfg.Paths.Server, "logs", "latest.log")
+		if c, p, finished, ok := chunkyStatus(logPath, job.LogOffset, job.Dimension); ok {
+			chunks, percent = c, p
+			if finished {
+				if err := s.updatePregen(job, func(j *domain.PregenJob) {
+					j.Chunks, j.Percent = chunks, percent
+					j.Running, j.FinishedAt = false, &now
+				}); err != nil {
+					return err
+				}
+				s.logger.Info("Pregeneration finished", zap.String("dimension", job.Dimension), zap.Int64("chunks", chunks))
+				_ = n.SendSuccess(ctx, n.T("notify.pregen_done", "dimension", job.Dimension, "radius", job.Radius,
+					"chunks", chunks, "batches", job.Batches+1))
+				return nil
+			}
+		}
+		stop := false
+		switch {
+		case !ready:
+			// Chunky saves its task when the server stops; continue it later.
+			stop = true
+		case now.Sub(job.BatchStart) >= time.Duration(s.cfg.Pregen.BatchMinutes)*time.Minute ||
+			!s.cfg.Pregen.Window().Allows(now) || s.pregenBusy(ctx):
+			if err := s.sendAll(ctx, "chunky pause "+job.Dimension); err != nil {
+				return err
+			}
+			stop = true
+			s.logger.Info("Pregeneration batch paused", zap.String("dimension", job.Dimension), zap.Float64("percent", percent))
+		}
+		return s.updatePregen(job, func(j *domain.PregenJob) {
+			j.Chunks, j.Percent = chunks, percent
+			if stop {
+				j.Running = false
+				j.Batches++
+			}
+		})
+	}
+
+	p := s.cfg.Pregen
+	if job.Started && now.Before(job.BatchStart.Add(time.Duration(p.BatchMinutes+p.RestMinutes)*time.Minute)) {
+		return nil
+	}
+	if !ready || !p.Window().Allows(now) || s.pregenBusy(ctx) {
+		return nil
+	}
+	commands := []string{"chunky continue " + job.Dimension}
+	if !job.Started {
+		commands = []string{
+			"chunky world " + job.Dimension,
+			fmt.Sprintf("chunky center %d %d", job.CenterX, job.CenterZ),
+			fmt.Sprintf("chunky radius %d", job.Radius),
+			"chunky start",
+		}
+		if job.Border {
+			// The console runs in the overworld; the border belongs to the
+			// pregenerated dimension.
+			in := "execute in " + job.Dimension + " run "
+			commands = append(commands,
+				fmt.Sprintf("%sworldborder center %d %d", in, job.CenterX, job.CenterZ),
+				fmt.Sprintf("%sworldborder set %d", in, 2*job.Radius))
+		}
+	}
+	var offset int64
+	if info, err := os.Stat(filepath.Join(s.cfg.Paths.Server, "logs", "latest.log")); err == nil {
+		offset = info.Size()
+	}
+	if s.cfg.DryRun {
+		s.logger.Info("Dry run: Would start pregeneration batch", zap.Strings("commands", commands))
+		return nil
+	}
+	if err := s.sendAll(ctx, commands...); err != nil {
+		return err
+	}
+	s.logger.Info("Pregeneration batch started", zap.String("dimension", job.Dimension), zap.Int("batch", job.Batches+1))
+	return s.updatePregen(job, func(j *domain.PregenJob) {
+		j.Started, j.Running, j.BatchStart, j.LogOffset = true, true, now, offset
+	})
+}
+
+// pregenBusy reports whether more than pregen.max_players are online. If
+// the count cannot be read the batch is not held back.
+func (s *Server) pregenBusy(ctx context.Context) bool {
+	limit := s.cfg.Pregen.MaxPlayers
+	if limit < 0 {
+		return false
+	}
+	online, err := s.PlayerCount(ctx)
+	return err == nil && online > limit
+}
+
+// consoleReady reports whether the server runs in its screen session.
+func (s *Server) consoleReady(ctx context.Context) bool {
+	status, err := s.Status(ctx)
+	return err == nil && status.IsRunning && !status.Unmanaged
+}
+
+func (s *Server) sendAll(ctx context.Context, commands ...string) error {
+	for _, c := range commands {
+		if err := s.SendConsole(ctx, c); err != nil {
+			return fmt.Errorf("sending %q: %w", c, err)
+		}
+	}
+	return nil
+}
+
+// updatePregen applies fn to the stored pregeneration job if it is still
+// job, the one planned at job.CreatedAt.
+func (s *Server) updatePregen(job *domain.PregenJob, fn func(*domain.PregenJob)) error {
+	return s.state.Update(func(st *domain.State) {
+		if st.Pregen != nil && st.Pregen.CreatedAt.Equal(job.CreatedAt) {
+			fn(st.Pregen)
+		}
+	})
+}
+
+// chunkyStatus reads the last progress Chunky logged for dimension after
+// offset in the log at path; a log shorter than offset has been rotated and
+// is read from the start. ok is false when there is none.
+func chunkyStatus(path string, offset int64, dimension string) (chunks int64, percent float64, finished, ok bool) {
+	f, err := os.Open(path) //nolint:gosec // server dir from config
+	if err != nil {
+		return 0, 0, false, false
+	}
+	defer func() { _ = f.Close() }()
+	if info, err := f.Stat(); err == nil && info.Size() >= offset {
+		_, _ = f.Seek(offset, io.SeekStart)
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		m := chunkyProgress.FindStringSubmatch(sc.Text())
+		if m == nil || m[2] != dimension {
+			continue
+		}
+		chunks, _ = strconv.ParseInt(m[3], 10, 64)
+		percent, _ = strconv.ParseFloat(m[4], 64)
+		finished, ok = m[1] == "finished", true
+	}
+	return chunks, percent, finished, ok
+}
+
+// dimensionID returns the namespaced ID Chunky knows a dimension by:
+// overworld, nether and end or namespace:path.
+func dimensionID(dimension string) (string, error) {
+	switch strings.TrimPrefix(strings.ToLower(dimension), "minecraft:") {
+	case "", "overworld", "world":
+		return "minecraft:overworld", nil
+	case "nether", "the_nether":
+		return "minecraft:the_nether", nil
+	case "end", "the_end":
+		return "minecraft:the_end", nil
+	}
+	if ns, p, ok := strings.Cut(dimension, ":"); !ok || ns == "" || p == "" {
+		return "", fmt.Errorf("unknown dimension %q: use overworld, nether, end or namespace:path", dimension)
+	}
+	return dimension, nil
+}
@@ -0,0 +1,77 @@
+package service_test
+
+import (
+	"os"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestChunkyStatus(t *testing.T) {
+	dir := t.TempDir()
+	old := "[01:00:00] [Server thread/INFO]: [Chunky] Task finished for minecraft:overworld. Processed: 900 chunks (100.00%), Total time: 0:01:00\n"
+	log := writeFile(t, dir, "latest.log", old+
+		"[02:00:00] [Server thread/INFO]: [Chunky] Task running for minecraft:overworld. Processed: 120 chunks (1.50%), ETA: 1:00:00, Rate: 10.0 cps, Current: 3, -4\n"+
+		"[02:00:05] [Server thread/INFO]: [Chunky] Task running for minecraft:the_nether. Processed: 999 chunks (50.00%), ETA: 0:10:00\n"+
+		"[02:00:10] [Server thread/INFO]: [Chunky] Task running for minecraft:overworld. Processed: 240 chunks (3.00%), ETA: 0:59:00\n")
+
+	// Lines before the batch's offset belong to an earlier task.
+	chunks, percent, finished, ok := service.ChunkyStatus(log, int64(len(old)), "minecraft:overworld")
+	if !ok || finished || chunks != 240 || percent != 3 {
+		t.Errorf("status = %d %.2f finished=%v ok=%v, want 240 3.00 running", chunks, percent, finished, ok)
+	}
+	if _, _, finished, _ = service.ChunkyStatus(log, 0, "minecraft:overworld"); finished {
+		t.Error("an earlier finished line outranked later progress")
+	}
+	if _, _, _, ok = service.ChunkyStatus(log, int64(len(old)), "minecraft:the_end"); ok {
+		t.Error("reported progress for a dimension Chunky never mentioned")
+	}
+
+	// A rotated log, shorter than the offset, is read from the start.
+	if err := os.WriteFile(log, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if chunks, _, finished, ok = service.ChunkyStatus(log, 1<<20, "minecraft:overworld"); !ok || !finished || chunks != 900 {
+		t.Errorf("rotated log: %d finished=%v ok=%v", chunks, finished, ok)
+	}
+}
+
+func TestServer_PlanPregen(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := service.NewServer(cfg, logger)
+
+	if err := srv.PlanPregen(domain.PregenJob{Dimension: "nether", Radius: 0}); err == nil {
+		t.Error("accepted a zero radius")
+	}
+	if err := srv.PlanPregen(domain.PregenJob{Dimension: "moon", Radius: 100}); err == nil {
+		t.Error("accepted an unknown dimension")
+	}
+	if err := srv.PlanPregen(domain.PregenJob{Dimension: "nether", Radius: 1000, CenterX: 5}); err != nil {
+		t.Fatalf("PlanPregen: %v", err)
+	}
+	job, err := srv.Pregen()
+	if err != nil || job == nil {
+		t.Fatalf("Pregen = %v, %v", job, err)
+	}
+	if job.Dimension != "minecraft:the_nether" || job.Radius != 1000 || job.CenterX != 5 || job.Started {
+		t.Errorf("job = %+v", job)
+	}
+
+	// A job not yet started may be replaced; a started one must be cancelled.
+	if err := srv.PlanPregen(domain.PregenJob{Radius: 2000}); err != nil {
+		t.Fatalf("replacing a pending job: %v", err)
+	}
+	if err := service.NewStateStore(cfg).Update(func(st *domain.State) { st.Pregen.Started = true }); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.PlanPregen(domain.PregenJob{Radius: 3000}); err == nil {
+		t.Error("replaced a job in progress")
+	}
+	if err := srv.CancelPregen(ctx); err != nil {
+		t.Fatalf("CancelPregen: %v", err)
+	}
+	if job, _ = srv.Pregen(); job != nil {
+		t.Errorf("job after cancel = %+v", job)
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"craftops/internal/domain"
+)
+
+const defaultServerPort = 25565
+
+// screenPID extracts the screen session PID from `screen -ls` output.
+func screenPID(output, session string) int {
+	for _, line := range strings.Split(output, "\n") {
+		field, _, _ := strings.Cut(strings.TrimSpace(line), "\t")
+		pid, name, ok := strings.Cut(field, ".")
+		if ok && name == session {
+			if n, err := strconv.Atoi(pid); err == nil {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+// javaPID returns the first java process descended from root, or 0.
+func javaPID(ctx context.Context, root int) int {
+	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=,ppid=,comm=").Output()
+	if err != nil {
+		return 0
+	}
+	children := map[int][]int{}
+	comm := map[int]string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(f[0])
+		ppid, err2 := strconv.Atoi(f[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		children[ppid] = append(children[ppid], pid)
+		comm[pid] = strings.Join(f[2:], " ")
+	}
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if pid != root && strings.HasSuffix(comm[pid], "java") {
+			return pid
+		}
+		queue = append(queue, children[pid]...)
+	}
+	return 0
+}
+
+// processStats fills CPU, RSS and uptime for pid from ps.
+func processStats(ctx context.Context, pid int, status *domain.ServerStatus) {
+	out, err := exec.CommandContext(ctx, "ps", "-o", "%cpu=,rss=,etime=", "-p", strconv.Itoa(pid)).Output() //nolint:gosec
+	if err != nil {
+		return
+	}
+	f := strings.Fields(string(out))
+	if len(f) < 3 {
+		return
+	}
+	status.CPUPercent, _ = strconv.ParseFloat(f[0], 64)
+	if kb, err := strconv.ParseInt(f[1], 10, 64); err == nil {
+		status.MemoryRSS = kb * 1024
+	}
+	if up, err := parseEtime(f[2]); err == nil {
+		status.Uptime = up
+	}
+}
+
+// parseEtime parses ps elapsed time, formatted [[dd-]hh:]mm:ss.
+func parseEtime(s string) (time.Duration, error) {
+	var days int
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, fmt.Errorf("invalid etime %q", s)
+		}
+		days, s = n, rest
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid etime %q", s)
+	}
+	var secs int
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return 0, fmt.Errorf("invalid etime %q", s)
+		}
+		secs = secs*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(secs)*time.Second, nil
+}
+
+// serverPort returns server-port from server.properties.
+func serverPort(dir string) int {
+	if p, err := strconv.Atoi(serverProperties(dir)["server-port"]); err == nil && p > 0 {
+		return p
+	}
+	return defaultServerPort
+}
+
+// serverHost returns the address the server listens on: server-ip from
+// server.properties, or localhost when it binds every interface.
+func serverHost(dir string) string {
+	if ip := serverProperties(dir)["server-ip"]; ip != "" {
+		return ip
+	}
+	return "127.0.0.1"
+}
+
+// portOpen reports whether something accepts TCP connections on host:port.
+func portOpen(ctx context.Context, host string, port int) bool {
+	d := net.Dialer{Timeout: time.Second}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return false
+	}
+	_ = conn.Close()
+	return true
+}
+
+// checkPortFree fails when port is already bound, naming the owning process
+// when it can be found.
+func checkPortFree(ctx context.Context, port int) error {
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err == nil {
+		_ = ln.Close()
+		return nil
+	}
+	if !errors.Is(err, syscall.EADDRINUSE) {
+		return nil
+	}
+	owner := "another process"
+	if pid := portOwner(ctx, port); pid > 0 {
+		owner = "PID " + strconv.Itoa(pid)
+		if name, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid)); err == nil {
+			owner += " (" + strings.TrimSpace(string(name)) + ")"
+		}
+	}
+	return fmt.Errorf("%w: port %d is held by %s", domain.ErrPortInUse, port, owner)
+}
+
+// portOwner finds the PID listening on port via /proc, falling back to lsof.
+func portOwner(ctx context.Context, port int) int {
+	if pid := procPortOwner(port); pid > 0 {
+		return pid
+	}
+	out, err := exec.CommandContext(ctx, "lsof", "-nP", "-t", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN").Output() //nolint:gosec
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0]))
+	return pid
+}
+
+// procPortOwner maps a listening port to its socket inode in /proc/net/tcp*
+// and then to the process holding that socket.
+func procPortOwner(port int) int {
+	inodes := map[string]bool{}
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		data, err := os.ReadFile(table)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n")[1:] {
+			f := strings.Fields(line)
+			// f[1] is local addr:port in hex, f[3] state (0A = LISTEN), f[9] inode.
+			if len(f) < 10 || f[3] != "0A" {
+				continue
+			}
+			_, hexPort, _ := strings.Cut(f[1], ":")
+			if p, err := strconv.ParseInt(hexPort, 16, 32); err == nil && int(p) == port {
+				inodes["socket:["+f[9]+"]"] = true
+			}
+		}
+	}
+	if len(inodes) == 0 {
+		return 0
+	}
+	procs, _ := os.ReadDir("/proc")
+	for _, p := range procs {
+		pid, err := strconv.Atoi(p.Name())
+		if err != nil {
+			continue
+		}
+		fds, _ := os.ReadDir(filepath.Join("/proc", p.Name(), "fd"))
+		for _, fd := range fds {
+			if link, err := os.Readlink(filepath.Join("/proc", p.Name(), "fd", fd.Name())); err == nil && inodes[link] {
+				return pid
+			}
+		}
+	}
+	return 0
+}
+
+// orphanJava returns a java process whose working directory is serverDir, or
+// 0. Such a process is a server that outlived its screen session.
+func orphanJava(ctx context.Context, serverDir string) int {
+	want, err := filepath.EvalSymlinks(serverDir)
+	if err != nil {
+		return 0
+	}
+	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=,comm=").Output()
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		f := strings.Fields(line)
+		if len(f) < 2 || !strings.HasSuffix(f[len(f)-1], "java") {
+			continue
+		}
+		pid, err := strconv.Atoi(f[0])
+		if err != nil {
+			continue
+		}
+		if cwd := processCwd(ctx, pid); cwd != "" {
+			if resolved, err := filepath.EvalSymlinks(cwd); err == nil && resolved == want {
+				return pid
+			}
+		}
+	}
+	return 0
+}
+
+// processCwd returns pid's working directory via /proc, falling back to lsof.
+func processCwd(ctx context.Context, pid int) string {
+	if cwd, err := os.Readlink(fmt.Sprintf("/proc/%d/cwd", pid)); err == nil {
+		return cwd
+	}
+	out, err := exec.CommandContext(ctx, "lsof", "-a", "-p", strconv.Itoa(pid), "-d", "cwd", "-Fn").Output() //nolint:gosec
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "n") {
+			return line[1:]
+		}
+	}
+	return ""
+}
+
+// processAlive reports whether pid exists.
+func processAlive(pid int) bool {
+	return pid > 0 && syscall.Kill(pid, 0) == nil
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"craftops/internal/domain"
+)
+
+// modrinthProject is the part of a Modrinth project the update, advisory
+// and audit paths read.
+type modrinthProject struct {
+	ID          string `json:"id"`
+	Slug        string `json:"slug"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Status      string `json:"status"`
+	ClientSide  string `json:"client_side"`
+	ServerSide  string `json:"server_side"`
+	Downloads   int64  `json:"downloads"`
+	License     struct {
+		ID  string `json:"id"`
+		URL string `json:"url"`
+	} `json:"license"`
+	SourceURL  string `json:"source_url"`
+	IssuesURL  string `json:"issues_url"`
+	WikiURL    string `json:"wiki_url"`
+	DiscordURL string `json:"discord_url"`
+}
+
+// projects looks up the Modrinth projects of sources in bulk, keyed by both
+// ID and slug. Sources Modrinth does not know are absent.
+func (m *Mods) projects(ctx context.Context, sources []string) (map[string]modrinthProject, error) {
+	var keys []string
+	for _, src := range sources {
+		if id, err := parseProjectID(src); err == nil {
+			keys = append(keys, id)
+		}
+	}
+	out := make(map[string]modrinthProject, 2*len(keys))
+	for chunk := range slices.Chunk(keys, modrinthBatchSize) {
+		ids, _ := json.Marshal(chunk)
+		var projects []modrinthProject
+		if err := m.apiRequest(ctx, modrinthAPI+"/projects?ids="+url.QueryEscape(string(ids)), &projects); err != nil {
+			return nil, err
+		}
+		for _, p := range projects {
+			out[p.ID], out[p.Slug] = p, p
+		}
+	}
+	return out, nil
+}
+
+// Info returns the Modrinth metadata of one mod, given by slug, project ID
+// or project URL, with the installed version when the lockfile has it.
+// Responses come from the API cache when fresh, and only from it offline.
+func (m *Mods) Info(ctx context.Context, mod string) (*domain.ModProject, error) {
+	slug, err := parseProjectID(mod)
+	if err != nil {
+		return nil, err
+	}
+	var p modrinthProject
+	if err := m.apiRequest(ctx, modrinthAPI+"/project/"+url.PathEscape(slug), &p); err != nil {
+		var apiErr *domain.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("no Modrinth project %q", slug)
+		}
+		return nil, err
+	}
+	info := modProject(slug, p)
+	if st, err := m.state.Load(); err == nil {
+		addInstalled(info, st.Mods)
+	}
+	return info, nil
+}
+
+// Report returns the Modrinth metadata of every configured mod source,
+// sorted by slug, looked up in bulk.
+func (m *Mods) Report(ctx context.Context) ([]domain.ModProject, error) {
+	projects, err := m.projects(ctx, m.cfg.Mods.ModrinthSources)
+	if err != nil {
+		return nil, err
+	}
+	st, err := m.state.Load()
+	if err != nil {
+		return nil, err
+	}
+	report := make([]domain.ModProject, 0, len(m.cfg.Mods.ModrinthSources))
+	for _, src := range m.cfg.Mods.ModrinthSources {
+		slug, err := parseProjectID(src)
+		if err != nil {
+			return nil, err
+		}
+		info := modProject(slug, projects[slug])
+		addInstalled(info, st.Mods)
+		report = append(report, *info)
+	}
+	slices.SortFunc(report, func(a, b domain.ModProject) int { return strings.Compare(a.Slug, b.Slug) })
+	return report, nil
+}
+
+// modProject converts p, looked up as slug; a zero p keeps only the slug.
+func modProject(slug string, p modrinthProject) *domain.ModProject {
+	info := &domain.ModProject{Slug: slug}
+	if p.ID == "" {
+		return info
+	}
+	info.Slug, info.ID, info.Title, info.Description = p.Slug, p.ID, p.Title, p.Description
+	info.License, info.LicenseURL = p.License.ID, p.License.URL
+	info.ClientSide, info.ServerSide = p.ClientSide, p.ServerSide
+	info.Status, info.Downloads = p.Status, p.Downloads
+	info.Links = map[string]string{"page": "https://modrinth.com/mod/" + p.Slug}
+	for name, link := range map[string]string{"source": p.SourceURL, "issues": p.IssuesURL, "wiki": p.WikiURL, "discord": p.DiscordURL} {
+		if link != "" {
+			info.Links[name] = link
+		}
+	}
+	return info
+}
+
+// addInstalled fills in the version and file the lockfile pins for info,
+// whichever of its slug or ID the source was configured as.
+func addInstalled(info *domain.ModProject, lock map[string]domain.LockedMod) {
+	for key, lm := range lock {
+		if key == info.Slug || (info.ID != "" && (key == info.ID || lm.Project == info.ID)) {
+			info.Version, info.Filename = lm.Version, lm.Filename
+			return
+		}
+	}
+}
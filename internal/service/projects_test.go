@@ -0,0 +1,101 @@
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+const (
+	sodiumProject = `{"id":"AANobbMI","slug":"sodium","title":"Sodium","status":"approved",
+		"client_side":"required","server_side":"unsupported","downloads":42,
+		"license":{"id":"LicenseRef-Polyform-Shield-1.0.0","url":"https://polyformproject.org"},
+		"source_url":"https://github.com/CaffeineMC/sodium","issues_url":""}`
+	lithiumProject = `{"id":"gvQqBUqZ","slug":"lithium","title":"Lithium","status":"approved",
+		"client_side":"optional","server_side":"required","license":{"id":"LGPL-3.0-only"}}`
+)
+
+func TestMods_Info(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/project/sodium" {
+			_, _ = w.Write([]byte(sodiumProject))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	cfg.Mods.MaxRetries = 0
+	err := service.NewStateStore(cfg).Update(func(st *domain.State) {
+		st.Mods["AANobbMI"] = domain.LockedMod{Project: "AANobbMI", Version: "0.6.0", Filename: "sodium-0.6.0.jar"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+
+	info, err := svc.Info(ctx, "https://modrinth.com/mod/sodium")
+	if err != nil {
+		t.Fatalf("Info: %v", err)
+	}
+	if info.License != "LicenseRef-Polyform-Shield-1.0.0" || info.ServerSide != "unsupported" || info.Downloads != 42 {
+		t.Errorf("Info = %+v", info)
+	}
+	if info.Version != "0.6.0" || info.Filename != "sodium-0.6.0.jar" {
+		t.Errorf("installed = %q %q, want the lockfile entry pinned by project ID", info.Version, info.Filename)
+	}
+	if info.Links["source"] != "https://github.com/CaffeineMC/sodium" || info.Links["page"] != "https://modrinth.com/mod/sodium" {
+		t.Errorf("Links = %v", info.Links)
+	}
+	if _, ok := info.Links["issues"]; ok {
+		t.Errorf("Links = %v, want empty links left out", info.Links)
+	}
+
+	if _, err := svc.Info(ctx, "missing"); err == nil || !strings.Contains(err.Error(), `no Modrinth project "missing"`) {
+		t.Errorf("Info(missing) error = %v", err)
+	}
+}
+
+func TestMods_Report(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	var bulk int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/projects" {
+			bulk++
+			_, _ = w.Write([]byte("[" + sodiumProject + "," + lithiumProject + "]"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.ModrinthSources = []string{"sodium", "https://modrinth.com/mod/lithium", "gone"}
+
+	report, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).Report(ctx)
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+	if bulk != 1 {
+		t.Errorf("bulk lookups = %d, want 1", bulk)
+	}
+	var slugs []string
+	for _, p := range report {
+		slugs = append(slugs, p.Slug)
+	}
+	if strings.Join(slugs, ",") != "gone,lithium,sodium" {
+		t.Fatalf("report slugs = %v, want sorted", slugs)
+	}
+	if notes := report[0].Notes(); len(notes) != 1 || notes[0] != "not found on Modrinth" {
+		t.Errorf("gone notes = %v", notes)
+	}
+	if notes := report[1].Notes(); len(notes) != 0 {
+		t.Errorf("lithium notes = %v, want none", notes)
+	}
+	if notes := report[2].Notes(); len(notes) != 2 {
+		t.Errorf("sodium notes = %v, want client-only and custom license", notes)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"bufio"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// serverProperties parses server.properties in dir. A missing or unreadable
+// file yields an empty map so callers fall back to vanilla defaults.
+func serverProperties(dir string) map[string]string {
+	props := map[string]string{}
+	f, err := os.Open(filepath.Join(dir, "server.properties")) //nolint:gosec // server dir from config
+	if err != nil {
+		return props
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		props[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return props
+}
+
+// levelName returns the world directory name from server.properties.
+func levelName(dir string) string {
+	if name := serverProperties(dir)["level-name"]; name != "" {
+		return name
+	}
+	return "world"
+}
+
+// setProperties rewrites keys in the server.properties file at path in
+// place, appending any that are missing.
+func setProperties(path string, values map[string]string) error {
+	data, err := os.ReadFile(path) //nolint:gosec // server dir from config
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	done := make(map[string]bool, len(values))
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	for i, line := range lines {
+		k, _, ok := strings.Cut(line, "=")
+		k = strings.TrimSpace(k)
+		if v, set := values[k]; ok && set && !strings.HasPrefix(k, "#") {
+			lines[i] = k + "=" + v
+			done[k] = true
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		if !done[k] {
+			lines = append(lines, k+"="+values[k])
+		}
+	}
+	return os.WriteFile(path, []byte(strings.TrimLeft(strings.Join(lines, "\n"), "\n")+"\n"), 0o640) //nolint:gosec
+}
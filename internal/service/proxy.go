@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// Proxy talks to the Velocity or BungeeCord proxy in front of this backend
+// over the proxy's RCON plugin. A nil Proxy, or one whose [proxy] section is
+// disabled, does nothing, so callers need not check.
+type Proxy struct {
+	cfg    *config.Config
+	logger *zap.Logger
+}
+
+// NewProxy creates a proxy client.
+func NewProxy(cfg *config.Config, logger *zap.Logger) *Proxy {
+	return &Proxy{cfg: cfg, logger: logger}
+}
+
+func (p *Proxy) enabled() bool { return p != nil && p.cfg.Proxy.Enabled }
+
+// Broadcast shows message to every player on the network.
+func (p *Proxy) Broadcast(ctx context.Context, message string) error {
+	if !p.enabled() {
+		return nil
+	}
+	return p.command(ctx, p.cfg.Proxy.Broadcast(message))
+}
+
+// Evacuate moves the players on this backend to the fallback server so a
+// restart does not disconnect them from the network. Without a fallback
+// the proxy's own try list decides where they go once the backend stops.
+func (p *Proxy) Evacuate(ctx context.Context) error {
+	if !p.enabled() || p.cfg.Proxy.Fallback == "" {
+		return nil
+	}
+	return p.command(ctx, p.cfg.Proxy.Send())
+}
+
+func (p *Proxy) command(ctx context.Context, cmd string) error {
+	if p.cfg.DryRun {
+		p.logger.Info("Dry run: Would send proxy command", zap.String("command", cmd))
+		return nil
+	}
+	c, err := dialRCONAddr(ctx, p.cfg.Proxy.Address, p.cfg.Proxy.Password)
+	if err != nil {
+		return fmt.Errorf("proxy: %w", err)
+	}
+	defer func() { _ = c.Close() }()
+	out, err := c.Command(cmd)
+	if err != nil {
+		return fmt.Errorf("proxy: %w", err)
+	}
+	p.logger.Info("Proxy command sent", zap.String("command", cmd), zap.String("output", out))
+	return nil
+}
+
+// HealthCheck verifies the proxy's RCON listener accepts the password.
+func (p *Proxy) HealthCheck(ctx context.Context) []domain.HealthCheck {
+	if !p.enabled() {
+		return nil
+	}
+	name := "Proxy (" + p.cfg.Proxy.Type + ")"
+	c, err := dialRCONAddr(ctx, p.cfg.Proxy.Address, p.cfg.Proxy.Password)
+	if err != nil {
+		return []domain.HealthCheck{{Name: name, Status: domain.StatusError, Message: err.Error()}}
+	}
+	_ = c.Close()
+	return []domain.HealthCheck{{Name: name, Status: domain.StatusOK, Message: "RCON reachable at " + p.cfg.Proxy.Address}}
+}
@@ -0,0 +1,46 @@
+package service_test
+
+import (
+	"net"
+	"strconv"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestProxy(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	port := fakeRCON(t, "pw", map[string]string{"send survival lobby": "Sent 2 players to lobby"})
+	cfg.Proxy.Address = net.JoinHostPort("127.0.0.1", strconv.Itoa(port))
+	cfg.Proxy.Password = "pw"
+	cfg.Proxy.ServerName = "survival"
+
+	p := service.NewProxy(cfg, logger)
+	if checks := p.HealthCheck(ctx); len(checks) != 0 {
+		t.Errorf("disabled proxy reported checks: %v", checks)
+	}
+	var nilProxy *service.Proxy
+	if err := nilProxy.Evacuate(ctx); err != nil {
+		t.Errorf("nil proxy Evacuate: %v", err)
+	}
+
+	cfg.Proxy.Enabled = true
+	if err := p.Broadcast(ctx, "Maintenance in 5 minutes"); err != nil {
+		t.Errorf("Broadcast: %v", err)
+	}
+	if err := p.Evacuate(ctx); err != nil {
+		t.Errorf("Evacuate: %v", err)
+	}
+	if checks := p.HealthCheck(ctx); len(checks) != 1 || checks[0].Status != domain.StatusOK {
+		t.Errorf("HealthCheck = %v, want one OK check", checks)
+	}
+
+	cfg.Proxy.Password = "wrong"
+	if err := p.Broadcast(ctx, "hello"); err == nil {
+		t.Error("expected auth error with the wrong password")
+	}
+	if checks := p.HealthCheck(ctx); len(checks) != 1 || checks[0].Status != domain.StatusError {
+		t.Errorf("HealthCheck = %v, want one ERROR check", checks)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// quarantineDir is where mods.strict moves undeclared jars.
+func (m *Mods) quarantineDir() string {
+	if m.cfg.Mods.QuarantineDir != "" {
+		return m.cfg.Mods.QuarantineDir
+	}
+	return filepath.Join(filepath.Dir(m.cfg.Paths.Mods), "mods.quarantine")
+}
+
+// quarantineUndeclared moves every jar out of the mods directory that
+// belongs to no configured source and returns their names. A jar belongs to
+// a source when it is the source's locked file or, by its SHA-1 in owners,
+// a file of the source's project. Without a readable lockfile nothing is
+// moved.
+func (m *Mods) quarantineUndeclared(projects map[string]modrinthProject, owners map[string]string) []string {
+	st, err := m.state.Load()
+	if err != nil {
+		m.logger.Warn("Lockfile unavailable, skipping quarantine", zap.Error(err))
+		return nil
+	}
+	declared := make(map[string]bool, len(m.cfg.Mods.ModrinthSources))
+	declaredIDs := make(map[string]bool, len(m.cfg.Mods.ModrinthSources))
+	for _, src := range m.cfg.Mods.ModrinthSources {
+		id, err := parseProjectID(src)
+		if err != nil {
+			continue
+		}
+		if lm, ok := st.Mods[id]; ok {
+			declared[lm.Filename] = true
+		}
+		if p, ok := projects[id]; ok && p.ID != "" {
+			declaredIDs[p.ID] = true
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
+	files = slices.DeleteFunc(files, func(file string) bool { return declared[filepath.Base(file)] })
+	var sums map[string]string
+	if len(owners) > 0 {
+		sums = hashFiles(files)
+	}
+	var moved []string
+	for _, file := range files {
+		name := filepath.Base(file)
+		if sum, ok := sums[file]; ok && declaredIDs[owners[sum]] {
+			continue
+		}
+		if m.cfg.DryRun {
+			m.logger.Info("Dry run: Would quarantine undeclared jar", zap.String("filename", name))
+			moved = append(moved, name)
+			continue
+		}
+		if err := os.MkdirAll(m.quarantineDir(), 0o750); err != nil {
+			m.logger.Warn("Failed to create quarantine directory", zap.Error(err))
+			return moved
+		}
+		if err := os.Rename(file, filepath.Join(m.quarantineDir(), name)); err != nil {
+			m.logger.Warn("Failed to quarantine jar", zap.String("filename", name), zap.Error(err))
+			continue
+		}
+		m.logger.Info("Quarantined undeclared jar", zap.String("filename", name), zap.String("dir", m.quarantineDir()))
+		moved = append(moved, name)
+	}
+	return moved
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Source RCON packet types as used by Minecraft.
+const (
+	rconAuth     = 3
+	rconExec     = 2
+	rconResponse = 0
+	rconMaxBody  = 4096
+	rconTimeout  = 5 * time.Second
+)
+
+// errRCONDisabled is returned when server.properties does not enable RCON.
+var errRCONDisabled = errors.New("RCON is not enabled in server.properties (enable-rcon, rcon.password)")
+
+// rconConn is an authenticated RCON session.
+type rconConn struct {
+	conn net.Conn
+	id   int32
+}
+
+// dialRCON connects to the RCON port configured in serverDir's
+// server.properties and authenticates with rcon.password.
+func dialRCON(ctx context.Context, serverDir string) (*rconConn, error) {
+	props := serverProperties(serverDir)
+	if props["enable-rcon"] != "true" || props["rcon.password"] == "" {
+		return nil, errRCONDisabled
+	}
+	port := props["rcon.port"]
+	if port == "" {
+		port = "25575"
+	}
+	return dialRCONAddr(ctx, net.JoinHostPort("127.0.0.1", port), props["rcon.password"])
+}
+
+// dialRCONAddr connects to an RCON listener at addr and authenticates.
+func dialRCONAddr(ctx context.Context, addr, password string) (*rconConn, error) {
+	d := net.Dialer{Timeout: rconTimeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("rcon connect: %w", err)
+	}
+	c := &rconConn{conn: conn}
+	id, _, err := c.roundTrip(rconAuth, password)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("rcon auth: %w", err)
+	}
+	if id == -1 {
+		_ = conn.Close()
+		return nil, errors.New("rcon auth: wrong password")
+	}
+	return c, nil
+}
+
+// Command runs a console command and returns its output.
+func (c *rconConn) Command(cmd string) (string, error) {
+	_, body, err := c.roundTrip(rconExec, cmd)
+	return body, err
+}
+
+// Close ends the session.
+func (c *rconConn) Close() error { return c.conn.Close() }
+
+func (c *rconConn) roundTrip(typ int32, body string) (int32, string, error) {
+	if len(body) > rconMaxBody {
+		return 0, "", fmt.Errorf("rcon command longer than %d bytes", rconMaxBody)
+	}
+	c.id++
+	_ = c.conn.SetDeadline(time.Now().Add(rconTimeout))
+
+	var buf bytes.Buffer
+	_ = binary.Write(&buf, binary.LittleEndian, int32(10+len(body)))
+	_ = binary.Write(&buf, binary.LittleEndian, c.id)
+	_ = binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return 0, "", err
+	}
+
+	for {
+		id, respType, resp, err := readRCONPacket(c.conn)
+		if err != nil {
+			return 0, "", err
+		}
+		// Auth is preceded by an empty response-value packet on some servers.
+		if typ == rconAuth && respType == rconResponse {
+			continue
+		}
+		return id, resp, nil
+	}
+}
+
+func readRCONPacket(r io.Reader) (id, typ int32, body string, err error) {
+	var size int32
+	if err = binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+	if size < 10 || size > rconMaxBody+10 {
+		return 0, 0, "", fmt.Errorf("rcon: invalid packet size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err = io.ReadFull(r, data); err != nil {
+		return 0, 0, "", err
+	}
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))  //nolint:gosec // wire format is signed
+	typ = int32(binary.LittleEndian.Uint32(data[4:8])) //nolint:gosec // wire format is signed
+	return id, typ, string(bytes.TrimRight(data[8:], "\x00")), nil
+}
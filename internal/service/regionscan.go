@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// Chunk compression types in a region file. The external flag marks a
+// chunk too large for the region, stored in c.<x>.<z>.mcc beside it.
+const (
+	chunkGzip     = 1
+	chunkZlib     = 2
+	chunkNone     = 3
+	chunkLZ4      = 4
+	chunkCustom   = 127
+	chunkExternal = 128
+
+	regionSector = 4096
+	nbtCompound  = 10
+)
+
+// ScanRegions checks the header and chunk framing of every region file
+// (region, entities and poi) of dimension, or of all dimensions when it is
+// empty. With parseNBT each chunk is also decompressed and must hold an NBT
+// compound. It only reads files, so the server may be running, though
+// chunks it is writing at that moment may be reported.
+func (s *Server) ScanRegions(ctx context.Context, dim string, parseNBT bool) (*domain.RegionScan, error) {
+	level := levelName(s.cfg.Paths.Server)
+	dims := worldDimensions(s.cfg.Paths.Server, level)
+	if dim != "" {
+		dirs, err := dimensionDirs(level, dim)
+		if err != nil {
+			return nil, err
+		}
+		dims = nil
+		for _, dir := range dirs {
+			if _, err := os.Stat(filepath.Join(s.cfg.Paths.Server, filepath.FromSlash(dir))); err == nil {
+				dims = append(dims, dimension{name: dim, dir: dir})
+				break
+			}
+		}
+		if len(dims) == 0 {
+			return nil, fmt.Errorf("dimension %s not found in %s", dim, s.cfg.Paths.Server)
+		}
+	}
+
+	res := &domain.RegionScan{Issues: []domain.ChunkIssue{}}
+	for _, d := range dims {
+		for _, kind := range regionKinds {
+			files, _ := filepath.Glob(filepath.Join(s.cfg.Paths.Server, filepath.FromSlash(d.dir), kind, "*.mca"))
+			for _, f := range files {
+				if err := ctx.Err(); err != nil {
+					return res, err
+				}
+				rel := path.Join(d.dir, kind, filepath.Base(f))
+				chunks, issues := scanRegionFile(f, rel, parseNBT)
+				res.Files++
+				res.Chunks += chunks
+				res.Issues = append(res.Issues, issues...)
+			}
+		}
+	}
+	s.logger.Info("Region scan finished", zap.Int("files", res.Files), zap.Int("chunks", res.Chunks),
+		zap.Int("issues", len(res.Issues)))
+	return res, nil
+}
+
+// scanRegionFile returns the number of chunks in the region file at path
+// and the problems found in it, reported under rel.
+func scanRegionFile(path, rel string, parseNBT bool) (int, []domain.ChunkIssue) {
+	fileIssue := func(problem string) []domain.ChunkIssue {
+		return []domain.ChunkIssue{{File: rel, Problem: problem}}
+	}
+	var rx, rz int
+	if _, err := fmt.Sscanf(filepath.Base(path), "r.%d.%d.mca", &rx, &rz); err != nil {
+		return 0, fileIssue("not named r.<x>.<z>.mca")
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // world folder from config
+	if err != nil {
+		return 0, fileIssue(err.Error())
+	}
+	if len(data) == 0 {
+		return 0, nil // allocated but never written
+	}
+	if len(data) < 2*regionSector {
+		return 0, fileIssue(fmt.Sprintf("header truncated at %d bytes", len(data)))
+	}
+
+	owner := make(map[int]int) // sector → index of the chunk using it
+	chunks := 0
+	var issues []domain.ChunkIssue
+	for i := range regionSector / 4 {
+		loc := binary.BigEndian.Uint32(data[i*4:])
+		if loc == 0 {
+			continue
+		}
+		chunks++
+		x, z := rx*32+i%32, rz*32+i/32
+		problem := checkChunk(data, i, int(loc>>8), int(loc&0xFF), owner, filepath.Dir(path), x, z, parseNBT)
+		if problem != "" {
+			issues = append(issues, domain.ChunkIssue{File: rel, Chunk: true, X: x, Z: z, Problem: problem})
+		}
+	}
+	return chunks, issues
+}
+
+// checkChunk validates chunk i of a region file stored at offset for count
+// sectors and returns what is wrong with it, or "".
+func checkChunk(data []byte, i, offset, count int, owner map[int]int, dir string, x, z int, parseNBT bool) string {
+	switch {
+	case offset < 2:
+		return "location points into the header"
+	case count == 0:
+		return "location has no sectors"
+	case (offset+count)*regionSector > len(data):
+		return "data extends past the end of the file"
+	}
+	for sec := offset; sec < offset+count; sec++ {
+		if other, ok := owner[sec]; ok {
+			return fmt.Sprintf("shares sectors with chunk %d,%d", x-i%32+other%32, z-i/32+other/32)
+		}
+		owner[sec] = i
+	}
+
+	chunk := data[offset*regionSector:]
+	length := int(binary.BigEndian.Uint32(chunk))
+	if length < 1 || length+4 > count*regionSector {
+		return fmt.Sprintf("invalid length %d for %d sector(s)", length, count)
+	}
+	kind := chunk[4]
+	if kind&chunkExternal != 0 {
+		if _, err := os.Stat(filepath.Join(dir, fmt.Sprintf("c.%d.%d.mcc", x, z))); err != nil {
+			return "external chunk file missing"
+		}
+		return ""
+	}
+	switch kind {
+	case chunkGzip, chunkZlib, chunkNone, chunkLZ4, chunkCustom:
+	default:
+		return fmt.Sprintf("unknown compression type %d", kind)
+	}
+	if parseNBT {
+		return checkNBT(kind, chunk[5:4+length])
+	}
+	return ""
+}
+
+// checkNBT decompresses a chunk payload and checks that it is a complete
+// stream starting with an NBT compound. LZ4 and custom compression are not
+// checked.
+func checkNBT(kind byte, payload []byte) string {
+	var r io.Reader
+	switch kind {
+	case chunkGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return "gzip: " + err.Error()
+		}
+		r = zr
+	case chunkZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return "zlib: " + err.Error()
+		}
+		r = zr
+	case chunkNone:
+		r = bytes.NewReader(payload)
+	default:
+		return ""
+	}
+	var tag [1]byte
+	if _, err := io.ReadFull(r, tag[:]); err != nil {
+		return "empty chunk data"
+	}
+	if tag[0] != nbtCompound {
+		return fmt.Sprintf("NBT root is tag type %d, not a compound", tag[0])
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return "corrupt compressed data: " + err.Error()
+	}
+	return ""
+}
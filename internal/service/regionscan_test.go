@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+// chunkRecord returns a zlib chunk record (length, type, data) whose NBT
+// root has the given tag type; 10 is a valid empty compound.
+func chunkRecord(t *testing.T, tag byte) []byte {
+	t.Helper()
+	var nbt bytes.Buffer
+	zw := zlib.NewWriter(&nbt)
+	_, _ = zw.Write([]byte{tag, 0, 0, 0})
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rec := binary.BigEndian.AppendUint32(nil, uint32(nbt.Len()+1))
+	rec = append(rec, 2)
+	return append(rec, nbt.Bytes()...)
+}
+
+// buildRegion lays out records one sector each after the header, at the
+// header slots given by their map keys.
+func buildRegion(records map[int][]byte) []byte {
+	data := make([]byte, 8192)
+	sector := 2
+	for i := range 1024 {
+		rec, ok := records[i]
+		if !ok {
+			continue
+		}
+		binary.BigEndian.PutUint32(data[i*4:], uint32(sector<<8|1))
+		padded := make([]byte, 4096)
+		copy(padded, rec)
+		data = append(data, padded...)
+		sector++
+	}
+	return data
+}
+
+func TestServer_ScanRegions(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Server, "server.properties", "level-name=world\n")
+
+	good := chunkRecord(t, 10)
+	writeFile(t, cfg.Paths.Server, "world/region/r.0.0.mca", string(buildRegion(map[int][]byte{0: good, 33: good})))
+
+	badType := append([]byte(nil), good...)
+	badType[4] = 9
+	damaged := buildRegion(map[int][]byte{
+		1:  good,
+		2:  badType,
+		3:  chunkRecord(t, 8), // root is a string tag
+		32: {0, 0, 0, 0, 2},   // zero length
+	})
+	binary.BigEndian.PutUint32(damaged[4*4:], 2<<8|1) // slot 4 reuses slot 1's sector
+	writeFile(t, cfg.Paths.Server, "world/region/r.-1.2.mca", string(damaged))
+	writeFile(t, cfg.Paths.Server, "world/entities/r.0.0.mca", "short")
+	writeFile(t, cfg.Paths.Server, "world/DIM-1/region/r.0.0.mca", string(buildRegion(map[int][]byte{0: badType})))
+
+	srv := service.NewServer(cfg, logger)
+	scan, err := srv.ScanRegions(ctx, "overworld", false)
+	if err != nil {
+		t.Fatalf("ScanRegions: %v", err)
+	}
+	if scan.Files != 3 || scan.Chunks != 7 {
+		t.Errorf("files=%d chunks=%d, want 3 and 7", scan.Files, scan.Chunks)
+	}
+	got := map[string]bool{}
+	for _, i := range scan.Issues {
+		if i.Chunk {
+			got[fmt.Sprintf("%s@%d,%d", i.File, i.X, i.Z)] = true
+		} else {
+			got[i.File] = true
+		}
+	}
+	// Region -1,2 starts at chunk -32,64.
+	for _, want := range []string{
+		"world/region/r.-1.2.mca@-30,64", // unknown compression
+		"world/region/r.-1.2.mca@-28,64", // overlapping sectors
+		"world/region/r.-1.2.mca@-32,65", // zero length
+		"world/entities/r.0.0.mca",       // truncated header
+	} {
+		if !got[want] {
+			t.Errorf("missing issue %s in %+v", want, scan.Issues)
+		}
+	}
+	if len(scan.Issues) != 4 {
+		t.Errorf("issues = %+v, want 4", scan.Issues)
+	}
+
+	scan, err = srv.ScanRegions(ctx, "overworld", true)
+	if err != nil {
+		t.Fatalf("ScanRegions with NBT: %v", err)
+	}
+	if len(scan.Issues) != 5 {
+		t.Errorf("NBT scan issues = %+v, want the string root reported too", scan.Issues)
+	}
+
+	scan, err = srv.ScanRegions(ctx, "", false)
+	if err != nil {
+		t.Fatalf("ScanRegions all: %v", err)
+	}
+	if scan.Files != 4 || len(scan.Issues) != 5 {
+		t.Errorf("all dimensions: files=%d issues=%+v", scan.Files, scan.Issues)
+	}
+	if _, err := srv.ScanRegions(ctx, "end", false); err == nil {
+		t.Error("expected an error for a dimension that does not exist")
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // see contentHash
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+)
+
+// RemoteEnv is set on commands craftops runs over SSH so the remote side
+// executes them instead of forwarding again.
+const RemoteEnv = "CRAFTOPS_REMOTE"
+
+// remoteDir holds uploaded binaries and configs, relative to the remote home.
+const remoteDir = ".cache/craftops"
+
+// Remote runs craftops on the config's host through the system ssh client,
+// so ~/.ssh/config, agents and jump hosts apply. Unless remote_binary is
+// set, this binary is uploaded once per build under its hash; the config
+// file is uploaded on every run so edits take effect immediately, and
+// edits the remote command makes to it are brought back.
+type Remote struct {
+	cfg    *config.Config
+	logger *zap.Logger
+}
+
+// NewRemote creates an SSH runner for cfg.Host.
+func NewRemote(cfg *config.Config, logger *zap.Logger) *Remote {
+	return &Remote{cfg: cfg, logger: logger}
+}
+
+// RemoteIO connects a remote command to local streams. TTY allocates a
+// terminal so prompts and colors work; use it only when Stdin is one.
+type RemoteIO struct {
+	Stdin          io.Reader
+	Stdout, Stderr io.Writer
+	TTY            bool
+}
+
+// Run runs craftops with args on the host and returns its exit status.
+// The error reports failures to reach the host or prepare it.
+func (r *Remote) Run(ctx context.Context, args []string, stdio RemoteIO) (int, error) {
+	if r.cfg.Path == "" {
+		return 0, errors.New("remote execution needs a config file to upload")
+	}
+	data, err := os.ReadFile(r.cfg.Path)
+	if err != nil {
+		return 0, err
+	}
+	cfgPath := remoteDir + "/config-" + contentHash(data) + ".toml"
+
+	bin := r.cfg.RemoteBinary
+	var exe string
+	if bin == "" {
+		if exe, err = os.Executable(); err != nil {
+			return 0, err
+		}
+		sum, err := fileSHA1(exe)
+		if err != nil {
+			return 0, err
+		}
+		bin = remoteDir + "/craftops-" + sum[:12]
+	}
+
+	// One round trip stores the config and reports whether the binary is
+	// there, or else the platform an upload has to match.
+	var out bytes.Buffer
+	prepare := fmt.Sprintf("umask 077 && mkdir -p %s && cat > %s && { test -x %s && echo present || uname -sm; }",
+		remoteDir, shellQuote(cfgPath), shellQuote(bin))
+	if err := r.ssh(ctx, prepare, RemoteIO{Stdin: bytes.NewReader(data), Stdout: &out}); err != nil {
+		return 0, fmt.Errorf("prepare %s: %w", r.cfg.Host, err)
+	}
+	if reply := strings.TrimSpace(out.String()); reply != "present" {
+		if exe == "" {
+			return 0, fmt.Errorf("remote_binary %s is not executable on %s", bin, r.cfg.Host)
+		}
+		if err := r.upload(ctx, exe, bin, reply); err != nil {
+			return 0, err
+		}
+	}
+
+	command := RemoteEnv + "=1 " + shellQuote(bin) + " --config " + shellQuote(cfgPath)
+	for _, a := range args {
+		command += " " + shellQuote(a)
+	}
+	r.logger.Debug("Running on remote host", zap.String("host", r.cfg.Host), zap.Strings("args", args))
+	err = r.ssh(ctx, command, stdio)
+	var ee *exec.ExitError
+	code := 0
+	switch {
+	case err == nil:
+	case errors.As(err, &ee) && ee.ExitCode() != 255: // 255 is ssh's own failure
+		code = ee.ExitCode()
+	default:
+		return 0, fmt.Errorf("ssh %s: %w", r.cfg.Host, err)
+	}
+	return code, r.fetchConfig(ctx, cfgPath, data)
+}
+
+// fetchConfig brings back edits the remote command made to the uploaded
+// config at cfgPath (migrate, doctor --fix, sync, added mod sources) into
+// the local file. A local file that no longer holds what was uploaded is
+// left alone and reported.
+func (r *Remote) fetchConfig(ctx context.Context, cfgPath string, uploaded []byte) error {
+	var out bytes.Buffer
+	if err := r.ssh(ctx, "cat "+shellQuote(cfgPath), RemoteIO{Stdout: &out}); err != nil {
+		return fmt.Errorf("read back config from %s: %w", r.cfg.Host, err)
+	}
+	if bytes.Equal(out.Bytes(), uploaded) {
+		return nil
+	}
+	current, err := os.ReadFile(r.cfg.Path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, uploaded) {
+		return fmt.Errorf("%s changed while craftops ran on %s; its edits to the config are in %s there",
+			r.cfg.Path, r.cfg.Host, cfgPath)
+	}
+	r.logger.Info("Saving config edited on remote host", zap.String("host", r.cfg.Host), zap.String("path", r.cfg.Path))
+	return config.WriteFile(r.cfg.Path, out.Bytes())
+}
+
+// Upload copies the local file at path to the host for a command that
+// reads it there, and returns its path on the host.
+func (r *Remote) Upload(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user-supplied input file
+	if err != nil {
+		return "", err
+	}
+	dest := remoteDir + "/upload-" + contentHash(data) + "-" + filepath.Base(path)
+	script := fmt.Sprintf("umask 077 && mkdir -p %s && cat > %s", remoteDir, shellQuote(dest))
+	if err := r.ssh(ctx, script, RemoteIO{Stdin: bytes.NewReader(data)}); err != nil {
+		return "", fmt.Errorf("upload %s to %s: %w", path, r.cfg.Host, err)
+	}
+	return dest, nil
+}
+
+// upload copies exe to bin on the host after checking that platform, the
+// host's `uname -sm`, can run it.
+func (r *Remote) upload(ctx context.Context, exe, bin, platform string) error {
+	if want := unamePlatform(); platform != want {
+		return fmt.Errorf("%s is %q but this craftops is built for %q; install craftops there and set remote_binary",
+			r.cfg.Host, platform, want)
+	}
+	f, err := os.Open(exe) //nolint:gosec // our own executable
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	r.logger.Info("Uploading craftops", zap.String("host", r.cfg.Host), zap.String("path", bin))
+	tmp := shellQuote(bin + ".tmp")
+	script := fmt.Sprintf("umask 077 && cat > %s && chmod 700 %s && mv %s %s", tmp, tmp, tmp, shellQuote(bin))
+	if err := r.ssh(ctx, script, RemoteIO{Stdin: f}); err != nil {
+		return fmt.Errorf("upload craftops to %s: %w", r.cfg.Host, err)
+	}
+	return nil
+}
+
+func (r *Remote) ssh(ctx context.Context, command string, stdio RemoteIO) error {
+	var args []string
+	if stdio.TTY {
+		args = append(args, "-t")
+	}
+	// "--" keeps a host starting with "-" from being read as an option.
+	args = append(args, "--", r.cfg.Host, command)
+	cmd := exec.CommandContext(ctx, "ssh", args...) //nolint:gosec // host from user config
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = stdio.Stdin, stdio.Stdout, stdio.Stderr
+	return cmd.Run()
+}
+
+// unamePlatform is what `uname -sm` prints on hosts this binary runs on.
+func unamePlatform() string {
+	machine := map[string]string{"amd64": "x86_64", "arm64": "aarch64", "386": "i686", "arm": "armv7l"}[runtime.GOARCH]
+	if machine == "" {
+		machine = runtime.GOARCH
+	}
+	if runtime.GOOS == "darwin" && runtime.GOARCH == "arm64" {
+		machine = "arm64"
+	}
+	kernel := strings.ToUpper(runtime.GOOS[:1]) + runtime.GOOS[1:]
+	return kernel + " " + machine
+}
+
+func contentHash(data []byte) string {
+	sum := sha1.Sum(data) //nolint:gosec // names the upload, not a security check
+	return hex.EncodeToString(sum[:6])
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,103 @@
+package service_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+// fakeSSH puts an ssh on PATH that runs the remote command with sh in home.
+// It fails like ssh unless the host follows "--".
+func fakeSSH(t *testing.T) (home string) {
+	t.Helper()
+	dir, home := t.TempDir(), t.TempDir()
+	writeFile(t, dir, "ssh", "#!/bin/sh\n[ \"$1\" = -t ] && shift\n[ \"$1\" = -- ] || exit 255\nshift 2\ncd "+home+" && exec sh -c \"$1\"\n")
+	if err := os.Chmod(filepath.Join(dir, "ssh"), 0o700); err != nil { //nolint:gosec // test script
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return home
+}
+
+func TestRemote_Run(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	home := fakeSSH(t)
+	cfg.Host = "mc@mc1"
+	cfg.Path = writeFile(t, t.TempDir(), "config.toml", "host = \"mc@mc1\"\n")
+	// An installed craftops that echoes how it was called.
+	cfg.RemoteBinary = writeFile(t, home, "bin/craftops",
+		"#!/bin/sh\necho \"$CRAFTOPS_REMOTE $*\"\ncat \"$2\" >&2\nexit 4\n")
+	if err := os.Chmod(cfg.RemoteBinary, 0o700); err != nil { //nolint:gosec // test script
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code, err := service.NewRemote(cfg, logger).Run(ctx, []string{"mods", "import", "it's here.json"},
+		service.RemoteIO{Stdout: &stdout, Stderr: &stderr})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if code != 4 {
+		t.Errorf("exit status = %d, want 4", code)
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "1 --config .cache/craftops/config-") || !strings.HasSuffix(out, "mods import it's here.json\n") {
+		t.Errorf("remote invocation = %q", out)
+	}
+	if stderr.String() != "host = \"mc@mc1\"\n" {
+		t.Errorf("uploaded config = %q", stderr.String())
+	}
+
+	cfg.RemoteBinary = "missing/craftops"
+	if _, err := service.NewRemote(cfg, logger).Run(ctx, nil, service.RemoteIO{}); err == nil {
+		t.Error("expected error for a remote_binary that is not installed")
+	}
+}
+
+func TestRemote_RunBringsBackConfigEdits(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	home := fakeSSH(t)
+	cfg.Host = "mc@mc1"
+	cfg.Path = writeFile(t, t.TempDir(), "config.toml", "host = \"mc@mc1\"\n")
+	// A craftops that adds a mod source to its config, like `mods search`.
+	cfg.RemoteBinary = writeFile(t, home, "bin/craftops", "#!/bin/sh\necho '[mods]' >> \"$2\"\n")
+	if err := os.Chmod(cfg.RemoteBinary, 0o700); err != nil { //nolint:gosec // test script
+		t.Fatal(err)
+	}
+	if _, err := service.NewRemote(cfg, logger).Run(ctx, nil, service.RemoteIO{}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if data, _ := os.ReadFile(cfg.Path); string(data) != "host = \"mc@mc1\"\n[mods]\n" {
+		t.Errorf("local config = %q, want the remote edit", data)
+	}
+
+	// The local file changing during the run keeps it as it is.
+	writeFile(t, home, "bin/craftops", "#!/bin/sh\necho '[backup]' >> \"$2\"\necho '# local' >> '"+cfg.Path+"'\n")
+	if _, err := service.NewRemote(cfg, logger).Run(ctx, nil, service.RemoteIO{}); err == nil {
+		t.Error("expected error when the local config changed during the run")
+	}
+	if data, _ := os.ReadFile(cfg.Path); strings.Contains(string(data), "[backup]") {
+		t.Errorf("remote edit overwrote a local change:\n%s", data)
+	}
+}
+
+func TestRemote_Upload(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	home := fakeSSH(t)
+	cfg.Host = "mc@mc1"
+	src := writeFile(t, t.TempDir(), "modlist.json", `{"mods":[]}`)
+	dest, err := service.NewRemote(cfg, logger).Upload(ctx, src)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if !strings.HasPrefix(dest, ".cache/craftops/upload-") || !strings.HasSuffix(dest, "-modlist.json") {
+		t.Errorf("remote path = %q", dest)
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, dest)); string(data) != `{"mods":[]}` {
+		t.Errorf("uploaded file = %q", data)
+	}
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+const (
+	reportsDir = "reports"
+	maxReports = 100
+)
+
+// ReportStore keeps one JSON report per operation run under
+// Paths.State/reports, pruning all but the most recent. A nil *ReportStore
+// is valid: writes are no-ops and there is no last report.
+type ReportStore struct {
+	dir    string
+	dryRun bool
+}
+
+// NewReportStore returns the store for cfg.Paths.State, or nil if unset.
+func NewReportStore(cfg *config.Config) *ReportStore {
+	if cfg.Paths.State == "" {
+		return nil
+	}
+	return &ReportStore{dir: filepath.Join(cfg.Paths.State, reportsDir), dryRun: cfg.DryRun}
+}
+
+// Write saves r as <operation>-<started>.json and returns its path.
+func (s *ReportStore) Write(r *domain.Report) (string, error) {
+	if s == nil || s.dryRun {
+		return "", nil
+	}
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create reports directory: %w", err)
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%s-%s.json", r.Operation, r.StartedAt.UTC().Format("20060102T150405.000Z")))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", fmt.Errorf("failed to write report: %w", err)
+	}
+	if files := s.files(""); len(files) > maxReports {
+		for _, f := range files[maxReports:] {
+			_ = os.Remove(f)
+		}
+	}
+	return path, nil
+}
+
+// Last returns the newest report, restricted to operation unless empty.
+func (s *ReportStore) Last(operation string) (*domain.Report, string, error) {
+	var files []string
+	if s != nil {
+		files = s.files(operation)
+	}
+	if len(files) == 0 {
+		return nil, "", errors.New("no reports recorded yet")
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read report: %w", err)
+	}
+	var r domain.Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, "", fmt.Errorf("failed to parse report %s: %w", files[0], err)
+	}
+	return &r, files[0], nil
+}
+
+// files lists report paths newest first. Names sort by their UTC timestamp.
+func (s *ReportStore) files(operation string) []string {
+	pattern := "*.json"
+	if operation != "" {
+		pattern = operation + "-*.json"
+	}
+	files, _ := filepath.Glob(filepath.Join(s.dir, pattern))
+	stamp := func(p string) string { return p[strings.LastIndex(p, "-")+1:] }
+	slices.SortFunc(files, func(a, b string) int { return strings.Compare(stamp(b), stamp(a)) })
+	return files
+}
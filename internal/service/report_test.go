@@ -0,0 +1,37 @@
+package service_test
+
+import (
+	"testing"
+	"time"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestReportStore_Last(t *testing.T) {
+	cfg, _, _ := setup(t)
+	store := service.NewReportStore(cfg)
+	if _, _, err := store.Last(""); err == nil {
+		t.Error("expected error when no reports exist")
+	}
+
+	start := time.Date(2024, 6, 1, 3, 0, 0, 0, time.UTC)
+	for i, op := range []string{domain.OpBackup, domain.OpModUpdate, domain.OpBackup} {
+		r := &domain.Report{Operation: op, StartedAt: start.Add(time.Duration(i) * time.Minute)}
+		if op == domain.OpModUpdate {
+			r.Mods = &domain.ModUpdateResult{Changes: []domain.ModChange{{Name: "Sodium", From: "0.5.8", To: "0.5.11"}}}
+		}
+		if _, err := store.Write(r); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	last, _, err := store.Last("")
+	if err != nil || last.Operation != domain.OpBackup || !last.StartedAt.Equal(start.Add(2*time.Minute)) {
+		t.Errorf("Last() = %+v, %v", last, err)
+	}
+	mods, _, err := store.Last(domain.OpModUpdate)
+	if err != nil || mods.Mods == nil || mods.Mods.Changes[0].From != "0.5.8" {
+		t.Errorf("Last(mod_update) = %+v, %v", mods, err)
+	}
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// pendingPollInterval is how often a warned restart checks for cancellation.
+const pendingPollInterval = time.Second
+
+// BeginPendingRestart records a restart due at `at` and returns a context
+// that is cancelled with domain.ErrRestartCancelled once another process
+// calls CancelPendingRestart. done clears the record.
+func (s *Server) BeginPendingRestart(ctx context.Context, at time.Time) (context.Context, func()) {
+	pid := os.Getpid()
+	if err := s.state.Update(func(st *domain.State) {
+		st.PendingRestart = &domain.PendingRestart{At: at, PID: pid}
+	}); err != nil {
+		s.logger.Warn("Failed to record pending restart; it cannot be cancelled", zap.Error(err))
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(pendingPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				st, err := s.state.Load()
+				if err == nil && (st.PendingRestart == nil || st.PendingRestart.PID != pid) {
+					cancel(domain.ErrRestartCancelled)
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, func() {
+		close(stop)
+		cancel(nil)
+		_ = s.state.Update(func(st *domain.State) {
+			if st.PendingRestart != nil && st.PendingRestart.PID == pid {
+				st.PendingRestart = nil
+			}
+		})
+	}
+}
+
+// CancelPendingRestart aborts a warned restart waiting in another process
+// and tells players it was called off.
+func (s *Server) CancelPendingRestart(ctx context.Context) (*domain.PendingRestart, error) {
+	var pending *domain.PendingRestart
+	err := s.state.Update(func(st *domain.State) {
+		pending, st.PendingRestart = st.PendingRestart, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, errors.New("no pending restart")
+	}
+	if s.cfg.Notifications.InGameWarnings {
+		if err := s.SendConsole(ctx, "say Scheduled restart cancelled"); err != nil {
+			s.logger.Debug("In-game cancel notice not sent", zap.Error(err))
+		}
+	}
+	return pending, nil
+}
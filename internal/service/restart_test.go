@@ -0,0 +1,105 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"craftops/internal/domain"
+	"craftops/internal/i18n"
+	"craftops/internal/service"
+)
+
+type fakeConsole struct {
+	mu   sync.Mutex
+	cmds []string
+}
+
+func (c *fakeConsole) SendConsole(_ context.Context, command string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cmds = append(c.cmds, command)
+	return nil
+}
+
+func TestNotification_InGameCountdown(t *testing.T) {
+	cfg, logger, _ := setup(t)
+	cfg.Notifications.WarningIntervals = []int{1}
+	console := &fakeConsole{}
+	svc := service.NewNotification(cfg, logger)
+	svc.UseConsole(console)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	if err := svc.SendRestartWarnings(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("countdown should run until the restart, got %v", err)
+	}
+	console.mu.Lock()
+	defer console.mu.Unlock()
+	if len(console.cmds) < 3 || !strings.HasPrefix(console.cmds[0], "say Server will restart in 1 minute") ||
+		!strings.Contains(console.cmds[2], "actionbar") {
+		t.Errorf("unexpected console commands: %q", console.cmds)
+	}
+}
+
+func TestNotification_LocalizedWarnings(t *testing.T) {
+	cfg, logger, _ := setup(t)
+	cfg.Notifications.WarningIntervals = []int{1}
+	console := &fakeConsole{}
+	svc := service.NewNotification(cfg, logger)
+	svc.UseConsole(console)
+	messages, err := i18n.Load("de", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc.UseMessages(messages)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_ = svc.SendRestartWarnings(ctx)
+	console.mu.Lock()
+	if len(console.cmds) < 2 || console.cmds[0] != "say Der Server startet in 1 Minute(n) für Mod-Updates neu" ||
+		!strings.Contains(console.cmds[1], "Neustart in 1m0s") {
+		t.Errorf("unexpected console commands: %q", console.cmds)
+	}
+	console.cmds = nil
+	console.mu.Unlock()
+
+	cfg.Notifications.RestartWarning = "Restart in {minutes}m"
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_ = svc.SendRestartWarnings(ctx)
+	console.mu.Lock()
+	defer console.mu.Unlock()
+	if len(console.cmds) == 0 || console.cmds[0] != "say Restart in 1m" {
+		t.Errorf("restart_warning not used: %q", console.cmds)
+	}
+}
+
+func TestServer_CancelPendingRestart(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Notifications.InGameWarnings = false
+	svc := service.NewServer(cfg, logger)
+
+	if _, err := svc.CancelPendingRestart(ctx); err == nil {
+		t.Error("expected error with nothing pending")
+	}
+
+	warnCtx, done := svc.BeginPendingRestart(ctx, time.Now().Add(time.Minute))
+	defer done()
+	pending, err := svc.CancelPendingRestart(ctx)
+	if err != nil || pending == nil {
+		t.Fatalf("CancelPendingRestart = %v, %v", pending, err)
+	}
+	select {
+	case <-warnCtx.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("pending restart context was not cancelled")
+	}
+	if !errors.Is(context.Cause(warnCtx), domain.ErrRestartCancelled) {
+		t.Errorf("cause = %v, want ErrRestartCancelled", context.Cause(warnCtx))
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"craftops/internal/domain"
+)
+
+// maxBackoff caps both computed backoff and server-provided Retry-After hints.
+const maxBackoff = 5 * time.Minute
+
+// withRetry runs op up to maxRetries+1 times with exponential backoff and jitter.
+// Non-retryable API errors and a full disk abort immediately; a RetryAfter
+// hint on an APIError replaces the computed delay.
+func withRetry(ctx context.Context, maxRetries int, baseDelay time.Duration, op func() error) error {
+	var apiErr *domain.APIError
+	var err error
+	for attempt := range maxRetries + 1 {
+		if err = op(); err == nil {
+			return nil
+		}
+		isAPIErr := errors.As(err, &apiErr)
+		if isAPIErr && !apiErr.IsRetryable() || errors.Is(err, domain.ErrInsufficientSpace) {
+			return err
+		}
+		if attempt == maxRetries {
+			break
+		}
+		delay := backoff(baseDelay, attempt)
+		if isAPIErr && apiErr.RetryAfter > 0 {
+			delay = min(apiErr.RetryAfter, maxBackoff)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		requestStats.retries.Add(1)
+	}
+	return err
+}
+
+// backoff returns base*2^attempt with equal jitter (between half and the full value).
+func backoff(base time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	d := base << min(attempt, 16)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	half := d / 2
+	return half + rand.N(half+1) //nolint:gosec // jitter does not need a CSPRNG
+}
+
+// parseRetryAfter reads Retry-After (seconds or HTTP date) or, failing that,
+// Modrinth's X-Ratelimit-Reset (seconds until the window resets).
+func parseRetryAfter(h http.Header) time.Duration {
+	for _, key := range []string{"Retry-After", "X-Ratelimit-Reset"} {
+		v := h.Get(key)
+		if v == "" {
+			continue
+		}
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(time.Until(t), 0)
+		}
+	}
+	return 0
+}
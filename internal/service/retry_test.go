@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"craftops/internal/service"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"none", http.Header{}, 0},
+		{"seconds", http.Header{"Retry-After": {"3"}}, 3 * time.Second},
+		{"fractional", http.Header{"Retry-After": {"0.5"}}, 500 * time.Millisecond},
+		{"modrinth reset", http.Header{"X-Ratelimit-Reset": {"7"}}, 7 * time.Second},
+		{"retry-after wins", http.Header{"Retry-After": {"1"}, "X-Ratelimit-Reset": {"9"}}, time.Second},
+		{"garbage", http.Header{"Retry-After": {"soon"}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.ParseRetryAfter(tt.header); got != tt.want {
+				t.Errorf("ParseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff_GrowsWithinBounds(t *testing.T) {
+	base := 100 * time.Millisecond
+	for attempt := range 5 {
+		full := base << attempt
+		got := service.Backoff(base, attempt)
+		if got < full/2 || got > full {
+			t.Errorf("Backoff(attempt=%d) = %v, want within [%v, %v]", attempt, got, full/2, full)
+		}
+	}
+	if got := service.Backoff(0, 3); got != 0 {
+		t.Errorf("Backoff with zero base = %v, want 0", got)
+	}
+}
+
+func TestMods_RetriesAfter429(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files/mod.jar":
+			_, _ = w.Write([]byte("JAR"))
+		case "/v2/projects":
+			_, _ = w.Write([]byte("[]"))
+		default:
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(modrinthVersionFixture("mod.jar", "http://"+r.Host+"/files/mod.jar"))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg.Mods.ModrinthSources = []string{"lithium"}
+	cfg.Mods.MaxRetries = 2
+	cfg.Mods.RetryDelay = 0.01
+	cfg.Mods.Timeout = 5
+
+	result, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false)
+	if err != nil {
+		t.Fatalf("UpdateAll error: %v", err)
+	}
+	if len(result.UpdatedMods) != 1 {
+		t.Errorf("expected update to succeed after 429 retry, got failed=%v", result.FailedMods)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected 2 API calls, got %d", got)
+	}
+}
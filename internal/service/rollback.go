@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// errNoRollback is returned before any mod update has saved a mod set.
+var errNoRollback = errors.New("no saved mod set to roll back to: mods update has not run")
+
+// rollbackLock holds the lockfile entries saved alongside the jars.
+const rollbackLock = "lock.json"
+
+// rollbackDir is where SaveRollback keeps the previous mod set, next to the
+// mods directory so the server does not load it.
+func (m *Mods) rollbackDir() string {
+	return filepath.Join(filepath.Dir(m.cfg.Paths.Mods), "mods.rollback")
+}
+
+// SaveRollback records the installed jars and their lockfile entries so
+// Rollback can put them back if the updated set fails to boot, and links
+// them in the state store to backup, the pre-update backup ("" without
+// one). Jars are hardlinked where possible; updates replace files rather
+// than rewrite them. Under the symlinks layout the links themselves are
+// saved.
+func (m *Mods) SaveRollback(ctx context.Context, backup string) error {
+	if m.cfg.DryRun {
+		return nil
+	}
+	dir := m.rollbackDir()
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	jars, _ := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
+	for _, jar := range jars {
+		dst := filepath.Join(dir, filepath.Base(jar))
+		if os.Link(jar, dst) == nil {
+			continue
+		}
+		if err := copyJar(ctx, jar, dst); err != nil {
+			return err
+		}
+	}
+	lock := map[string]domain.LockedMod{}
+	if st, err := m.state.Load(); err == nil {
+		lock = st.Mods
+	}
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, rollbackLock), data, 0o600); err != nil {
+		return err
+	}
+	err = m.state.Update(func(st *domain.State) {
+		st.ModRollback = &domain.ModRollback{SavedAt: time.Now(), Backup: backup}
+	})
+	if err != nil {
+		return err
+	}
+	m.logger.Info("Saved mod set for rollback", zap.String("dir", dir), zap.Int("jars", len(jars)),
+		zap.String("backup", backup))
+	return nil
+}
+
+// SavedRollback describes the mod set SaveRollback last saved and the
+// pre-update backup it is linked to, or returns an error if none is saved.
+func (m *Mods) SavedRollback() (domain.ModRollback, error) {
+	info, err := os.Stat(filepath.Join(m.rollbackDir(), rollbackLock))
+	if errors.Is(err, os.ErrNotExist) {
+		return domain.ModRollback{}, errNoRollback
+	} else if err != nil {
+		return domain.ModRollback{}, err
+	}
+	saved := domain.ModRollback{SavedAt: info.ModTime()}
+	if st, err := m.state.Load(); err == nil && st.ModRollback != nil {
+		saved = *st.ModRollback
+	}
+	return saved, nil
+}
+
+// Rollback replaces the jars in the mods directory with the set saved by
+// SaveRollback and restores their lockfile entries. It returns the restored
+// filenames.
+func (m *Mods) Rollback(ctx context.Context) ([]string, error) {
+	dir := m.rollbackDir()
+	data, err := os.ReadFile(filepath.Join(dir, rollbackLock)) //nolint:gosec // path from validated config
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, errNoRollback
+	} else if err != nil {
+		return nil, err
+	}
+	var lock map[string]domain.LockedMod
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("reading saved lockfile: %w", err)
+	}
+	saved, _ := filepath.Glob(filepath.Join(dir, "*.jar"))
+	names := make([]string, len(saved))
+	for i, jar := range saved {
+		names[i] = filepath.Base(jar)
+	}
+	if m.cfg.DryRun {
+		m.logger.Info("Dry run: Would roll back mods", zap.Strings("jars", names))
+		return names, nil
+	}
+
+	current, _ := filepath.Glob(filepath.Join(m.cfg.ModsDir(), "*.jar"))
+	for _, jar := range current {
+		if err := os.Remove(jar); err != nil {
+			return nil, err
+		}
+	}
+	for _, jar := range saved {
+		if err := copyJar(ctx, jar, filepath.Join(m.cfg.ModsDir(), filepath.Base(jar))); err != nil {
+			return nil, err
+		}
+	}
+	if err := m.state.Update(func(st *domain.State) { st.Mods = lock }); err != nil {
+		return names, fmt.Errorf("restoring lockfile: %w", err)
+	}
+	m.logger.Info("Mods rolled back", zap.Strings("jars", names))
+	return names, nil
+}
+
+// copyJar copies src to dst, recreating it as a symlink if it is one so
+// jars of the symlinks layout switch back without copying.
+func copyJar(ctx context.Context, src, dst string) error {
+	info, err := os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		_ = os.Remove(dst)
+		return os.Symlink(target, dst)
+	}
+	return copyFile(ctx, src, dst, info)
+}
+
+// Restore copies every file of the named backup's server directory back
+// into paths.server, overwriting the current copies. Files created since
+// the backup are left in place and include_paths are not touched. The
+// server must be stopped. It returns the number of files written.
+func (b *Backup) Restore(ctx context.Context, name string) (int, error) {
+	bk, err := b.find(name)
+	if err != nil {
+		return 0, err
+	}
+	if b.cfg.DryRun {
+		b.logger.Info("Dry run: Would restore backup", zap.String("backup", name), zap.String("to", b.cfg.Paths.Server))
+		return 0, nil
+	}
+	n, err := b.extract(ctx, bk, "", b.cfg.Paths.Server)
+	if err != nil {
+		return n, fmt.Errorf("restoring %s: %w", name, err)
+	}
+	b.logger.Info("Backup restored", zap.String("backup", name), zap.Int("files", n))
+	return n, nil
+}
+
+// CreatePreUpdate creates the backup taken before a mod update, tagged
+// pre-modupdate so retention keeps max_backups of them apart from the
+// rest.
+func (b *Backup) CreatePreUpdate(ctx context.Context) (string, error) {
+	return b.CreateTagged(ctx, domain.TagPreModUpdate)
+}
@@ -0,0 +1,92 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestMods_SaveRollbackAndRollback(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Mods, "sodium-1.0.jar", "old")
+	store := service.NewStateStore(cfg)
+	if err := store.Update(func(st *domain.State) {
+		st.Mods = map[string]domain.LockedMod{"sodium": {Version: "1.0", Filename: "sodium-1.0.jar"}}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	mods := service.NewMods(cfg, logger)
+	backup := filepath.Join(cfg.Paths.Backups, "minecraft_backup_20260101_120000.tar.gz")
+	if err := mods.SaveRollback(ctx, backup); err != nil {
+		t.Fatalf("SaveRollback: %v", err)
+	}
+	if saved, err := mods.SavedRollback(); err != nil || saved.Backup != backup || saved.SavedAt.IsZero() {
+		t.Errorf("SavedRollback = %+v, %v; want it linked to %s", saved, err, backup)
+	}
+
+	// The update replaces the jar and the lock entry.
+	_ = os.Remove(filepath.Join(cfg.Paths.Mods, "sodium-1.0.jar"))
+	writeFile(t, cfg.Paths.Mods, "sodium-2.0.jar", "new")
+	_ = store.Update(func(st *domain.State) {
+		st.Mods["sodium"] = domain.LockedMod{Version: "2.0", Filename: "sodium-2.0.jar"}
+	})
+
+	jars, err := mods.Rollback(ctx)
+	if err != nil || len(jars) != 1 || jars[0] != "sodium-1.0.jar" {
+		t.Fatalf("Rollback = %v, %v", jars, err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, "sodium-2.0.jar")); !os.IsNotExist(err) {
+		t.Error("updated jar still installed after rollback")
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.Paths.Mods, "sodium-1.0.jar")); string(data) != "old" {
+		t.Errorf("restored jar content = %q", data)
+	}
+	if st, _ := store.Load(); st.Mods["sodium"].Version != "1.0" {
+		t.Errorf("lockfile not restored: %+v", st.Mods["sodium"])
+	}
+}
+
+func TestMods_Rollback_NothingSaved(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	mods := service.NewMods(cfg, logger)
+	if _, err := mods.SavedRollback(); err == nil {
+		t.Error("SavedRollback: expected an error with no saved mod set")
+	}
+	if _, err := mods.Rollback(ctx); err == nil {
+		t.Error("expected an error with no saved mod set")
+	}
+}
+
+func TestBackup_Restore(t *testing.T) {
+	for _, mode := range []string{"archive", "snapshot"} {
+		t.Run(mode, func(t *testing.T) {
+			cfg, logger, ctx := setup(t)
+			cfg.Backup.Enabled = true
+			cfg.Backup.Mode = mode
+			extra := t.TempDir()
+			cfg.Backup.IncludePaths = []string{extra}
+			writeFile(t, extra, "plugin.yml", "extra")
+			level := writeFile(t, cfg.Paths.Server, "world/level.dat", "before")
+			svc := service.NewBackup(cfg, logger)
+			path, err := svc.Create(ctx)
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			_ = os.WriteFile(level, []byte("corrupted"), 0o600)
+			n, err := svc.Restore(ctx, filepath.Base(path))
+			if err != nil || n != 1 {
+				t.Fatalf("Restore = %d, %v", n, err)
+			}
+			if data, _ := os.ReadFile(level); string(data) != "before" {
+				t.Errorf("level.dat = %q after restore", data)
+			}
+			if _, err := os.Stat(filepath.Join(cfg.Paths.Server, "_extra")); !os.IsNotExist(err) {
+				t.Error("include_paths were restored into the server directory")
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"craftops/internal/domain"
+)
+
+// Search finds Modrinth mods matching query that have a build for the
+// configured loader and Minecraft version, most relevant first.
+func (m *Mods) Search(ctx context.Context, query string, limit int) ([]domain.ModSearchHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, errors.New("empty search query")
+	}
+	facets, _ := json.Marshal([][]string{
+		{"project_type:mod"},
+		{"categories:" + m.cfg.Minecraft.Modloader},
+		{"versions:" + m.cfg.Minecraft.Version},
+	})
+	apiURL := fmt.Sprintf("%s/search?query=%s&facets=%s&limit=%d",
+		modrinthAPI, url.QueryEscape(query), url.QueryEscape(string(facets)), limit)
+	var res struct {
+		Hits []domain.ModSearchHit `json:"hits"`
+	}
+	if err := m.apiRequest(ctx, apiURL, &res); err != nil {
+		return nil, err
+	}
+	declared := m.declaredSlugs()
+	for i := range res.Hits {
+		h := &res.Hits[i]
+		h.Declared = declared[h.Slug] || declared[h.ID]
+	}
+	return res.Hits, nil
+}
+
+// AddSource declares the Modrinth project slug as a mod source, reporting
+// false when it already is one. The config is changed in memory only.
+func (m *Mods) AddSource(slug string) bool {
+	if m.declaredSlugs()[slug] {
+		return false
+	}
+	m.cfg.Mods.ModrinthSources = append(m.cfg.Mods.ModrinthSources, "https://modrinth.com/mod/"+slug)
+	return true
+}
+
+// declaredSlugs is the set of project slugs and IDs configured as sources.
+func (m *Mods) declaredSlugs() map[string]bool {
+	declared := make(map[string]bool, len(m.cfg.Mods.ModrinthSources))
+	for _, src := range m.cfg.Mods.ModrinthSources {
+		if slug, err := parseProjectID(src); err == nil {
+			declared[slug] = true
+		}
+	}
+	return declared
+}
@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestMods_Search(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	var query, facets, limit string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/search" {
+			http.NotFound(w, r)
+			return
+		}
+		q := r.URL.Query()
+		query, facets, limit = q.Get("query"), q.Get("facets"), q.Get("limit")
+		_, _ = w.Write([]byte(`{"hits":[
+			{"slug":"sodium","project_id":"AANobbMI","title":"Sodium","author":"jellysquid3","downloads":5,"license":"LicenseRef-Polyform-Shield-1.0.0"},
+			{"slug":"lithium","project_id":"gvQqBUqZ","title":"Lithium","author":"jellysquid3","downloads":3,"license":"LGPL-3.0-only"}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.ModrinthSources = []string{"https://modrinth.com/mod/sodium"}
+	svc := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+
+	hits, err := svc.Search(ctx, " performance ", 5)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if query != "performance" || limit != "5" {
+		t.Errorf("query = %q, limit = %q", query, limit)
+	}
+	want := `[["project_type:mod"],["categories:` + cfg.Minecraft.Modloader + `"],["versions:` + cfg.Minecraft.Version + `"]]`
+	if facets != want {
+		t.Errorf("facets = %s, want %s", facets, want)
+	}
+	if len(hits) != 2 || hits[0].ID != "AANobbMI" || !hits[0].Declared || hits[1].Declared {
+		t.Errorf("hits = %+v, want sodium marked declared", hits)
+	}
+
+	if _, err := svc.Search(ctx, "  ", 5); err == nil {
+		t.Error("Search with an empty query succeeded")
+	}
+
+	if svc.AddSource("sodium") {
+		t.Error("AddSource added a declared mod again")
+	}
+	if !svc.AddSource("lithium") {
+		t.Error("AddSource(lithium) = false")
+	}
+	if !slices.Contains(cfg.Mods.ModrinthSources, "https://modrinth.com/mod/lithium") {
+		t.Errorf("sources = %v", cfg.Mods.ModrinthSources)
+	}
+}
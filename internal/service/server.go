@@ -8,7 +8,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
+	"unicode"
 
 	"go.uber.org/zap"
 
@@ -20,13 +22,18 @@ import (
 type Server struct {
 	cfg    *config.Config
 	logger *zap.Logger
+	state  *StateStore
+	proxy  *Proxy
 }
 
 // NewServer creates a server manager.
 func NewServer(cfg *config.Config, logger *zap.Logger) *Server {
-	return &Server{cfg: cfg, logger: logger}
+	return &Server{cfg: cfg, logger: logger, state: NewStateStore(cfg)}
 }
 
+// UseProxy attaches the network proxy that Restart moves players through.
+func (s *Server) UseProxy(p *Proxy) { s.proxy = p }
+
 // Status checks if the server screen session is running.
 func (s *Server) Status(ctx context.Context) (*domain.ServerStatus, error) {
 	cmd := exec.CommandContext(ctx, "screen", "-ls")
@@ -36,17 +43,77 @@ func (s *Server) Status(ctx context.Context) (*domain.ServerStatus, error) {
 	}
 
 	session := s.sessionName()
-	isRunning := strings.Contains(string(output), "."+session)
-
-	return &domain.ServerStatus{
-		IsRunning:   isRunning,
+	status := &domain.ServerStatus{
+		IsRunning:   strings.Contains(string(output), "."+session),
 		SessionName: session,
 		CheckedAt:   time.Now(),
-	}, nil
+	}
+	if !status.IsRunning {
+		if pid := orphanJava(ctx, s.cfg.Paths.Server); pid > 0 {
+			status.IsRunning, status.Unmanaged, status.PID = true, true, pid
+			status.Adopted = s.adoptedPID() == pid
+		}
+	}
+	return status, nil
+}
+
+// Adopt takes over an unmanaged server so Stop can shut it down with
+// SIGTERM (the server saves worlds on SIGTERM). It returns the adopted PID.
+func (s *Server) Adopt(ctx context.Context) (int, error) {
+	status, err := s.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !status.Unmanaged {
+		return 0, errors.New("no unmanaged server process found in " + s.cfg.Paths.Server)
+	}
+	if err := s.state.Update(func(st *domain.State) { st.AdoptedPID = status.PID }); err != nil {
+		return 0, err
+	}
+	s.logger.Info("Adopted unmanaged server", zap.Int("pid", status.PID))
+	return status.PID, nil
+}
+
+// adoptedPID returns the recorded adopted PID if that process is still alive.
+func (s *Server) adoptedPID() int {
+	st, err := s.state.Load()
+	if err != nil || !processAlive(st.AdoptedPID) {
+		return 0
+	}
+	return st.AdoptedPID
+}
+
+// Usage is Status plus the java process's PID, CPU, memory, uptime and
+// whether the configured server port is accepting connections.
+func (s *Server) Usage(ctx context.Context) (*domain.ServerStatus, error) {
+	status, err := s.Status(ctx)
+	if err != nil {
+		return status, err
+	}
+	if st, err := s.state.Load(); err == nil && len(st.LastSuccess) > 0 {
+		status.LastSuccess = st.LastSuccess
+	}
+	if !status.IsRunning {
+		return status, nil
+	}
+	if status.PID == 0 {
+		output, _ := exec.CommandContext(ctx, "screen", "-ls").Output()
+		if root := screenPID(string(output), status.SessionName); root > 0 {
+			status.PID = javaPID(ctx, root)
+		}
+	}
+	if status.PID > 0 {
+		processStats(ctx, status.PID, status)
+	}
+	status.Port = serverPort(s.cfg.Paths.Server)
+	status.PortOpen = portOpen(ctx, serverHost(s.cfg.Paths.Server), status.Port)
+	return status, nil
 }
 
 // Start launches the server in a detached screen session.
-func (s *Server) Start(ctx context.Context) error {
+func (s *Server) Start(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "server.start")
+	defer func() { span.finish(err) }()
 	if s.cfg.DryRun {
 		s.logger.Info("Dry run: Would start server")
 		return nil
@@ -56,30 +123,81 @@ func (s *Server) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("server.start: %w", err)
 	}
+	if status.Unmanaged {
+		return fmt.Errorf("%w: PID %d is serving %s outside screen; run `craftops server adopt`",
+			domain.ErrServerUnmanaged, status.PID, s.cfg.Paths.Server)
+	}
 	if status.IsRunning {
 		s.logger.Warn("Server is already running")
 		return nil
 	}
+	if s.cfg.Templates.ApplyOnStart {
+		if _, err := s.RenderTemplates(); err != nil {
+			return fmt.Errorf("server.start: %w", err)
+		}
+	}
 
-	serverJar := filepath.Join(s.cfg.Paths.Server, s.cfg.Server.JarName)
-	if _, err := os.Stat(serverJar); errors.Is(err, os.ErrNotExist) {
-		return domain.ErrServerJarNotFound
+	var launch []string
+	if s.cfg.Server.StartCommand == "" {
+		if launch, err = launchArgs(s.cfg.Paths.Server, s.cfg.Server.JarName); err != nil {
+			return err
+		}
+	}
+	if err := checkPortFree(ctx, serverPort(s.cfg.Paths.Server)); err != nil {
+		return err
+	}
+	if err := s.runHooks(ctx, "pre_start", s.cfg.Server.PreStartCommands); err != nil {
+		return err
 	}
 
-	javaArgs := append(append([]string{}, s.cfg.Server.JavaFlags...), "-jar", s.cfg.Server.JarName, "nogui")
-	cmdArgs := append([]string{"-dmS", s.sessionName(), "java"}, javaArgs...)
+	cmdArgs := append([]string{"-dmS", s.sessionName()}, s.cfg.Server.Resources.LaunchPrefix(s.scopeUnit())...)
+	if s.cfg.Server.StartCommand != "" {
+		cmdArgs = append(cmdArgs, "sh", "-c", s.cfg.Server.StartCommand)
+	} else {
+		java, err := s.javaBinary(ctx)
+		if err != nil {
+			return fmt.Errorf("server.start: %w", err)
+		}
+		diag := s.cfg.Server.Diagnostics
+		if flags := diag.Flags(s.cfg.Paths.Logs); len(flags) > 0 {
+			dir := s.cfg.Paths.Logs
+			if diag.FlightRecorder {
+				dir = filepath.Join(dir, "jfr")
+			}
+			if err := os.MkdirAll(dir, 0o750); err != nil {
+				return fmt.Errorf("server.start: %w", err)
+			}
+		}
+		cmdArgs = append(append(cmdArgs, java), s.cfg.Server.JVMFlags()...)
+		cmdArgs = append(append(cmdArgs, diag.Flags(s.cfg.Paths.Logs)...), launch...)
+	}
 
+	startedAt := time.Now()
 	cmd := exec.CommandContext(ctx, "screen", cmdArgs...) //nolint:gosec
 	cmd.Dir = s.cfg.Paths.Server
 	if err := cmd.Start(); err != nil {
 		return fmt.Errorf("server.start: %w", err)
 	}
 
-	return s.waitForStatus(ctx, true, s.cfg.Server.StartupTimeout, "started")
+	err = s.waitForStatus(ctx, true, s.cfg.Server.StartupTimeout, "started")
+	switch {
+	case err == nil && s.cfg.Server.ReadyTimeout > 0:
+		err = s.waitReady(ctx, startedAt)
+	case errors.Is(err, domain.ErrServerTimeout):
+		err = s.diagnoseStartup(err, startedAt)
+	}
+	if err != nil {
+		s.recordCrash(err)
+		return err
+	}
+	s.recordSuccess(domain.OpStart)
+	return nil
 }
 
 // Stop sends the stop command and waits for exit.
-func (s *Server) Stop(ctx context.Context) error {
+func (s *Server) Stop(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "server.stop")
+	defer func() { span.finish(err) }()
 	if s.cfg.DryRun {
 		s.logger.Info("Dry run: Would stop server")
 		return nil
@@ -94,56 +212,252 @@ func (s *Server) Stop(ctx context.Context) error {
 		return nil
 	}
 
-	stopCmd := s.cfg.Server.StopCommand + "\n"
-	cmd := exec.CommandContext(ctx, "screen", "-S", s.sessionName(), "-X", "stuff", stopCmd) //nolint:gosec
-	if err := cmd.Run(); err != nil {
+	if status.Unmanaged {
+		if !status.Adopted {
+			return fmt.Errorf("%w: PID %d; run `craftops server adopt` to let craftops stop it",
+				domain.ErrServerUnmanaged, status.PID)
+		}
+		s.logger.Info("Stopping adopted server", zap.Int("pid", status.PID))
+		if err := signalProcess(status.PID, syscall.SIGTERM); err != nil {
+			return fmt.Errorf("server.stop: %w", err)
+		}
+	} else if err := s.SendConsole(ctx, s.cfg.Server.StopCommand); err != nil {
 		return fmt.Errorf("server.stop: %w", err)
 	}
 
-	return s.waitForStatus(ctx, false, s.cfg.Server.MaxStopWait, "stopped")
+	if err := s.waitForStatus(ctx, false, s.cfg.Server.MaxStopWait, "stopped"); err != nil {
+		if !s.cfg.Server.ForceStop || !errors.Is(err, domain.ErrServerTimeout) {
+			return err
+		}
+		pid := s.javaProcess(ctx, status.PID)
+		s.recordHangDump(ctx, pid)
+		if err := s.escalateStop(ctx, pid); err != nil {
+			return err
+		}
+	}
+	s.recordSuccess(domain.OpStop)
+	return s.runHooks(ctx, "post_stop", s.cfg.Server.PostStopCommands)
+}
+
+// runHooks runs each lifecycle command through sh in the server directory,
+// stopping at the first failure.
+func (s *Server) runHooks(ctx context.Context, stage string, commands []string) error {
+	for _, c := range commands {
+		s.logger.Info("Running "+stage+" command", zap.String("command", c))
+		cmd := exec.CommandContext(ctx, "sh", "-c", c) //nolint:gosec // command from user config
+		cmd.Dir = s.cfg.Paths.Server
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("%s command %q failed: %w: %s", stage, c, err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// SendConsole types a command into the server console.
+func (s *Server) SendConsole(ctx context.Context, command string) error {
+	cmd := exec.CommandContext(ctx, "screen", "-S", s.sessionName(), "-X", "stuff", command+"\n") //nolint:gosec
+	return cmd.Run()
+}
+
+// escalateStop runs after a graceful stop timed out: it repeats the stop
+// command, then sends SIGTERM and finally SIGKILL to the java process,
+// waiting server.escalation_wait seconds after each step. pid is the java
+// process if already known, otherwise it is resolved from the screen session.
+func (s *Server) escalateStop(ctx context.Context, pid int) error {
+	pid = s.javaProcess(ctx, pid)
+	wait := s.cfg.Server.EscalationWait
+
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"resend stop command", func() error { return s.SendConsole(ctx, s.cfg.Server.StopCommand) }},
+		{"SIGTERM", func() error { return signalProcess(pid, syscall.SIGTERM) }},
+		{"SIGKILL", func() error { return signalProcess(pid, syscall.SIGKILL) }},
+	}
+	for _, step := range steps {
+		s.logger.Warn("Server did not stop, escalating", zap.String("step", step.name), zap.Int("pid", pid))
+		if err := step.run(); err != nil {
+			s.logger.Warn("Stop escalation step failed", zap.String("step", step.name), zap.Error(err))
+			continue
+		}
+		err := s.waitForStatus(ctx, false, wait, "stopped")
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, domain.ErrServerTimeout) {
+			return err
+		}
+	}
+	_ = exec.CommandContext(ctx, "screen", "-S", s.sessionName(), "-X", "quit").Run() //nolint:gosec
+	return s.waitForStatus(ctx, false, wait, "stopped")
+}
+
+// javaProcess returns pid, or when it is 0 the java process running in the
+// screen session (0 if there is none).
+func (s *Server) javaProcess(ctx context.Context, pid int) int {
+	if pid != 0 {
+		return pid
+	}
+	output, _ := exec.CommandContext(ctx, "screen", "-ls").Output()
+	return javaPID(ctx, screenPID(string(output), s.sessionName()))
+}
+
+func signalProcess(pid int, sig syscall.Signal) error {
+	if pid <= 0 {
+		return errors.New("java process not found")
+	}
+	return syscall.Kill(pid, sig)
 }
 
-// Restart performs a sequential stop then start.
-func (s *Server) Restart(ctx context.Context) error {
+// Restart performs a sequential stop then start. Behind a proxy, players
+// are sent to the fallback server first and told once the backend is back.
+func (s *Server) Restart(ctx context.Context) (err error) {
+	ctx, span := startSpan(ctx, "server.restart")
+	defer func() { span.finish(err) }()
 	s.logger.Info("Restarting server")
-	if err := s.Stop(ctx); err != nil {
+	return s.restart(ctx, s.Stop)
+}
+
+// restart runs stop, then starts the server again.
+func (s *Server) restart(ctx context.Context, stop func(context.Context) error) error {
+	if err := s.proxy.Evacuate(ctx); err != nil {
+		s.logger.Warn("Failed to move players to the fallback server", zap.Error(err))
+	}
+	if err := stop(ctx); err != nil {
+		return err
+	}
+	if delay := time.Duration(s.cfg.Server.RestartDelay) * time.Second; delay > 0 && !s.cfg.DryRun {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	if err := s.Start(ctx); err != nil {
 		return err
 	}
-	return s.Start(ctx)
+	if err := s.proxy.Broadcast(ctx, s.displayName()+" is back online"); err != nil {
+		s.logger.Warn("Failed to announce restart through the proxy", zap.Error(err))
+	}
+	s.recordSuccess(domain.OpRestart)
+	return nil
+}
+
+// displayName is the backend's name on the proxy network.
+func (s *Server) displayName() string {
+	if s.cfg.Proxy.ServerName != "" {
+		return s.cfg.Proxy.ServerName
+	}
+	return "The server"
+}
+
+func (s *Server) recordSuccess(op string) {
+	if err := s.state.RecordSuccess(op); err != nil {
+		s.logger.Warn("Failed to record operation in state", zap.String("op", op), zap.Error(err))
+	}
+}
+
+func (s *Server) recordCrash(cause error) {
+	if err := s.state.RecordCrash(cause.Error()); err != nil {
+		s.logger.Warn("Failed to record crash in state", zap.Error(err))
+	}
 }
 
-// HealthCheck verifies server dependencies (Java, screen, paths).
-func (s *Server) HealthCheck(_ context.Context) []domain.HealthCheck {
+// HealthCheck verifies server dependencies (Java, screen, paths) and reports
+// the last successful restart.
+func (s *Server) HealthCheck(ctx context.Context) []domain.HealthCheck {
 	checks := []domain.HealthCheck{
 		domain.CheckPath("Server directory", s.cfg.Paths.Server),
 	}
 
 	serverJar := filepath.Join(s.cfg.Paths.Server, s.cfg.Server.JarName)
-	if info, err := os.Stat(serverJar); err == nil && !info.IsDir() {
+	if s.cfg.Server.StartCommand != "" {
+		checks = append(checks, domain.HealthCheck{Name: "Server JAR", Status: domain.StatusOK, Message: "Not used: start_command is set"})
+	} else if info, err := os.Stat(serverJar); err == nil && !info.IsDir() {
 		checks = append(checks, domain.HealthCheck{
 			Name:    "Server JAR",
 			Status:  domain.StatusOK,
 			Message: fmt.Sprintf("Found (%.1f MB)", float64(info.Size())/(1024*1024)),
 		})
+	} else if args := forgeArgsFile(s.cfg.Paths.Server); args != "" {
+		checks = append(checks, domain.HealthCheck{Name: "Server JAR", Status: domain.StatusOK, Message: "Launching with @" + args})
 	} else {
 		checks = append(checks, domain.HealthCheck{Name: "Server JAR", Status: domain.StatusError, Message: "Not found"})
 	}
 
-	for _, b := range []struct{ bin, name string }{{"java", "Java Runtime"}, {"screen", "GNU screen"}} {
-		if _, err := exec.LookPath(b.bin); err == nil {
-			checks = append(checks, domain.HealthCheck{Name: b.name, Status: domain.StatusOK, Message: "Available"})
+	checks = append(checks, s.checkJava(ctx))
+	if _, err := exec.LookPath("screen"); err == nil {
+		checks = append(checks, domain.HealthCheck{Name: "GNU screen", Status: domain.StatusOK, Message: "Available"})
+	} else {
+		checks = append(checks, domain.HealthCheck{Name: "GNU screen", Status: domain.StatusError, Message: "screen not found in PATH"})
+	}
+	for _, tool := range s.cfg.Server.Resources.LaunchTools() {
+		if _, err := exec.LookPath(tool); err == nil {
+			checks = append(checks, domain.HealthCheck{Name: "Launch " + tool, Status: domain.StatusOK, Message: "Available"})
 		} else {
-			checks = append(checks, domain.HealthCheck{Name: b.name, Status: domain.StatusError, Message: b.bin + " not found in PATH"})
+			checks = append(checks, domain.HealthCheck{Name: "Launch " + tool, Status: domain.StatusError,
+				Message: tool + " not found in PATH (needed by [server.resources])"})
 		}
 	}
+	checks = append(checks, s.state.lastSuccessCheck("Last restart", domain.OpRestart, 0))
 	return checks
 }
 
+// defaultSessionName is the screen session used when none is configured.
+const defaultSessionName = "minecraft"
+
 func (s *Server) sessionName() string {
 	if s.cfg.Server.SessionName != "" {
 		return s.cfg.Server.SessionName
 	}
-	return "minecraft"
+	return defaultSessionName
+}
+
+// scopeUnit names the systemd scope of server.resources.scope after the
+// session, keeping only characters valid in a unit name.
+func (s *Server) scopeUnit() string {
+	name := strings.Map(func(r rune) rune {
+		if r < 128 && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(":_.-", r)) {
+			return r
+		}
+		return '_'
+	}, s.sessionName())
+	return "craftops-" + name
+}
+
+// waitReady waits until the server started at since has logged "Done" and
+// accepts connections on its port; a live screen session alone may still
+// be loading or about to crash. A server that exits first, or is not
+// ready within server.ready_timeout, fails with the log tail attached.
+func (s *Server) waitReady(ctx context.Context, since time.Time) error {
+	timeout := time.Duration(s.cfg.Server.ReadyTimeout) * time.Second
+	deadline := time.Now().Add(timeout)
+	logPath := filepath.Join(s.cfg.Paths.Server, "logs", "latest.log")
+	port := serverPort(s.cfg.Paths.Server)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if logDone(logPath, since) && portOpen(ctx, serverHost(s.cfg.Paths.Server), port) {
+			s.logger.Info("Server ready", zap.Int("port", port), zap.Duration("duration", time.Since(since)))
+			return nil
+		}
+		status, err := s.Status(ctx)
+		if err != nil {
+			return err
+		}
+		if !status.IsRunning {
+			return s.diagnoseStartup(errors.New("server exited during startup"), since)
+		}
+		if time.Now().After(deadline) {
+			return s.diagnoseStartup(fmt.Errorf("server not ready within %s: %w", timeout, domain.ErrServerTimeout), since)
+		}
+	}
 }
 
 // waitForStatus polls until the server reaches the target state or timeout.
@@ -170,7 +484,7 @@ func (s *Server) waitForStatus(ctx context.Context, target bool, timeout int, la
 				return nil
 			}
 			if time.Since(start) > time.Duration(timeout)*time.Second {
-				return fmt.Errorf("server failed to %s within %ds", label, timeout)
+				return fmt.Errorf("server failed to %s within %ds: %w", label, timeout, domain.ErrServerTimeout)
 			}
 		}
 	}
@@ -1,8 +1,18 @@
 package service_test
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"craftops/internal/domain"
 	"craftops/internal/service"
 )
 
@@ -64,3 +74,226 @@ func TestServer_Stop_DryRun(t *testing.T) {
 		t.Errorf("Stop() dry-run error: %v", err)
 	}
 }
+
+func TestScreenPID(t *testing.T) {
+	out := "There are screens on:\n\t4242.minecraft\t(Detached)\n\t77.minecraft_old\t(Detached)\n2 Sockets in /run/screen.\n"
+	if got := service.ScreenPID(out, "minecraft"); got != 4242 {
+		t.Errorf("ScreenPID = %d, want 4242", got)
+	}
+	if got := service.ScreenPID(out, "other"); got != 0 {
+		t.Errorf("ScreenPID for missing session = %d, want 0", got)
+	}
+}
+
+func TestParseEtime(t *testing.T) {
+	tests := map[string]time.Duration{
+		"05:03":      5*time.Minute + 3*time.Second,
+		"02:00:01":   2*time.Hour + time.Second,
+		"3-00:00:10": 72*time.Hour + 10*time.Second,
+	}
+	for in, want := range tests {
+		if got, err := service.ParseEtime(in); err != nil || got != want {
+			t.Errorf("ParseEtime(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := service.ParseEtime("bogus"); err == nil {
+		t.Error("expected error for malformed etime")
+	}
+}
+
+func TestServer_DiagnoseStartup(t *testing.T) {
+	cfg, logger, _ := setup(t)
+	svc := service.NewServer(cfg, logger)
+	timeout := fmt.Errorf("server failed to started within 1s: %w", domain.ErrServerTimeout)
+
+	writeFile(t, cfg.Paths.Server, "logs/latest.log",
+		"[Server thread/INFO]: Loading properties\n[Server thread/WARN]: **** FAILED TO BIND TO PORT!\n")
+	err := service.DiagnoseStartup(svc, timeout, time.Now().Add(-time.Minute))
+	var se *domain.StartupError
+	if !errors.As(err, &se) || se.Cause != "port already in use" {
+		t.Fatalf("expected port diagnosis, got %v", err)
+	}
+	if !errors.Is(err, domain.ErrServerTimeout) || len(se.LogTail) != 2 {
+		t.Errorf("diagnosis should wrap timeout and keep log tail: %+v", se)
+	}
+
+	writeFile(t, cfg.Paths.Server, "logs/latest.log", "")
+	report := writeFile(t, cfg.Paths.Server, "crash-reports/crash-2024.txt",
+		"Description: Mod loading has failed\n")
+	err = service.DiagnoseStartup(svc, timeout, time.Now().Add(-time.Minute))
+	if !errors.As(err, &se) || se.Cause != "mod error" || se.CrashReport != report {
+		t.Errorf("expected mod error from crash report, got %+v", se)
+	}
+}
+
+func TestServer_Start_PortInUse(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close() //nolint:errcheck
+	port := ln.Addr().(*net.TCPAddr).Port
+	writeFile(t, cfg.Paths.Server, cfg.Server.JarName, "jar")
+	writeFile(t, cfg.Paths.Server, "server.properties", "server-port="+strconv.Itoa(port)+"\n")
+	cfg.Server.SessionName = "craftops-test-port-" + strconv.Itoa(port)
+
+	err = service.NewServer(cfg, logger).Start(ctx)
+	if !errors.Is(err, domain.ErrPortInUse) {
+		t.Fatalf("expected ErrPortInUse, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "PID "+strconv.Itoa(os.Getpid())) {
+		t.Errorf("error should name the owning PID: %v", err)
+	}
+}
+
+func TestServer_OrphanDetectionAndAdopt(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Server.SessionName = "craftops-test-orphan"
+	cfg.Server.MaxStopWait = 5
+	fake := writeFile(t, t.TempDir(), "java", "#!/bin/sh\nwhile :; do sleep 1; done\n")
+	_ = os.Chmod(fake, 0o700)  //nolint:gosec
+	proc := exec.Command(fake) //nolint:gosec
+	proc.Dir = cfg.Paths.Server
+	if err := proc.Start(); err != nil {
+		t.Skipf("cannot start fake java: %v", err)
+	}
+	t.Cleanup(func() { _ = proc.Process.Kill() })
+	go func() { _ = proc.Wait() }()
+	svc := service.NewServer(cfg, logger)
+
+	status, err := svc.Status(ctx)
+	if err != nil || !status.Unmanaged || status.PID != proc.Process.Pid {
+		t.Fatalf("expected unmanaged PID %d, got %+v (%v)", proc.Process.Pid, status, err)
+	}
+	writeFile(t, cfg.Paths.Server, cfg.Server.JarName, "jar")
+	if err := svc.Start(ctx); !errors.Is(err, domain.ErrServerUnmanaged) {
+		t.Errorf("Start must refuse a second instance, got %v", err)
+	}
+	if err := svc.Stop(ctx); !errors.Is(err, domain.ErrServerUnmanaged) {
+		t.Errorf("Stop before adopt should refuse, got %v", err)
+	}
+	if pid, err := svc.Adopt(ctx); err != nil || pid != proc.Process.Pid {
+		t.Fatalf("Adopt = %d, %v", pid, err)
+	}
+	if err := svc.Stop(ctx); err != nil {
+		t.Errorf("Stop after adopt: %v", err)
+	}
+}
+
+func TestServer_Start_PreStartCommandFails(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Server.SessionName = "craftops-test-hooks"
+	writeFile(t, cfg.Paths.Server, cfg.Server.JarName, "jar")
+	writeFile(t, cfg.Paths.Server, "server.properties", "server-port=0\n")
+	cfg.Server.PreStartCommands = []string{"touch ran", "echo mount missing >&2; exit 1", "touch never"}
+
+	err := service.NewServer(cfg, logger).Start(ctx)
+	if err == nil || !strings.Contains(err.Error(), "mount missing") {
+		t.Fatalf("expected pre_start failure with output, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Server, "ran")); err != nil {
+		t.Error("first pre_start command should run in the server directory")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Server, "never")); err == nil {
+		t.Error("commands after a failure must not run")
+	}
+}
+
+func TestServer_HealthCheck_JavaPath(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	fake := writeFile(t, t.TempDir(), "java", "#!/bin/sh\necho 'openjdk version \"1.8.0_392\"' >&2\n")
+	_ = os.Chmod(fake, 0o700) //nolint:gosec
+	cfg.Server.JavaPath = fake
+
+	javaCheck := func() domain.HealthCheck {
+		for _, c := range service.NewServer(cfg, logger).HealthCheck(ctx) {
+			if c.Name == "Java Runtime" {
+				return c
+			}
+		}
+		t.Fatal("missing Java Runtime check")
+		return domain.HealthCheck{}
+	}
+	if c := javaCheck(); c.Status != domain.StatusOK || !strings.HasPrefix(c.Message, "Java 8 ") {
+		t.Errorf("expected Java 8 OK, got %+v", c)
+	}
+	cfg.Server.JavaVersion = 21
+	if c := javaCheck(); c.Status != domain.StatusError {
+		t.Errorf("version mismatch should fail, got %+v", c)
+	}
+}
+
+func TestServer_WaitReady(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	since := time.Now().Add(-time.Minute)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	writeFile(t, cfg.Paths.Server, "server.properties", fmt.Sprintf("server-port=%d\n", ln.Addr().(*net.TCPAddr).Port))
+	writeFile(t, cfg.Paths.Server, "logs/latest.log",
+		"[Server thread/INFO]: Preparing spawn area\n[Server thread/INFO]: Done (3.512s)! For help, type \"help\"\n")
+	if err := service.NewServer(cfg, logger).WaitReady(ctx, since); err != nil {
+		t.Errorf("WaitReady: %v", err)
+	}
+
+	// A log from before the start does not count, even with the port open;
+	// without the server running, WaitReady reports the failed startup.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(cfg.Paths.Server, "logs", "latest.log"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	cfg.Server.SessionName = "craftops-test-waitready"
+	if err := service.NewServer(cfg, logger).WaitReady(ctx, since); err == nil {
+		t.Error("expected WaitReady to fail for a server that is not running")
+	}
+}
+
+func TestServer_EscalateStop(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Server.SessionName = "craftops-test-escalate"
+	cfg.Server.EscalationWait = 1
+	dir := t.TempDir()
+	log, running := filepath.Join(dir, "steps.log"), filepath.Join(dir, "running")
+	writeFile(t, dir, "running", "")
+	// A screen whose session lives until `-X quit`, logging the commands
+	// it is sent.
+	writeFile(t, dir, "bin/screen", fmt.Sprintf(`#!/bin/sh
+case "$*" in
+-ls) [ -f %[1]q ] && printf '\t4242.%[3]s\t(Detached)\n'; exit 0 ;;
+*quit) echo quit >> %[2]q; rm -f %[1]q ;;
+*stuff*) echo stop >> %[2]q ;;
+esac
+`, running, log, cfg.Server.SessionName))
+	_ = os.Chmod(filepath.Join(dir, "bin", "screen"), 0o700) //nolint:gosec
+	t.Setenv("PATH", filepath.Join(dir, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	// A java process that ignores SIGTERM.
+	java := writeFile(t, dir, "decoy", fmt.Sprintf("#!/bin/sh\ntrap 'echo TERM >> %q' TERM\nwhile :; do sleep 0.1; done\n", log))
+	_ = os.Chmod(java, 0o700)  //nolint:gosec
+	proc := exec.Command(java) //nolint:gosec
+	if err := proc.Start(); err != nil {
+		t.Skipf("cannot start fake java: %v", err)
+	}
+	t.Cleanup(func() { _ = proc.Process.Kill() })
+	exited := make(chan error, 1)
+	go func() { exited <- proc.Wait() }()
+	time.Sleep(200 * time.Millisecond) // let the trap be installed
+
+	if err := service.NewServer(cfg, logger).EscalateStop(ctx, proc.Process.Pid); err != nil {
+		t.Fatalf("EscalateStop: %v", err)
+	}
+	if data, _ := os.ReadFile(log); string(data) != "stop\nTERM\nquit\n" {
+		t.Errorf("escalation steps = %q, want stop, SIGTERM, then quit", data)
+	}
+	select {
+	case err := <-exited:
+		if err == nil || !strings.Contains(err.Error(), "killed") {
+			t.Errorf("java exited with %v, want SIGKILL", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("java still running after SIGKILL")
+	}
+}
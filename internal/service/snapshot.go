@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// snapshotMarker identifies a directory in the backups folder as a complete
+// snapshot; its mtime is the snapshot time. It holds the snapshot's name
+// and the bytes it wrote itself, one per line: files hardlinked from the
+// previous snapshot cost no space and are not counted.
+const snapshotMarker = ".craftops-snapshot"
+
+// createSnapshot writes an uncompressed copy of the backup set, hardlinking
+// every file whose size and mtime match the previous snapshot (rsync
+// --link-dest style). It is built in a hidden partial directory and renamed
+// into place only when complete.
+func (b *Backup) createSnapshot(ctx context.Context, tag string) (string, error) {
+	name := b.baseName(time.Now(), tag)
+	final := filepath.Join(b.cfg.Paths.Backups, name)
+	partial := filepath.Join(b.cfg.Paths.Backups, "."+name+".partial")
+	_ = os.RemoveAll(partial)
+
+	prev := b.latestSnapshot()
+	b.logger.Info("Creating snapshot", zap.String("name", name), zap.String("link_dest", prev))
+
+	var linked, copied int
+	var written int64
+	err := b.walkFiles(ctx, func(path, rel string, info fs.FileInfo) error {
+		dst := filepath.Join(partial, filepath.FromSlash(rel))
+		if info.IsDir() {
+			return os.MkdirAll(dst, 0o750)
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if prev != "" {
+			old := filepath.Join(prev, filepath.FromSlash(rel))
+			if st, err := os.Stat(old); err == nil && st.Size() == info.Size() && st.ModTime().Equal(info.ModTime()) {
+				if os.Link(old, dst) == nil {
+					linked++
+					b.progress.add(rel, info.Size())
+					return nil
+				}
+			}
+		}
+		copied++
+		if err := copyFile(ctx, path, dst, info); err != nil {
+			return err
+		}
+		written += info.Size()
+		b.progress.add(rel, info.Size())
+		return nil
+	})
+	if err == nil {
+		err = os.WriteFile(filepath.Join(partial, snapshotMarker), fmt.Appendf(nil, "%s\n%d\n", name, written), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(partial, final)
+	}
+	if err != nil {
+		_ = os.RemoveAll(partial)
+		return "", fmt.Errorf("snapshot failed: %w", err)
+	}
+
+	b.logger.Info("Snapshot created", zap.String("name", name), zap.Int("linked", linked), zap.Int("copied", copied),
+		zap.Int64("written", written))
+	return final, nil
+}
+
+// latestSnapshot returns the path of the newest snapshot, or "".
+func (b *Backup) latestSnapshot() string {
+	backups, err := b.List()
+	if err != nil {
+		return ""
+	}
+	for _, bk := range backups {
+		if bk.Snapshot {
+			return bk.Path
+		}
+	}
+	return ""
+}
+
+// snapshotInfo describes the snapshot directory name if it is complete.
+// Its size is the one stored in the marker; a marker written before sizes
+// were stored gets one walk of the tree, saved back into it.
+func (b *Backup) snapshotInfo(name string) (domain.BackupInfo, bool) {
+	dir := filepath.Join(b.cfg.Paths.Backups, name)
+	markerPath := filepath.Join(dir, snapshotMarker)
+	marker, err := os.Stat(markerPath)
+	if err != nil {
+		return domain.BackupInfo{}, false
+	}
+	size, ok := markerSize(markerPath)
+	if !ok {
+		size = treeSize(dir)
+		if !b.cfg.DryRun && os.WriteFile(markerPath, fmt.Appendf(nil, "%s\n%d\n", name, size), 0o600) == nil {
+			_ = os.Chtimes(markerPath, marker.ModTime(), marker.ModTime())
+		}
+	}
+	return domain.BackupInfo{
+		Name:      name,
+		Path:      dir,
+		CreatedAt: marker.ModTime(),
+		Size:      size,
+		Snapshot:  true,
+	}, true
+}
+
+// markerSize reads the size stored in a snapshot marker.
+func markerSize(path string) (int64, bool) {
+	data, err := os.ReadFile(path) //nolint:gosec // marker in the backups folder
+	if err != nil {
+		return 0, false
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(lines[1]), 10, 64)
+	return size, err == nil
+}
+
+// treeSize adds up the regular files under dir.
+func treeSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// copyFile copies src to dst preserving mode and mtime so later snapshots
+// can recognise the file as unchanged. An existing dst is unlinked first:
+// it may be a hardlink into the artifact cache or another snapshot, which
+// writing through it would overwrite.
+func copyFile(ctx context.Context, src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src) //nolint:gosec // path from backup walk
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	if err := os.Remove(dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm()) //nolint:gosec
+	if err != nil {
+		return err
+	}
+	if _, err := copyContext(ctx, out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,98 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"craftops/internal/service"
+)
+
+func TestBackup_Snapshot_HardlinksUnchanged(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	cfg.Backup.Mode = "snapshot"
+	cfg.Backup.NameTemplate = "snap_{tag}_{timestamp}"
+	writeFile(t, cfg.Paths.Server, "world/region/r.0.0.mca", "static")
+	changing := writeFile(t, cfg.Paths.Server, "world/level.dat", "v1")
+	svc := service.NewBackup(cfg, logger)
+
+	first, err := svc.CreateTagged(ctx, "a")
+	if err != nil {
+		t.Fatalf("first snapshot: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	_ = os.Chtimes(filepath.Join(first, ".craftops-snapshot"), past, past)
+	_ = os.WriteFile(changing, []byte("v2-longer"), 0o600)
+	_ = os.Chtimes(changing, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+
+	second, err := svc.CreateTagged(ctx, "b")
+	if err != nil {
+		t.Fatalf("second snapshot: %v", err)
+	}
+
+	same := func(rel string) bool {
+		a, errA := os.Stat(filepath.Join(first, rel))
+		b, errB := os.Stat(filepath.Join(second, rel))
+		return errA == nil && errB == nil && os.SameFile(a, b)
+	}
+	if !same("world/region/r.0.0.mca") {
+		t.Error("unchanged region file should be hardlinked to previous snapshot")
+	}
+	if same("world/level.dat") {
+		t.Error("changed file must be copied, not linked")
+	}
+	data, _ := os.ReadFile(filepath.Join(second, "world/level.dat"))
+	if string(data) != "v2-longer" {
+		t.Errorf("second snapshot has stale content %q", data)
+	}
+
+	backups, err := svc.List()
+	if err != nil || len(backups) != 2 || !backups[0].Snapshot {
+		t.Fatalf("expected 2 snapshots listed, got %+v (%v)", backups, err)
+	}
+	if backups[0].Size != int64(len("v2-longer")) {
+		t.Errorf("second snapshot size = %d, want only the bytes it copied (%d)", backups[0].Size, len("v2-longer"))
+	}
+
+	// A marker from before sizes were stored is sized once and keeps its time.
+	marker := filepath.Join(second, ".craftops-snapshot")
+	info, _ := os.Stat(marker)
+	_ = os.WriteFile(marker, []byte(filepath.Base(second)+"\n"), 0o600)
+	_ = os.Chtimes(marker, info.ModTime(), info.ModTime())
+	if backups, _ = svc.List(); backups[0].Size == 0 || !backups[0].CreatedAt.Equal(info.ModTime()) {
+		t.Errorf("legacy snapshot listed as %+v", backups[0])
+	}
+	if data, _ := os.ReadFile(marker); strings.Count(string(data), "\n") != 2 {
+		t.Errorf("legacy marker not updated with the size: %q", data)
+	}
+	if err := svc.Delete(backups[1].Name); err != nil {
+		t.Fatalf("Delete snapshot: %v", err)
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Error("deleted snapshot directory still exists")
+	}
+}
+
+func TestCopyFile_ReplacesHardlink(t *testing.T) {
+	_, _, ctx := setup(t)
+	dir := t.TempDir()
+	cached := writeFile(t, dir, "cached.jar", "cached")
+	linked := filepath.Join(dir, "linked.jar")
+	if err := os.Link(cached, linked); err != nil {
+		t.Skip("hardlinks unsupported:", err)
+	}
+	src := writeFile(t, dir, "restored.jar", "restored")
+
+	if err := service.CopyFile(ctx, src, linked); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(linked); string(data) != "restored" {
+		t.Errorf("copy = %q", data)
+	}
+	if data, _ := os.ReadFile(cached); string(data) != "cached" {
+		t.Errorf("copy wrote through the hardlink: cache now %q", data)
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"path/filepath"
+
+	"craftops/internal/config"
+)
+
+// StagingConfig derives a throwaway copy of cfg under paths.cache/staging
+// for validating mod updates: its own server, mods and state directories,
+// screen session and no backups, proxy or remote host.
+func StagingConfig(cfg *config.Config) *config.Config {
+	st := *cfg
+	base := filepath.Join(cfg.Paths.Cache, "staging")
+	st.Paths.Server = filepath.Join(base, "server")
+	st.Paths.Mods = filepath.Join(base, "mods")
+	if rel, err := filepath.Rel(cfg.Paths.Server, cfg.Paths.Mods); err == nil && within(cfg.Paths.Server, cfg.Paths.Mods) {
+		st.Paths.Mods = filepath.Join(st.Paths.Server, rel)
+	}
+	st.Paths.State = base
+	st.Paths.Backups = filepath.Join(base, "backups")
+	st.Backup.Enabled = false
+	st.Server.SessionName = cfg.Server.SessionName + "-staging"
+	if cfg.Server.SessionName == "" {
+		st.Server.SessionName = defaultSessionName + "-staging"
+	}
+	st.Proxy.Enabled = false
+	st.Host, st.Path = "", ""
+	return &st
+}
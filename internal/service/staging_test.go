@@ -0,0 +1,27 @@
+package service_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestStagingConfig(t *testing.T) {
+	cfg, _, _ := setup(t)
+	cfg.Paths.Mods = filepath.Join(cfg.Paths.Server, "mods")
+	cfg.Host, cfg.Proxy.Enabled = "mc@mc1", true
+
+	st := service.StagingConfig(cfg)
+	base := filepath.Join(cfg.Paths.Cache, "staging")
+	if st.Paths.Server != filepath.Join(base, "server") || st.Paths.Mods != filepath.Join(base, "server", "mods") {
+		t.Errorf("staging paths = %+v", st.Paths)
+	}
+	if st.Server.SessionName != "minecraft-staging" || st.Backup.Enabled || st.Proxy.Enabled || st.Host != "" {
+		t.Errorf("staging config not isolated: session %q, backup %v, proxy %v, host %q",
+			st.Server.SessionName, st.Backup.Enabled, st.Proxy.Enabled, st.Host)
+	}
+	if cfg.Paths.Server == st.Paths.Server || cfg.Host == "" {
+		t.Error("StagingConfig modified the production config")
+	}
+}
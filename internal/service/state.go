@@ -0,0 +1,232 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+const (
+	stateFile  = "state.json"
+	maxCrashes = 50
+)
+
+// StateStore persists operational metadata (mod lockfile, backup index,
+// last-success timestamps, crash history) as JSON under Paths.State.
+// A nil *StateStore is valid: reads return empty state and writes are no-ops.
+// Stores on the same file share its lock, and updates also take an OS lock
+// on state.json.lock, so writes serialize across services and across
+// craftops processes (serve, cron jobs), while dry-run and the event log
+// stay those of each store's config.
+type StateStore struct {
+	file   *lockedFile
+	dryRun bool
+	events *EventLog
+}
+
+// lockedFile is a state file and the lock every store on it takes.
+type lockedFile struct {
+	path string
+	mu   sync.Mutex
+}
+
+// lock takes the in-process lock and an exclusive flock on the file's
+// ".lock" companion, held by other processes' updates; unlock releases both.
+func (f *lockedFile) lock() (unlock func(), err error) {
+	f.mu.Lock()
+	defer func() {
+		if err != nil {
+			f.mu.Unlock()
+		}
+	}()
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+	lf, err := os.OpenFile(f.path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state lock: %w", err)
+	}
+	fd := int(lf.Fd())
+	for {
+		err = syscall.Flock(fd, syscall.LOCK_EX)
+		if !errors.Is(err, syscall.EINTR) {
+			break
+		}
+	}
+	if err != nil {
+		_ = lf.Close()
+		return nil, fmt.Errorf("failed to lock state: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(fd, syscall.LOCK_UN)
+		_ = lf.Close()
+		f.mu.Unlock()
+	}, nil
+}
+
+// stateFiles holds one lockedFile per state file path.
+var stateFiles sync.Map
+
+// NewStateStore returns the store for cfg.Paths.State, or nil if unset.
+func NewStateStore(cfg *config.Config) *StateStore {
+	if cfg.Paths.State == "" {
+		return nil
+	}
+	path := filepath.Join(cfg.Paths.State, stateFile)
+	f, _ := stateFiles.LoadOrStore(path, &lockedFile{path: path})
+	return &StateStore{file: f.(*lockedFile), dryRun: cfg.DryRun, events: NewEventLog(cfg)}
+}
+
+// Path returns the state file location.
+func (s *StateStore) Path() string {
+	if s == nil {
+		return ""
+	}
+	return s.file.path
+}
+
+// Load reads the current state. A missing file yields empty state. Saves
+// replace the file by rename, so Load needs no lock against other processes.
+func (s *StateStore) Load() (*domain.State, error) {
+	if s == nil {
+		return newState(), nil
+	}
+	s.file.mu.Lock()
+	defer s.file.mu.Unlock()
+	return s.load()
+}
+
+// Update applies fn to the state and saves the result atomically.
+func (s *StateStore) Update(fn func(*domain.State)) error {
+	if s == nil || s.dryRun {
+		return nil
+	}
+	unlock, err := s.file.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+	fn(st)
+	return s.save(st)
+}
+
+// RecordSuccess stamps op with the current time and logs it as an event.
+func (s *StateStore) RecordSuccess(op string) error {
+	if err := s.Update(func(st *domain.State) { st.LastSuccess[op] = time.Now() }); err != nil {
+		return err
+	}
+	return s.RecordEvent(op, "")
+}
+
+// RecordEvent appends to the event log next to the state file.
+func (s *StateStore) RecordEvent(typ, message string) error {
+	if s == nil {
+		return nil
+	}
+	return s.events.Record(typ, message)
+}
+
+// lastSuccessCheck reports when op last succeeded, warning when that was
+// more than warnAfter ago or never (a zero warnAfter never warns).
+func (s *StateStore) lastSuccessCheck(name, op string, warnAfter time.Duration) domain.HealthCheck {
+	st, err := s.Load()
+	if err != nil {
+		return domain.HealthCheck{Name: name, Status: domain.StatusWarn, Message: err.Error()}
+	}
+	at, ok := st.LastSuccess[op]
+	switch {
+	case !ok && warnAfter > 0:
+		return domain.HealthCheck{Name: name, Status: domain.StatusWarn, Message: "None recorded"}
+	case !ok:
+		return domain.HealthCheck{Name: name, Status: domain.StatusOK, Message: "None recorded"}
+	}
+	age := time.Since(at)
+	check := domain.HealthCheck{Name: name, Status: domain.StatusOK,
+		Message: fmt.Sprintf("%s (%s)", at.Format("2006-01-02 15:04"), domain.FormatAge(age))}
+	if warnAfter > 0 && age > warnAfter {
+		check.Status = domain.StatusWarn
+		check.Message += fmt.Sprintf(", older than %s", warnAfter)
+	}
+	return check
+}
+
+// RecordCrash appends to the crash history, keeping the most recent
+// entries, and to the event log.
+func (s *StateStore) RecordCrash(reason string) error {
+	err := s.Update(func(st *domain.State) {
+		st.Crashes = append(st.Crashes, domain.CrashRecord{At: time.Now(), Reason: reason})
+		if len(st.Crashes) > maxCrashes {
+			st.Crashes = st.Crashes[len(st.Crashes)-maxCrashes:]
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return s.RecordEvent(domain.EventCrash, reason)
+}
+
+func newState() *domain.State {
+	return &domain.State{
+		Mods:        make(map[string]domain.LockedMod),
+		LastSuccess: make(map[string]time.Time),
+	}
+}
+
+func (s *StateStore) load() (*domain.State, error) {
+	st := newState()
+	data, err := os.ReadFile(s.file.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("failed to parse state %s: %w", s.file.path, err)
+	}
+	if st.Mods == nil {
+		st.Mods = make(map[string]domain.LockedMod)
+	}
+	if st.LastSuccess == nil {
+		st.LastSuccess = make(map[string]time.Time)
+	}
+	return st, nil
+}
+
+func (s *StateStore) save(st *domain.State) error {
+	if err := os.MkdirAll(filepath.Dir(s.file.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	// A temp file of its own, so a crashed or concurrent writer cannot leave
+	// a torn file to be renamed into place.
+	tmp, err := os.CreateTemp(filepath.Dir(s.file.path), stateFile+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.file.path)
+}
@@ -0,0 +1,195 @@
+package service_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestStateStore_RoundTrip(t *testing.T) {
+	cfg, _, _ := setup(t)
+	store := service.NewStateStore(cfg)
+
+	if err := store.RecordSuccess(domain.OpBackup); err != nil {
+		t.Fatalf("RecordSuccess: %v", err)
+	}
+	if err := store.RecordCrash("boom"); err != nil {
+		t.Fatalf("RecordCrash: %v", err)
+	}
+
+	st, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if st.LastSuccess[domain.OpBackup].IsZero() {
+		t.Error("expected backup timestamp to be recorded")
+	}
+	if len(st.Crashes) != 1 || st.Crashes[0].Reason != "boom" {
+		t.Errorf("unexpected crash history: %+v", st.Crashes)
+	}
+}
+
+func TestStateStore_UpdatesAcrossProcesses(t *testing.T) {
+	cfg, _, _ := setup(t)
+	stores := []*service.StateStore{service.NewProcessStateStore(cfg), service.NewProcessStateStore(cfg)}
+
+	const perStore = 20
+	var wg sync.WaitGroup
+	for i, store := range stores {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range perStore {
+				op := fmt.Sprintf("op-%d-%d", i, j)
+				if err := store.Update(func(st *domain.State) { st.LastSuccess[op] = time.Now() }); err != nil {
+					t.Errorf("Update: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	st, err := stores[0].Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(st.LastSuccess) != 2*perStore {
+		t.Errorf("kept %d of %d updates", len(st.LastSuccess), 2*perStore)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(cfg.Paths.State, "*.tmp"))
+	if len(leftovers) > 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestStateStore_DisabledAndDryRun(t *testing.T) {
+	cfg, _, _ := setup(t)
+	cfg.Paths.State = ""
+	if store := service.NewStateStore(cfg); store != nil {
+		t.Fatal("expected nil store when Paths.State is empty")
+	}
+	var nilStore *service.StateStore
+	if st, err := nilStore.Load(); err != nil || st.Mods == nil {
+		t.Errorf("nil store Load() = %+v, %v", st, err)
+	}
+
+	cfg, _, _ = setup(t)
+	cfg.DryRun = true
+	store := service.NewStateStore(cfg)
+	_ = store.RecordSuccess(domain.OpStart)
+	if _, err := os.Stat(store.Path()); !os.IsNotExist(err) {
+		t.Error("dry run should not write the state file")
+	}
+
+	// Dry run belongs to each store, not to every store on the same file.
+	live := *cfg
+	live.DryRun = false
+	if err := service.NewStateStore(&live).RecordSuccess(domain.OpStart); err != nil {
+		t.Fatalf("RecordSuccess: %v", err)
+	}
+	_ = store.RecordSuccess(domain.OpBackup)
+	st, err := store.Load()
+	if err != nil || st.LastSuccess[domain.OpStart].IsZero() || !st.LastSuccess[domain.OpBackup].IsZero() {
+		t.Errorf("stores on one file mixed their dry run: %+v, %v", st.LastSuccess, err)
+	}
+}
+
+func TestStateStore_CorruptFile(t *testing.T) {
+	cfg, _, _ := setup(t)
+	_ = os.MkdirAll(cfg.Paths.State, 0o750)
+	_ = os.WriteFile(filepath.Join(cfg.Paths.State, "state.json"), []byte("{not json"), 0o600)
+
+	if _, err := service.NewStateStore(cfg).Load(); err == nil {
+		t.Error("expected error for corrupt state file")
+	}
+}
+
+func TestBackup_RecordsIndex(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	cfg.Backup.Enabled = true
+	writeFile(t, cfg.Paths.Server, "level.dat", "x")
+	svc := service.NewBackup(cfg, logger)
+
+	path, err := svc.Create(ctx)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	st, _ := service.NewStateStore(cfg).Load()
+	if len(st.Backups) != 1 || st.Backups[0].Path != path {
+		t.Fatalf("expected backup in index, got %+v", st.Backups)
+	}
+
+	if err := svc.Delete(filepath.Base(path)); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	st, _ = service.NewStateStore(cfg).Load()
+	if len(st.Backups) != 0 {
+		t.Errorf("expected index entry removed, got %+v", st.Backups)
+	}
+}
+
+func TestMods_UpdateAll_WritesLockfile(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	srv := newMockModrinth(t, "/v2/project/sodium/version", "/files/mod-1.0.0.jar", []byte("JAR"))
+	cfg.Mods.ModrinthSources = []string{"sodium"}
+	cfg.Mods.MaxRetries = 0
+	cfg.Mods.Timeout = 5
+
+	if _, err := service.NewModsWithBaseURL(cfg, logger, srv.URL).UpdateAll(ctx, false); err != nil {
+		t.Fatalf("UpdateAll: %v", err)
+	}
+	st, _ := service.NewStateStore(cfg).Load()
+	lock, ok := st.Mods["sodium"]
+	if !ok || lock.Filename != "mod-1.0.0.jar" || lock.SHA1 == "" {
+		t.Errorf("unexpected lockfile entry: %+v", lock)
+	}
+	if st.LastSuccess[domain.OpModUpdate].IsZero() {
+		t.Error("expected mod update timestamp")
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	cfg, _, _ := setup(t)
+	store := service.NewStateStore(cfg)
+	if err := store.RecordSuccess(domain.OpRestart); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordCrash("server exited during startup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordEvent(domain.OpBackup, "world.tar.gz (1.0 MB)"); err != nil {
+		t.Fatal(err)
+	}
+
+	log := service.NewEventLog(cfg)
+	events, err := log.Query(time.Time{}, nil)
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(events) != 3 || events[0].Type != domain.OpBackup || events[2].Type != domain.OpRestart {
+		t.Fatalf("events = %+v, want backup, crash, restart (newest first)", events)
+	}
+	if events, _ := log.Query(time.Time{}, []string{domain.EventCrash}); len(events) != 1 || events[0].Message != "server exited during startup" {
+		t.Errorf("crash events = %+v", events)
+	}
+	if events, _ := log.Query(time.Now().Add(time.Minute), nil); len(events) != 0 {
+		t.Errorf("events after now = %+v, want none", events)
+	}
+
+	// A dry-run log on the same file does not silence this one.
+	dry := *cfg
+	dry.DryRun = true
+	_ = service.NewEventLog(&dry).Record(domain.OpBackup, "dry run")
+	if err := log.Record(domain.OpBackup, "real"); err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := log.Query(time.Time{}, nil); len(events) != 4 || events[0].Message != "real" {
+		t.Errorf("events after a dry-run log was opened = %+v", events)
+	}
+}
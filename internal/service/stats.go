@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"craftops/internal/domain"
+)
+
+// regionHeaderSize is the chunk location table at the start of an anvil
+// region file: 1024 four-byte entries, zero for chunks never generated.
+const regionHeaderSize = 4096
+
+// Stats reads the world folders and player statistics in paths.server for
+// a capacity overview. It only reads files, so the server may be running.
+func (s *Server) Stats(ctx context.Context) (*domain.WorldStats, error) {
+	level := levelName(s.cfg.Paths.Server)
+	root := func(rel string) string { return filepath.Join(s.cfg.Paths.Server, filepath.FromSlash(rel)) }
+	stats := &domain.WorldStats{Level: level}
+
+	for _, dir := range []string{level, level + "_nether", level + "_the_end"} {
+		n, err := dirSize(ctx, root(dir))
+		if err != nil {
+			return nil, err
+		}
+		stats.WorldBytes += n
+	}
+
+	for _, dim := range worldDimensions(s.cfg.Paths.Server, level) {
+		d, err := dimensionStats(ctx, root(dim.dir))
+		if err != nil {
+			return nil, err
+		}
+		d.Name, d.Path = dim.name, dim.dir
+		stats.Dimensions = append(stats.Dimensions, d)
+	}
+
+	players, _ := filepath.Glob(filepath.Join(root(level), "playerdata", "*.dat"))
+	stats.Players = len(players)
+	files, _ := filepath.Glob(filepath.Join(root(level), "stats", "*.json"))
+	for _, f := range files {
+		stats.Playtime += playtime(f)
+	}
+	return stats, ctx.Err()
+}
+
+// dimension is a world folder present on disk; dir is relative to the
+// server directory.
+type dimension struct {
+	name, dir string
+}
+
+// worldDimensions lists the overworld, nether, end and datapack dimensions
+// of level that exist in serverDir.
+func worldDimensions(serverDir, level string) []dimension {
+	names := []string{"overworld", "nether", "end"}
+	custom, _ := filepath.Glob(filepath.Join(serverDir, level, "dimensions", "*", "*"))
+	for _, dir := range custom {
+		rel, _ := filepath.Rel(filepath.Join(serverDir, level, "dimensions"), dir)
+		names = append(names, strings.Replace(filepath.ToSlash(rel), "/", ":", 1))
+	}
+	var dims []dimension
+	for _, name := range names {
+		dirs, err := dimensionDirs(level, name)
+		if err != nil {
+			continue
+		}
+		for _, dir := range dirs {
+			if _, err := os.Stat(filepath.Join(serverDir, filepath.FromSlash(dir))); err == nil {
+				dims = append(dims, dimension{name: name, dir: dir})
+				break
+			}
+		}
+	}
+	return dims
+}
+
+// dimensionStats counts the region files of one dimension folder and the
+// chunks their headers list.
+func dimensionStats(ctx context.Context, dir string) (domain.DimensionStats, error) {
+	var d domain.DimensionStats
+	for _, kind := range regionKinds {
+		n, err := dirSize(ctx, filepath.Join(dir, kind))
+		if err != nil {
+			return d, err
+		}
+		d.Bytes += n
+	}
+	regions, _ := filepath.Glob(filepath.Join(dir, "region", "*.mca"))
+	for _, r := range regions {
+		d.Regions++
+		d.Chunks += regionChunks(r)
+	}
+	entities, _ := filepath.Glob(filepath.Join(dir, "entities", "*.mca"))
+	for _, r := range entities {
+		d.EntityRegions++
+		d.EntityChunks += regionChunks(r)
+	}
+	return d, ctx.Err()
+}
+
+// regionChunks returns the number of chunks stored in a region file.
+func regionChunks(path string) int {
+	f, err := os.Open(path) //nolint:gosec // world folder from config
+	if err != nil {
+		return 0
+	}
+	defer func() { _ = f.Close() }()
+	header := make([]byte, regionHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return 0
+	}
+	n := 0
+	for i := 0; i < regionHeaderSize; i += 4 {
+		if binary.BigEndian.Uint32(header[i:]) != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// playtime reads a player's time played from a stats file. Worlds from
+// before 1.17 call the statistic play_one_minute, though it counts ticks too.
+func playtime(path string) time.Duration {
+	data, err := os.ReadFile(path) //nolint:gosec // world folder from config
+	if err != nil {
+		return 0
+	}
+	var st struct {
+		Stats struct {
+			Custom map[string]int64 `json:"minecraft:custom"`
+		} `json:"stats"`
+	}
+	if json.Unmarshal(data, &st) != nil {
+		return 0
+	}
+	ticks := st.Stats.Custom["minecraft:play_time"]
+	if ticks == 0 {
+		ticks = st.Stats.Custom["minecraft:play_one_minute"]
+	}
+	return time.Duration(ticks) * time.Second / 20
+}
+
+// dirSize sums the sizes of the regular files under dir; a missing dir is
+// empty.
+func dirSize(ctx context.Context, dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total, err
+}
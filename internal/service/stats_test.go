@@ -0,0 +1,60 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"craftops/internal/service"
+)
+
+// regionFile returns an anvil region file holding chunks chunks.
+func regionFile(chunks int) string {
+	header := make([]byte, 8192)
+	for i := range chunks {
+		header[i*4+3] = 1 // sector count
+	}
+	return string(header)
+}
+
+func TestServer_Stats(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Server, "server.properties", "level-name=survival\n")
+	writeFile(t, cfg.Paths.Server, "survival/region/r.0.0.mca", regionFile(3))
+	writeFile(t, cfg.Paths.Server, "survival/region/r.0.1.mca", regionFile(1))
+	writeFile(t, cfg.Paths.Server, "survival/region/r.1.1.mca", "") // never written
+	writeFile(t, cfg.Paths.Server, "survival/entities/r.0.0.mca", regionFile(2))
+	writeFile(t, cfg.Paths.Server, "survival/DIM-1/region/r.0.0.mca", regionFile(1))
+	writeFile(t, cfg.Paths.Server, "survival/dimensions/mypack/mining/region/r.0.0.mca", regionFile(5))
+	writeFile(t, cfg.Paths.Server, "survival/playerdata/a.dat", "x")
+	writeFile(t, cfg.Paths.Server, "survival/playerdata/b.dat", "x")
+	writeFile(t, cfg.Paths.Server, "survival/stats/a.json", `{"stats":{"minecraft:custom":{"minecraft:play_time":72000}}}`)
+	writeFile(t, cfg.Paths.Server, "survival/stats/b.json", `{"stats":{"minecraft:custom":{"minecraft:play_one_minute":36000}}}`)
+
+	stats, err := service.NewServer(cfg, logger).Stats(ctx)
+	if err != nil {
+		t.Fatalf("Stats: %v", err)
+	}
+	if stats.Level != "survival" || stats.Players != 2 || stats.Playtime != 90*time.Minute {
+		t.Errorf("level=%s players=%d playtime=%s", stats.Level, stats.Players, stats.Playtime)
+	}
+	if stats.WorldBytes < 5*8192 {
+		t.Errorf("WorldBytes = %d, want at least the five region files", stats.WorldBytes)
+	}
+	var got []string
+	for _, d := range stats.Dimensions {
+		got = append(got, strings.Join([]string{d.Name, d.Path}, "@"))
+	}
+	if len(stats.Dimensions) != 3 {
+		t.Fatalf("dimensions = %v", got)
+	}
+	if o := stats.Dimensions[0]; o.Name != "overworld" || o.Regions != 3 || o.Chunks != 4 || o.EntityRegions != 1 || o.EntityChunks != 2 {
+		t.Errorf("overworld = %+v", o)
+	}
+	if n := stats.Dimensions[1]; n.Name != "nether" || n.Path != "survival/DIM-1" || n.Chunks != 1 {
+		t.Errorf("nether = %+v", n)
+	}
+	if c := stats.Dimensions[2]; c.Name != "mypack:mining" || c.Chunks != 5 {
+		t.Errorf("custom dimension = %+v", c)
+	}
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1" //nolint:gosec // names a directory, not a security check
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// Sync keeps the local config in step with a git repository.
+type Sync struct {
+	cfg    *config.Config
+	logger *zap.Logger
+}
+
+// NewSync creates a Sync service.
+func NewSync(cfg *config.Config, logger *zap.Logger) *Sync {
+	return &Sync{cfg: cfg, logger: logger}
+}
+
+// syncLocal are the config sections Apply keeps from the local file: they
+// describe this host rather than the server setup the repository shares.
+var syncLocal = []string{"paths", "api", "sync"}
+
+// Pull clones or fast-forwards the checkout of Sync.Repo to the tip of
+// Sync.Branch, discarding local edits, and returns the checked-out commit.
+// A checkout of another repository, after sync.repo changed, is replaced
+// by a fresh clone.
+func (s *Sync) Pull(ctx context.Context) (string, error) {
+	sc := s.cfg.Sync
+	if sc.Repo == "" {
+		return "", domain.ErrSyncNotConfigured
+	}
+	dir := s.dir()
+	clone := true
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		origin, err := git(ctx, dir, "remote", "get-url", "origin")
+		clone = err != nil || origin != sc.Repo
+		if clone {
+			s.logger.Info("Sync repository changed, cloning it again", zap.String("was", origin), zap.String("repo", sc.Repo))
+			if err := os.RemoveAll(dir); err != nil {
+				return "", fmt.Errorf("sync.pull: %w", err)
+			}
+		}
+	}
+	if clone {
+		s.logger.Info("Cloning sync repository", zap.String("repo", sc.Repo), zap.String("dir", dir))
+		if err := os.MkdirAll(filepath.Dir(dir), 0o750); err != nil {
+			return "", fmt.Errorf("sync.pull: %w", err)
+		}
+		if _, err := git(ctx, "", "clone", "--branch", sc.Branch, "--single-branch", sc.Repo, dir); err != nil {
+			return "", err
+		}
+	} else {
+		s.logger.Info("Fetching sync repository", zap.String("repo", sc.Repo), zap.String("branch", sc.Branch))
+		if _, err := git(ctx, dir, "fetch", "origin", sc.Branch); err != nil {
+			return "", err
+		}
+		if _, err := git(ctx, dir, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+	return git(ctx, dir, "rev-parse", "--short", "HEAD")
+}
+
+// dir is sync.dir, or a checkout per config file under the user's data
+// directory, so profiles syncing from different repositories never share
+// one.
+func (s *Sync) dir() string {
+	if s.cfg.Sync.Dir != "" {
+		return s.cfg.Sync.Dir
+	}
+	home, _ := os.UserHomeDir()
+	abs, _ := filepath.Abs(s.cfg.Path)
+	sum := sha1.Sum([]byte(abs)) //nolint:gosec // names a directory, not a security check
+	name := strings.TrimSuffix(filepath.Base(abs), filepath.Ext(abs))
+	return filepath.Join(home, ".local", "share", "craftops", "sync", name+"-"+hex.EncodeToString(sum[:4]))
+}
+
+// Apply validates the repository's config file and merges its sections
+// into the local config file. The host's own sections (see syncLocal) and
+// top-level settings keep their local values, and sections the repository
+// leaves out stay as they are. Like a migration, the merge re-encodes the
+// local file and drops its comments. It returns the new config.
+func (s *Sync) Apply() (*config.Config, error) {
+	if s.cfg.Path == "" {
+		return nil, errors.New("sync.apply: no local config file to update (pass --config)")
+	}
+	src := filepath.Join(s.dir(), s.cfg.Sync.ConfigFile)
+	if _, err := config.LoadConfig(src); err != nil {
+		return nil, fmt.Errorf("sync.apply: %w", err)
+	}
+	repo, err := config.ReadTables(src)
+	if err != nil {
+		return nil, fmt.Errorf("sync.apply: %w", err)
+	}
+	local, err := os.ReadFile(s.cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("sync.apply: %w", err)
+	}
+	merged, err := config.PatchFile(local, func(raw map[string]any) {
+		for key, v := range repo {
+			switch v.(type) {
+			case map[string]any, []map[string]any:
+				if !slices.Contains(syncLocal, key) {
+					raw[key] = v
+				}
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sync.apply: %w", err)
+	}
+	next, err := s.validateMerged(merged)
+	if err != nil {
+		return nil, fmt.Errorf("sync.apply: %w", err)
+	}
+	next.Path = s.cfg.Path
+	next.Debug, next.DryRun, next.Offline = s.cfg.Debug, s.cfg.DryRun, s.cfg.Offline
+	next.Quiet, next.Verbose = s.cfg.Quiet, s.cfg.Verbose
+
+	if s.cfg.DryRun {
+		s.logger.Info("Dry run: would apply synced config", zap.String("from", src), zap.String("to", s.cfg.Path))
+		return next, nil
+	}
+	if err := config.WriteFile(s.cfg.Path, merged); err != nil {
+		return nil, fmt.Errorf("sync.apply: %w", err)
+	}
+	s.logger.Info("Applied synced config", zap.String("from", src), zap.String("to", s.cfg.Path))
+	return next, nil
+}
+
+// Manifest reads sync.manifest_file from the checkout: the mod set the
+// repository pins. It returns nil when the repository has none.
+func (s *Sync) Manifest() (*domain.ModManifest, error) {
+	if s.cfg.Sync.ManifestFile == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir(), s.cfg.Sync.ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("sync.manifest: %w", err)
+	}
+	var manifest domain.ModManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("sync.manifest: invalid %s: %w", s.cfg.Sync.ManifestFile, err)
+	}
+	return &manifest, nil
+}
+
+// validateMerged loads the merged config from a temporary file next to the
+// local one, so relative paths in it resolve the same.
+func (s *Sync) validateMerged(data []byte) (*config.Config, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(s.cfg.Path), ".tmp-sync-*.toml")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return config.LoadConfig(tmp.Name())
+}
+
+// git runs a git subcommand in dir and returns its trimmed stdout.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,115 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestSync_PullAndApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	cfg, logger, ctx := setup(t)
+	tmp := t.TempDir()
+
+	repo := filepath.Join(tmp, "repo")
+	writeFile(t, repo, "servers/survival.toml", "[mods]\nmodrinth_sources = [\"https://modrinth.com/mod/sodium\"]\n")
+	gitRun(t, repo, "init", "-q", "-b", "main")
+	gitRun(t, repo, "add", ".")
+	gitRun(t, repo, "commit", "-q", "-m", "initial")
+
+	checkout := filepath.Join(tmp, "checkout")
+	cfg.Path = writeFile(t, tmp, "config.toml", fmt.Sprintf("[paths]\nserver = %q\n[sync]\nrepo = %q\nconfig_file = %q\ndir = %q\n",
+		cfg.Paths.Server, repo, "servers/survival.toml", checkout))
+	cfg.Sync = config.SyncConfig{Repo: repo, Branch: "main", ConfigFile: "servers/survival.toml", Dir: checkout}
+	cfg.DryRun = false
+	cfg.Debug = true // a command-line override, not in the file
+	s := service.NewSync(cfg, logger)
+
+	if _, err := s.Pull(ctx); err != nil {
+		t.Fatalf("Pull (clone): %v", err)
+	}
+	writeFile(t, repo, "servers/survival.toml", "[paths]\nserver = \"/elsewhere\"\n[mods]\nmodrinth_sources = [\"https://modrinth.com/mod/lithium\"]\n")
+	gitRun(t, repo, "commit", "-q", "-am", "swap mods")
+	if _, err := s.Pull(ctx); err != nil {
+		t.Fatalf("Pull (fetch): %v", err)
+	}
+
+	next, err := s.Apply()
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(next.Mods.ModrinthSources) != 1 || next.Mods.ModrinthSources[0] != "https://modrinth.com/mod/lithium" {
+		t.Errorf("synced sources = %v", next.Mods.ModrinthSources)
+	}
+	saved, err := config.LoadConfig(cfg.Path)
+	if err != nil {
+		t.Fatalf("LoadConfig(applied): %v", err)
+	}
+	if saved.Sync.Repo != repo || saved.Mods.ModrinthSources[0] != "https://modrinth.com/mod/lithium" {
+		t.Errorf("applied config lost local [sync] or synced mods: %+v", saved.Sync)
+	}
+	if saved.Paths.Server != cfg.Paths.Server || saved.Debug {
+		t.Errorf("applied config replaced local paths (%s) or saved overrides (debug %v)", saved.Paths.Server, saved.Debug)
+	}
+
+	// A changed sync.repo replaces the checkout instead of fetching the old one.
+	other := filepath.Join(tmp, "other")
+	writeFile(t, other, "servers/survival.toml", "[mods]\nmodrinth_sources = [\"https://modrinth.com/mod/iris\"]\n")
+	gitRun(t, other, "init", "-q", "-b", "main")
+	gitRun(t, other, "add", ".")
+	gitRun(t, other, "commit", "-q", "-m", "initial")
+	cfg.Sync.Repo = other
+	if _, err := s.Pull(ctx); err != nil {
+		t.Fatalf("Pull (new repo): %v", err)
+	}
+	if next, err := s.Apply(); err != nil || next.Mods.ModrinthSources[0] != "https://modrinth.com/mod/iris" {
+		t.Errorf("Apply after repo change: %v, %+v", err, next)
+	}
+}
+
+func TestSync_NotConfigured(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	if _, err := service.NewSync(cfg, logger).Pull(ctx); !errors.Is(err, domain.ErrSyncNotConfigured) {
+		t.Errorf("Pull without repo: got %v, want ErrSyncNotConfigured", err)
+	}
+}
+
+func TestSync_Manifest(t *testing.T) {
+	cfg, logger, _ := setup(t)
+	checkout := t.TempDir()
+	cfg.Sync = config.SyncConfig{Dir: checkout, ManifestFile: "servers/survival.json"}
+	s := service.NewSync(cfg, logger)
+	if m, err := s.Manifest(); m != nil || err != nil {
+		t.Errorf("Manifest without the file = %v, %v; want nil", m, err)
+	}
+
+	writeFile(t, checkout, "servers/survival.json",
+		`{"minecraft_version":"1.20.1","modloader":"fabric","mods":[{"slug":"lithium","version_id":"AABBccDD","filename":"lithium.jar"}]}`)
+	m, err := s.Manifest()
+	if err != nil || m == nil || len(m.Mods) != 1 || m.Mods[0].Slug != "lithium" {
+		t.Fatalf("Manifest = %+v, %v", m, err)
+	}
+
+	writeFile(t, checkout, "servers/survival.json", "{")
+	if _, err := s.Manifest(); err == nil {
+		t.Error("expected error for an invalid manifest")
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+)
+
+// templateSuffix marks the files under templates.dir that are rendered.
+const templateSuffix = ".tmpl"
+
+// templateFuncs are available to templates besides the text/template
+// builtins: env reads an environment variable (for secrets kept out of the
+// config), default substitutes a fallback for an empty value and json quotes
+// a value for ops.json and similar files.
+var templateFuncs = template.FuncMap{
+	"env": os.Getenv,
+	"default": func(fallback, v any) any {
+		if v == nil || v == "" {
+			return fallback
+		}
+		return v
+	},
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// templateData is what templates see as dot.
+type templateData struct {
+	Values map[string]any
+	Config *config.Config
+}
+
+// renderedFile is a template's output destined for Target in the server dir.
+type renderedFile struct {
+	Target string
+	Data   []byte
+	Mode   fs.FileMode
+}
+
+// RenderTemplates renders every template under templates.dir into
+// paths.server and returns the targets, relative to paths.server, whose
+// content changed. All templates are rendered before any file is written,
+// so a broken template leaves the server untouched. Dry runs only report.
+func (s *Server) RenderTemplates() ([]string, error) {
+	t := s.cfg.Templates
+	if t.Dir == "" {
+		return nil, nil
+	}
+	files, err := renderTemplates(t.Dir, templateData{Values: t.Values, Config: s.cfg})
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, f := range files {
+		path := filepath.Join(s.cfg.Paths.Server, f.Target)
+		if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, f.Data) { //nolint:gosec // server dir from config
+			continue
+		}
+		if s.cfg.DryRun {
+			s.logger.Info("Dry run: Would render template", zap.String("file", f.Target))
+		} else if err := writeFileAtomic(path, f.Data, f.Mode); err != nil {
+			return changed, fmt.Errorf("templates: %s: %w", f.Target, err)
+		} else {
+			s.logger.Info("Rendered template", zap.String("file", f.Target))
+		}
+		changed = append(changed, f.Target)
+	}
+	return changed, nil
+}
+
+// renderTemplates executes the *.tmpl files under dir with data.
+func renderTemplates(dir string, data templateData) ([]renderedFile, error) {
+	var files []renderedFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, templateSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		src, err := os.ReadFile(path) //nolint:gosec // templates dir from config
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(rel).Funcs(templateFuncs).Option("missingkey=error").Parse(string(src))
+		if err != nil {
+			return fmt.Errorf("templates: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return fmt.Errorf("templates: %w", err)
+		}
+		files = append(files, renderedFile{
+			Target: strings.TrimSuffix(rel, templateSuffix),
+			Data:   buf.Bytes(),
+			Mode:   info.Mode().Perm(),
+		})
+		return nil
+	})
+	return files, err
+}
+
+// writeFileAtomic replaces path with data through a temporary file, keeping
+// the mode of an existing file and otherwise using mode.
+func writeFileAtomic(path string, data []byte, mode fs.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,59 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestServer_RenderTemplates(t *testing.T) {
+	cfg, logger, _ := setup(t)
+	cfg.Templates.Dir = t.TempDir()
+	cfg.Templates.Values = map[string]any{"motd": "Survival", "max_players": int64(20), "ops": []any{"alice"}}
+	t.Setenv("RCON_PASSWORD", "hunter2")
+	writeFile(t, cfg.Templates.Dir, "server.properties.tmpl",
+		"motd={{ .Values.motd }}\nmax-players={{ .Values.max_players }}\nrcon.password={{ env \"RCON_PASSWORD\" }}\n")
+	writeFile(t, cfg.Templates.Dir, "ops.json.tmpl",
+		"[{{ range $i, $name := .Values.ops }}{{ if $i }},{{ end }}{\"name\":{{ json $name }}}{{ end }}]\n")
+	writeFile(t, cfg.Templates.Dir, "config/loader.txt.tmpl", "{{ .Config.Minecraft.Modloader }}\n")
+	writeFile(t, cfg.Templates.Dir, "README.md", "not a template\n")
+	srv := service.NewServer(cfg, logger)
+
+	changed, err := srv.RenderTemplates()
+	if err != nil {
+		t.Fatalf("RenderTemplates: %v", err)
+	}
+	slices.Sort(changed)
+	if want := []string{"config/loader.txt", "ops.json", "server.properties"}; !slices.Equal(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	for file, want := range map[string]string{
+		"server.properties": "motd=Survival\nmax-players=20\nrcon.password=hunter2\n",
+		"ops.json":          "[{\"name\":\"alice\"}]\n",
+		"config/loader.txt": cfg.Minecraft.Modloader + "\n",
+	} {
+		if data, _ := os.ReadFile(filepath.Join(cfg.Paths.Server, file)); string(data) != want { //nolint:gosec
+			t.Errorf("%s = %q, want %q", file, data, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Server, "README.md")); !os.IsNotExist(err) {
+		t.Errorf("non-template file copied: %v", err)
+	}
+
+	if changed, err = srv.RenderTemplates(); err != nil || len(changed) != 0 {
+		t.Errorf("second render changed %v (err %v), want nothing", changed, err)
+	}
+
+	// A missing value fails before anything is written.
+	writeFile(t, cfg.Templates.Dir, "server.properties.tmpl", "motd={{ .Values.motd }}!\n")
+	writeFile(t, cfg.Templates.Dir, "whitelist.json.tmpl", "{{ .Values.whitelist }}\n")
+	if _, err = srv.RenderTemplates(); err == nil {
+		t.Fatal("expected an error for a missing value")
+	}
+	if data, _ := os.ReadFile(filepath.Join(cfg.Paths.Server, "server.properties")); string(data) != "motd=Survival\nmax-players=20\nrcon.password=hunter2\n" { //nolint:gosec
+		t.Errorf("server.properties rewritten despite a failing template: %q", data)
+	}
+}
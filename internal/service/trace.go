@@ -0,0 +1,256 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+)
+
+// maxBufferedSpans caps the spans held between exports; beyond it the
+// oldest are dropped, so an unreachable collector cannot grow a
+// long-running serve without bound.
+const maxBufferedSpans = 2048
+
+// Tracer collects OpenTelemetry spans for long operations and exports them
+// to an OTLP/HTTP collector (JSON encoding) on Flush, or periodically while
+// Export runs. A nil *Tracer is valid and records nothing.
+type Tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	client   *http.Client
+	logger   *zap.Logger
+
+	mu      sync.Mutex
+	spans   []*Span
+	dropped int
+}
+
+// activeTracer receives spans started anywhere in the service layer.
+var activeTracer atomic.Pointer[Tracer]
+
+// NewTracer returns a tracer for [telemetry], or nil when it is disabled.
+func NewTracer(cfg *config.Config, logger *zap.Logger) *Tracer {
+	if !cfg.Telemetry.Enabled {
+		return nil
+	}
+	client, err := newHTTPClient(cfg, 10*time.Second)
+	if err != nil {
+		logger.Warn("Network settings not applied to OTLP client", zap.Error(err))
+	}
+	return &Tracer{
+		endpoint: strings.TrimSuffix(cfg.Telemetry.Endpoint, "/") + "/v1/traces",
+		headers:  cfg.Telemetry.Headers,
+		service:  cfg.Telemetry.ServiceName,
+		client:   client,
+		logger:   logger,
+	}
+}
+
+// UseTracer makes t receive spans from subsequent operations.
+func UseTracer(t *Tracer) { activeTracer.Store(t) }
+
+// Span is one timed operation. A nil *Span is valid and ignores all calls.
+type Span struct {
+	tracer  *Tracer
+	traceID string
+	spanID  string
+	parent  string
+	name    string
+	start   time.Time
+	end     time.Time
+	err     error
+
+	mu    sync.Mutex
+	attrs map[string]any
+}
+
+type spanKey struct{}
+
+// startSpan begins a span named name as a child of the span in ctx, if any.
+// attrs are key/value pairs.
+func startSpan(ctx context.Context, name string, attrs ...any) (context.Context, *Span) {
+	t := activeTracer.Load()
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{tracer: t, spanID: randomHex(8), name: name, start: time.Now(), attrs: make(map[string]any)}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok && parent != nil {
+		s.traceID, s.parent = parent.traceID, parent.spanID
+	} else {
+		s.traceID = randomHex(16)
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		s.set(fmt.Sprint(attrs[i]), attrs[i+1])
+	}
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// set records an attribute on the span.
+func (s *Span) set(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs[key] = value
+}
+
+// finish ends the span, marking it failed if err is non-nil.
+func (s *Span) finish(err error) {
+	if s == nil {
+		return
+	}
+	s.end, s.err = time.Now(), err
+	t := s.tracer
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.spans) >= maxBufferedSpans {
+		t.spans = append(t.spans[:0], t.spans[1:]...)
+		t.dropped++
+	}
+	t.spans = append(t.spans, s)
+}
+
+// Export flushes the finished spans every interval until ctx is done, for
+// commands that run until killed such as serve. Failed exports are logged
+// and their spans dropped.
+func (t *Tracer) Export(ctx context.Context, interval time.Duration) {
+	if t == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil && ctx.Err() == nil {
+				t.logger.Warn("Failed to export traces", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Flush exports the finished spans and clears them.
+func (t *Tracer) Flush(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans, dropped := t.spans, t.dropped
+	t.spans, t.dropped = nil, 0
+	t.mu.Unlock()
+	if dropped > 0 {
+		t.logger.Warn("Dropped spans over the export buffer", zap.Int("count", dropped))
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(t.payload(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp export: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp export: collector returned %s", resp.Status)
+	}
+	t.logger.Debug("Exported spans", zap.Int("count", len(spans)), zap.String("endpoint", t.endpoint))
+	return nil
+}
+
+type otlpAttr struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+// payload builds an ExportTraceServiceRequest in OTLP's JSON mapping.
+func (t *Tracer) payload(spans []*Span) map[string]any {
+	host, _ := os.Hostname()
+	out := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		status := map[string]any{"code": 1}
+		if s.err != nil {
+			status = map[string]any{"code": 2, "message": s.err.Error()}
+		}
+		span := map[string]any{
+			"traceId":           s.traceID,
+			"spanId":            s.spanID,
+			"name":              s.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+			"attributes":        otlpAttrs(s.attrs),
+			"status":            status,
+		}
+		if s.parent != "" {
+			span["parentSpanId"] = s.parent
+		}
+		out = append(out, span)
+	}
+	return map[string]any{"resourceSpans": []any{map[string]any{
+		"resource": map[string]any{"attributes": otlpAttrs(map[string]any{
+			"service.name": t.service,
+			"host.name":    host,
+		})},
+		"scopeSpans": []any{map[string]any{
+			"scope": map[string]any{"name": "craftops"},
+			"spans": out,
+		}},
+	}}}
+}
+
+func otlpAttrs(attrs map[string]any) []otlpAttr {
+	out := make([]otlpAttr, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]any
+		switch v := v.(type) {
+		case bool:
+			value = map[string]any{"boolValue": v}
+		case int:
+			value = map[string]any{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]any{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]any{"doubleValue": v}
+		default:
+			value = map[string]any{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, otlpAttr{Key: k, Value: value})
+	}
+	return out
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,100 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"craftops/internal/service"
+)
+
+func TestTracer_ExportsBackupSpan(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Server, "server.properties", "motd=hi\n")
+
+	var body []byte
+	var apiKey string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" {
+			http.NotFound(w, r)
+			return
+		}
+		apiKey = r.Header.Get("X-Api-Key")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer collector.Close()
+
+	cfg.Telemetry.Enabled = true
+	cfg.Telemetry.Endpoint = collector.URL
+	cfg.Telemetry.Headers = map[string]string{"X-Api-Key": "k"}
+	tracer := service.NewTracer(cfg, logger)
+	service.UseTracer(tracer)
+	t.Cleanup(func() { service.UseTracer(nil) })
+
+	if _, err := service.NewBackup(cfg, logger).Create(ctx); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := tracer.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	var req struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name    string `json:"name"`
+					TraceID string `json:"traceId"`
+					Status  struct {
+						Code int `json:"code"`
+					} `json:"status"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		t.Fatalf("collector got invalid JSON: %v\n%s", err, body)
+	}
+	if apiKey != "k" {
+		t.Errorf("configured header not sent, got %q", apiKey)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].Name != "backup.create" || len(spans[0].TraceID) != 32 || spans[0].Status.Code != 1 {
+		t.Errorf("unexpected spans: %+v", spans)
+	}
+}
+
+func TestTracer_ExportSendsSpansPeriodically(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	writeFile(t, cfg.Paths.Server, "server.properties", "motd=hi\n")
+
+	exported := make(chan struct{}, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		select {
+		case exported <- struct{}{}:
+		default:
+		}
+	}))
+	defer collector.Close()
+
+	cfg.Telemetry.Enabled = true
+	cfg.Telemetry.Endpoint = collector.URL
+	tracer := service.NewTracer(cfg, logger)
+	service.UseTracer(tracer)
+	t.Cleanup(func() { service.UseTracer(nil) })
+
+	exportCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go tracer.Export(exportCtx, 10*time.Millisecond)
+	if _, err := service.NewBackup(cfg, logger).Create(ctx); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	select {
+	case <-exported:
+	case <-time.After(5 * time.Second):
+		t.Fatal("spans were not exported without Flush")
+	}
+}
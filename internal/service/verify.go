@@ -0,0 +1,127 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/domain"
+)
+
+// Verify hashes every jar in the mods directory against the lockfile and
+// reports each lock entry plus any untracked jars and leftover partial
+// downloads.
+func (m *Mods) Verify(ctx context.Context) ([]domain.ModIntegrity, error) {
+	st, err := m.state.Load()
+	if err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(m.cfg.ModsDir())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read mods directory: %w", err)
+	}
+
+	var report []domain.ModIntegrity
+	tracked := make(map[string]bool, len(st.Mods))
+	for _, project := range slices.Sorted(maps.Keys(st.Mods)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		lm := st.Mods[project]
+		tracked[lm.Filename] = true
+		report = append(report, m.verifyLocked(project, lm))
+	}
+	for _, f := range files {
+		name := f.Name()
+		switch {
+		case f.IsDir() || tracked[name]:
+		case strings.HasPrefix(name, ".tmp-"):
+			report = append(report, domain.ModIntegrity{Filename: name, Status: domain.IntegrityPartial,
+				Detail: "leftover of an interrupted download"})
+		case strings.HasSuffix(name, ".jar"):
+			report = append(report, domain.ModIntegrity{Filename: name, Status: domain.IntegrityUntracked,
+				Detail: "not in the lockfile"})
+		}
+	}
+	return report, nil
+}
+
+func (m *Mods) verifyLocked(project string, lm domain.LockedMod) domain.ModIntegrity {
+	res := domain.ModIntegrity{Project: project, Filename: lm.Filename, Status: domain.IntegrityOK}
+	path := filepath.Join(m.cfg.ModsDir(), lm.Filename)
+	sum, err := fileSHA1(path)
+	switch {
+	case os.IsNotExist(err):
+		res.Status, res.Detail = domain.IntegrityMissing, "file not found"
+	case err != nil:
+		res.Status, res.Detail = domain.IntegrityCorrupt, err.Error()
+	case lm.SHA1 != "" && sum != lm.SHA1:
+		res.Status, res.Detail = domain.IntegrityModified, fmt.Sprintf("sha1 %s, lockfile has %s", sum[:12], lm.SHA1[:min(12, len(lm.SHA1))])
+	default:
+		if zr, err := zip.OpenReader(path); err != nil {
+			res.Status, res.Detail = domain.IntegrityCorrupt, "not a valid jar: "+err.Error()
+		} else {
+			_ = zr.Close()
+			if lm.SHA1 == "" {
+				res.Detail = "no hash in lockfile"
+			}
+		}
+	}
+	return res
+}
+
+// Repair re-downloads the locked version of every missing, modified or
+// corrupt mod and removes partial downloads. It returns the files fixed.
+func (m *Mods) Repair(ctx context.Context, issues []domain.ModIntegrity) ([]string, error) {
+	st, err := m.state.Load()
+	if err != nil {
+		return nil, err
+	}
+	var fixed []string
+	var errs []error
+	for _, issue := range issues {
+		switch issue.Status {
+		case domain.IntegrityPartial:
+			if !m.cfg.DryRun {
+				_ = os.Remove(filepath.Join(m.cfg.ModsDir(), issue.Filename))
+			}
+			fixed = append(fixed, issue.Filename)
+		case domain.IntegrityMissing, domain.IntegrityModified, domain.IntegrityCorrupt:
+			lm := st.Mods[issue.Project]
+			info, err := m.fetchVersion(ctx, lm.VersionID, issue.Project)
+			if err == nil {
+				_, err = m.downloadMod(ctx, info, true, m.cfg.ModsDir())
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", issue.Filename, err))
+				continue
+			}
+			m.lock(issue.Project, info)
+			m.logger.Info("Repaired mod", zap.String("project", issue.Project), zap.String("filename", info.Filename))
+			fixed = append(fixed, info.Filename)
+		}
+	}
+	if len(errs) > 0 {
+		return fixed, fmt.Errorf("repair failed for %d mod(s): %v", len(errs), errs)
+	}
+	return fixed, nil
+}
+
+// fetchVersion looks up one exact version, as recorded in the lockfile.
+func (m *Mods) fetchVersion(ctx context.Context, versionID, projectID string) (*domain.ModInfo, error) {
+	if versionID == "" {
+		return nil, fmt.Errorf("lockfile has no version id for %s", projectID)
+	}
+	var v modrinthVersion
+	if err := m.apiRequest(ctx, modrinthAPI+"/version/"+versionID, &v); err != nil {
+		return nil, err
+	}
+	return m.modInfo(&v, projectID)
+}
@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"craftops/internal/domain"
+	"craftops/internal/service"
+)
+
+func TestMods_VerifyAndRepair(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	good := filepath.Join(cfg.Paths.Mods, "good.jar")
+	writeJar(t, good, "fabric.mod.json")
+	goodSum := sha1Hex(t, good)
+	writeJar(t, filepath.Join(cfg.Paths.Mods, "manual.jar"), "fabric.mod.json")
+	writeFile(t, cfg.Paths.Mods, "tampered.jar", "not the original")
+	writeFile(t, cfg.Paths.Mods, ".tmp-123", "partial")
+
+	var jar []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/version/v-tampered":
+			v := modrinthVersionFixture("tampered.jar", "http://"+r.Host+"/dl/tampered.jar")[0]
+			_ = json.NewEncoder(w).Encode(v)
+		case "/dl/tampered.jar":
+			_, _ = w.Write(jar)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	jar, _ = os.ReadFile(good) // any valid jar will do as the "original"
+
+	err := service.NewStateStore(cfg).Update(func(st *domain.State) {
+		st.Mods["good"] = domain.LockedMod{Project: "good", Filename: "good.jar", SHA1: goodSum}
+		st.Mods["gone"] = domain.LockedMod{Project: "gone", Filename: "gone.jar", SHA1: "abc"}
+		st.Mods["tampered"] = domain.LockedMod{Project: "tampered", VersionID: "v-tampered", Filename: "tampered.jar", SHA1: goodSum}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := service.NewModsWithBaseURL(cfg, logger, srv.URL)
+	results, err := svc.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	got := make(map[string]domain.IntegrityStatus)
+	for _, r := range results {
+		got[r.Filename] = r.Status
+	}
+	want := map[string]domain.IntegrityStatus{
+		"good.jar":     domain.IntegrityOK,
+		"gone.jar":     domain.IntegrityMissing,
+		"tampered.jar": domain.IntegrityModified,
+		"manual.jar":   domain.IntegrityUntracked,
+		".tmp-123":     domain.IntegrityPartial,
+	}
+	for file, status := range want {
+		if got[file] != status {
+			t.Errorf("%s: status %q, want %q", file, got[file], status)
+		}
+	}
+
+	var issues []domain.ModIntegrity
+	for _, r := range results {
+		if r.Filename == "tampered.jar" || r.Filename == ".tmp-123" {
+			issues = append(issues, r)
+		}
+	}
+	fixed, err := svc.Repair(ctx, issues)
+	if err != nil || len(fixed) != 2 {
+		t.Fatalf("Repair = %v, %v", fixed, err)
+	}
+	if sum := sha1Hex(t, filepath.Join(cfg.Paths.Mods, "tampered.jar")); sum != goodSum {
+		t.Errorf("tampered.jar not restored, sha1 %s", sum)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.Paths.Mods, ".tmp-123")); !os.IsNotExist(err) {
+		t.Error("partial download not removed")
+	}
+}
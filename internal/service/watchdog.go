@@ -0,0 +1,165 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+)
+
+// maxStatusResponse bounds the server list ping JSON (it embeds the favicon).
+const maxStatusResponse = 1 << 20
+
+// WatchHangs probes a running server every watchdog.interval seconds until
+// ctx is done. After watchdog.failures consecutive failed probes it saves a
+// thread dump, records the hang in the crash history, notifies through n
+// and, with watchdog.restart, force-restarts the server. Servers that are
+// not running, still starting or not managed by craftops are not probed.
+func (s *Server) WatchHangs(ctx context.Context, n *Notification) {
+	w := s.cfg.Watchdog
+	if !w.Enabled {
+		return
+	}
+	var (
+		failures int
+		hung     bool // reported and left running; wait for it to recover
+	)
+	ticker := time.NewTicker(time.Duration(w.Interval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		status, err := s.Usage(ctx)
+		if err != nil || !status.IsRunning || (status.Unmanaged && !status.Adopted) ||
+			status.Uptime < time.Duration(s.cfg.Server.StartupTimeout+s.cfg.Server.ReadyTimeout)*time.Second {
+			failures, hung = 0, false
+			continue
+		}
+		err = s.probe(ctx)
+		if err == nil {
+			if failures > 0 {
+				s.logger.Info("Server answered the liveness probe again", zap.Int("failed_probes", failures))
+			}
+			failures, hung = 0, false
+			continue
+		}
+		failures++
+		s.logger.Warn("Liveness probe failed", zap.String("probe", w.Probe), zap.Int("failures", failures), zap.Error(err))
+		if failures < w.Failures || hung {
+			continue
+		}
+		s.recoverHang(ctx, n, status.PID, fmt.Errorf("%d %s probes failed: %w", failures, w.Probe, err))
+		failures, hung = 0, !w.Restart
+	}
+}
+
+// probe checks that the server is alive with the configured watchdog probe.
+func (s *Server) probe(ctx context.Context) error {
+	if s.cfg.Watchdog.Probe == config.ProbeLog {
+		info, err := os.Stat(filepath.Join(s.cfg.Paths.Server, "logs", "latest.log"))
+		if err != nil {
+			return err
+		}
+		stale := time.Duration(s.cfg.Watchdog.LogStaleMinutes) * time.Minute
+		if age := time.Since(info.ModTime()); age > stale {
+			return fmt.Errorf("latest.log not written for %s", age.Round(time.Second))
+		}
+		return nil
+	}
+	timeout := time.Duration(s.cfg.Watchdog.PingTimeout) * time.Second
+	latency, err := pingServer(ctx, serverHost(s.cfg.Paths.Server), serverPort(s.cfg.Paths.Server), timeout)
+	if err == nil {
+		s.logger.Debug("Server list ping", zap.Duration("latency", latency))
+	}
+	return err
+}
+
+// recoverHang handles a server declared hung: thread dump, crash record,
+// notification and, with watchdog.restart, a forced restart.
+func (s *Server) recoverHang(ctx context.Context, n *Notification, pid int, cause error) {
+	pid = s.javaProcess(ctx, pid)
+	msg := n.T("notify.hang", "cause", cause)
+	s.logger.Error("Server hang detected", zap.Int("pid", pid), zap.Error(cause))
+	if file, err := s.dump(ctx, pid, DumpThreads); err != nil {
+		s.logger.Warn("Hang thread dump failed", zap.Error(err))
+	} else {
+		msg = n.T("notify.hang_dump", "message", msg, "file", file)
+	}
+	s.recordCrash(fmt.Errorf("hang detected: %w", cause))
+	if !s.cfg.Watchdog.Restart {
+		if err := n.SendError(ctx, msg); err != nil {
+			s.logger.Warn("Hang notification failed", zap.Error(err))
+		}
+		return
+	}
+	_ = n.SendError(ctx, n.T("notify.hang_restarting", "message", msg))
+	err := s.restart(ctx, func(ctx context.Context) error {
+		if err := s.escalateStop(ctx, pid); err != nil {
+			return err
+		}
+		return s.runHooks(ctx, "post_stop", s.cfg.Server.PostStopCommands)
+	})
+	if err != nil {
+		s.logger.Error("Restart after hang failed", zap.Error(err))
+		_ = n.SendError(ctx, n.T("notify.hang_restart_failed", "error", err))
+		return
+	}
+	_ = n.SendSuccess(ctx, n.T("notify.hang_restarted"))
+}
+
+// pingServer performs a server list ping (handshake then status request)
+// and returns how long the status response took to arrive.
+func pingServer(ctx context.Context, host string, port int, timeout time.Duration) (time.Duration, error) {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return 0, fmt.Errorf("server list ping: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	start := time.Now()
+	_ = conn.SetDeadline(start.Add(timeout))
+
+	handshake := binary.AppendUvarint(nil, 0x00)            // packet id
+	handshake = binary.AppendUvarint(handshake, 0xFFFFFFFF) // protocol -1: any version
+	handshake = binary.AppendUvarint(handshake, uint64(len(host)))
+	handshake = append(handshake, host...)
+	handshake = binary.BigEndian.AppendUint16(handshake, uint16(port)) //nolint:gosec // port from server.properties
+	handshake = binary.AppendUvarint(handshake, 1)                     // next state: status
+	packet := binary.AppendUvarint(nil, uint64(len(handshake)))
+	packet = append(packet, handshake...)
+	packet = append(packet, 1, 0x00) // status request
+	if _, err := conn.Write(packet); err != nil {
+		return 0, fmt.Errorf("server list ping: %w", err)
+	}
+
+	r := bufio.NewReader(conn)
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, fmt.Errorf("server list ping: no response: %w", err)
+	}
+	if length == 0 || length > maxStatusResponse {
+		return 0, fmt.Errorf("server list ping: bad response length %d", length)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, fmt.Errorf("server list ping: %w", err)
+	}
+	if body[0] != 0x00 {
+		return 0, errors.New("server list ping: unexpected packet")
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"craftops/internal/service"
+)
+
+// listen serves each accepted connection with handle until the test ends.
+func listen(t *testing.T, handle func(net.Conn)) (string, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				handle(conn)
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestPingServer(t *testing.T) {
+	_, _, ctx := setup(t)
+	host, port := listen(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		for range 2 { // handshake, status request
+			n, err := binary.ReadUvarint(r)
+			if err != nil {
+				return
+			}
+			if _, err := io.CopyN(io.Discard, r, int64(n)); err != nil {
+				return
+			}
+		}
+		status := `{"version":{"name":"1.21.1","protocol":767}}`
+		body := binary.AppendUvarint([]byte{0x00}, uint64(len(status)))
+		body = append(body, status...)
+		_, _ = conn.Write(append(binary.AppendUvarint(nil, uint64(len(body))), body...))
+	})
+	if _, err := service.PingServer(ctx, host, port, time.Second); err != nil {
+		t.Fatalf("PingServer: %v", err)
+	}
+
+	// A hung server accepts the connection but never answers.
+	host, port = listen(t, func(conn net.Conn) { _, _ = io.Copy(io.Discard, conn) })
+	start := time.Now()
+	if _, err := service.PingServer(ctx, host, port, 200*time.Millisecond); err == nil {
+		t.Error("expected a timeout from a server that does not answer")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("ping took %s, want it bounded by the timeout", elapsed)
+	}
+}
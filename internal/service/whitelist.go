@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"craftops/internal/config"
+	"craftops/internal/domain"
+)
+
+// maxWhitelistSource bounds the allowlist download.
+const maxWhitelistSource = 4 << 20
+
+// Whitelist keeps whitelist.json in step with the allowlist in
+// whitelist.source.
+type Whitelist struct {
+	cfg    *config.Config
+	logger *zap.Logger
+	client *http.Client
+	server *Server
+}
+
+// NewWhitelist creates the whitelist syncer; srv reloads the list.
+func NewWhitelist(cfg *config.Config, logger *zap.Logger, srv *Server) *Whitelist {
+	client, err := newHTTPClient(cfg, time.Duration(cfg.Mods.Timeout)*time.Second)
+	if err != nil {
+		logger.Warn("Network settings not applied to whitelist client", zap.Error(err))
+	}
+	return &Whitelist{cfg: cfg, logger: logger, client: client, server: srv}
+}
+
+// Sync fetches the allowlist, resolves its names to UUIDs, rewrites
+// whitelist.json to match and, if it changed and the server is running,
+// sends `whitelist reload`. Dry runs only compute the changes. A source that
+// lists no players is refused rather than emptying the whitelist.
+func (w *Whitelist) Sync(ctx context.Context) (*domain.WhitelistSyncResult, error) {
+	data, err := w.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names, invalid, err := parseAllowlist(data, w.cfg.Whitelist.Column)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, errors.New("whitelist source lists no players; refusing to empty whitelist.json")
+	}
+
+	path := filepath.Join(w.cfg.Paths.Server, "whitelist.json")
+	current, err := readPlayerList(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading whitelist.json: %w", err)
+	}
+	desired, unresolved, err := resolvePlayers(ctx, w.client, w.cfg.Paths.Server, names, current)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &domain.WhitelistSyncResult{Unresolved: append(invalid, unresolved...)}
+	had := make(map[string]bool, len(current))
+	for _, e := range current {
+		had[e.UUID] = true
+	}
+	keep := make(map[string]bool, len(desired))
+	for _, e := range desired {
+		keep[e.UUID] = true
+		if !had[e.UUID] {
+			res.Added = append(res.Added, e.Name)
+		}
+	}
+	for _, e := range current {
+		if !keep[e.UUID] {
+			res.Removed = append(res.Removed, e.Name)
+		}
+	}
+	if len(res.Added) == 0 && len(res.Removed) == 0 {
+		return res, nil
+	}
+	if w.cfg.DryRun {
+		w.logger.Info("Dry run: Would update whitelist.json", zap.Strings("added", res.Added), zap.Strings("removed", res.Removed))
+		return res, nil
+	}
+
+	out, err := json.MarshalIndent(desired, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(path, append(out, '\n'), 0o640); err != nil {
+		return nil, fmt.Errorf("writing whitelist.json: %w", err)
+	}
+	w.logger.Info("Whitelist updated", zap.Strings("added", res.Added), zap.Strings("removed", res.Removed))
+
+	if status, err := w.server.Status(ctx); err == nil && status.IsRunning && !status.Unmanaged {
+		if err := w.server.SendConsole(ctx, "whitelist reload"); err != nil {
+			w.logger.Warn("Reloading the whitelist failed; it applies on the next start", zap.Error(err))
+		} else {
+			res.Reloaded = true
+		}
+	}
+	return res, nil
+}
+
+// fetch downloads whitelist.source.
+func (w *Whitelist) fetch(ctx context.Context) ([]byte, error) {
+	source := allowlistURL(w.cfg.Whitelist.Source)
+	var data []byte
+	err := withRetry(ctx, notifyMaxRetries, notifyRetryDelay, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", userAgent)
+		for k, v := range w.cfg.Whitelist.Headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := w.client.Do(req) //nolint:gosec // URL from user config
+		if err != nil {
+			return err
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return &domain.APIError{URL: source, StatusCode: resp.StatusCode, Message: "whitelist source unavailable",
+				RetryAfter: parseRetryAfter(resp.Header)}
+		}
+		data, err = io.ReadAll(io.LimitReader(resp.Body, maxWhitelistSource))
+		return err
+	})
+	return data, err
+}
+
+// allowlistURL turns the page URL of a Gist or Google Sheet into the URL of
+// its raw content or CSV export; other URLs are returned unchanged.
+func allowlistURL(source string) string {
+	u, err := url.Parse(source)
+	if err != nil {
+		return source
+	}
+	switch {
+	case u.Host == "gist.github.com" && !strings.Contains(u.Path, "/raw"):
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/raw"
+		u.Fragment = ""
+	case u.Host == "docs.google.com" && strings.HasPrefix(u.Path, "/spreadsheets/d/") &&
+		!strings.Contains(u.Path, "/export") && !strings.Contains(u.Path, "/pub"):
+		id, _, _ := strings.Cut(strings.TrimPrefix(u.Path, "/spreadsheets/d/"), "/")
+		q := url.Values{"format": {"csv"}}
+		if gid := u.Query().Get("gid"); gid != "" {
+			q.Set("gid", gid)
+		} else if gid, ok := strings.CutPrefix(u.Fragment, "gid="); ok {
+			q.Set("gid", gid)
+		}
+		u.Path, u.RawQuery, u.Fragment = "/spreadsheets/d/"+id+"/export", q.Encode(), ""
+	}
+	return u.String()
+}
+
+// parseAllowlist reads player names from a plain list or CSV file: the
+// column headed column, or the first column. Without a column a first row
+// that is not a player name is taken as the header. Names are deduplicated
+// case-insensitively; other entries that are not valid names are returned
+// as invalid.
+func parseAllowlist(data []byte, column string) (names, invalid []string, err error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("parsing whitelist source: %w", err)
+	}
+	col := 0
+	if column != "" {
+		if len(records) == 0 {
+			return nil, nil, fmt.Errorf("whitelist source has no %q column", column)
+		}
+		col = -1
+		for i, h := range records[0] {
+			if strings.EqualFold(strings.TrimSpace(h), column) {
+				col = i
+			}
+		}
+		if col < 0 {
+			return nil, nil, fmt.Errorf("whitelist source has no %q column", column)
+		}
+		records = records[1:]
+	} else if len(records) > 0 && len(records[0]) > 0 && !playerNamePattern.MatchString(strings.TrimSpace(records[0][0])) {
+		records = records[1:]
+	}
+
+	seen := map[string]bool{}
+	for _, rec := range records {
+		if col >= len(rec) {
+			continue
+		}
+		name := strings.TrimSpace(rec[col])
+		switch {
+		case name == "" || seen[strings.ToLower(name)]:
+		case !playerNamePattern.MatchString(name):
+			invalid = append(invalid, name)
+		default:
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	return names, invalid, nil
+}
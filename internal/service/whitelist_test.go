@@ -0,0 +1,102 @@
+package service_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"craftops/internal/service"
+)
+
+func TestWhitelist_Sync(t *testing.T) {
+	cfg, logger, ctx := setup(t)
+	var looked []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/sheet.csv":
+			_, _ = w.Write([]byte("Discord,Minecraft name\nal#1,Alice\ncarol#2,carol\nx#3,not a name!\ny#4,ghost\nz#5,ALICE\n"))
+		case "/minecraft/profile/lookup/bulk/byname":
+			_ = json.NewDecoder(r.Body).Decode(&looked)
+			_, _ = w.Write([]byte(`[{"id":"0123456789abcdef0123456789abcdef","name":"Carol"}]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	defer service.SetMojangAPI(ts.URL)()
+	cfg.Whitelist.Source = ts.URL + "/sheet.csv"
+	cfg.Whitelist.Column = "minecraft name"
+	writeFile(t, cfg.Paths.Server, "whitelist.json",
+		`[{"uuid":"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa","name":"Alice"},{"uuid":"bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb","name":"Bob"}]`)
+	wl := service.NewWhitelist(cfg, logger, service.NewServer(cfg, logger))
+
+	res, err := wl.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if !slices.Equal(looked, []string{"carol", "ghost"}) {
+		t.Errorf("looked up %v, want only names not on the whitelist", looked)
+	}
+	if !slices.Equal(res.Added, []string{"Carol"}) || !slices.Equal(res.Removed, []string{"Bob"}) {
+		t.Errorf("added %v removed %v, want [Carol] [Bob]", res.Added, res.Removed)
+	}
+	if !slices.Equal(res.Unresolved, []string{"not a name!", "ghost"}) {
+		t.Errorf("unresolved = %v", res.Unresolved)
+	}
+	if res.Reloaded {
+		t.Error("reloaded a server that is not running")
+	}
+	data, _ := os.ReadFile(filepath.Join(cfg.Paths.Server, "whitelist.json")) //nolint:gosec
+	var got []struct{ UUID, Name string }
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("whitelist.json: %v", err)
+	}
+	want := []struct{ UUID, Name string }{
+		{"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa", "Alice"},
+		{"01234567-89ab-cdef-0123-456789abcdef", "Carol"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("whitelist.json = %+v, want %+v", got, want)
+	}
+
+	// Offline-mode servers get offline UUIDs without asking Mojang.
+	looked = nil
+	writeFile(t, cfg.Paths.Server, "server.properties", "online-mode=false\n")
+	if res, err = wl.Sync(ctx); err != nil {
+		t.Fatalf("offline Sync: %v", err)
+	}
+	if looked != nil || !slices.Equal(res.Added, []string{"ghost"}) {
+		t.Errorf("offline: looked up %v, added %v", looked, res.Added)
+	}
+
+	cfg.Whitelist.Source = ts.URL + "/missing.csv"
+	if _, err := wl.Sync(ctx); err == nil {
+		t.Error("expected an error for an unavailable source")
+	}
+}
+
+func TestOfflineUUID(t *testing.T) {
+	// As computed by UUID.nameUUIDFromBytes("OfflinePlayer:Notch").
+	if got := service.OfflineUUID("Notch"); got != "b50ad385-829d-3141-a216-7e7d7539ba7f" {
+		t.Errorf("OfflineUUID(Notch) = %s", got)
+	}
+}
+
+func TestAllowlistURL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"https://gist.github.com/me/abc123", "https://gist.github.com/me/abc123/raw"},
+		{"https://gist.github.com/me/abc123/raw/list.txt", "https://gist.github.com/me/abc123/raw/list.txt"},
+		{"https://docs.google.com/spreadsheets/d/SHEET/edit#gid=42", "https://docs.google.com/spreadsheets/d/SHEET/export?format=csv&gid=42"},
+		{"https://docs.google.com/spreadsheets/d/e/KEY/pub?output=csv", "https://docs.google.com/spreadsheets/d/e/KEY/pub?output=csv"},
+		{"https://example.com/allow.txt", "https://example.com/allow.txt"},
+	}
+	for _, tt := range tests {
+		if got := service.AllowlistURL(tt.in); got != tt.want {
+			t.Errorf("AllowlistURL(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
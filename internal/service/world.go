@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// regionKinds are the per-region anvil folders a chunk's data is spread over.
+var regionKinds = []string{"region", "entities", "poi"}
+
+// dimensionDirs returns candidate world folders for a dimension, covering the
+// vanilla layout (world/DIM-1) and the Bukkit one (world_nether/DIM-1).
+// Datapack dimensions are given as "namespace:path".
+func dimensionDirs(level, dimension string) ([]string, error) {
+	switch strings.TrimPrefix(strings.ToLower(dimension), "minecraft:") {
+	case "overworld", "world":
+		return []string{level}, nil
+	case "nether", "the_nether":
+		return []string{level + "/DIM-1", level + "_nether/DIM-1"}, nil
+	case "end", "the_end":
+		return []string{level + "/DIM1", level + "_the_end/DIM1"}, nil
+	}
+	ns, p, ok := strings.Cut(dimension, ":")
+	if !ok || ns == "" || p == "" || strings.Contains(dimension, "..") {
+		return nil, fmt.Errorf("unknown dimension %q: use overworld, nether, end or namespace:path", dimension)
+	}
+	return []string{path.Join(level, "dimensions", ns, p)}, nil
+}
+
+// RestoreRegion copies the region, entities and poi files for region (x, z)
+// of dimension from the named backup into the live world, overwriting the
+// current files. The server must be stopped. It returns the restored paths
+// relative to the server directory.
+func (b *Backup) RestoreRegion(ctx context.Context, name, dimension string, x, z int) ([]string, error) {
+	dirs, err := dimensionDirs(levelName(b.cfg.Paths.Server), dimension)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := b.Contents(ctx, name, "")
+	if err != nil {
+		return nil, err
+	}
+	inBackup := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		inBackup[strings.TrimSuffix(e.Name, "/")] = true
+	}
+
+	file := fmt.Sprintf("r.%d.%d.mca", x, z)
+	var targets []string
+	for _, dir := range dirs {
+		for _, kind := range regionKinds {
+			if p := path.Join(dir, kind, file); inBackup[p] {
+				targets = append(targets, p)
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("region %s for %s not found in backup %s", file, dimension, name)
+	}
+	if b.cfg.DryRun {
+		b.logger.Info("Dry run: Would restore region files", zap.Strings("files", targets))
+		return targets, nil
+	}
+	for _, t := range targets {
+		if _, err := b.Extract(ctx, name, t, b.cfg.Paths.Server); err != nil {
+			return nil, fmt.Errorf("restoring %s: %w", t, err)
+		}
+	}
+	b.logger.Info("Region restored", zap.String("backup", name), zap.Strings("files", targets))
+	return targets, nil
+}
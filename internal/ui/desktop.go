@@ -0,0 +1,72 @@
+package ui
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+const (
+	// desktopMinDuration is how long an operation runs before its end is
+	// worth a desktop notification; shorter ones finish while watched.
+	desktopMinDuration = 30 * time.Second
+	// desktopTimeout bounds the notifier command.
+	desktopTimeout = 5 * time.Second
+)
+
+// errNoDesktop reports a session without a desktop to notify.
+var errNoDesktop = errors.New("no desktop session")
+
+// UseDesktopNotifications turns native desktop notifications from
+// NotifyDone on or off.
+func (t *Terminal) UseDesktopNotifications(enabled bool) {
+	t.desktop = nil
+	if enabled {
+		t.desktop = desktopNotify
+	}
+}
+
+// NotifyDone shows a desktop notification that an operation started at
+// started has ended, so a user who switched windows during a long backup
+// or update learns it is done. It does nothing unless desktop
+// notifications are on, output is an interactive terminal and the
+// operation ran for at least desktopMinDuration. It reports whether a
+// notification was shown; a missing notifier is not an error.
+func (t *Terminal) NotifyDone(started time.Time, title, message string) bool {
+	if t.desktop == nil || !t.isTTY || time.Since(started) < desktopMinDuration {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), desktopTimeout)
+	defer cancel()
+	return t.desktop(ctx, title, message) == nil
+}
+
+// desktopNotify shows a notification with notify-send (freedesktop
+// desktops) or osascript (macOS). Title and message are passed as
+// arguments, never as script source. Over SSH the desktop is someone
+// else's, so nothing is shown.
+func desktopNotify(ctx context.Context, title, message string) error {
+	if os.Getenv("SSH_CONNECTION") != "" {
+		return errNoDesktop
+	}
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.CommandContext(ctx, "osascript",
+			"-e", "on run argv",
+			"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+			"-e", "end run",
+			title, message)
+	case "windows":
+		return errNoDesktop
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return errNoDesktop
+		}
+		cmd = exec.CommandContext(ctx, "notify-send", "--app-name=craftops", "--", title, message)
+	}
+	return cmd.Run()
+}
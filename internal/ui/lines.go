@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// lineWriter collects one operation's output and hands the terminal whole
+// lines only.
+type lineWriter struct {
+	t      *Terminal
+	prefix string
+	mu     sync.Mutex
+	buf    []byte
+}
+
+// LineWriter returns a writer for the progress of one of several concurrent
+// operations, such as a download or a fleet member. It holds back partial
+// lines and writes each complete one, prefixed with prefix, between the
+// terminal's other output, so lines from parallel writers never interleave.
+// Close writes an unterminated last line. Quiet terminals drop the lines.
+func (t *Terminal) LineWriter(prefix string) io.WriteCloser {
+	if prefix != "" {
+		prefix = t.DimSprint(prefix) + " "
+	}
+	return &lineWriter{t: t, prefix: prefix}
+}
+
+// Write implements io.Writer.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	end := bytes.LastIndexByte(w.buf, '\n')
+	if end < 0 {
+		return len(p), nil
+	}
+	w.emit(w.buf[:end+1])
+	w.buf = append(w.buf[:0], w.buf[end+1:]...)
+	return len(p), nil
+}
+
+// Close implements io.Closer, writing any unterminated last line.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.emit(append(w.buf, '\n'))
+		w.buf = nil
+	}
+	return nil
+}
+
+// emit writes complete lines in one piece under the terminal's lock.
+func (w *lineWriter) emit(lines []byte) {
+	if w.t.quiet {
+		return
+	}
+	var out bytes.Buffer
+	for line := range bytes.Lines(lines) {
+		out.WriteString(w.prefix)
+		out.Write(line)
+	}
+	w.t.lock()
+	defer w.t.mu.Unlock()
+	_, _ = w.t.out.Write(out.Bytes())
+}
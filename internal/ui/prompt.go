@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SetInput sets where answers to prompts are read from.
+func (t *Terminal) SetInput(in io.Reader) { t.in = bufio.NewReader(in) }
+
+// SetAssumeYes makes Confirm return true and Prompt/Select return their
+// defaults without asking (the global --yes flag).
+func (t *Terminal) SetAssumeYes(yes bool) { t.assumeYes = yes }
+
+// Confirm asks a yes/no question. An empty answer or closed input (e.g. a
+// cron job without --yes) selects def.
+func (t *Terminal) Confirm(question string, def bool) (bool, error) {
+	if t.assumeYes {
+		return true, nil
+	}
+	t.lock()
+	defer t.mu.Unlock()
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	for {
+		answer, err := t.ask(fmt.Sprintf("%s %s ", question, hint))
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// Prompt asks for free text, returning def for an empty answer.
+func (t *Terminal) Prompt(question, def string) (string, error) {
+	if t.assumeYes {
+		return def, nil
+	}
+	t.lock()
+	defer t.mu.Unlock()
+	label := question + ": "
+	if def != "" {
+		label = fmt.Sprintf("%s [%s]: ", question, def)
+	}
+	answer, err := t.ask(label)
+	if err != nil || answer == "" {
+		return def, err
+	}
+	return answer, nil
+}
+
+// Select lists options and returns the index chosen by number, or def for
+// an empty answer.
+func (t *Terminal) Select(question string, options []string, def int) (int, error) {
+	if len(options) == 0 {
+		return 0, errors.New("select: no options")
+	}
+	if t.assumeYes {
+		return def, nil
+	}
+	t.lock()
+	defer t.mu.Unlock()
+	_, _ = fmt.Fprintln(t.out, question)
+	for i, o := range options {
+		_, _ = fmt.Fprintf(t.out, "  %d) %s\n", i+1, o)
+	}
+	for {
+		answer, err := t.ask(fmt.Sprintf("Choice [%d]: ", def+1))
+		if err != nil {
+			return def, err
+		}
+		if answer == "" {
+			return def, nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+			return n - 1, nil
+		}
+	}
+}
+
+// ask prints label and reads one trimmed line. Closed input yields "".
+// Callers hold t.mu, so other output waits until the prompt is answered.
+func (t *Terminal) ask(label string) (string, error) {
+	if t.isTTY {
+		_, _ = accentColor.Fprint(t.out, label)
+	} else {
+		_, _ = fmt.Fprint(t.out, label)
+	}
+	line, err := t.in.ReadString('\n')
+	if errors.Is(err, io.EOF) {
+		if line == "" {
+			_, _ = fmt.Fprintln(t.out)
+		}
+		return strings.TrimSpace(line), nil
+	}
+	return strings.TrimSpace(line), err
+}
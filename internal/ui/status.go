@@ -0,0 +1,136 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/olekukonko/tablewriter/pkg/twwidth"
+)
+
+const (
+	// statusInterval throttles redraws of a status line.
+	statusInterval = 250 * time.Millisecond
+	// spinnerInterval is the time between spinner frames.
+	spinnerInterval = 100 * time.Millisecond
+	// eraseLine returns the cursor to the line start and clears the line.
+	eraseLine = "\r\x1b[K"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// StatusLine is a line of live feedback rewritten in place, such as a
+// running file count. Off a TTY and on quiet terminals it prints nothing,
+// so pipes and logs only get the messages around it. Other output erases
+// the line first; it comes back on the next Set.
+type StatusLine struct {
+	t    *Terminal
+	mu   sync.Mutex
+	last time.Time
+}
+
+// StatusLine returns an empty status line for t.
+func (t *Terminal) StatusLine() *StatusLine { return &StatusLine{t: t} }
+
+// Set replaces the status text, redrawing at most every statusInterval.
+func (s *StatusLine) Set(format string, args ...any) {
+	if !s.live() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Since(s.last) < statusInterval {
+		return
+	}
+	s.last = time.Now()
+	s.t.drawStatus(fmt.Sprintf(format, args...))
+}
+
+// Clear erases the line so regular output can follow.
+func (s *StatusLine) Clear() {
+	if !s.live() {
+		return
+	}
+	s.t.lock()
+	s.t.mu.Unlock()
+}
+
+func (s *StatusLine) live() bool { return s.t.isTTY && !s.t.quiet }
+
+// Spinner animates a status line with the elapsed time while a step with
+// no progress of its own runs, such as a server restart. Like StatusLine
+// it prints nothing off a TTY.
+type Spinner struct {
+	t       *Terminal
+	mu      sync.Mutex
+	message string
+	once    sync.Once
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// Spinner starts a spinner showing message; Stop it when the step ends.
+func (t *Terminal) Spinner(message string) *Spinner {
+	s := &Spinner{t: t, message: message, stop: make(chan struct{}), done: make(chan struct{})}
+	if !t.isTTY || t.quiet {
+		close(s.done)
+		return s
+	}
+	go s.run()
+	return s
+}
+
+// Update replaces the message shown next to the spinner.
+func (s *Spinner) Update(message string) {
+	s.mu.Lock()
+	s.message = message
+	s.mu.Unlock()
+}
+
+// Stop ends the spinner and erases its line. It is safe to call more than
+// once.
+func (s *Spinner) Stop() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *Spinner) run() {
+	defer close(s.done)
+	started := time.Now()
+	tick := time.NewTicker(spinnerInterval)
+	defer tick.Stop()
+	for frame := 0; ; frame++ {
+		s.mu.Lock()
+		msg := s.message
+		s.mu.Unlock()
+		s.t.drawStatus(fmt.Sprintf("%s %s %s", spinnerFrames[frame%len(spinnerFrames)], msg,
+			s.t.DimSprint(time.Since(started).Round(time.Second).String())))
+		select {
+		case <-s.stop:
+			s.t.lock()
+			s.t.mu.Unlock()
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// drawStatus shows text as the live status line, cut to the terminal width
+// so it never wraps onto a line it cannot erase.
+func (t *Terminal) drawStatus(text string) {
+	if t.width > 1 {
+		text = twwidth.Truncate(text, t.width-1)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = fmt.Fprint(t.out, eraseLine+text)
+	t.mu.status = true
+}
+
+// eraseStatus clears a live status line. Callers hold t.mu.
+func (t *Terminal) eraseStatus() {
+	if t.mu.status {
+		_, _ = fmt.Fprint(t.out, eraseLine)
+		t.mu.status = false
+	}
+}
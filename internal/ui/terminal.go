@@ -2,10 +2,14 @@
 package ui
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 	"github.com/olekukonko/tablewriter"
@@ -13,13 +17,52 @@ import (
 	"golang.org/x/term"
 
 	"craftops/internal/domain"
+	"craftops/internal/i18n"
 )
 
 // Terminal provides structured output with optional color and formatting.
+// It is safe for concurrent use: each message, table and prompt is written
+// in one piece, and terminals sharing stdout also serialize with each other.
 type Terminal struct {
 	out    io.Writer
 	errOut io.Writer
+	in     *bufio.Reader
 	isTTY  bool
+	width  int         // terminal columns, 0 if unknown
+	mu     *outputLock // held while writing to out or errOut
+
+	quiet     bool
+	assumeYes bool
+	messages  *i18n.Catalog
+	desktop   func(ctx context.Context, title, message string) error // nil when off
+}
+
+// outputLock serializes the terminals sharing an output and tracks the
+// status line drawn on it.
+type outputLock struct {
+	sync.Mutex
+	status bool // a status line is drawn on the current line
+}
+
+// stdio serializes the terminals writing to the process's stdout and
+// stderr, such as those of fleet members running in parallel.
+var stdio outputLock
+
+// Stderr writes to the process's stderr in turn with the terminals on
+// stdout, erasing a live status line first, so console log lines neither
+// split terminal output nor land on a spinner.
+var Stderr io.Writer = stderrWriter{}
+
+type stderrWriter struct{}
+
+func (stderrWriter) Write(p []byte) (int, error) {
+	stdio.Lock()
+	defer stdio.Unlock()
+	if stdio.status {
+		_, _ = fmt.Fprint(os.Stdout, eraseLine)
+		stdio.status = false
+	}
+	return os.Stderr.Write(p)
 }
 
 var (
@@ -32,23 +75,58 @@ var (
 	dimColor     = color.New(color.FgHiBlack)
 )
 
-// NewTerminal creates a terminal linked to stdout/stderr.
+// NewTerminal creates a terminal linked to stdout/stderr. Color is enabled
+// on a TTY unless NO_COLOR is set (https://no-color.org).
 func NewTerminal() *Terminal {
-	isTTY := term.IsTerminal(int(os.Stdout.Fd())) //nolint:gosec
-	color.NoColor = !isTTY
-	return &Terminal{out: os.Stdout, errOut: os.Stderr, isTTY: isTTY}
+	fd := int(os.Stdout.Fd()) //nolint:gosec
+	isTTY := term.IsTerminal(fd)
+	color.NoColor = !isTTY || os.Getenv("NO_COLOR") != ""
+	width := 0
+	if isTTY {
+		width, _, _ = term.GetSize(fd)
+	}
+	return &Terminal{out: os.Stdout, errOut: os.Stderr, in: bufio.NewReader(os.Stdin), isTTY: isTTY, width: width, mu: &stdio}
 }
 
 // NewTerminalWithWriter creates a terminal with custom writers (for testing).
 func NewTerminalWithWriter(out, errOut io.Writer, isTTY bool) *Terminal {
-	return &Terminal{out: out, errOut: errOut, isTTY: isTTY}
+	return &Terminal{out: out, errOut: errOut, in: bufio.NewReader(strings.NewReader("")), isTTY: isTTY, mu: &outputLock{}}
+}
+
+// lock takes the output lock and erases a live status line, which its
+// owner redraws on its next update.
+func (t *Terminal) lock() {
+	t.mu.Lock()
+	t.eraseStatus()
 }
 
 // IsTTY reports whether output is a terminal.
 func (t *Terminal) IsTTY() bool { return t.isTTY }
 
+// SetWidth overrides the detected terminal width used to fit tables.
+func (t *Terminal) SetWidth(columns int) { t.width = columns }
+
+// SetNoColor disables colored output while keeping the TTY layout.
+func (t *Terminal) SetNoColor() { color.NoColor = true }
+
+// UseMessages sets the catalog T translates with; without one messages are
+// English.
+func (t *Terminal) UseMessages(c *i18n.Catalog) { t.messages = c }
+
+// T translates a message for display (see i18n.Catalog.T).
+func (t *Terminal) T(key string, args ...any) string { return t.messages.T(key, args...) }
+
+// SetQuiet suppresses everything but errors and requested data (tables,
+// Printf/Println output).
+func (t *Terminal) SetQuiet(quiet bool) { t.quiet = quiet }
+
 // Banner prints a prominent header.
 func (t *Terminal) Banner(title string) {
+	if t.quiet {
+		return
+	}
+	t.lock()
+	defer t.mu.Unlock()
 	if !t.isTTY {
 		_, _ = fmt.Fprintf(t.out, "%s\n", title)
 		return
@@ -64,6 +142,11 @@ func (t *Terminal) Banner(title string) {
 
 // Section prints a secondary header.
 func (t *Terminal) Section(title string) {
+	if t.quiet {
+		return
+	}
+	t.lock()
+	defer t.mu.Unlock()
 	if t.isTTY {
 		_, _ = accentColor.Fprintf(t.out, "\n▶ %s\n", title)
 		_, _ = dimColor.Fprintln(t.out, strings.Repeat("─", len(title)+2))
@@ -105,6 +188,11 @@ func (t *Terminal) Infof(format string, args ...interface{}) {
 }
 
 func (t *Terminal) printMsg(c *color.Color, label, msg string) {
+	if t.quiet && label != "ERROR" {
+		return
+	}
+	t.lock()
+	defer t.mu.Unlock()
 	if t.isTTY {
 		_, _ = c.Fprintln(t.out, msg)
 	} else {
@@ -114,6 +202,11 @@ func (t *Terminal) printMsg(c *color.Color, label, msg string) {
 
 // Step prints a progress indicator like [1/5].
 func (t *Terminal) Step(current, total int, message string) {
+	if t.quiet {
+		return
+	}
+	t.lock()
+	defer t.mu.Unlock()
 	if t.isTTY {
 		_, _ = accentColor.Fprintf(t.out, "[%d/%d] ", current, total)
 	} else {
@@ -124,11 +217,15 @@ func (t *Terminal) Step(current, total int, message string) {
 
 // Printf writes formatted output.
 func (t *Terminal) Printf(format string, args ...interface{}) {
+	t.lock()
+	defer t.mu.Unlock()
 	_, _ = fmt.Fprintf(t.out, format, args...)
 }
 
 // Println writes a line of output.
 func (t *Terminal) Println(args ...interface{}) {
+	t.lock()
+	defer t.mu.Unlock()
 	_, _ = fmt.Fprintln(t.out, args...)
 }
 
@@ -151,7 +248,9 @@ func (t *Terminal) sprintWithColor(text string, c *color.Color) string {
 	return text
 }
 
-// Table renders a formatted table.
+// Table renders a formatted table. Column widths are measured in display
+// cells with ANSI codes ignored, so colored, CJK and emoji cells line up.
+// When the terminal width is known, long cells wrap to keep the table inside it.
 func (t *Terminal) Table(headers []string, rows [][]string) {
 	var opts []tablewriter.Option
 	if t.isTTY {
@@ -170,15 +269,28 @@ func (t *Terminal) Table(headers []string, rows [][]string) {
 		}
 	}
 
-	table := tablewriter.NewTable(t.out, opts...)
+	if t.width > 0 {
+		opts = append(opts, tablewriter.WithMaxWidth(t.width), tablewriter.WithRowAutoWrap(tw.WrapNormal))
+	}
+
+	// Render off the lock, then write the table in one piece.
+	var buf bytes.Buffer
+	var errs []string
+	table := tablewriter.NewTable(&buf, opts...)
 	table.Header(stringsToAny(headers)...)
 	for _, row := range rows {
 		if err := table.Append(stringsToAny(row)...); err != nil {
-			_, _ = fmt.Fprintf(t.errOut, "Table append error: %v\n", err)
+			errs = append(errs, fmt.Sprintf("Table append error: %v", err))
 		}
 	}
 	if err := table.Render(); err != nil {
-		_, _ = fmt.Fprintf(t.errOut, "Table render error: %v\n", err)
+		errs = append(errs, fmt.Sprintf("Table render error: %v", err))
+	}
+	t.lock()
+	defer t.mu.Unlock()
+	_, _ = t.out.Write(buf.Bytes())
+	for _, err := range errs {
+		_, _ = fmt.Fprintln(t.errOut, err)
 	}
 }
 
@@ -192,7 +304,7 @@ func stringsToAny(strs []string) []interface{} {
 
 // HealthCheckTable renders a diagnostic results table with colored status.
 func (t *Terminal) HealthCheckTable(checks []domain.HealthCheck) {
-	headers := []string{"Component", "Status", "Details"}
+	headers := []string{t.T("health.component"), t.T("health.status"), t.T("health.details")}
 	rows := make([][]string, len(checks))
 	for i, check := range checks {
 		status := string(check.Status)
@@ -203,6 +315,8 @@ func (t *Terminal) HealthCheckTable(checks []domain.HealthCheck) {
 			status = t.WarningSprint(status)
 		case domain.StatusError:
 			status = t.ErrorSprint(status)
+		case domain.StatusSkipped:
+			status = t.DimSprint(status)
 		}
 		rows[i] = []string{check.Name, status, check.Message}
 	}
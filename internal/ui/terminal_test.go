@@ -2,8 +2,12 @@ package ui
 
 import (
 	"bytes"
+	"context"
+	"regexp"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"craftops/internal/domain"
 )
@@ -117,3 +121,214 @@ func TestTerminal_HealthCheckTable(t *testing.T) {
 		}
 	}
 }
+
+func TestTerminal_Confirm(t *testing.T) {
+	tests := []struct {
+		input string
+		def   bool
+		want  bool
+	}{
+		{"y\n", false, true},
+		{"no\n", true, false},
+		{"\n", true, true},
+		{"", false, false}, // closed input falls back to the default
+		{"maybe\nyes\n", false, true},
+	}
+	for _, tt := range tests {
+		term, _, _ := newTestTerminal()
+		term.SetInput(strings.NewReader(tt.input))
+		got, err := term.Confirm("Proceed?", tt.def)
+		if err != nil || got != tt.want {
+			t.Errorf("Confirm(%q, %v) = %v, %v; want %v", tt.input, tt.def, got, err, tt.want)
+		}
+	}
+
+	term, _, _ := newTestTerminal()
+	term.SetAssumeYes(true)
+	if ok, _ := term.Confirm("Proceed?", false); !ok {
+		t.Error("Confirm with assume-yes should return true")
+	}
+}
+
+func TestTerminal_PromptAndSelect(t *testing.T) {
+	term, out, _ := newTestTerminal()
+	term.SetInput(strings.NewReader("\n0\n2\n"))
+	if got, _ := term.Prompt("Name", "survival"); got != "survival" {
+		t.Errorf("Prompt default = %q", got)
+	}
+	if got, _ := term.Select("Loader", []string{"fabric", "forge"}, 0); got != 1 {
+		t.Errorf("Select = %d, want 1", got)
+	}
+	if !strings.Contains(out.String(), "2) forge") {
+		t.Errorf("Select did not list options: %q", out.String())
+	}
+}
+
+func TestTerminal_Quiet(t *testing.T) {
+	term, out, _ := newTestTerminal()
+	term.SetQuiet(true)
+	term.Banner("Title")
+	term.Info("info msg")
+	term.Warning("warn msg")
+	term.Error("error msg")
+	got := out.String()
+	if strings.Contains(got, "Title") || strings.Contains(got, "info msg") || strings.Contains(got, "warn msg") {
+		t.Errorf("quiet terminal printed non-error output: %q", got)
+	}
+	if !strings.Contains(got, "error msg") {
+		t.Errorf("quiet terminal dropped error: %q", got)
+	}
+}
+
+func TestTerminal_Table_FitsWidth(t *testing.T) {
+	out := &bytes.Buffer{}
+	term := NewTerminalWithWriter(out, &bytes.Buffer{}, true)
+	term.SetWidth(40)
+	term.Table([]string{"Component", "Status", "Details"}, [][]string{
+		{"Java", term.SuccessSprint("OK"), strings.Repeat("a very long detail message ", 4)},
+		{"日本語", term.ErrorSprint("ERROR"), "short"},
+	})
+	ansi := regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	for _, line := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n") {
+		plain := ansi.ReplaceAllString(line, "")
+		width := 0
+		for _, r := range plain {
+			width++
+			if r >= 0x3000 && r <= 0x9fff { // CJK is two cells wide
+				width++
+			}
+		}
+		if width > 40 {
+			t.Errorf("line is %d cells wide, want <= 40: %q", width, plain)
+		}
+	}
+	if !strings.Contains(out.String(), "日本語") {
+		t.Errorf("wide characters missing from table: %q", out.String())
+	}
+}
+
+func TestTerminal_ConcurrentWritesStayWhole(t *testing.T) {
+	term, out, _ := newTestTerminal()
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Go(func() {
+			for j := range 50 {
+				term.Infof("worker %d line %d", i, j)
+				term.Printf("worker %d printed %d\n", i, j)
+			}
+		})
+	}
+	wg.Wait()
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 20*50*2 {
+		t.Fatalf("got %d lines, want %d", len(lines), 20*50*2)
+	}
+	line := regexp.MustCompile(`^(INFO: worker \d+ line \d+|worker \d+ printed \d+)$`)
+	for _, l := range lines {
+		if !line.MatchString(l) {
+			t.Fatalf("garbled line %q", l)
+		}
+	}
+}
+
+func TestTerminal_LineWriter(t *testing.T) {
+	term, out, _ := newTestTerminal()
+	w := term.LineWriter("[sodium]")
+	_, _ = w.Write([]byte("downloading 50"))
+	if out.Len() != 0 {
+		t.Fatalf("partial line written early: %q", out.String())
+	}
+	_, _ = w.Write([]byte("%\ndone\nverifying"))
+	term.Info("between")
+	_ = w.Close()
+	want := "[sodium] downloading 50%\n[sodium] done\nINFO: between\n[sodium] verifying\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	term.SetQuiet(true)
+	out.Reset()
+	w = term.LineWriter("")
+	_, _ = w.Write([]byte("progress\n"))
+	if out.Len() != 0 {
+		t.Errorf("quiet terminal wrote %q", out.String())
+	}
+}
+
+func TestTerminal_StatusLine(t *testing.T) {
+	term, out, _ := newTestTerminal()
+	status := term.StatusLine()
+	status.Set("%d file(s)", 3)
+	status.Clear()
+	if out.Len() != 0 {
+		t.Errorf("status line written off a TTY: %q", out.String())
+	}
+
+	out = &bytes.Buffer{}
+	term = NewTerminalWithWriter(out, &bytes.Buffer{}, true)
+	status = term.StatusLine()
+	status.Set("%d file(s)", 3)
+	status.Set("%d file(s)", 4) // throttled
+	term.Printf("done\n")
+	status.Clear()
+	if want := "\r\x1b[K3 file(s)\r\x1b[Kdone\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	term.SetWidth(8)
+	term.StatusLine().Set("a very long status")
+	if got := strings.TrimPrefix(out.String(), "\r\x1b[K"); len([]rune(got)) > 7 {
+		t.Errorf("status %q not cut to the terminal width", got)
+	}
+}
+
+func TestTerminal_Spinner(t *testing.T) {
+	term, out, _ := newTestTerminal()
+	spin := term.Spinner("restarting")
+	spin.Stop()
+	if out.Len() != 0 {
+		t.Errorf("spinner written off a TTY: %q", out.String())
+	}
+
+	out = &bytes.Buffer{}
+	term = NewTerminalWithWriter(out, &bytes.Buffer{}, true)
+	spin = term.Spinner("restarting")
+	spin.Update("still restarting")
+	spin.Stop()
+	spin.Stop()
+	got := out.String()
+	if !strings.HasPrefix(got, "\r\x1b[K") || !strings.Contains(got, "restarting") || !strings.HasSuffix(got, "\r\x1b[K") {
+		t.Errorf("spinner output = %q, want a drawn and erased line", got)
+	}
+}
+
+func TestTerminal_NotifyDone(t *testing.T) {
+	term := NewTerminalWithWriter(&bytes.Buffer{}, &bytes.Buffer{}, true)
+	var shown []string
+	long := time.Now().Add(-time.Minute)
+	if term.NotifyDone(long, "craftops", "Backup created") {
+		t.Error("notified with desktop notifications off")
+	}
+
+	term.UseDesktopNotifications(true)
+	term.desktop = func(_ context.Context, title, message string) error {
+		shown = append(shown, title+": "+message)
+		return nil
+	}
+	if term.NotifyDone(time.Now(), "craftops", "quick") {
+		t.Error("notified for a short operation")
+	}
+	if !term.NotifyDone(long, "craftops", "Backup created") {
+		t.Error("no notification for a long operation")
+	}
+	if len(shown) != 1 || shown[0] != "craftops: Backup created" {
+		t.Errorf("shown = %q", shown)
+	}
+
+	piped := NewTerminalWithWriter(&bytes.Buffer{}, &bytes.Buffer{}, false)
+	piped.desktop = term.desktop
+	if piped.NotifyDone(long, "craftops", "Backup created") {
+		t.Error("notified without an interactive terminal")
+	}
+}